@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/ingress-nginx/pkg/render"
+)
+
+const usage = `Usage: ingress-nginx <command> [flags]
+
+Commands:
+  render    Renders the NGINX configuration of a TemplateConfig in JSON format
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "render":
+		err = renderCommand(os.Args[2:], os.Stdin, os.Stdout)
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %q\n%v", os.Args[1], usage)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// renderCommand renders the NGINX configuration of the TemplateConfig
+// of the --config flag, which is read from stdin if it is "-"
+func renderCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := pflag.NewFlagSet("render", pflag.ContinueOnError)
+
+	var (
+		configFile = flags.String("config", "",
+			`Path of the TemplateConfig in JSON format, or - to read it from stdin`)
+
+		template = flags.String("template", "",
+			`Path of the NGINX template. The template of the ingress controller is used by default`)
+
+		includeDir = flags.String("include-dir", "",
+			`Directory of the templates included in the http, server and location blocks of the NGINX template`)
+
+		output = flags.String("output", "",
+			`Path of the file where the configuration is written. The configuration is written to stdout by default`)
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *configFile == "" {
+		return fmt.Errorf("flag --config is required")
+	}
+
+	var data []byte
+	var err error
+	if *configFile == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(*configFile)
+	}
+	if err != nil {
+		return fmt.Errorf("unexpected error reading the template configuration: %v", err)
+	}
+
+	content, err := render.Render(data, render.Options{
+		Template:   *template,
+		IncludeDir: *includeDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return ioutil.WriteFile(*output, content, 0644)
+	}
+
+	_, err = stdout.Write(content)
+	return err
+}
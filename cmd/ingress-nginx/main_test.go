@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingress-nginx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := filepath.Join(dir, "nginx.tmpl")
+	err = ioutil.WriteFile(tmpl, []byte("worker_processes {{ .Cfg.WorkerProcesses }};\n"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stdin := strings.NewReader(`{"Cfg": {"worker-processes": "4"}}`)
+	stdout := &bytes.Buffer{}
+	err = renderCommand([]string{"--config", "-", "--template", tmpl}, stdin, stdout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "worker_processes 4;\n" {
+		t.Errorf("unexpected configuration %q", stdout.String())
+	}
+
+	output := filepath.Join(dir, "nginx.conf")
+	err = renderCommand([]string{"--config", "-", "--template", tmpl, "--output", output},
+		strings.NewReader(`{"Cfg": {"worker-processes": "2"}}`), stdout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "worker_processes 2;\n" {
+		t.Errorf("unexpected configuration %q", string(content))
+	}
+
+	if err := renderCommand([]string{"--template", tmpl}, stdin, stdout); err == nil {
+		t.Errorf("expected an error without --config")
+	}
+}
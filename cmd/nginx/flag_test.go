@@ -59,6 +59,97 @@ func TestDefaults(t *testing.T) {
 	}
 }
 
+func TestDynamicCertificatesFlag(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--default-backend-service", "namespace/test", "--http-port", "0", "--https-port", "0",
+		"--enable-dynamic-certificates"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flag --enable-dynamic-configuration")
+	}
+}
+
+func TestSyncBatchWindowFlag(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--default-backend-service", "namespace/test", "--http-port", "0", "--https-port", "0",
+		"--sync-batch-window", "-1s"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flag --sync-batch-window")
+	}
+}
+
+func TestShardFlags(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--default-backend-service", "namespace/test", "--http-port", "0", "--https-port", "0",
+		"--shard-count", "2", "--shard-index", "2"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flag --shard-index")
+	}
+}
+
+func TestShutdownGracePeriodFlag(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--default-backend-service", "namespace/test", "--http-port", "0", "--https-port", "0",
+		"--shutdown-grace-period", "-1s"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flag --shutdown-grace-period")
+	}
+}
+
+func TestValidationWebhookFlags(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--default-backend-service", "namespace/test", "--http-port", "0", "--https-port", "0",
+		"--validating-webhook", ":8443"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flags --validating-webhook-certificate and --validating-webhook-key")
+	}
+}
+
+func TestDefaultBackendServiceFlag(t *testing.T) {
+	resetForTesting(func() { t.Fatal("bad parse") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatal("expected an error about the flag --default-backend-service")
+	}
+
+	resetForTesting(func() { t.Fatal("bad parse") })
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--enable-ingress-class-resource"}
+
+	_, _, err = parseFlags()
+	if err != nil {
+		t.Fatalf("unexpected error using the default backend of the IngressClassParameters: %v", err)
+	}
+}
+
 func TestSetupSSLProxy(t *testing.T) {
 	// TODO
 }
@@ -31,6 +31,8 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/gateway"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 )
 
@@ -47,7 +49,8 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		defaultSvc = flags.String("default-backend-service", "",
 			`Service used to serve a 404 page for the default backend. Takes the form
 		namespace/name. The controller uses the first node port of this Service for
-		the default backend.`)
+		the default backend. The defaultBackendService of the IngressClassParameters is used if it
+		is empty and --enable-ingress-class-resource is set.`)
 
 		ingressClass = flags.String("ingress-class", "",
 			`Name of the ingress class to route through this controller.`)
@@ -57,7 +60,12 @@ func parseFlags() (bool, *controller.Configuration, error) {
 
 		publishSvc = flags.String("publish-service", "",
 			`Service fronting the ingress controllers. Takes the form namespace/name.
-		The controller will set the endpoint records on the ingress objects to reflect those on the service.`)
+		The controller will set the endpoint records on the ingress objects to reflect those on the service.
+		Accepts a comma-separated list of services, like the services of the load balancers of each IP family.`)
+
+		publishStatusAddress = flags.String("publish-status-address", "",
+			`Comma-separated list of IP addresses or hostnames published in the status of the ingress objects,
+		instead of the addresses of the --publish-service or of the nodes.`)
 
 		tcpConfigMapName = flags.String("tcp-services-configmap", "",
 			`Name of the ConfigMap that contains the definition of the TCP services to expose.
@@ -72,11 +80,67 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		service with the format namespace/serviceName and the port of the service could be a
 		number of the name of the port.`)
 
+		templateConfigMap = flags.String("template-configmap", "",
+			`Name of the ConfigMap that contains the NGINX template in the key nginx.tmpl, replacing the template of the image.
+		The template is reloaded when the ConfigMap changes, and the previous one is kept if the new one is not valid.`)
+
+		templateIncludeDir = flags.String("template-include-dir", "",
+			`Directory of templates included in the http, server and location blocks of the NGINX template.
+		The files are named <block>-<name>.tmpl and rendered in the order of their names.`)
+
+		enableStreamRoutes = flags.Bool("enable-stream-routes", false,
+			`Enables the StreamRoute custom resources (nginx.ingress.kubernetes.io/v1alpha1) to expose TCP and UDP services.
+		The routes complement the services defined in the --tcp-services-configmap and --udp-services-configmap ConfigMaps.
+		The CustomResourceDefinition must be created before starting the ingress controller.`)
+
+		enableReferenceGrants = flags.Bool("enable-reference-grants", false,
+			`Enables the ReferenceGrant custom resources (nginx.ingress.kubernetes.io/v1alpha1) to share Secrets between namespaces.
+		The TLS secrets and the auth-secret annotation of the Ingresses can reference a Secret of another namespace, using
+		the format namespace/name, if a ReferenceGrant of that namespace allows it.
+		The CustomResourceDefinition must be created before starting the ingress controller.`)
+
+		enableNginxDefaults = flags.Bool("enable-nginx-defaults", false,
+			`Enables the NginxDefaults custom resources (nginx.ingress.kubernetes.io/v1alpha1) to override the defaults of the
+		ConfigMap per namespace or ingress class. The defaults of the namespace of the ConfigMap apply to all the Ingresses.
+		The CustomResourceDefinition must be created before starting the ingress controller.`)
+
+		enableGatewayAPI = flags.Bool("enable-gateway-api", false,
+			`Enables the Gateway API resources (gateway.networking.k8s.io/v1alpha2). The HTTPRoutes and TLSRoutes attached
+		to the Gateways of the classes of the controller are configured like the Ingresses.
+		The CustomResourceDefinitions must be created before starting the ingress controller.`)
+
+		gatewayControllerName = flags.String("gateway-controller-name", gateway.ControllerName,
+			`controllerName of the GatewayClasses whose Gateways are managed by the ingress controller.`)
+
+		enableIngressClassResource = flags.Bool("enable-ingress-class-resource", false,
+			`Reads the IngressClass resource (networking.k8s.io/v1beta1) named as the --ingress-class flag (nginx by default).
+		The IngressClassParameters (nginx.ingress.kubernetes.io/v1alpha1) referenced by the class define the default
+		ConfigMaps, publish service, default SSL certificate, default backend service and template of the controller.
+		The flags take precedence.`)
+
+		enableEndpointSlices = flags.Bool("enable-endpoint-slices", false,
+			`Discover the endpoints of the services using the EndpointSlices (discovery.k8s.io/v1beta1) instead of the Endpoints.
+		The Endpoints are used if the Kubernetes API server does not serve the EndpointSlices.`)
+
 		resyncPeriod = flags.Duration("sync-period", 600*time.Second,
 			`Relist and confirm cloud resources this often. Default is 10 minutes`)
 
 		watchNamespace = flags.String("watch-namespace", apiv1.NamespaceAll,
-			`Namespace to watch for Ingress. Default is to watch all namespaces`)
+			`Namespace to watch for Ingress. Accepts a comma-separated list of namespaces. Default is to watch all namespaces`)
+
+		watchNamespaceSelector = flags.String("watch-namespace-selector", "",
+			`Label selector of the namespaces to watch for Ingress, like team=frontend. The namespaces are selected when the controller starts.
+		Cannot be used with --watch-namespace.`)
+
+		shardCount = flags.Int("shard-count", 1,
+			`Number of shards splitting the Ingresses between several deployments of the ingress controller.
+		Every deployment must use a different --shard-index and --election-id. Default is disabled (1)`)
+
+		shardIndex = flags.Int("shard-index", 0,
+			`Shard of the Ingresses processed by this ingress controller, from 0 to --shard-count minus one`)
+
+		shardKey = flags.String("shard-key", shard.NamespaceKey,
+			`Value used to assign the Ingresses to the shards, namespace or host. Using the host the rules of an Ingress can be split between shards`)
 
 		profiling = flags.Bool("profiling", true, `Enable profiling via web interface host:port/debug/pprof/`)
 
@@ -104,7 +168,8 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		is being stopped. Default is true`)
 
 		sortBackends = flags.Bool("sort-backends", false,
-			`Defines if backends and it's endpoints should be sorted`)
+			`Defines if the endpoints of the backends should be sorted. Otherwise the order of the endpoints is different in each replica
+		of the ingress controller, but does not change between syncs.`)
 
 		useNodeInternalIP = flags.Bool("report-node-internal-ip-address", false,
 			`Defines if the nodes IP address to be returned in the ingress status should be the internal instead of the external IP address`)
@@ -114,6 +179,18 @@ func parseFlags() (bool, *controller.Configuration, error) {
 
 		enableSSLPassthrough = flags.Bool("enable-ssl-passthrough", false, `Enable SSL passthrough feature. Default is disabled`)
 
+		sslPassthroughMaxConnections = flags.Int("ssl-passthrough-max-connections", 0,
+			`Maximum number of concurrent connections handled by the SSL passthrough proxy. New connections are closed when the limit is reached. Default is unlimited (0)`)
+
+		enableDynamicConfiguration = flags.Bool("enable-dynamic-configuration", false,
+			`Dynamically update the endpoints of the backends using the Lua balancer, avoiding NGINX reloads when only the endpoints change. Requires an NGINX image with LuaJIT and lua-resty-core. Default is disabled`)
+
+		enableDynamicCertificates = flags.Bool("enable-dynamic-certificates", false,
+			`Dynamically update the SSL certificates using ssl_certificate_by_lua, avoiding NGINX reloads when a certificate is rotated. Requires --enable-dynamic-configuration. Default is disabled`)
+
+		enableDynamicServers = flags.Bool("enable-dynamic-servers", false,
+			`Route the requests of the servers defined in Ingress rules without annotations using Lua, avoiding NGINX reloads when these Ingress rules are created, updated or deleted. Requires --enable-dynamic-configuration. Default is disabled`)
+
 		httpPort      = flags.Int("http-port", 80, `Indicates the port to use for HTTP traffic`)
 		httpsPort     = flags.Int("https-port", 443, `Indicates the port to use for HTTPS traffic`)
 		statusPort    = flags.Int("status-port", 18080, `Indicates the TCP port to use for exposing the nginx status page`)
@@ -121,6 +198,9 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		defServerPort = flags.Int("default-server-port", 8181, `Default port to use for exposing the default server (catch all)`)
 		healthzPort   = flags.Int("healthz-port", 10254, "port for healthz endpoint.")
 
+		configurationPort = flags.Int("configuration-port", 18081,
+			`Port of the configuration endpoint used by --enable-dynamic-configuration. NGINX only listens on 127.0.0.1 in this port`)
+
 		annotationsPrefix = flags.String("annotations-prefix", "nginx.ingress.kubernetes.io", `Prefix of the ingress annotations.`)
 
 		enableSSLChainCompletion = flags.Bool("enable-ssl-chain-completion", true,
@@ -130,6 +210,39 @@ func parseFlags() (bool, *controller.Configuration, error) {
 
 		syncRateLimit = flags.Float32("sync-rate-limit", 0.3,
 			`Define the sync frequency upper limit`)
+
+		syncWorkers = flags.Int("sync-workers", 1,
+			`Number of workers processing the changes of the synced objects. The configuration is generated
+		concurrently by the workers and the reloads are serialized.`)
+
+		endpointsSyncRateLimit = flags.Float32("endpoints-sync-rate-limit", 0,
+			`Define the sync frequency upper limit of the changes of Endpoints, coalescing the changes that exceed it.
+		The changes of Ingresses, Secrets and ConfigMaps are not delayed by them. Default is disabled (0)`)
+
+		syncBatchWindow = flags.Duration("sync-batch-window", 0,
+			`Time to accumulate the changes of Ingress, Service, Endpoints, Secret and ConfigMap objects before syncing, coalescing bursts of updates in a single NGINX reload (e.g. 2s). Default is disabled (0)`)
+
+		minReloadInterval = flags.Duration("min-reload-interval", 0,
+			`Minimum time between two NGINX reloads. Reloads required before the interval elapses are delayed. Default is disabled (0)`)
+
+		lintConfiguration = flags.String("lint-configuration", controller.LintWarn,
+			`Detects the duplicated and conflicting directives of the server and location blocks of the configuration, usually added by snippets.
+		The values are off, warn (logs the directives) and reject (rejects the snippets that contain them).`)
+
+		splitServerConfiguration = flags.Bool("split-server-configuration", false,
+			`Writes each server block to its own configuration file in /etc/nginx/servers, included from nginx.conf.
+		Only the files of the servers that changed are written, reducing the changes in clusters with thousands of hosts.`)
+
+		shutdownGracePeriod = flags.Duration("shutdown-grace-period", 0,
+			`Time to wait after receiving SIGTERM, failing the readiness probe (/ready) and serving the existing connections, before stopping NGINX. Should be longer than the time the load balancers need to remove the pod. Default is disabled (0)`)
+
+		validationWebhook = flags.String("validating-webhook", "",
+			`The address to start an admission controller on to validate incoming ingresses.
+		Takes the form "<host>:port". If not provided, no admission controller is started.`)
+		validationWebhookCert = flags.String("validating-webhook-certificate", "",
+			`The path of the validating webhook certificate PEM.`)
+		validationWebhookKey = flags.String("validating-webhook-key", "",
+			`The path of the validating webhook key PEM.`)
 	)
 
 	flags.MarkDeprecated("disable-node-list", "This flag is currently no-op and will be deleted.")
@@ -151,7 +264,8 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		return true, nil, nil
 	}
 
-	if *defaultSvc == "" {
+	// the service can be defined by the parameters of the IngressClass
+	if *defaultSvc == "" && !*enableIngressClassResource {
 		return false, nil, fmt.Errorf("Please specify --default-backend-service")
 	}
 
@@ -167,6 +281,12 @@ func parseFlags() (bool, *controller.Configuration, error) {
 
 	parser.AnnotationsPrefix = *annotationsPrefix
 
+	if *gatewayControllerName == "" {
+		return false, nil, fmt.Errorf("Flag --gateway-controller-name must not be empty")
+	}
+
+	gateway.ControllerName = *gatewayControllerName
+
 	// check port collisions
 	if !ing_net.IsPortAvailable(*httpPort) {
 		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --http-port", *httpPort)
@@ -184,6 +304,72 @@ func parseFlags() (bool, *controller.Configuration, error) {
 		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --default-server-port", *defServerPort)
 	}
 
+	if *enableDynamicConfiguration && !ing_net.IsPortAvailable(*configurationPort) {
+		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --configuration-port", *configurationPort)
+	}
+
+	if *enableDynamicCertificates && !*enableDynamicConfiguration {
+		return false, nil, fmt.Errorf("Flag --enable-dynamic-certificates requires --enable-dynamic-configuration")
+	}
+
+	if *enableDynamicServers && !*enableDynamicConfiguration {
+		return false, nil, fmt.Errorf("Flag --enable-dynamic-servers requires --enable-dynamic-configuration")
+	}
+
+	if *shardCount < 1 {
+		return false, nil, fmt.Errorf("Flag --shard-count must be greater than zero")
+	}
+
+	if *shardIndex < 0 || *shardIndex >= *shardCount {
+		return false, nil, fmt.Errorf("Flag --shard-index must be between 0 and %v", *shardCount-1)
+	}
+
+	if *shardKey != shard.NamespaceKey && *shardKey != shard.HostKey {
+		return false, nil, fmt.Errorf("Flag --shard-key must be %v or %v", shard.NamespaceKey, shard.HostKey)
+	}
+
+	if *shardCount > 1 {
+		glog.Infof("processing the shard %v of %v (key %v)", *shardIndex, *shardCount, *shardKey)
+		shard.Count = *shardCount
+		shard.Index = *shardIndex
+		shard.Key = *shardKey
+	}
+
+	if *watchNamespace != "" && *watchNamespaceSelector != "" {
+		return false, nil, fmt.Errorf("Flags --watch-namespace and --watch-namespace-selector are mutually exclusive")
+	}
+
+	if *syncWorkers < 1 {
+		return false, nil, fmt.Errorf("Flag --sync-workers must be at least 1")
+	}
+
+	switch *lintConfiguration {
+	case controller.LintDisabled, controller.LintWarn, controller.LintReject:
+	default:
+		return false, nil, fmt.Errorf("Flag --lint-configuration must be %v, %v or %v",
+			controller.LintDisabled, controller.LintWarn, controller.LintReject)
+	}
+
+	if *endpointsSyncRateLimit < 0 {
+		return false, nil, fmt.Errorf("Flag --endpoints-sync-rate-limit must not be negative")
+	}
+
+	if *syncBatchWindow < 0 {
+		return false, nil, fmt.Errorf("Flag --sync-batch-window must not be negative")
+	}
+
+	if *minReloadInterval < 0 {
+		return false, nil, fmt.Errorf("Flag --min-reload-interval must not be negative")
+	}
+
+	if *shutdownGracePeriod < 0 {
+		return false, nil, fmt.Errorf("Flag --shutdown-grace-period must not be negative")
+	}
+
+	if *validationWebhook != "" && (*validationWebhookCert == "" || *validationWebhookKey == "") {
+		return false, nil, fmt.Errorf("Flag --validating-webhook requires --validating-webhook-certificate and --validating-webhook-key")
+	}
+
 	if *enableSSLPassthrough && !ing_net.IsPortAvailable(*sslProxyPort) {
 		return false, nil, fmt.Errorf("Port %v is already in use. Please check the flag --ssl-passtrough-proxy-port", *sslProxyPort)
 	}
@@ -193,27 +379,51 @@ func parseFlags() (bool, *controller.Configuration, error) {
 	}
 
 	config := &controller.Configuration{
-		APIServerHost:            *apiserverHost,
-		KubeConfigFile:           *kubeConfigFile,
-		UpdateStatus:             *updateStatus,
-		ElectionID:               *electionID,
-		EnableProfiling:          *profiling,
-		EnableSSLPassthrough:     *enableSSLPassthrough,
-		EnableSSLChainCompletion: *enableSSLChainCompletion,
-		ResyncPeriod:             *resyncPeriod,
-		DefaultService:           *defaultSvc,
-		Namespace:                *watchNamespace,
-		ConfigMapName:            *configMap,
-		TCPConfigMapName:         *tcpConfigMapName,
-		UDPConfigMapName:         *udpConfigMapName,
-		DefaultSSLCertificate:    *defSSLCertificate,
-		DefaultHealthzURL:        *defHealthzURL,
-		PublishService:           *publishSvc,
-		ForceNamespaceIsolation:  *forceIsolation,
-		UpdateStatusOnShutdown:   *updateStatusOnShutdown,
-		SortBackends:             *sortBackends,
-		UseNodeInternalIP:        *useNodeInternalIP,
-		SyncRateLimit:            *syncRateLimit,
+		APIServerHost:                *apiserverHost,
+		KubeConfigFile:               *kubeConfigFile,
+		UpdateStatus:                 *updateStatus,
+		ElectionID:                   *electionID,
+		EnableProfiling:              *profiling,
+		EnableSSLPassthrough:         *enableSSLPassthrough,
+		SSLPassthroughMaxConnections: *sslPassthroughMaxConnections,
+		DynamicConfigurationEnabled:  *enableDynamicConfiguration,
+		DynamicCertificatesEnabled:   *enableDynamicCertificates,
+		DynamicServersEnabled:        *enableDynamicServers,
+		EnableSSLChainCompletion:     *enableSSLChainCompletion,
+		ResyncPeriod:                 *resyncPeriod,
+		DefaultService:               *defaultSvc,
+		Namespace:                    *watchNamespace,
+		NamespaceSelector:            *watchNamespaceSelector,
+		ConfigMapName:                *configMap,
+		TCPConfigMapName:             *tcpConfigMapName,
+		UDPConfigMapName:             *udpConfigMapName,
+		TemplateConfigMap:            *templateConfigMap,
+		TemplateIncludeDir:           *templateIncludeDir,
+		EnableStreamRoutes:           *enableStreamRoutes,
+		EnableReferenceGrants:        *enableReferenceGrants,
+		EnableNginxDefaults:          *enableNginxDefaults,
+		EnableGatewayAPI:             *enableGatewayAPI,
+		EnableEndpointSlices:         *enableEndpointSlices,
+		EnableIngressClassResource:   *enableIngressClassResource,
+		DefaultSSLCertificate:        *defSSLCertificate,
+		DefaultHealthzURL:            *defHealthzURL,
+		PublishService:               *publishSvc,
+		PublishStatusAddress:         *publishStatusAddress,
+		ForceNamespaceIsolation:      *forceIsolation,
+		UpdateStatusOnShutdown:       *updateStatusOnShutdown,
+		SortBackends:                 *sortBackends,
+		UseNodeInternalIP:            *useNodeInternalIP,
+		SyncRateLimit:                *syncRateLimit,
+		SyncWorkers:                  *syncWorkers,
+		LintConfiguration:            *lintConfiguration,
+		EndpointsSyncRateLimit:       *endpointsSyncRateLimit,
+		SyncBatchWindow:              *syncBatchWindow,
+		MinReloadInterval:            *minReloadInterval,
+		SplitServerConfiguration:     *splitServerConfiguration,
+		ShutdownGracePeriod:          *shutdownGracePeriod,
+		ValidationWebhook:            *validationWebhook,
+		ValidationWebhookCertPath:    *validationWebhookCert,
+		ValidationWebhookKeyPath:     *validationWebhookKey,
 		ListenPorts: &ngx_config.ListenPorts{
 			Default:  *defServerPort,
 			Health:   *healthzPort,
@@ -221,6 +431,8 @@ func parseFlags() (bool, *controller.Configuration, error) {
 			HTTPS:    *httpsPort,
 			SSLProxy: *sslProxyPort,
 			Status:   *statusPort,
+
+			Configuration: *configurationPort,
 		},
 	}
 
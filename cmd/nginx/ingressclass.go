@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	networking "k8s.io/ingress-nginx/internal/apis/networking/v1beta1"
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/controller"
+)
+
+// ingressClassParametersKind is the kind of the resources
+// referenced by the parameters of the IngressClasses
+const ingressClassParametersKind = "IngressClassParameters"
+
+// applyIngressClassParameters reads the IngressClass of the ingress controller
+// and uses the referenced IngressClassParameters as the defaults of the flags
+func applyIngressClassParameters(conf *controller.Configuration) error {
+	cfg, err := buildConfigFromFlags(conf.APIServerHost, conf.KubeConfigFile)
+	if err != nil {
+		return err
+	}
+
+	classClient, err := networking.NewRESTClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	paramsClient, err := v1alpha1.NewRESTClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	params, err := getIngressClassParameters(classClient, paramsClient, class.IngressClass)
+	if err != nil {
+		return err
+	}
+	if params == nil {
+		return nil
+	}
+
+	err = params.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid IngressClassParameters %v: %v", params.Name, err)
+	}
+
+	glog.Infof("using IngressClassParameters %v of IngressClass %v", params.Name, class.IngressClass)
+	mergeIngressClassParameters(conf, &params.Spec)
+	return nil
+}
+
+// getIngressClassParameters returns the IngressClassParameters referenced by
+// the IngressClass with the given name, or nil if the class does not exist, is
+// implemented by another controller or does not reference parameters
+func getIngressClassParameters(classClient, paramsClient rest.Interface, name string) (*v1alpha1.IngressClassParameters, error) {
+	ic := &networking.IngressClass{}
+	err := classClient.Get().Resource("ingressclasses").Name(name).Do().Into(ic)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			glog.Infof("IngressClass %v not found, using the flags", name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected error reading the IngressClass %v: %v", name, err)
+	}
+
+	if ic.Spec.Controller != class.ControllerName {
+		glog.Warningf("IngressClass %v is implemented by the controller %v instead of %v, ignoring the class", name, ic.Spec.Controller, class.ControllerName)
+		return nil, nil
+	}
+
+	ref := ic.Spec.Parameters
+	if ref == nil {
+		return nil, nil
+	}
+
+	if ref.APIGroup == nil || *ref.APIGroup != v1alpha1.GroupName || ref.Kind != ingressClassParametersKind {
+		return nil, fmt.Errorf("IngressClass %v references unsupported parameters of kind %v, only %v (%v) is supported",
+			name, ref.Kind, ingressClassParametersKind, v1alpha1.GroupName)
+	}
+
+	params := &v1alpha1.IngressClassParameters{}
+	err = paramsClient.Get().Resource("ingressclassparameters").Name(ref.Name).Do().Into(params)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error reading the IngressClassParameters %v: %v", ref.Name, err)
+	}
+
+	return params, nil
+}
+
+// mergeIngressClassParameters sets the values of the parameters that are not
+// configured using the flags
+func mergeIngressClassParameters(conf *controller.Configuration, spec *v1alpha1.IngressClassParametersSpec) {
+	if conf.ConfigMapName == "" {
+		conf.ConfigMapName = spec.ConfigMap
+	}
+	if conf.TCPConfigMapName == "" {
+		conf.TCPConfigMapName = spec.TCPServicesConfigMap
+	}
+	if conf.UDPConfigMapName == "" {
+		conf.UDPConfigMapName = spec.UDPServicesConfigMap
+	}
+	if conf.PublishService == "" {
+		conf.PublishService = spec.PublishService
+	}
+	if conf.DefaultSSLCertificate == "" {
+		conf.DefaultSSLCertificate = spec.DefaultSSLCertificate
+	}
+	if conf.DefaultService == "" {
+		conf.DefaultService = spec.DefaultBackendService
+	}
+	if conf.TemplatePath == "" {
+		conf.TemplatePath = spec.Template
+	}
+	if conf.TemplateConfigMap == "" {
+		conf.TemplateConfigMap = spec.TemplateConfigMap
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	networking "k8s.io/ingress-nginx/internal/apis/networking/v1beta1"
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress/controller"
+)
+
+func newIngressClassServer(objects map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestGetIngressClassParameters(t *testing.T) {
+	server := newIngressClassServer(map[string]string{
+		"/apis/networking.k8s.io/v1beta1/ingressclasses/nginx": `{"kind":"IngressClass","apiVersion":"networking.k8s.io/v1beta1",
+			"metadata":{"name":"nginx"},"spec":{"controller":"k8s.io/ingress-nginx",
+			"parameters":{"apiGroup":"nginx.ingress.kubernetes.io","kind":"IngressClassParameters","name":"external"}}}`,
+		"/apis/networking.k8s.io/v1beta1/ingressclasses/other": `{"kind":"IngressClass","apiVersion":"networking.k8s.io/v1beta1",
+			"metadata":{"name":"other"},"spec":{"controller":"example.com/other-controller"}}`,
+		"/apis/networking.k8s.io/v1beta1/ingressclasses/unsupported": `{"kind":"IngressClass","apiVersion":"networking.k8s.io/v1beta1",
+			"metadata":{"name":"unsupported"},"spec":{"controller":"k8s.io/ingress-nginx",
+			"parameters":{"kind":"ConfigMap","name":"external"}}}`,
+		"/apis/nginx.ingress.kubernetes.io/v1alpha1/ingressclassparameters/external": `{"kind":"IngressClassParameters",
+			"apiVersion":"nginx.ingress.kubernetes.io/v1alpha1","metadata":{"name":"external"},
+			"spec":{"configMap":"ingress-nginx/external-configuration","publishService":"ingress-nginx/external"}}`,
+	})
+	defer server.Close()
+
+	cfg := &rest.Config{Host: server.URL}
+	classClient, err := networking.NewRESTClient(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	paramsClient, err := v1alpha1.NewRESTClient(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params, err := getIngressClassParameters(classClient, paramsClient, "nginx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params == nil || params.Spec.ConfigMap != "ingress-nginx/external-configuration" {
+		t.Errorf("expected the parameters external but returned %+v", params)
+	}
+
+	for _, name := range []string{"missing", "other"} {
+		params, err = getIngressClassParameters(classClient, paramsClient, name)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", name, err)
+		}
+		if params != nil {
+			t.Errorf("%v: expected no parameters but returned %+v", name, params)
+		}
+	}
+
+	_, err = getIngressClassParameters(classClient, paramsClient, "unsupported")
+	if err == nil {
+		t.Errorf("expected an error for unsupported parameters")
+	}
+}
+
+func TestMergeIngressClassParameters(t *testing.T) {
+	conf := &controller.Configuration{
+		ConfigMapName: "ingress-nginx/flag-configuration",
+	}
+	mergeIngressClassParameters(conf, &v1alpha1.IngressClassParametersSpec{
+		ConfigMap:             "ingress-nginx/external-configuration",
+		PublishService:        "ingress-nginx/external",
+		DefaultBackendService: "ingress-nginx/external-default-backend",
+		Template:              "/etc/nginx/custom/nginx.tmpl",
+		TemplateConfigMap:     "ingress-nginx/external-template",
+	})
+
+	if conf.ConfigMapName != "ingress-nginx/flag-configuration" {
+		t.Errorf("expected the configmap of the flag but returned %v", conf.ConfigMapName)
+	}
+	if conf.PublishService != "ingress-nginx/external" {
+		t.Errorf("expected the publish service of the parameters but returned %v", conf.PublishService)
+	}
+	if conf.DefaultService != "ingress-nginx/external-default-backend" {
+		t.Errorf("expected the default backend of the parameters but returned %v", conf.DefaultService)
+	}
+	if conf.TemplatePath != "/etc/nginx/custom/nginx.tmpl" {
+		t.Errorf("expected the template of the parameters but returned %v", conf.TemplatePath)
+	}
+	if conf.TemplateConfigMap != "ingress-nginx/external-template" {
+		t.Errorf("expected the template configmap of the parameters but returned %v", conf.TemplateConfigMap)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"net/http/pprof"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -31,15 +32,21 @@ import (
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+	gateway_v1alpha2 "k8s.io/ingress-nginx/internal/apis/gateway/v1alpha2"
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
+	"k8s.io/ingress-nginx/internal/ingress/gateway"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/net/ssl"
 	"k8s.io/ingress-nginx/version"
@@ -69,6 +76,17 @@ func main() {
 		handleFatalInitError(err)
 	}
 
+	if conf.EnableIngressClassResource {
+		err = applyIngressClassParameters(conf)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+	}
+
+	if conf.DefaultService == "" {
+		glog.Fatalf("Please specify --default-backend-service or the defaultBackendService of the IngressClassParameters")
+	}
+
 	ns, name, err := k8s.ParseNameNS(conf.DefaultService)
 	if err != nil {
 		glog.Fatal(err)
@@ -83,34 +101,39 @@ func main() {
 	}
 	glog.Infof("validated %v as the default backend", conf.DefaultService)
 
-	if conf.PublishService != "" {
-		ns, name, err := k8s.ParseNameNS(conf.PublishService)
+	for _, key := range strings.Split(conf.PublishService, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		ns, name, err := k8s.ParseNameNS(key)
 		if err != nil {
 			glog.Fatal(err)
 		}
 
 		svc, err := kubeClient.CoreV1().Services(ns).Get(name, metav1.GetOptions{})
 		if err != nil {
-			glog.Fatalf("unexpected error getting information about service %v: %v", conf.PublishService, err)
+			glog.Fatalf("unexpected error getting information about service %v: %v", key, err)
 		}
 
-		if len(svc.Status.LoadBalancer.Ingress) == 0 {
+		if svc.Spec.Type == apiv1.ServiceTypeExternalName {
+			glog.Infof("service %v validated as source of Ingress status with external name %v", key, svc.Spec.ExternalName)
+		} else if len(svc.Status.LoadBalancer.Ingress) == 0 {
 			if len(svc.Spec.ExternalIPs) > 0 {
-				glog.Infof("service %v validated as assigned with externalIP", conf.PublishService)
+				glog.Infof("service %v validated as assigned with externalIP", key)
 			} else {
 				// We could poll here, but we instead just exit and rely on k8s to restart us
-				glog.Fatalf("service %s does not (yet) have ingress points", conf.PublishService)
+				glog.Fatalf("service %s does not (yet) have ingress points", key)
 			}
 		} else {
-			glog.Infof("service %v validated as source of Ingress status", conf.PublishService)
+			glog.Infof("service %v validated as source of Ingress status", key)
 		}
 	}
 
-	if conf.Namespace != "" {
-		_, err = kubeClient.CoreV1().Namespaces().Get(conf.Namespace, metav1.GetOptions{})
-		if err != nil {
-			glog.Fatalf("no namespace with name %v found: %v", conf.Namespace, err)
-		}
+	conf.Namespaces, err = watchedNamespaces(kubeClient, conf.Namespace, conf.NamespaceSelector)
+	if err != nil {
+		glog.Fatal(err)
 	}
 
 	if conf.ResyncPeriod.Seconds() < 10 {
@@ -129,6 +152,47 @@ func main() {
 
 	conf.Client = kubeClient
 
+	if conf.EnableStreamRoutes {
+		conf.StreamRouteClient, err = createCustomResourceClient(conf.APIServerHost, conf.KubeConfigFile)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+	}
+
+	if conf.EnableReferenceGrants {
+		conf.ReferenceGrantClient, err = createCustomResourceClient(conf.APIServerHost, conf.KubeConfigFile)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+	}
+
+	if conf.EnableNginxDefaults {
+		conf.NginxDefaultsClient, err = createCustomResourceClient(conf.APIServerHost, conf.KubeConfigFile)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+	}
+
+	if conf.EnableGatewayAPI {
+		conf.GatewayClient, err = createGatewayClient(conf.APIServerHost, conf.KubeConfigFile)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+		glog.Infof("using the Gateways of the classes of the controller %v", gateway.ControllerName)
+	}
+
+	if conf.EnableEndpointSlices {
+		if isEndpointSliceAPIAvailable(kubeClient) {
+			conf.EndpointSliceClient, err = createEndpointSliceClient(conf.APIServerHost, conf.KubeConfigFile)
+			if err != nil {
+				handleFatalInitError(err)
+			}
+			glog.Infof("using EndpointSlices (%v) to discover the endpoints of the services", discovery.SchemeGroupVersion)
+		} else {
+			glog.Warningf("the EndpointSlice API (%v) is not available, using Endpoints", discovery.SchemeGroupVersion)
+		}
+	}
+
 	ngx := controller.NewNGINXController(conf, fs)
 
 	go handleSigterm(ngx, func(code int) {
@@ -196,6 +260,113 @@ func createApiserverClient(apiserverHost string, kubeConfig string) (*kubernetes
 	return client, nil
 }
 
+// createCustomResourceClient creates a REST client for the custom resources
+// (nginx.ingress.kubernetes.io/v1alpha1) using the same configuration of the
+// Kubernetes Apiserver client
+func createCustomResourceClient(apiserverHost string, kubeConfig string) (rest.Interface, error) {
+	cfg, err := buildConfigFromFlags(apiserverHost, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.QPS = defaultQPS
+	cfg.Burst = defaultBurst
+
+	return v1alpha1.NewRESTClient(cfg)
+}
+
+// watchedNamespaces returns the namespaces to watch, a comma-separated list
+// or the namespaces matching the label selector. The list contains the empty
+// namespace if all the namespaces are watched.
+func watchedNamespaces(client kubernetes.Interface, namespace, selector string) ([]string, error) {
+	if selector != "" {
+		nsList, err := client.CoreV1().Namespaces().List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("unexpected error listing the namespaces with selector %v: %v", selector, err)
+		}
+		if len(nsList.Items) == 0 {
+			return nil, fmt.Errorf("no namespace matches the selector %v", selector)
+		}
+
+		var namespaces []string
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+		sort.Strings(namespaces)
+		glog.Infof("watching the namespaces %v (selector %v)", strings.Join(namespaces, ","), selector)
+		return namespaces, nil
+	}
+
+	if namespace == "" {
+		return []string{apiv1.NamespaceAll}, nil
+	}
+
+	namespaces := sets.NewString()
+	for _, ns := range strings.Split(namespace, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+
+		_, err := client.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("no namespace with name %v found: %v", ns, err)
+		}
+		namespaces.Insert(ns)
+	}
+
+	if namespaces.Len() == 0 {
+		return nil, fmt.Errorf("invalid list of namespaces %v", namespace)
+	}
+
+	return namespaces.List(), nil
+}
+
+// createEndpointSliceClient creates a REST client for the EndpointSlices
+// using the same configuration of the Kubernetes Apiserver client
+func createEndpointSliceClient(apiserverHost string, kubeConfig string) (rest.Interface, error) {
+	cfg, err := buildConfigFromFlags(apiserverHost, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.QPS = defaultQPS
+	cfg.Burst = defaultBurst
+
+	return discovery.NewRESTClient(cfg)
+}
+
+// createGatewayClient creates a REST client for the Gateway API resources
+func createGatewayClient(apiserverHost string, kubeConfig string) (rest.Interface, error) {
+	cfg, err := buildConfigFromFlags(apiserverHost, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.QPS = defaultQPS
+	cfg.Burst = defaultBurst
+
+	return gateway_v1alpha2.NewRESTClient(cfg)
+}
+
+// isEndpointSliceAPIAvailable checks if the Kubernetes Apiserver serves
+// the EndpointSlices
+func isEndpointSliceAPIAvailable(client kubernetes.Interface) bool {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(discovery.SchemeGroupVersion.String())
+	if err != nil {
+		glog.V(2).Infof("unexpected error checking the EndpointSlice API: %v", err)
+		return false
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == "endpointslices" {
+			return true
+		}
+	}
+
+	return false
+}
+
 const (
 	// High enough QPS to fit all expected use cases. QPS=0 is not set here, because
 	// client code is overriding it.
@@ -247,6 +418,16 @@ func registerHandlers(enableProfiling bool, port int, ic *controller.NGINXContro
 		ic,
 	)
 
+	// readiness check endpoint, failing while the controller is shutting down
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if err := ic.Ready(r); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+
 	mux.Handle("/metrics", promhttp.Handler())
 
 	mux.HandleFunc("/build", func(w http.ResponseWriter, r *http.Request) {
@@ -255,6 +436,42 @@ func registerHandlers(enableProfiling bool, port int, ic *controller.NGINXContro
 		w.Write(b)
 	})
 
+	mux.HandleFunc("/render", renderHandler(ic))
+
+	mux.HandleFunc("/leader", func(w http.ResponseWriter, r *http.Request) {
+		election, ok := ic.LeaderElection()
+		if !ok {
+			http.Error(w, "the update of the ingress status is disabled", http.StatusNotFound)
+			return
+		}
+		b, _ := json.Marshal(election)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	})
+
+	mux.HandleFunc("/configuration/checksum", func(w http.ResponseWriter, r *http.Request) {
+		checksum, reload := ic.ConfigurationChecksum()
+		b, _ := json.Marshal(struct {
+			Checksum   string    `json:"checksum"`
+			ReloadTime time.Time `json:"reloadTime"`
+		}{checksum, reload})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	})
+
+	mux.HandleFunc("/configuration/diff", func(w http.ResponseWriter, r *http.Request) {
+		diff, err := ic.ConfigurationDiff()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(diff)
+	})
+
 	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
 		err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
 		if err != nil {
@@ -19,10 +19,16 @@ package main
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"syscall"
 	"testing"
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 )
@@ -45,6 +51,66 @@ func TestCreateApiserverClient(t *testing.T) {
 	}
 }
 
+func TestIsEndpointSliceAPIAvailable(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	if isEndpointSliceAPIAvailable(client) {
+		t.Errorf("expected the EndpointSlice API to be unavailable")
+	}
+
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "discovery.k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "endpointslices", Namespaced: true, Kind: "EndpointSlice"},
+			},
+		},
+	}
+	if !isEndpointSliceAPIAvailable(client) {
+		t.Errorf("expected the EndpointSlice API to be available")
+	}
+}
+
+func TestWatchedNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Labels: map[string]string{"team": "web"}}},
+		&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "backend", Labels: map[string]string{"team": "web"}}},
+		&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}},
+	)
+
+	tests := []struct {
+		title     string
+		namespace string
+		selector  string
+		expected  []string
+		expErr    bool
+	}{
+		{"all namespaces", "", "", []string{""}, false},
+		{"single namespace", "frontend", "", []string{"frontend"}, false},
+		{"list of namespaces", "monitoring, frontend,frontend", "", []string{"frontend", "monitoring"}, false},
+		{"missing namespace", "frontend,missing", "", nil, true},
+		{"empty list", ",", "", nil, true},
+		{"selector", "", "team=web", []string{"backend", "frontend"}, false},
+		{"selector without matches", "", "team=ops", nil, true},
+	}
+
+	for _, test := range tests {
+		namespaces, err := watchedNamespaces(client, test.namespace, test.selector)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+		if !reflect.DeepEqual(namespaces, test.expected) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.expected, namespaces)
+		}
+	}
+}
+
 func TestHandleSigterm(t *testing.T) {
 	home := os.Getenv("HOME")
 	kubeConfigFile := fmt.Sprintf("%v/.kube/config", home)
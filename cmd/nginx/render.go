@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ingressRenderer renders the NGINX configuration of an Ingress
+type ingressRenderer interface {
+	RenderIngress(ing *extensions.Ingress) ([]byte, error)
+}
+
+// renderHandler returns the handler of the dry-run render endpoint. The body of
+// the POST request is an Ingress manifest (YAML or JSON) and the response contains
+// the server blocks of the NGINX configuration generated with the Ingress.
+// The namespace of the query string is used if the manifest does not define one.
+func renderHandler(r ingressRenderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ing := &extensions.Ingress{}
+		err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(ing)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if ing.Namespace == "" {
+			ing.Namespace = req.URL.Query().Get("namespace")
+		}
+		if ing.Namespace == "" {
+			ing.Namespace = apiv1.NamespaceDefault
+		}
+
+		content, err := r.RenderIngress(ing)
+		if err != nil {
+			glog.V(2).Infof("error rendering ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(content)
+	}
+}
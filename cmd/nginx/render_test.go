@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+type fakeRenderer struct {
+	ingress *extensions.Ingress
+}
+
+func (r *fakeRenderer) RenderIngress(ing *extensions.Ingress) ([]byte, error) {
+	r.ingress = ing
+	if ing.Name == "invalid" {
+		return nil, fmt.Errorf("invalid configuration")
+	}
+	return []byte("server {}\n"), nil
+}
+
+func TestRenderHandler(t *testing.T) {
+	manifest := `
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: %v
+spec:
+  backend:
+    serviceName: app
+    servicePort: 80
+`
+
+	testCases := []struct {
+		method string
+		url    string
+		name   string
+		code   int
+		ns     string
+	}{
+		{http.MethodGet, "/render", "app", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/render", "app", http.StatusOK, "default"},
+		{http.MethodPost, "/render?namespace=team", "app", http.StatusOK, "team"},
+		{http.MethodPost, "/render", "invalid", http.StatusUnprocessableEntity, "default"},
+	}
+
+	for _, tc := range testCases {
+		r := &fakeRenderer{}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(fmt.Sprintf(manifest, tc.name)))
+
+		renderHandler(r).ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%v %v: expected status %v but got %v", tc.method, tc.name, tc.code, w.Code)
+		}
+		if tc.ns != "" && (r.ingress == nil || r.ingress.Namespace != tc.ns || r.ingress.Spec.Backend == nil) {
+			t.Errorf("%v %v: expected the ingress to be decoded in the namespace %v", tc.method, tc.name, tc.ns)
+		}
+		if tc.code == http.StatusOK && w.Body.String() != "server {}\n" {
+			t.Errorf("unexpected response %q", w.Body.String())
+		}
+	}
+}
+
+func TestRenderHandlerInvalidManifest(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderHandler(&fakeRenderer{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("{")))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %v but got %v", http.StatusBadRequest, w.Code)
+	}
+}
@@ -33,6 +33,10 @@ export ZIPKIN_CPP_VERSION=0.2.0
 export JAEGER_VERSION=0.1.0
 export MODSECURITY_VERSION=1.0.0
 export LUA_VERSION=0.10.12rc2
+export LUAJIT_VERSION=2.1-20180419
+export LUA_RESTY_CORE_VERSION=0.1.14rc1
+export LUA_RESTY_LRUCACHE_VERSION=0.08rc1
+export LUA_CJSON_VERSION=2.1.0.6
 
 export BUILD_PATH=/tmp/build
 
@@ -75,7 +79,6 @@ clean-install \
   libperl-dev \
   cmake \
   util-linux \
-  lua5.1 liblua5.1-0 liblua5.1-dev \
   lmdb-utils \
   libjemalloc1 libjemalloc-dev \
   wget \
@@ -84,8 +87,6 @@ clean-install \
   git g++ pkgconf flex bison doxygen libyajl-dev liblmdb-dev libtool dh-autoreconf libxml2 libpcre++-dev libxml2-dev \
   || exit 1
 
-ln -s /usr/lib/x86_64-linux-gnu/liblua5.1.so /usr/lib/liblua.so
-
 mkdir -p /etc/nginx
 
 if [[ ${ARCH} == "s390x" ]]; then
@@ -197,6 +198,33 @@ sh build.sh
 make
 make install
 
+# build luajit, required by lua-resty-core (dynamic configuration)
+cd "$BUILD_PATH"
+git clone --depth 1 -b v$LUAJIT_VERSION https://github.com/openresty/luajit2
+cd luajit2
+make CCDEBUG=-g
+make install
+
+export LUAJIT_LIB=/usr/local/lib
+export LUAJIT_INC=/usr/local/include/luajit-2.1
+
+# install the lua libraries in /usr/local/lib/lua
+cd "$BUILD_PATH"
+git clone --depth 1 -b v$LUA_RESTY_CORE_VERSION https://github.com/openresty/lua-resty-core
+cd lua-resty-core
+make install LUA_LIB_DIR=/usr/local/lib/lua
+
+cd "$BUILD_PATH"
+git clone --depth 1 -b v$LUA_RESTY_LRUCACHE_VERSION https://github.com/openresty/lua-resty-lrucache
+cd lua-resty-lrucache
+make install LUA_LIB_DIR=/usr/local/lib/lua
+
+cd "$BUILD_PATH"
+git clone --depth 1 -b $LUA_CJSON_VERSION https://github.com/openresty/lua-cjson
+cd lua-cjson
+make LUA_INCLUDE_DIR=$LUAJIT_INC
+make install
+
 # build nginx
 cd "$BUILD_PATH/nginx-$NGINX_VERSION"
 
@@ -226,7 +254,7 @@ if [[ ${ARCH} != "armv7l" || ${ARCH} != "aarch64" ]]; then
 fi
 
 CC_OPT="-g -O3 -flto -fPIE -fstack-protector-strong -Wformat -Werror=format-security -Wdate-time -D_FORTIFY_SOURCE=2 -Wno-deprecated-declarations --param=ssp-buffer-size=4 -DTCP_FASTOPEN=23 -Wno-error=strict-aliasing -fPIC -I$HUNTER_INSTALL_DIR/include"
-LD_OPT="-ljemalloc -Wl,-Bsymbolic-functions -fPIE -fPIC -pie -Wl,-z,relro -Wl,-z,now -L$HUNTER_INSTALL_DIR/lib"
+LD_OPT="-ljemalloc -Wl,-rpath,$LUAJIT_LIB -Wl,-Bsymbolic-functions -fPIE -fPIC -pie -Wl,-z,relro -Wl,-z,now -L$HUNTER_INSTALL_DIR/lib"
    
 if [[ ${ARCH} == "x86_64" ]]; then
   CC_OPT+=' -m64 -mtune=generic'
@@ -263,8 +291,6 @@ WITH_MODULES="--add-module=$BUILD_PATH/ngx_devel_kit-$NDK_VERSION \
   --without-mail_pop3_module \
   --without-mail_smtp_module \
   --without-mail_imap_module \
-  --without-http_uwsgi_module \
-  --without-http_scgi_module \
   --with-cc-opt="${CC_OPT}" \
   --with-ld-opt="${LD_OPT}" \
   ${WITH_MODULES} \
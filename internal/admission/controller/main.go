@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/ingress-nginx/internal/apis/admission/v1beta1"
+)
+
+// Checker checks if an Ingress generates a valid configuration
+type Checker interface {
+	CheckIngress(ing *extensions.Ingress) error
+}
+
+// IngressAdmission validates the Ingresses sent by the API server in
+// admission reviews rejecting the ones that generate an invalid configuration
+type IngressAdmission struct {
+	Checker Checker
+}
+
+var ingressResources = []metav1.GroupVersionResource{
+	{Group: "extensions", Version: "v1beta1", Resource: "ingresses"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"},
+}
+
+// HandleAdmission populates the response of an admission review
+// with the result of the check of the submitted Ingress
+func (ia *IngressAdmission) HandleAdmission(ar *v1beta1.AdmissionReview) {
+	if ar.Request == nil {
+		ar.Response = denied("", fmt.Errorf("the admission review does not contain a request"))
+		return
+	}
+
+	req := ar.Request
+	if !isIngressResource(req.Resource) {
+		glog.Warningf("ignoring admission review of unexpected resource %v", req.Resource)
+		ar.Response = &v1beta1.AdmissionResponse{UID: req.UID, Allowed: true}
+		return
+	}
+
+	ing := &extensions.Ingress{}
+	err := json.Unmarshal(req.Object.Raw, ing)
+	if err != nil {
+		glog.Errorf("unexpected error decoding Ingress %v/%v: %v", req.Namespace, req.Name, err)
+		ar.Response = denied(req.UID, err)
+		return
+	}
+
+	if ing.Namespace == "" {
+		ing.Namespace = req.Namespace
+	}
+
+	err = ia.Checker.CheckIngress(ing)
+	if err != nil {
+		glog.Warningf("rejecting Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+		ar.Response = denied(req.UID, err)
+		return
+	}
+
+	glog.V(2).Infof("accepting Ingress %v/%v", ing.Namespace, ing.Name)
+	ar.Response = &v1beta1.AdmissionResponse{UID: req.UID, Allowed: true}
+}
+
+func isIngressResource(resource metav1.GroupVersionResource) bool {
+	for _, r := range ingressResources {
+		if r == resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+func denied(uid types.UID, err error) *v1beta1.AdmissionResponse {
+	return &v1beta1.AdmissionResponse{
+		UID:     uid,
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusBadRequest,
+			Reason:  metav1.StatusReasonBadRequest,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k8s.io/ingress-nginx/internal/apis/admission/v1beta1"
+)
+
+type failChecker struct {
+	checked []string
+}
+
+func (c *failChecker) CheckIngress(ing *extensions.Ingress) error {
+	c.checked = append(c.checked, fmt.Sprintf("%v/%v", ing.Namespace, ing.Name))
+	if ing.Name == "invalid" {
+		return fmt.Errorf("invalid configuration")
+	}
+	return nil
+}
+
+func newReview(t *testing.T, resource metav1.GroupVersionResource, name string) *v1beta1.AdmissionReview {
+	raw, err := json.Marshal(&extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return &v1beta1.AdmissionReview{
+		Request: &v1beta1.AdmissionRequest{
+			UID:       "uid",
+			Resource:  resource,
+			Namespace: "default",
+			Name:      name,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func TestHandleAdmission(t *testing.T) {
+	ingresses := metav1.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}
+	services := metav1.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	testCases := []struct {
+		resource metav1.GroupVersionResource
+		name     string
+		allowed  bool
+		checked  int
+	}{
+		{ingresses, "valid", true, 1},
+		{ingresses, "invalid", false, 1},
+		{services, "invalid", true, 0},
+	}
+
+	for _, tc := range testCases {
+		checker := &failChecker{}
+		adm := &IngressAdmission{Checker: checker}
+
+		review := newReview(t, tc.resource, tc.name)
+		adm.HandleAdmission(review)
+
+		if review.Response == nil {
+			t.Fatalf("expected a response for %v", tc.name)
+		}
+		if review.Response.UID != "uid" {
+			t.Errorf("expected the uid of the request but got %v", review.Response.UID)
+		}
+		if review.Response.Allowed != tc.allowed {
+			t.Errorf("expected allowed %v for %v %v but got %v", tc.allowed, tc.resource.Resource, tc.name, review.Response.Allowed)
+		}
+		if !tc.allowed && review.Response.Result.Message != "invalid configuration" {
+			t.Errorf("expected the error of the check but got %v", review.Response.Result.Message)
+		}
+		if len(checker.checked) != tc.checked {
+			t.Errorf("expected %v checks but got %v", tc.checked, checker.checked)
+		}
+		if tc.checked > 0 && checker.checked[0] != "default/"+tc.name {
+			t.Errorf("expected the namespace of the request to be used but got %v", checker.checked[0])
+		}
+	}
+}
+
+func TestHandleAdmissionWithoutRequest(t *testing.T) {
+	adm := &IngressAdmission{Checker: &failChecker{}}
+
+	review := &v1beta1.AdmissionReview{}
+	adm.HandleAdmission(review)
+
+	if review.Response == nil || review.Response.Allowed {
+		t.Errorf("expected a review without request to be denied")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+
+	"k8s.io/ingress-nginx/internal/apis/admission/v1beta1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// AdmissionController checks the objects of an admission review
+type AdmissionController interface {
+	HandleAdmission(*v1beta1.AdmissionReview)
+}
+
+// AdmissionControllerServer implements the HTTP handler of the
+// validating webhook called by the Kubernetes API server
+type AdmissionControllerServer struct {
+	AdmissionController AdmissionController
+	Decoder             runtime.Decoder
+}
+
+// NewAdmissionControllerServer creates a new HTTP handler for the admission controller
+func NewAdmissionControllerServer(ac AdmissionController) *AdmissionControllerServer {
+	return &AdmissionControllerServer{
+		AdmissionController: ac,
+		Decoder:             codecs.UniversalDeserializer(),
+	}
+}
+
+// ServeHTTP decodes the admission review of the request and
+// writes the review with the response of the admission controller
+func (acs *AdmissionControllerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %v not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		glog.Errorf("unexpected error reading the admission review: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := &v1beta1.AdmissionReview{}
+	_, _, err = acs.Decoder.Decode(body, nil, review)
+	if err != nil {
+		glog.Errorf("unexpected error decoding the admission review: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	acs.AdmissionController.HandleAdmission(review)
+
+	review.Request = nil
+	review.APIVersion = v1beta1.SchemeGroupVersion.String()
+	review.Kind = "AdmissionReview"
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(review)
+	if err != nil {
+		glog.Errorf("unexpected error writing the admission review: %v", err)
+	}
+}
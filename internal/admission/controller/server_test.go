@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/apis/admission/v1beta1"
+)
+
+func TestServeHTTP(t *testing.T) {
+	server := NewAdmissionControllerServer(&IngressAdmission{Checker: &failChecker{}})
+
+	review := newReview(t, metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}, "invalid")
+	review.APIVersion = "admission.k8s.io/v1beta1"
+	review.Kind = "AdmissionReview"
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v but got %v: %v", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	result := &v1beta1.AdmissionReview{}
+	err = json.Unmarshal(w.Body.Bytes(), result)
+	if err != nil {
+		t.Fatalf("unexpected error decoding the response: %v", err)
+	}
+
+	if result.Kind != "AdmissionReview" || result.APIVersion != "admission.k8s.io/v1beta1" {
+		t.Errorf("unexpected type of the response %v %v", result.APIVersion, result.Kind)
+	}
+	if result.Request != nil {
+		t.Errorf("expected the response without the request")
+	}
+	if result.Response == nil || result.Response.Allowed {
+		t.Errorf("expected the Ingress to be denied")
+	}
+}
+
+func TestServeHTTPInvalidRequest(t *testing.T) {
+	server := NewAdmissionControllerServer(&IngressAdmission{Checker: &failChecker{}})
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %v but got %v", http.StatusMethodNotAllowed, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{"))))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %v but got %v", http.StatusBadRequest, w.Code)
+	}
+}
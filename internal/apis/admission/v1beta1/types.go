@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AdmissionReview describes an admission review request and response
+type AdmissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	// Request describes the attributes of the admission request
+	// +optional
+	Request *AdmissionRequest `json:"request,omitempty"`
+	// Response describes the attributes of the admission response
+	// +optional
+	Response *AdmissionResponse `json:"response,omitempty"`
+}
+
+// AdmissionRequest describes the attributes of an admission request
+type AdmissionRequest struct {
+	// UID identifies the admission request
+	UID types.UID `json:"uid"`
+	// Kind is the type of the object being submitted
+	Kind metav1.GroupVersionKind `json:"kind"`
+	// Resource is the resource being requested
+	Resource metav1.GroupVersionResource `json:"resource"`
+	// Name is the name of the object
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Namespace is the namespace of the object
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Operation is the operation being performed (CREATE, UPDATE, DELETE or CONNECT)
+	Operation string `json:"operation"`
+	// Object is the object from the incoming request
+	// +optional
+	Object runtime.RawExtension `json:"object,omitempty"`
+	// OldObject is the existing object in an update request
+	// +optional
+	OldObject runtime.RawExtension `json:"oldObject,omitempty"`
+}
+
+// AdmissionResponse describes the result of an admission review
+type AdmissionResponse struct {
+	// UID is the identifier of the admission request
+	UID types.UID `json:"uid"`
+	// Allowed indicates whether or not the admission request was permitted
+	Allowed bool `json:"allowed"`
+	// Result contains the details of a rejected request
+	// +optional
+	Result *metav1.Status `json:"status,omitempty"`
+}
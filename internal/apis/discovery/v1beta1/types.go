@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelServiceName is the label of the EndpointSlices that contains the
+// name of the service the endpoints belong to
+const LabelServiceName = "kubernetes.io/service-name"
+
+// AddressType is the type of the addresses of an EndpointSlice
+type AddressType string
+
+const (
+	// AddressTypeIPv4 represents IPv4 addresses
+	AddressTypeIPv4 = AddressType("IPv4")
+	// AddressTypeIPv6 represents IPv6 addresses
+	AddressTypeIPv6 = AddressType("IPv6")
+	// AddressTypeFQDN represents fully qualified domain names
+	AddressTypeFQDN = AddressType("FQDN")
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EndpointSlice contains a subset of the endpoints of a service. A service
+// is backed by several slices, so the changes of the endpoints don't
+// require the update of a single large object.
+type EndpointSlice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// AddressType is the type of the addresses of the endpoints
+	AddressType AddressType `json:"addressType"`
+	// Endpoints contains the endpoints of the slice
+	Endpoints []Endpoint `json:"endpoints"`
+	// Ports contains the ports exposed by all the endpoints of the slice
+	// +optional
+	Ports []EndpointPort `json:"ports"`
+}
+
+// Endpoint is a backend of a service
+type Endpoint struct {
+	// Addresses contains the addresses of the endpoint
+	Addresses []string `json:"addresses"`
+	// Conditions contains the current state of the endpoint
+	// +optional
+	Conditions EndpointConditions `json:"conditions,omitempty"`
+	// Hostname is the hostname of the endpoint
+	// +optional
+	Hostname *string `json:"hostname,omitempty"`
+	// TargetRef references the object (usually a pod) of the endpoint
+	// +optional
+	TargetRef *apiv1.ObjectReference `json:"targetRef,omitempty"`
+	// Topology contains the topology labels of the endpoint, like the
+	// kubernetes.io/hostname label with the name of the node
+	// +optional
+	Topology map[string]string `json:"topology,omitempty"`
+}
+
+// EndpointConditions contains the state of an endpoint
+type EndpointConditions struct {
+	// Ready indicates the endpoint is ready to receive traffic.
+	// A nil value is interpreted as ready
+	// +optional
+	Ready *bool `json:"ready,omitempty"`
+}
+
+// EndpointPort is a port exposed by the endpoints
+type EndpointPort struct {
+	// Name is the name of the port of the service
+	// +optional
+	Name *string `json:"name,omitempty"`
+	// Protocol is the protocol of the port, TCP (default) or UDP
+	// +optional
+	Protocol *apiv1.Protocol `json:"protocol,omitempty"`
+	// Port is the port number of the endpoints
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EndpointSliceList is a list of EndpointSlices
+type EndpointSliceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []EndpointSlice `json:"items"`
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayClass describes a class of Gateways managed by a controller. It is
+// a cluster scoped resource.
+type GatewayClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GatewayClassSpec `json:"spec"`
+}
+
+// GatewayClassSpec describes the controller of the Gateways of the class
+type GatewayClassSpec struct {
+	// ControllerName is the name of the controller that manages the
+	// Gateways of the class, e.g. k8s.io/ingress-nginx
+	ControllerName string `json:"controllerName"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayClassList is a list of GatewayClass resources
+type GatewayClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []GatewayClass `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Gateway describes the listeners of a load balancer, to which the routes
+// are attached
+type Gateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GatewaySpec `json:"spec"`
+}
+
+// GatewaySpec describes the class and the listeners of a Gateway
+type GatewaySpec struct {
+	// GatewayClassName is the name of the GatewayClass of the Gateway
+	GatewayClassName string `json:"gatewayClassName"`
+
+	// Listeners contains the listeners of the Gateway
+	Listeners []Listener `json:"listeners"`
+}
+
+// ProtocolType is the protocol of a listener
+type ProtocolType string
+
+const (
+	// HTTPProtocolType accepts plain text HTTP requests
+	HTTPProtocolType ProtocolType = "HTTP"
+	// HTTPSProtocolType accepts HTTP requests over TLS
+	HTTPSProtocolType ProtocolType = "HTTPS"
+	// TLSProtocolType accepts TLS connections routed using the SNI
+	TLSProtocolType ProtocolType = "TLS"
+)
+
+// TLSModeType is the TLS behavior of a listener
+type TLSModeType string
+
+const (
+	// TLSModeTerminate terminates the TLS connections in the Gateway
+	TLSModeTerminate TLSModeType = "Terminate"
+	// TLSModePassthrough sends the TLS connections to the backends
+	TLSModePassthrough TLSModeType = "Passthrough"
+)
+
+// FromNamespaces selects the namespaces of the routes allowed to attach
+// to a listener
+type FromNamespaces string
+
+const (
+	// NamespacesFromAll allows the routes of all the namespaces
+	NamespacesFromAll FromNamespaces = "All"
+	// NamespacesFromSame allows the routes of the namespace of the Gateway
+	NamespacesFromSame FromNamespaces = "Same"
+)
+
+// Listener describes a port, protocol and hostname accepted by a Gateway
+type Listener struct {
+	// Name is the name of the listener, unique in the Gateway
+	Name string `json:"name"`
+
+	// Hostname is the hostname, or wildcard hostname, accepted by the
+	// listener. All the hostnames are accepted if it is empty
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Port is the network port of the listener
+	Port int32 `json:"port"`
+
+	// Protocol is the protocol of the listener
+	Protocol ProtocolType `json:"protocol"`
+
+	// TLS is the TLS configuration of the HTTPS and TLS listeners
+	// +optional
+	TLS *GatewayTLSConfig `json:"tls,omitempty"`
+
+	// AllowedRoutes describes the routes that can attach to the listener.
+	// By default the routes of the namespace of the Gateway
+	// +optional
+	AllowedRoutes *AllowedRoutes `json:"allowedRoutes,omitempty"`
+}
+
+// GatewayTLSConfig describes the TLS configuration of a listener
+type GatewayTLSConfig struct {
+	// Mode is the TLS behavior of the listener, Terminate by default
+	// +optional
+	Mode TLSModeType `json:"mode,omitempty"`
+
+	// CertificateRefs contains the Secrets with the certificates used to
+	// terminate the TLS connections
+	// +optional
+	CertificateRefs []SecretObjectReference `json:"certificateRefs,omitempty"`
+}
+
+// SecretObjectReference references a Secret
+type SecretObjectReference struct {
+	// Name is the name of the Secret
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret, the namespace of the
+	// Gateway by default
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AllowedRoutes describes the routes that can attach to a listener
+type AllowedRoutes struct {
+	// Namespaces selects the namespaces of the routes
+	// +optional
+	Namespaces *RouteNamespaces `json:"namespaces,omitempty"`
+}
+
+// RouteNamespaces selects the namespaces of the routes
+type RouteNamespaces struct {
+	// From selects the namespaces, Same by default
+	// +optional
+	From FromNamespaces `json:"from,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayList is a list of Gateway resources
+type GatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Gateway `json:"items"`
+}
+
+// ParentReference references the Gateway, or a listener of a Gateway,
+// to which a route is attached
+type ParentReference struct {
+	// Name is the name of the Gateway
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Gateway, the namespace of the
+	// route by default
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener. The route is attached to
+	// all the compatible listeners of the Gateway if it is empty
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// BackendRef references the Service that receives the requests or
+// connections of a route
+type BackendRef struct {
+	// Name is the name of the Service
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Service, the namespace of the
+	// route by default. Only the Services of the namespace of the route
+	// are supported
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Port is the port of the Service
+	Port int32 `json:"port"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// HTTPRoute routes the HTTP requests of the listeners of a Gateway
+type HTTPRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HTTPRouteSpec `json:"spec"`
+}
+
+// HTTPRouteSpec describes the hostnames and rules of an HTTPRoute
+type HTTPRouteSpec struct {
+	// ParentRefs contains the Gateways of the route
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+
+	// Hostnames contains the hostnames of the requests. The hostnames of
+	// the listeners are used if it is empty
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Rules contains the rules of the route
+	Rules []HTTPRouteRule `json:"rules,omitempty"`
+}
+
+// HTTPRouteRule routes the requests that match any of the matches to the
+// backends of the rule
+type HTTPRouteRule struct {
+	// Matches contains the conditions of the requests. By default the
+	// requests with a path prefix /
+	// +optional
+	Matches []HTTPRouteMatch `json:"matches,omitempty"`
+
+	// BackendRefs contains the backends of the rule
+	BackendRefs []HTTPBackendRef `json:"backendRefs,omitempty"`
+}
+
+// PathMatchType is the type of the match of a path
+type PathMatchType string
+
+const (
+	// PathMatchExact matches the exact path
+	PathMatchExact PathMatchType = "Exact"
+	// PathMatchPathPrefix matches the paths with the given prefix
+	PathMatchPathPrefix PathMatchType = "PathPrefix"
+)
+
+// HTTPRouteMatch describes the conditions of the requests of a rule
+type HTTPRouteMatch struct {
+	// Path is the path of the requests
+	// +optional
+	Path *HTTPPathMatch `json:"path,omitempty"`
+}
+
+// HTTPPathMatch describes the path of the requests
+type HTTPPathMatch struct {
+	// Type is the type of the match, PathPrefix by default
+	// +optional
+	Type PathMatchType `json:"type,omitempty"`
+
+	// Value is the path, / by default
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// HTTPBackendRef references the Service of an HTTPRoute
+type HTTPBackendRef struct {
+	BackendRef `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// HTTPRouteList is a list of HTTPRoute resources
+type HTTPRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []HTTPRoute `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TLSRoute routes the TLS connections of the passthrough listeners of a
+// Gateway using the server name (SNI)
+type TLSRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TLSRouteSpec `json:"spec"`
+}
+
+// TLSRouteSpec describes the hostnames and rules of a TLSRoute
+type TLSRouteSpec struct {
+	// ParentRefs contains the Gateways of the route
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+
+	// Hostnames contains the server names of the connections
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Rules contains the rules of the route
+	Rules []TLSRouteRule `json:"rules,omitempty"`
+}
+
+// TLSRouteRule routes the connections to the backends of the rule
+type TLSRouteRule struct {
+	// BackendRefs contains the backends of the rule
+	BackendRefs []BackendRef `json:"backendRefs,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TLSRouteList is a list of TLSRoute resources
+type TLSRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []TLSRoute `json:"items"`
+}
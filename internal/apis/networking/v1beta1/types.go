@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IngressClass represents a class of Ingresses, the controller that
+// implements the class and the parameters of the controller.
+type IngressClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IngressClassSpec `json:"spec,omitempty"`
+}
+
+// IngressClassSpec describes the controller of an IngressClass
+type IngressClassSpec struct {
+	// Controller is the name of the controller that implements the class
+	// +optional
+	Controller string `json:"controller,omitempty"`
+	// Parameters references a resource with the configuration of the controller
+	// +optional
+	Parameters *IngressClassParametersReference `json:"parameters,omitempty"`
+}
+
+// IngressClassParametersReference references the cluster scoped resource
+// with the parameters of an IngressClass
+type IngressClassParametersReference struct {
+	// APIGroup is the group of the resource. The core API group is
+	// used if the group is not specified
+	// +optional
+	APIGroup *string `json:"apiGroup"`
+	// Kind is the type of the resource
+	Kind string `json:"kind"`
+	// Name is the name of the resource
+	Name string `json:"name"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IngressClassList is a list of IngressClasses
+type IngressClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []IngressClass `json:"items"`
+}
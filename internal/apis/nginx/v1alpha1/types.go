@@ -0,0 +1,295 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StreamRoute exposes a TCP or UDP service in a port of the ingress
+// controller. It replaces the entries of the tcp-services and
+// udp-services configmaps, allowing teams to manage the exposed ports
+// in their own namespaces.
+type StreamRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StreamRouteSpec `json:"spec"`
+}
+
+// StreamRouteSpec describes the port exposed by the ingress controller
+// and the service that receives the connections
+type StreamRouteSpec struct {
+	// Port is the port exposed by the ingress controller
+	Port int `json:"port"`
+	// Protocol is the protocol of the port, TCP (default) or UDP
+	// +optional
+	Protocol apiv1.Protocol `json:"protocol,omitempty"`
+	// Hosts contains the server names (SNI) of the TLS connections
+	// routed to the backend (TCP only). The TLS connections are not
+	// terminated, allowing several routes to share the same port
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+	// Backend is the service, located in the namespace of the
+	// StreamRoute, that receives the connections
+	Backend StreamBackend `json:"backend"`
+	// ProxyProtocol configures the PROXY protocol of TCP routes
+	// +optional
+	ProxyProtocol StreamProxyProtocol `json:"proxyProtocol,omitempty"`
+	// ConnectTimeout is the timeout to establish a connection with
+	// the upstream servers, using the NGINX time format (e.g. 5s)
+	// +optional
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+	// ProxyTimeout is the timeout between two successive read or write
+	// operations, using the NGINX time format (e.g. 10m)
+	// +optional
+	ProxyTimeout string `json:"proxyTimeout,omitempty"`
+	// ProxyResponses is the number of datagrams expected from the service
+	// in response to a client datagram (UDP only). By default the value of
+	// the proxy-stream-responses setting of the configuration configmap
+	// +optional
+	ProxyResponses *int32 `json:"proxyResponses,omitempty"`
+	// ReusePort creates an individual listening socket for each worker
+	// process, distributing the datagrams between them (UDP only)
+	// +optional
+	ReusePort bool `json:"reusePort,omitempty"`
+}
+
+// StreamBackend references a port of a service
+type StreamBackend struct {
+	// ServiceName is the name of the service
+	ServiceName string `json:"serviceName"`
+	// ServicePort is the name or number of the port of the service
+	ServicePort intstr.IntOrString `json:"servicePort"`
+}
+
+// StreamProxyProtocol configures the PROXY protocol of a route
+type StreamProxyProtocol struct {
+	// Decode indicates the clients send the PROXY protocol header
+	// +optional
+	Decode bool `json:"decode,omitempty"`
+	// Encode indicates the PROXY protocol header is sent to the service
+	// +optional
+	Encode bool `json:"encode,omitempty"`
+	// Version is the version of the header sent to the service, 1 (default) or 2
+	// +optional
+	Version int `json:"version,omitempty"`
+	// TLVs contains custom Type-Length-Value vectors added to the
+	// version 2 header. The types must be in the custom range (0xE0-0xEF)
+	// +optional
+	TLVs []StreamProxyProtocolTLV `json:"tlvs,omitempty"`
+}
+
+// StreamProxyProtocolTLV is a Type-Length-Value vector of the version 2
+// of the PROXY protocol
+type StreamProxyProtocolTLV struct {
+	// Type is the type of the vector
+	Type int `json:"type"`
+	// Value is the value of the vector
+	Value string `json:"value"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StreamRouteList is a list of StreamRoute resources
+type StreamRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []StreamRoute `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IngressClassParameters contains the configuration of the ingress
+// controller referenced by the parameters of an IngressClass. It is a
+// cluster scoped resource.
+type IngressClassParameters struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IngressClassParametersSpec `json:"spec"`
+}
+
+// IngressClassParametersSpec contains the defaults of the ingress controllers
+// of a class. The command line flags take precedence over the parameters.
+type IngressClassParametersSpec struct {
+	// ConfigMap is the ConfigMap with the configuration of NGINX,
+	// in the form namespace/name
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+	// TCPServicesConfigMap is the ConfigMap with the TCP services to expose,
+	// in the form namespace/name
+	// +optional
+	TCPServicesConfigMap string `json:"tcpServicesConfigMap,omitempty"`
+	// UDPServicesConfigMap is the ConfigMap with the UDP services to expose,
+	// in the form namespace/name
+	// +optional
+	UDPServicesConfigMap string `json:"udpServicesConfigMap,omitempty"`
+	// PublishService is the service fronting the ingress controllers used to
+	// update the status of the Ingresses, in the form namespace/name
+	// +optional
+	PublishService string `json:"publishService,omitempty"`
+	// DefaultSSLCertificate is the secret with the certificate of the
+	// catch-all server, in the form namespace/name
+	// +optional
+	DefaultSSLCertificate string `json:"defaultSSLCertificate,omitempty"`
+	// DefaultBackendService is the service of the default backend, which
+	// serves the requests that do not match any Ingress rule, in the form
+	// namespace/name
+	// +optional
+	DefaultBackendService string `json:"defaultBackendService,omitempty"`
+	// Template is the path of the NGINX template in the container of
+	// the ingress controller, usually mounted from a volume
+	// +optional
+	Template string `json:"template,omitempty"`
+	// TemplateConfigMap is the ConfigMap that contains the NGINX template in
+	// the key nginx.tmpl, in the form namespace/name
+	// +optional
+	TemplateConfigMap string `json:"templateConfigMap,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IngressClassParametersList is a list of IngressClassParameters resources
+type IngressClassParametersList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []IngressClassParameters `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReferenceGrant allows the Ingresses of other namespaces to reference
+// the Secrets of the namespace of the grant, i.e. TLS certificates or
+// basic authentication files managed in a central namespace.
+type ReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReferenceGrantSpec `json:"spec"`
+}
+
+// ReferenceGrantSpec describes the namespaces allowed to reference the
+// Secrets and the Secrets they can reference
+type ReferenceGrantSpec struct {
+	// From contains the namespaces of the Ingresses allowed to reference
+	// the Secrets
+	From []ReferenceGrantFrom `json:"from"`
+
+	// To contains the Secrets that can be referenced. All the Secrets of
+	// the namespace can be referenced if the list is empty
+	// +optional
+	To []ReferenceGrantTo `json:"to,omitempty"`
+}
+
+// ReferenceGrantFrom describes a namespace allowed to reference the Secrets
+type ReferenceGrantFrom struct {
+	// Namespace is the name of the namespace
+	Namespace string `json:"namespace"`
+}
+
+// ReferenceGrantTo describes a Secret that can be referenced
+type ReferenceGrantTo struct {
+	// Name is the name of the Secret
+	Name string `json:"name"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReferenceGrantList is a list of ReferenceGrant resources
+type ReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ReferenceGrant `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NginxDefaults contains the defaults of the configuration of the
+// locations of the Ingresses, which take precedence over the ConfigMap
+// and are overridden by the annotations of the Ingresses. The defaults
+// of the namespace of the ConfigMap apply to all the Ingresses, the
+// defaults of other namespaces only to the Ingresses of the namespace.
+type NginxDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NginxDefaultsSpec `json:"spec"`
+}
+
+// NginxDefaultsSpec describes the defaults of the locations. The empty
+// fields keep the value of the ConfigMap or of the previous defaults.
+type NginxDefaultsSpec struct {
+	// IngressClass restricts the defaults to the Ingresses of a class.
+	// The defaults apply to the Ingresses of any class if it is empty
+	// +optional
+	IngressClass string `json:"ingressClass,omitempty"`
+
+	// ProxyConnectTimeout is the timeout in seconds for establishing
+	// a connection with the backends
+	// +optional
+	ProxyConnectTimeout int `json:"proxyConnectTimeout,omitempty"`
+	// ProxySendTimeout is the timeout in seconds for transmitting
+	// a request to the backends
+	// +optional
+	ProxySendTimeout int `json:"proxySendTimeout,omitempty"`
+	// ProxyReadTimeout is the timeout in seconds for reading a response
+	// from the backends
+	// +optional
+	ProxyReadTimeout int `json:"proxyReadTimeout,omitempty"`
+
+	// ProxyBodySize is the maximum size of the body of the requests
+	// +optional
+	ProxyBodySize string `json:"proxyBodySize,omitempty"`
+	// ProxyBufferSize is the size of the buffer of the first part of
+	// the responses
+	// +optional
+	ProxyBufferSize string `json:"proxyBufferSize,omitempty"`
+	// ProxyBuffersNumber is the number of buffers of the responses
+	// +optional
+	ProxyBuffersNumber int `json:"proxyBuffersNumber,omitempty"`
+	// ProxyBuffering enables (on) or disables (off) the buffering of
+	// the responses
+	// +optional
+	ProxyBuffering string `json:"proxyBuffering,omitempty"`
+	// ProxyRequestBuffering enables (on) or disables (off) the buffering
+	// of the body of the requests
+	// +optional
+	ProxyRequestBuffering string `json:"proxyRequestBuffering,omitempty"`
+
+	// LogFormat is the format of the access log of the locations, using
+	// the syntax of the log-format-upstream setting of the ConfigMap
+	// +optional
+	LogFormat string `json:"logFormat,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NginxDefaultsList is a list of NginxDefaults resources
+type NginxDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NginxDefaults `json:"items"`
+}
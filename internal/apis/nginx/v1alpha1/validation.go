@@ -0,0 +1,223 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/net/proxyprotocol"
+)
+
+// timeRegex matches a time in the NGINX format
+var timeRegex = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+
+// maxTLVLength is the maximum length of the value of a TLV
+const maxTLVLength = 1024
+
+// Validate checks the specification of a StreamRoute returning
+// an error if the route cannot be used to configure NGINX
+func (r *StreamRoute) Validate() error {
+	spec := r.Spec
+
+	if spec.Port < 1 || spec.Port > 65535 {
+		return fmt.Errorf("invalid port %v", spec.Port)
+	}
+
+	switch spec.Protocol {
+	case "", apiv1.ProtocolTCP:
+		if spec.ProxyResponses != nil || spec.ReusePort {
+			return fmt.Errorf("proxyResponses and reusePort are only supported in UDP routes")
+		}
+		hosts := sets.NewString()
+		for _, host := range spec.Hosts {
+			errs := validation.IsDNS1123Subdomain(host)
+			if len(errs) > 0 {
+				errs = validation.IsWildcardDNS1123Subdomain(host)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("invalid host %v: %v", host, errs[0])
+			}
+			if hosts.Has(host) {
+				return fmt.Errorf("duplicated host %v", host)
+			}
+			hosts.Insert(host)
+		}
+	case apiv1.ProtocolUDP:
+		if spec.ProxyResponses != nil && *spec.ProxyResponses < 0 {
+			return fmt.Errorf("invalid number of responses %v", *spec.ProxyResponses)
+		}
+		if spec.ProxyProtocol.Decode || spec.ProxyProtocol.Encode {
+			return fmt.Errorf("PROXY protocol is not supported in UDP routes")
+		}
+		if len(spec.Hosts) > 0 {
+			return fmt.Errorf("hosts are only supported in TCP routes")
+		}
+	default:
+		return fmt.Errorf("invalid protocol %v", spec.Protocol)
+	}
+
+	if spec.Backend.ServiceName == "" {
+		return fmt.Errorf("the backend service name is required")
+	}
+	if spec.Backend.ServicePort.String() == "" || spec.Backend.ServicePort.String() == "0" {
+		return fmt.Errorf("the backend service port is required")
+	}
+
+	pp := spec.ProxyProtocol
+	switch pp.Version {
+	case 0, 1:
+		if len(pp.TLVs) > 0 {
+			return fmt.Errorf("TLVs require the version 2 of the PROXY protocol")
+		}
+	case 2:
+		if !pp.Encode {
+			return fmt.Errorf("the version 2 of the PROXY protocol requires encode")
+		}
+		for _, tlv := range pp.TLVs {
+			if tlv.Type < proxyprotocol.TLVTypeMinCustom || tlv.Type > proxyprotocol.TLVTypeMaxCustom {
+				return fmt.Errorf("invalid TLV type %#x, only custom types (0xE0-0xEF) are allowed", tlv.Type)
+			}
+			if len(tlv.Value) > maxTLVLength {
+				return fmt.Errorf("the value of the TLV type %#x exceeds %v bytes", tlv.Type, maxTLVLength)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid PROXY protocol version %v", pp.Version)
+	}
+
+	if spec.ConnectTimeout != "" && !timeRegex.MatchString(spec.ConnectTimeout) {
+		return fmt.Errorf("invalid connect timeout %v", spec.ConnectTimeout)
+	}
+	if spec.ProxyTimeout != "" && !timeRegex.MatchString(spec.ProxyTimeout) {
+		return fmt.Errorf("invalid proxy timeout %v", spec.ProxyTimeout)
+	}
+
+	return nil
+}
+
+// GetProtocol returns the protocol of the route, TCP by default
+func (r *StreamRoute) GetProtocol() apiv1.Protocol {
+	if r.Spec.Protocol == "" {
+		return apiv1.ProtocolTCP
+	}
+	return r.Spec.Protocol
+}
+
+// Validate checks the specification of the IngressClassParameters returning
+// an error if the parameters cannot be used to configure the ingress controller
+func (p *IngressClassParameters) Validate() error {
+	spec := p.Spec
+
+	refs := []struct {
+		field string
+		value string
+	}{
+		{"configMap", spec.ConfigMap},
+		{"tcpServicesConfigMap", spec.TCPServicesConfigMap},
+		{"udpServicesConfigMap", spec.UDPServicesConfigMap},
+		{"publishService", spec.PublishService},
+		{"defaultSSLCertificate", spec.DefaultSSLCertificate},
+		{"defaultBackendService", spec.DefaultBackendService},
+		{"templateConfigMap", spec.TemplateConfigMap},
+	}
+	for _, ref := range refs {
+		if ref.value == "" {
+			continue
+		}
+		ns, name, err := k8s.ParseNameNS(ref.value)
+		if err != nil {
+			return fmt.Errorf("invalid %v: %v", ref.field, err)
+		}
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace of %v %v: %v", ref.field, ref.value, errs[0])
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid name of %v %v: %v", ref.field, ref.value, errs[0])
+		}
+	}
+
+	if spec.Template != "" && !filepath.IsAbs(spec.Template) {
+		return fmt.Errorf("the template %v must be an absolute path", spec.Template)
+	}
+
+	return nil
+}
+
+// sizeRegex matches a size in the NGINX format
+var sizeRegex = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+// Validate checks the specification of the NginxDefaults returning an
+// error if the defaults cannot be used to configure NGINX
+func (d *NginxDefaults) Validate() error {
+	spec := d.Spec
+
+	numbers := []struct {
+		field string
+		value int
+	}{
+		{"proxyConnectTimeout", spec.ProxyConnectTimeout},
+		{"proxySendTimeout", spec.ProxySendTimeout},
+		{"proxyReadTimeout", spec.ProxyReadTimeout},
+		{"proxyBuffersNumber", spec.ProxyBuffersNumber},
+	}
+	for _, n := range numbers {
+		if n.value < 0 {
+			return fmt.Errorf("invalid %v %v", n.field, n.value)
+		}
+	}
+
+	sizes := []struct {
+		field string
+		value string
+	}{
+		{"proxyBodySize", spec.ProxyBodySize},
+		{"proxyBufferSize", spec.ProxyBufferSize},
+	}
+	for _, s := range sizes {
+		if s.value != "" && !sizeRegex.MatchString(s.value) {
+			return fmt.Errorf("invalid %v %v", s.field, s.value)
+		}
+	}
+
+	switches := []struct {
+		field string
+		value string
+	}{
+		{"proxyBuffering", spec.ProxyBuffering},
+		{"proxyRequestBuffering", spec.ProxyRequestBuffering},
+	}
+	for _, s := range switches {
+		if s.value != "" && s.value != "on" && s.value != "off" {
+			return fmt.Errorf("invalid %v %v, must be on or off", s.field, s.value)
+		}
+	}
+
+	// the format is rendered between single quotes
+	if strings.Contains(spec.LogFormat, "'") {
+		return fmt.Errorf("the logFormat must not contain single quotes")
+	}
+
+	return nil
+}
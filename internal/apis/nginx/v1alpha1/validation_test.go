@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidate(t *testing.T) {
+	backend := StreamBackend{ServiceName: "redis", ServicePort: intstr.FromInt(6379)}
+	one, negative := int32(1), int32(-1)
+
+	tests := []struct {
+		title  string
+		spec   StreamRouteSpec
+		expErr bool
+	}{
+		{"valid", StreamRouteSpec{Port: 6379, Backend: backend}, false},
+		{"udp", StreamRouteSpec{Port: 53, Protocol: apiv1.ProtocolUDP, Backend: backend}, false},
+		{"named port", StreamRouteSpec{Port: 6379, Backend: StreamBackend{ServiceName: "redis", ServicePort: intstr.FromString("redis")}}, false},
+		{"timeouts", StreamRouteSpec{Port: 6379, Backend: backend, ConnectTimeout: "5s", ProxyTimeout: "10m"}, false},
+		{"invalid port", StreamRouteSpec{Port: 70000, Backend: backend}, true},
+		{"invalid protocol", StreamRouteSpec{Port: 6379, Protocol: "SCTP", Backend: backend}, true},
+		{"udp with proxy protocol", StreamRouteSpec{Port: 53, Protocol: apiv1.ProtocolUDP, Backend: backend, ProxyProtocol: StreamProxyProtocol{Encode: true}}, true},
+		{"missing service", StreamRouteSpec{Port: 6379, Backend: StreamBackend{ServicePort: intstr.FromInt(6379)}}, true},
+		{"missing service port", StreamRouteSpec{Port: 6379, Backend: StreamBackend{ServiceName: "redis"}}, true},
+		{"udp options", StreamRouteSpec{Port: 53, Protocol: apiv1.ProtocolUDP, Backend: backend, ProxyResponses: &one, ReusePort: true}, false},
+		{"tcp with udp options", StreamRouteSpec{Port: 6379, Backend: backend, ReusePort: true}, true},
+		{"invalid responses", StreamRouteSpec{Port: 53, Protocol: apiv1.ProtocolUDP, Backend: backend, ProxyResponses: &negative}, true},
+		{"proxy protocol v2", StreamRouteSpec{Port: 6379, Backend: backend, ProxyProtocol: StreamProxyProtocol{Encode: true, Version: 2, TLVs: []StreamProxyProtocolTLV{{Type: 0xE0, Value: "abc"}}}}, false},
+		{"proxy protocol v2 without encode", StreamRouteSpec{Port: 6379, Backend: backend, ProxyProtocol: StreamProxyProtocol{Decode: true, Version: 2}}, true},
+		{"invalid proxy protocol version", StreamRouteSpec{Port: 6379, Backend: backend, ProxyProtocol: StreamProxyProtocol{Encode: true, Version: 3}}, true},
+		{"TLVs with version 1", StreamRouteSpec{Port: 6379, Backend: backend, ProxyProtocol: StreamProxyProtocol{Encode: true, TLVs: []StreamProxyProtocolTLV{{Type: 0xE0, Value: "abc"}}}}, true},
+		{"invalid TLV type", StreamRouteSpec{Port: 6379, Backend: backend, ProxyProtocol: StreamProxyProtocol{Encode: true, Version: 2, TLVs: []StreamProxyProtocolTLV{{Type: 0x01, Value: "h2"}}}}, true},
+		{"hosts", StreamRouteSpec{Port: 443, Backend: backend, Hosts: []string{"redis.example.com", "*.redis.example.com"}}, false},
+		{"invalid host", StreamRouteSpec{Port: 443, Backend: backend, Hosts: []string{"redis_example.com"}}, true},
+		{"duplicated host", StreamRouteSpec{Port: 443, Backend: backend, Hosts: []string{"redis.example.com", "redis.example.com"}}, true},
+		{"udp with hosts", StreamRouteSpec{Port: 53, Protocol: apiv1.ProtocolUDP, Backend: backend, Hosts: []string{"dns.example.com"}}, true},
+		{"invalid timeout", StreamRouteSpec{Port: 6379, Backend: backend, ProxyTimeout: "10 minutes"}, true},
+	}
+
+	for _, test := range tests {
+		r := &StreamRoute{Spec: test.spec}
+		err := r.Validate()
+		if test.expErr && err == nil {
+			t.Errorf("%v: expected error but returned nil", test.title)
+		}
+		if !test.expErr && err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+		}
+	}
+}
+
+func TestValidateIngressClassParameters(t *testing.T) {
+	tests := []struct {
+		title  string
+		spec   IngressClassParametersSpec
+		expErr bool
+	}{
+		{"empty", IngressClassParametersSpec{}, false},
+		{"valid", IngressClassParametersSpec{
+			ConfigMap:             "ingress-nginx/nginx-configuration",
+			TCPServicesConfigMap:  "ingress-nginx/tcp-services",
+			UDPServicesConfigMap:  "ingress-nginx/udp-services",
+			PublishService:        "ingress-nginx/ingress-nginx",
+			DefaultSSLCertificate: "ingress-nginx/default-tls",
+			DefaultBackendService: "ingress-nginx/default-http-backend",
+			Template:              "/etc/nginx/custom/nginx.tmpl",
+			TemplateConfigMap:     "ingress-nginx/nginx-template",
+		}, false},
+		{"missing namespace", IngressClassParametersSpec{ConfigMap: "nginx-configuration"}, true},
+		{"invalid namespace", IngressClassParametersSpec{PublishService: "Ingress_Nginx/ingress-nginx"}, true},
+		{"invalid name", IngressClassParametersSpec{DefaultSSLCertificate: "ingress-nginx/"}, true},
+		{"invalid default backend", IngressClassParametersSpec{DefaultBackendService: "default-http-backend"}, true},
+		{"relative template", IngressClassParametersSpec{Template: "custom/nginx.tmpl"}, true},
+		{"invalid template configmap", IngressClassParametersSpec{TemplateConfigMap: "nginx-template"}, true},
+	}
+
+	for _, test := range tests {
+		p := &IngressClassParameters{Spec: test.spec}
+		err := p.Validate()
+		if test.expErr && err == nil {
+			t.Errorf("%v: expected error but returned nil", test.title)
+		}
+		if !test.expErr && err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+		}
+	}
+}
+
+func TestValidateNginxDefaults(t *testing.T) {
+	tests := []struct {
+		title  string
+		spec   NginxDefaultsSpec
+		expErr bool
+	}{
+		{"empty", NginxDefaultsSpec{}, false},
+		{"valid", NginxDefaultsSpec{
+			IngressClass:        "nginx",
+			ProxyConnectTimeout: 5,
+			ProxyReadTimeout:    120,
+			ProxyBodySize:       "8m",
+			ProxyBufferSize:     "16k",
+			ProxyBuffersNumber:  8,
+			ProxyBuffering:      "off",
+			LogFormat:           `$remote_addr "$request" $status`,
+		}, false},
+		{"negative timeout", NginxDefaultsSpec{ProxySendTimeout: -1}, true},
+		{"invalid size", NginxDefaultsSpec{ProxyBodySize: "8 MB"}, true},
+		{"invalid buffering", NginxDefaultsSpec{ProxyRequestBuffering: "yes"}, true},
+		{"log format with quotes", NginxDefaultsSpec{LogFormat: `'$request'`}, true},
+	}
+
+	for _, test := range tests {
+		d := &NginxDefaults{Spec: test.spec}
+		err := d.Validate()
+		if test.expErr && err == nil {
+			t.Errorf("%v: expected error but returned nil", test.title)
+		}
+		if !test.expErr && err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+		}
+	}
+}
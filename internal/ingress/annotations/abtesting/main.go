@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package abtesting
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const defaultKey = "$remote_addr"
+
+// keyRegex matches one or more NGINX variables
+var keyRegex = regexp.MustCompile(`^(\$[a-zA-Z0-9_]+)+$`)
+
+// Config contains the alternate backend used to run an A/B test and
+// the percentage of the clients that should use it
+type Config struct {
+	// Upstream is the name of the upstream of the alternate backend
+	Upstream string `json:"upstream"`
+	// Percentage of the clients that are sent to the alternate backend
+	Percentage int `json:"percentage"`
+	// Key is the value used to assign a client to a backend
+	Key string `json:"key"`
+	// Backend contains the service referenced in the annotation
+	Backend *extensions.IngressBackend `json:"-"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Upstream != c2.Upstream {
+		return false
+	}
+	if c1.Percentage != c2.Percentage {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+
+	return true
+}
+
+type abTesting struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new A/B testing annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return abTesting{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send a percentage of the clients to an alternate backend
+func (a abTesting) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("ab-testing-backend", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := strings.SplitN(val, ":", 2)
+	if len(svc) != 2 || svc[0] == "" || svc[1] == "" {
+		return nil, ing_errors.NewInvalidAnnotationContent("ab-testing-backend", val)
+	}
+
+	percentage, err := parser.GetIntAnnotation("ab-testing-percentage", ing)
+	if err != nil {
+		return nil, err
+	}
+	if percentage <= 0 || percentage >= 100 {
+		return nil, ing_errors.NewInvalidAnnotationContent("ab-testing-percentage", percentage)
+	}
+
+	key, err := parser.GetStringAnnotation("ab-testing-key", ing)
+	if err != nil || key == "" {
+		key = defaultKey
+	}
+	if !keyRegex.MatchString(key) {
+		return nil, ing_errors.NewInvalidAnnotationContent("ab-testing-key", key)
+	}
+
+	backend := &extensions.IngressBackend{
+		ServiceName: svc[0],
+		ServicePort: intstr.Parse(svc[1]),
+	}
+
+	return &Config{
+		Upstream: fmt.Sprintf("%v-%v-%v",
+			ing.GetNamespace(),
+			backend.ServiceName,
+			backend.ServicePort.String()),
+		Percentage: percentage,
+		Key:        key,
+		Backend:    backend,
+	}, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package abtesting
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+}
+
+func TestParse(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("ab-testing-backend")] = "web-v2:80"
+	data[parser.GetAnnotationWithPrefix("ab-testing-percentage")] = "20"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a valid annotation: %v", err)
+	}
+	c, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+	if c.Upstream != "default-web-v2-80" {
+		t.Errorf("expected default-web-v2-80 as upstream but returned %v", c.Upstream)
+	}
+	if c.Percentage != 20 {
+		t.Errorf("expected 20 as percentage but returned %v", c.Percentage)
+	}
+	if c.Key != "$remote_addr" {
+		t.Errorf("expected $remote_addr as key but returned %v", c.Key)
+	}
+	if c.Backend == nil || c.Backend.ServiceName != "web-v2" {
+		t.Errorf("expected web-v2 as service but returned %v", c.Backend)
+	}
+
+	data[parser.GetAnnotationWithPrefix("ab-testing-key")] = "$cookie_session"
+	ing.SetAnnotations(data)
+
+	i, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a valid annotation: %v", err)
+	}
+	c = i.(*Config)
+	if c.Key != "$cookie_session" {
+		t.Errorf("expected $cookie_session as key but returned %v", c.Key)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []map[string]string{
+		{},
+		{"ab-testing-backend": "web-v2"},
+		{"ab-testing-backend": "web-v2:80"},
+		{"ab-testing-backend": "web-v2:80", "ab-testing-percentage": "0"},
+		{"ab-testing-backend": "web-v2:80", "ab-testing-percentage": "100"},
+		{"ab-testing-backend": "web-v2:80", "ab-testing-percentage": "10", "ab-testing-key": "cookie"},
+		{"ab-testing-backend": "web-v2:80", "ab-testing-percentage": "10", "ab-testing-key": "$cookie_a; return 200"},
+	}
+
+	for _, annotations := range invalid {
+		ing := buildIngress()
+		data := map[string]string{}
+		for k, v := range annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		_, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if err == nil {
+			t.Errorf("expected error parsing an invalid annotation (%v)", annotations)
+		}
+	}
+}
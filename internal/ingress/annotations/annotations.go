@@ -17,36 +17,68 @@ limitations under the License.
 package annotations
 
 import (
+	"path"
+
 	"github.com/golang/glog"
 	"github.com/imdario/mergo"
 
+	apiv1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/abtesting"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/alias"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/brotli"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodybuffersize"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/compression"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/countryfilter"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/customhttperrors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geobackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpcweb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/gzip"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipdenylist"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/limitrate"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/logformat"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/pathtype"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/portinredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/responseheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/satisfy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/secureupstream"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serversnippet"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceupstream"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/snippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslpassthrough"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamkeepalive"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamvhost"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/useragent"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/vtsfilterkey"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/websocket"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/xforwardedprefix"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
@@ -59,30 +91,58 @@ const DeniedKeyName = "Denied"
 type Ingress struct {
 	metav1.ObjectMeta
 	Alias                string
+	ABTesting            abtesting.Config
+	BackendProtocol      string
 	BasicDigestAuth      auth.Config
+	Brotli               *compression.Config
 	CertificateAuth      authtls.Config
 	ClientBodyBufferSize string
 	ConfigurationSnippet string
 	CorsConfig           cors.Config
-	DefaultBackend       string
+	CountryFilter        countryfilter.Config
+	CustomHTTPErrors     []int
+	DefaultBackend       *apiv1.Service
 	Denied               error
+	Denylist             ipwhitelist.SourceRange
 	ExternalAuth         authreq.Config
+	FastCGI              fastcgi.Config
+	GeoBackend           geobackend.Config
+	GRPCWeb              bool
+	Gzip                 *compression.Config
 	HealthCheck          healthcheck.Config
+	HSTS                 hsts.Config
+	LimitRate            limitrate.Config
+	LoadBalancing        string
+	LogFormat            string
+	Maintenance          maintenance.Config
+	Mirror               mirror.Config
+	ModSecurity          modsecurity.Config
+	PathType             string
 	Proxy                proxy.Config
+	ProxyCache           proxycache.Config
+	ProxySSL             proxyssl.Config
 	RateLimit            ratelimit.Config
 	Redirect             redirect.Config
+	RequestHeaders       requestheaders.Config
+	ResponseHeaders      responseheaders.Config
 	Rewrite              rewrite.Config
+	Satisfy              string
 	SecureUpstream       secureupstream.Config
+	SecurityHeaders      securityheaders.Config
 	ServerSnippet        string
 	ServiceUpstream      bool
 	SessionAffinity      sessionaffinity.Config
+	SSLCipher            sslcipher.Config
 	SSLPassthrough       bool
 	UsePortInRedirects   bool
 	UpstreamHashBy       string
+	UpstreamKeepalive    int
 	UpstreamVhost        string
+	UserAgent            useragent.Config
 	VtsFilterKey         string
+	WebSocket            websocket.Config
 	Whitelist            ipwhitelist.SourceRange
-	XForwardedPrefix     bool
+	XForwardedPrefix     string
 }
 
 // Extractor defines the annotation parsers to be used in the extraction of annotations
@@ -92,30 +152,70 @@ type Extractor struct {
 
 // NewAnnotationExtractor creates a new annotations extractor
 func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
+	return newAnnotationExtractor(cfg, auth.AuthDirectory, maintenance.PageDirectory)
+}
+
+// NewSandboxAnnotationExtractor creates a new annotations extractor that
+// writes the authentication files and the maintenance pages in the given
+// directory instead of the directories used by NGINX, to parse the
+// annotations of an Ingress without modifying the running configuration
+func NewSandboxAnnotationExtractor(cfg resolver.Resolver, dir string) Extractor {
+	return newAnnotationExtractor(cfg, path.Join(dir, "auth"), path.Join(dir, "maintenance"))
+}
+
+func newAnnotationExtractor(cfg resolver.Resolver, authDirectory, pageDirectory string) Extractor {
 	return Extractor{
 		map[string]parser.IngressAnnotation{
+			"ABTesting":            abtesting.NewParser(cfg),
 			"Alias":                alias.NewParser(cfg),
-			"BasicDigestAuth":      auth.NewParser(auth.AuthDirectory, cfg),
+			"BackendProtocol":      backendprotocol.NewParser(cfg),
+			"BasicDigestAuth":      auth.NewParser(authDirectory, cfg),
+			"Brotli":               brotli.NewParser(cfg),
 			"CertificateAuth":      authtls.NewParser(cfg),
 			"ClientBodyBufferSize": clientbodybuffersize.NewParser(cfg),
 			"ConfigurationSnippet": snippet.NewParser(cfg),
 			"CorsConfig":           cors.NewParser(cfg),
+			"CountryFilter":        countryfilter.NewParser(cfg),
+			"CustomHTTPErrors":     customhttperrors.NewParser(cfg),
 			"DefaultBackend":       defaultbackend.NewParser(cfg),
+			"Denylist":             ipdenylist.NewParser(cfg),
 			"ExternalAuth":         authreq.NewParser(cfg),
+			"FastCGI":              fastcgi.NewParser(cfg),
+			"GeoBackend":           geobackend.NewParser(cfg),
+			"GRPCWeb":              grpcweb.NewParser(cfg),
+			"Gzip":                 gzip.NewParser(cfg),
 			"HealthCheck":          healthcheck.NewParser(cfg),
+			"HSTS":                 hsts.NewParser(cfg),
+			"LimitRate":            limitrate.NewParser(cfg),
+			"LoadBalancing":        loadbalancing.NewParser(cfg),
+			"LogFormat":            logformat.NewParser(cfg),
+			"Maintenance":          maintenance.NewParser(pageDirectory, cfg),
+			"Mirror":               mirror.NewParser(cfg),
+			"ModSecurity":          modsecurity.NewParser(cfg),
+			"PathType":             pathtype.NewParser(cfg),
 			"Proxy":                proxy.NewParser(cfg),
+			"ProxyCache":           proxycache.NewParser(cfg),
+			"ProxySSL":             proxyssl.NewParser(cfg),
 			"RateLimit":            ratelimit.NewParser(cfg),
 			"Redirect":             redirect.NewParser(cfg),
+			"RequestHeaders":       requestheaders.NewParser(cfg),
+			"ResponseHeaders":      responseheaders.NewParser(cfg),
 			"Rewrite":              rewrite.NewParser(cfg),
+			"Satisfy":              satisfy.NewParser(cfg),
 			"SecureUpstream":       secureupstream.NewParser(cfg),
+			"SecurityHeaders":      securityheaders.NewParser(cfg),
 			"ServerSnippet":        serversnippet.NewParser(cfg),
 			"ServiceUpstream":      serviceupstream.NewParser(cfg),
 			"SessionAffinity":      sessionaffinity.NewParser(cfg),
+			"SSLCipher":            sslcipher.NewParser(cfg),
 			"SSLPassthrough":       sslpassthrough.NewParser(cfg),
 			"UsePortInRedirects":   portinredirect.NewParser(cfg),
 			"UpstreamHashBy":       upstreamhashby.NewParser(cfg),
+			"UpstreamKeepalive":    upstreamkeepalive.NewParser(cfg),
 			"UpstreamVhost":        upstreamvhost.NewParser(cfg),
+			"UserAgent":            useragent.NewParser(cfg),
 			"VtsFilterKey":         vtsfilterkey.NewParser(cfg),
+			"WebSocket":            websocket.NewParser(cfg),
 			"Whitelist":            ipwhitelist.NewParser(cfg),
 			"XForwardedPrefix":     xforwardedprefix.NewParser(cfg),
 		},
@@ -31,6 +31,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
 )
 
 var (
@@ -121,7 +122,11 @@ func (a auth) Parse(ing *extensions.Ingress) (interface{}, error) {
 		}
 	}
 
-	name := fmt.Sprintf("%v/%v", ing.Namespace, s)
+	name := k8s.ReferenceKey(ing.Namespace, s)
+	if !a.r.CanReferenceSecret(ing.Namespace, name) {
+		return nil, ing_errors.NewLocationDenied(fmt.Sprintf("secret %v is not shared with namespace %v", name, ing.Namespace))
+	}
+
 	secret, err := a.r.GetSecret(name)
 	if err != nil {
 		return nil, ing_errors.LocationDenied{
@@ -145,6 +145,42 @@ func TestIngressAuthWithoutSecret(t *testing.T) {
 	}
 }
 
+type sharedSecret struct {
+	mockSecret
+	shared bool
+}
+
+func (m sharedSecret) CanReferenceSecret(namespace, secret string) bool {
+	return m.shared
+}
+
+func TestIngressAuthWithSecretOfAnotherNamespace(t *testing.T) {
+	ing := buildIngress()
+	ing.Namespace = "team"
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("auth-type")] = "basic"
+	data[parser.GetAnnotationWithPrefix("auth-secret")] = "default/demo-secret"
+	ing.SetAnnotations(data)
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	_, err := NewParser(dir, sharedSecret{}).Parse(ing)
+	if err == nil {
+		t.Errorf("expected an error with a secret not shared with the namespace")
+	}
+
+	i, err := NewParser(dir, sharedSecret{shared: true}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error with a shared secret: %v", err)
+	}
+	auth := i.(*Config)
+	if auth.Secret != "default/demo-secret" {
+		t.Errorf("expected default/demo-secret as secret but returned %v", auth.Secret)
+	}
+}
+
 func dummySecretContent(t *testing.T) (string, string, *api.Secret) {
 	dir, err := ioutil.TempDir("", fmt.Sprintf("%v", time.Now().Unix()))
 	if err != nil {
@@ -37,6 +37,7 @@ type Config struct {
 	Method          string   `json:"method"`
 	ResponseHeaders []string `json:"responseHeaders,omitEmpty"`
 	RequestRedirect string   `json:"requestRedirect"`
+	AuthSnippet     string   `json:"authSnippet"`
 }
 
 // Equal tests for equality between two Config types
@@ -74,6 +75,9 @@ func (e1 *Config) Equal(e2 *Config) bool {
 	if e1.RequestRedirect != e2.RequestRedirect {
 		return false
 	}
+	if e1.AuthSnippet != e2.AuthSnippet {
+		return false
+	}
 
 	return true
 }
@@ -160,6 +164,8 @@ func (a authReq) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 	requestRedirect, _ := parser.GetStringAnnotation("auth-request-redirect", ing)
 
+	authSnippet, _ := parser.GetStringAnnotation("auth-snippet", ing)
+
 	return &Config{
 		URL:             urlString,
 		Host:            authUrl.Hostname(),
@@ -167,5 +173,6 @@ func (a authReq) Parse(ing *extensions.Ingress) (interface{}, error) {
 		Method:          authMethod,
 		ResponseHeaders: responseHeaders,
 		RequestRedirect: requestRedirect,
+		AuthSnippet:     authSnippet,
 	}, nil
 }
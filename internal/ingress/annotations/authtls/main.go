@@ -17,6 +17,8 @@ limitations under the License.
 package authtls
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 	extensions "k8s.io/api/extensions/v1beta1"
 
@@ -31,10 +33,15 @@ import (
 const (
 	defaultAuthTLSDepth     = 1
 	defaultAuthVerifyClient = "on"
+
+	defaultCertHeader      = "ssl-client-cert"
+	defaultSubjectDNHeader = "ssl-client-dn"
+	defaultIssuerDNHeader  = "ssl-client-issuer-dn"
 )
 
 var (
 	authVerifyClientRegex = regexp.MustCompile(`on|off|optional|optional_no_ca`)
+	headerRegex           = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
 )
 
 // Config contains the AuthSSLCert used for muthual autentication
@@ -45,6 +52,18 @@ type Config struct {
 	ValidationDepth    int    `json:"validationDepth"`
 	ErrorPage          string `json:"errorPage"`
 	PassCertToUpstream bool   `json:"passCertToUpstream"`
+	// CRLSecret is the name of the secret that contains the certificate
+	// revocation list, if it is not the secret with the CA
+	CRLSecret string `json:"crlSecret"`
+	// CertHeader is the name of the header used to pass the client
+	// certificate (PEM, URL encoded) to the upstream
+	CertHeader string `json:"certHeader"`
+	// SubjectDNHeader is the name of the header used to pass the subject DN
+	// of the client certificate to the upstream
+	SubjectDNHeader string `json:"subjectDNHeader"`
+	// IssuerDNHeader is the name of the header used to pass the issuer DN
+	// of the client certificate to the upstream
+	IssuerDNHeader string `json:"issuerDNHeader"`
 }
 
 // Equal tests for equality between two Config types
@@ -70,6 +89,18 @@ func (assl1 *Config) Equal(assl2 *Config) bool {
 	if assl1.PassCertToUpstream != assl2.PassCertToUpstream {
 		return false
 	}
+	if assl1.CRLSecret != assl2.CRLSecret {
+		return false
+	}
+	if assl1.CertHeader != assl2.CertHeader {
+		return false
+	}
+	if assl1.SubjectDNHeader != assl2.SubjectDNHeader {
+		return false
+	}
+	if assl1.IssuerDNHeader != assl2.IssuerDNHeader {
+		return false
+	}
 
 	return true
 }
@@ -118,6 +149,27 @@ func (a authTLS) Parse(ing *extensions.Ingress) (interface{}, error) {
 		}
 	}
 
+	crlsecret, err := parser.GetStringAnnotation("auth-tls-crl-secret", ing)
+	if err == nil && crlsecret != "" {
+		_, _, err = k8s.ParseNameNS(crlsecret)
+		if err != nil {
+			return &Config{}, ing_errors.NewLocationDenied(err.Error())
+		}
+
+		crl, err := a.r.GetAuthCertificate(crlsecret)
+		if err != nil {
+			return &Config{}, ing_errors.LocationDenied{
+				Reason: errors.Wrap(err, "error obtaining certificate revocation list"),
+			}
+		}
+		if crl.CRLFileName == "" {
+			return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("secret %v does not contain a 'ca.crl'", crlsecret))
+		}
+
+		authCert.CRLFileName = crl.CRLFileName
+		authCert.CRLSHA = crl.CRLSHA
+	}
+
 	errorpage, err := parser.GetStringAnnotation("auth-tls-error-page", ing)
 	if err != nil || errorpage == "" {
 		errorpage = ""
@@ -128,11 +180,45 @@ func (a authTLS) Parse(ing *extensions.Ingress) (interface{}, error) {
 		passCert = false
 	}
 
+	certHeader, err := parseHeader("auth-tls-cert-header", defaultCertHeader, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	subjectDNHeader, err := parseHeader("auth-tls-subject-dn-header", defaultSubjectDNHeader, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	issuerDNHeader, err := parseHeader("auth-tls-issuer-dn-header", defaultIssuerDNHeader, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
 	return &Config{
 		AuthSSLCert:        *authCert,
 		VerifyClient:       tlsVerifyClient,
 		ValidationDepth:    tlsdepth,
 		ErrorPage:          errorpage,
 		PassCertToUpstream: passCert,
+		CRLSecret:          crlsecret,
+		CertHeader:         certHeader,
+		SubjectDNHeader:    subjectDNHeader,
+		IssuerDNHeader:     issuerDNHeader,
 	}, nil
 }
+
+// parseHeader returns the name of the header defined in an annotation
+// or the default value if the annotation is not present
+func parseHeader(name, def string, ing *extensions.Ingress) (string, error) {
+	header, err := parser.GetStringAnnotation(name, ing)
+	if err != nil || header == "" {
+		return def, nil
+	}
+
+	if !headerRegex.MatchString(header) {
+		return "", ing_errors.NewLocationDenied(fmt.Sprintf("invalid header name in annotation %v", name))
+	}
+
+	return header, nil
+}
@@ -23,6 +23,9 @@ import (
 	extensions "k8s.io/api/extensions/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
 func buildIngress() *extensions.Ingress {
@@ -106,3 +109,126 @@ func TestAnnotations(t *testing.T) {
 				}
 		}*/
 }
+
+type mockSecret struct {
+	resolver.Mock
+}
+
+func (m mockSecret) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	if name == "default/crl" {
+		return &resolver.AuthSSLCert{
+			Secret:      name,
+			CRLFileName: "/ssl/crl.pem",
+			CRLSHA:      "123",
+		}, nil
+	}
+
+	return &resolver.AuthSSLCert{
+		Secret:     name,
+		CAFileName: "/ssl/ca.crt",
+	}, nil
+}
+
+func TestCertificateHeaders(t *testing.T) {
+	ing := buildIngress()
+
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		cert        string
+		subjectDN   string
+		issuerDN    string
+		expErr      bool
+	}{
+		{"default headers", map[string]string{}, "ssl-client-cert", "ssl-client-dn", "ssl-client-issuer-dn", false},
+		{"custom headers", map[string]string{
+			"auth-tls-cert-header":       "X-Client-Cert",
+			"auth-tls-subject-dn-header": "X-Client-Subject",
+			"auth-tls-issuer-dn-header":  "X-Client-Issuer",
+		}, "X-Client-Cert", "X-Client-Subject", "X-Client-Issuer", false},
+		{"invalid header", map[string]string{
+			"auth-tls-cert-header": "X Client Cert",
+		}, "", "", "", true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{
+			parser.GetAnnotationWithPrefix("auth-tls-secret"): "default/ca",
+		}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockSecret{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		u, ok := i.(*Config)
+		if !ok {
+			t.Errorf("%v: expected a Config type", test.title)
+			continue
+		}
+		if u.CertHeader != test.cert {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.cert, u.CertHeader)
+		}
+		if u.SubjectDNHeader != test.subjectDN {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.subjectDN, u.SubjectDNHeader)
+		}
+		if u.IssuerDNHeader != test.issuerDN {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.issuerDN, u.IssuerDNHeader)
+		}
+	}
+}
+
+func TestCertificateRevocationList(t *testing.T) {
+	ing := buildIngress()
+
+	tests := []struct {
+		title  string
+		crl    string
+		file   string
+		expErr bool
+	}{
+		{"no crl", "", "", false},
+		{"valid crl", "default/crl", "/ssl/crl.pem", false},
+		{"secret without crl", "default/ca", "", true},
+		{"invalid secret name", "crl", "", true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{
+			parser.GetAnnotationWithPrefix("auth-tls-secret"):     "default/ca",
+			parser.GetAnnotationWithPrefix("auth-tls-crl-secret"): test.crl,
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockSecret{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		u := i.(*Config)
+		if u.CRLFileName != test.file {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.file, u.CRLFileName)
+		}
+		if u.CAFileName != "/ssl/ca.crt" {
+			t.Errorf("%v: expected the CA file of the auth-tls-secret but \"%v\" was returned", test.title, u.CAFileName)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendprotocol
+
+import (
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// HTTP is the protocol used by default to connect to the upstream servers
+const HTTP = "HTTP"
+
+// validProtocols contains the protocols that can be used to connect to the upstream servers
+var validProtocols = sets.NewString(HTTP, "HTTPS", "GRPC", "GRPCS", "H2C", "H2", "FCGI", "UWSGI", "SCGI")
+
+type backendProtocol struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new backend protocol annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return backendProtocol{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to indicate the protocol used to connect to the upstream servers
+func (a backendProtocol) Parse(ing *extensions.Ingress) (interface{}, error) {
+	proto, err := parser.GetStringAnnotation("backend-protocol", ing)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return HTTP, nil
+		}
+		return nil, err
+	}
+
+	proto = strings.ToUpper(strings.TrimSpace(proto))
+	if !validProtocols.Has(proto) {
+		return nil, ing_errors.NewInvalidAnnotationContent("backend-protocol", proto)
+	}
+
+	return proto, nil
+}
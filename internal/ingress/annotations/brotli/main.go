@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brotli
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/compression"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// maxLevel is the maximum brotli compression level
+const maxLevel = 11
+
+type brotli struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new brotli annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return brotli{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// enable or disable the brotli compression and override the compression
+// level and MIME types of the global configuration
+func (a brotli) Parse(ing *extensions.Ingress) (interface{}, error) {
+	config, err := compression.Parse(ing, "brotli", maxLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
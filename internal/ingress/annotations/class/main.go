@@ -26,6 +26,10 @@ const (
 	// The controller only processes Ingresses with this annotation either
 	// unset, or set to either the configured value or the empty string.
 	IngressKey = "kubernetes.io/ingress.class"
+
+	// ControllerName is the name of the controller in the IngressClasses
+	// implemented by the nginx ingress controller
+	ControllerName = "k8s.io/ingress-nginx"
 )
 
 var (
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+)
+
+// mimeTypeRegex matches a MIME type or the special value *
+var mimeTypeRegex = regexp.MustCompile(`^(\*|[a-zA-Z0-9!#&^_.+-]+/[a-zA-Z0-9!#&^_.+*-]+)$`)
+
+// Config contains the configuration of a compression algorithm in the
+// locations of an Ingress rule
+type Config struct {
+	// Enable enables or disables the compression
+	Enable bool `json:"enable"`
+	// Level is the compression level. Zero means the global level
+	Level int `json:"level,omitempty"`
+	// Types contains the MIME types to compress. Empty means the global types
+	Types string `json:"types,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enable != c2.Enable {
+		return false
+	}
+	if c1.Level != c2.Level {
+		return false
+	}
+	if c1.Types != c2.Types {
+		return false
+	}
+
+	return true
+}
+
+// Parse parses the enable-<algorithm>, <algorithm>-level and
+// <algorithm>-types annotations contained in the ingress rule used to
+// enable or disable a compression algorithm and override the compression
+// level, from 1 to maxLevel, and MIME types of the global configuration.
+// Overriding the level or the types enables the compression
+func Parse(ing *extensions.Ingress, algorithm string, maxLevel int) (*Config, error) {
+	enable, enableErr := parser.GetBoolAnnotation(fmt.Sprintf("enable-%v", algorithm), ing)
+	level, levelErr := parser.GetIntAnnotation(fmt.Sprintf("%v-level", algorithm), ing)
+	types, typesErr := parser.GetStringAnnotation(fmt.Sprintf("%v-types", algorithm), ing)
+	if ing_errors.IsMissingAnnotations(enableErr) &&
+		ing_errors.IsMissingAnnotations(levelErr) &&
+		ing_errors.IsMissingAnnotations(typesErr) {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	if enableErr != nil {
+		if !ing_errors.IsMissingAnnotations(enableErr) {
+			return nil, enableErr
+		}
+		enable = true
+	}
+
+	config := &Config{Enable: enable}
+
+	if levelErr == nil {
+		if level < 1 || level > maxLevel {
+			return nil, ing_errors.NewInvalidAnnotationContent(fmt.Sprintf("%v-level", algorithm), level)
+		}
+		config.Level = level
+	} else if !ing_errors.IsMissingAnnotations(levelErr) {
+		return nil, levelErr
+	}
+
+	if typesErr == nil {
+		mimeTypes := strings.Fields(types)
+		for _, mimeType := range mimeTypes {
+			if !mimeTypeRegex.MatchString(mimeType) {
+				return nil, ing_errors.NewInvalidAnnotationContent(fmt.Sprintf("%v-types", algorithm), types)
+			}
+		}
+		config.Types = strings.Join(mimeTypes, " ")
+	}
+
+	return config, nil
+}
@@ -17,8 +17,12 @@ limitations under the License.
 package cors
 
 import (
+	"encoding/json"
 	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/golang/glog"
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -57,6 +61,24 @@ type Config struct {
 	CorsAllowHeaders     string `json:"corsAllowHeaders"`
 	CorsAllowCredentials bool   `json:"corsAllowCredentials"`
 	CorsMaxAge           int    `json:"corsMaxAge"`
+	// CorsAllowOrigins contains the allowed origins when more than one is configured
+	CorsAllowOrigins []string `json:"corsAllowOrigins,omitempty"`
+	// CorsAllowOriginRegex contains regular expressions matching the allowed origins
+	CorsAllowOriginRegex []string `json:"corsAllowOriginRegex,omitempty"`
+	// CorsPaths contains the paths of the Ingress where CORS is enabled.
+	// If empty CORS is enabled in all the paths
+	CorsPaths []string `json:"corsPaths,omitempty"`
+	// CorsPathConfig contains the CORS settings used in some paths of
+	// the Ingress instead of the ones of the Ingress
+	CorsPathConfig map[string]PathConfig `json:"corsPathConfig,omitempty"`
+}
+
+// PathConfig contains the CORS settings of a path of the Ingress.
+// Empty values use the setting of the Ingress
+type PathConfig struct {
+	AllowOrigin  string `json:"allow-origin,omitempty"`
+	AllowMethods string `json:"allow-methods,omitempty"`
+	AllowHeaders string `json:"allow-headers,omitempty"`
 }
 
 // NewParser creates a new CORS annotation parser
@@ -90,6 +112,23 @@ func (c1 *Config) Equal(c2 *Config) bool {
 	if c1.CorsEnabled != c2.CorsEnabled {
 		return false
 	}
+	if strings.Join(c1.CorsAllowOrigins, ",") != strings.Join(c2.CorsAllowOrigins, ",") {
+		return false
+	}
+	if strings.Join(c1.CorsAllowOriginRegex, " ") != strings.Join(c2.CorsAllowOriginRegex, " ") {
+		return false
+	}
+	if strings.Join(c1.CorsPaths, ",") != strings.Join(c2.CorsPaths, ",") {
+		return false
+	}
+	if len(c1.CorsPathConfig) != len(c2.CorsPathConfig) {
+		return false
+	}
+	for path, pc := range c1.CorsPathConfig {
+		if c2.CorsPathConfig[path] != pc {
+			return false
+		}
+	}
 
 	return true
 }
@@ -102,11 +141,21 @@ func (c cors) Parse(ing *extensions.Ingress) (interface{}, error) {
 		corsenabled = false
 	}
 
-	corsalloworigin, err := parser.GetStringAnnotation("cors-allow-origin", ing)
-	if err != nil || corsalloworigin == "" || !corsOriginRegex.MatchString(corsalloworigin) {
-		corsalloworigin = "*"
+	corsalloworiginregex := []string{}
+	val, _ := parser.GetStringAnnotation("cors-allow-origin-regex", ing)
+	for _, expr := range strings.Fields(val) {
+		if strings.Contains(expr, `"`) {
+			continue
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			continue
+		}
+		corsalloworiginregex = append(corsalloworiginregex, expr)
 	}
 
+	val, _ = parser.GetStringAnnotation("cors-allow-origin", ing)
+	corsalloworigin, corsalloworigins := parseAllowOrigin(val, len(corsalloworiginregex) > 0)
+
 	corsallowheaders, err := parser.GetStringAnnotation("cors-allow-headers", ing)
 	if err != nil || corsallowheaders == "" || !corsHeadersRegex.MatchString(corsallowheaders) {
 		corsallowheaders = defaultCorsHeaders
@@ -127,9 +176,52 @@ func (c cors) Parse(ing *extensions.Ingress) (interface{}, error) {
 		corsmaxage = defaultCorsMaxAge
 	}
 
+	corspaths := []string{}
+	val, _ = parser.GetStringAnnotation("cors-paths", ing)
+	for _, path := range strings.Split(val, ",") {
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			continue
+		}
+		corspaths = append(corspaths, path)
+	}
+
+	corspathconfig := map[string]PathConfig{}
+	val, _ = parser.GetStringAnnotation("cors-path-config", ing)
+	if val != "" {
+		if err := json.Unmarshal([]byte(val), &corspathconfig); err != nil {
+			glog.Warningf("ignoring invalid cors-path-config annotation in Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+			corspathconfig = map[string]PathConfig{}
+		}
+	}
+	paths := []string{}
+	for path, pc := range corspathconfig {
+		if !strings.HasPrefix(path, "/") {
+			delete(corspathconfig, path)
+			continue
+		}
+		if pc.AllowMethods != "" && !corsMethodsRegex.MatchString(pc.AllowMethods) {
+			pc.AllowMethods = ""
+		}
+		if pc.AllowHeaders != "" && !corsHeadersRegex.MatchString(pc.AllowHeaders) {
+			pc.AllowHeaders = ""
+		}
+		corspathconfig[path] = pc
+		paths = append(paths, path)
+	}
+	// the paths with their own settings also enable CORS
+	if len(paths) > 0 {
+		sort.Strings(paths)
+		corspaths = append(corspaths, paths...)
+	}
+
 	return &Config{
 		CorsEnabled:          corsenabled,
 		CorsAllowOrigin:      corsalloworigin,
+		CorsAllowOrigins:     corsalloworigins,
+		CorsAllowOriginRegex: corsalloworiginregex,
+		CorsPaths:            corspaths,
+		CorsPathConfig:       corspathconfig,
 		CorsAllowHeaders:     corsallowheaders,
 		CorsAllowMethods:     corsallowmethods,
 		CorsAllowCredentials: corsallowcredentials,
@@ -137,3 +229,87 @@ func (c cors) Parse(ing *extensions.Ingress) (interface{}, error) {
 	}, nil
 
 }
+
+// parseAllowOrigin parses a comma separated list of origins. A single
+// origin without regular expressions is sent as is, otherwise the
+// Origin header of the request is checked against the returned list
+func parseAllowOrigin(val string, regex bool) (string, []string) {
+	origins := []string{}
+	for _, origin := range strings.Split(val, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" || !corsOriginRegex.MatchString(origin) {
+			continue
+		}
+		if origin == "*" {
+			origins = []string{}
+			break
+		}
+		origins = append(origins, origin)
+	}
+
+	switch {
+	case regex:
+		return "", origins
+	case len(origins) == 0:
+		return "*", origins
+	case len(origins) == 1:
+		return origins[0], []string{}
+	default:
+		return "", origins
+	}
+}
+
+// matchesPath returns if a path of the configuration is equal to
+// the path or to one of its parent directories
+func matchesPath(path, p string) bool {
+	return path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/")
+}
+
+// ForPath returns the CORS configuration used in a path of the Ingress.
+// The settings of the longest path of the configuration matching the
+// path replace the ones of the Ingress
+func (c1 Config) ForPath(path string) Config {
+	match := ""
+	for p := range c1.CorsPathConfig {
+		if matchesPath(path, p) && len(p) > len(match) {
+			match = p
+		}
+	}
+	if match == "" {
+		return c1
+	}
+
+	pc := c1.CorsPathConfig[match]
+	if pc.AllowOrigin != "" {
+		c1.CorsAllowOrigin, c1.CorsAllowOrigins = parseAllowOrigin(pc.AllowOrigin, false)
+		c1.CorsAllowOriginRegex = []string{}
+	}
+	if pc.AllowMethods != "" {
+		c1.CorsAllowMethods = pc.AllowMethods
+	}
+	if pc.AllowHeaders != "" {
+		c1.CorsAllowHeaders = pc.AllowHeaders
+	}
+
+	return c1
+}
+
+// EnabledForPath returns if CORS is enabled in a path of the Ingress.
+// A path is matched by the paths of the configuration equal to the path
+// or to one of its parent directories
+func (c1 Config) EnabledForPath(path string) bool {
+	if !c1.CorsEnabled {
+		return false
+	}
+	if len(c1.CorsPaths) == 0 {
+		return true
+	}
+
+	for _, p := range c1.CorsPaths {
+		if matchesPath(path, p) {
+			return true
+		}
+	}
+
+	return false
+}
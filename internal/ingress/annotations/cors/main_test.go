@@ -17,6 +17,7 @@ limitations under the License.
 package cors
 
 import (
+	"reflect"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -100,3 +101,151 @@ func TestIngressCorsConfig(t *testing.T) {
 		t.Errorf("expected max age 600, but got  %v", nginxCors.CorsMaxAge)
 	}
 }
+
+func TestIngressCorsMultipleOrigins(t *testing.T) {
+	tests := []struct {
+		title   string
+		origin  string
+		regex   string
+		single  string
+		origins []string
+		exprs   []string
+	}{
+		{"default", "", "", "*", []string{}, []string{}},
+		{"single origin", "https://a.com, $host", "", "https://a.com", []string{}, []string{}},
+		{"multiple origins", "https://a.com,http://b.com:8080", "", "", []string{"https://a.com", "http://b.com:8080"}, []string{}},
+		{"wildcard", "https://a.com, *", "", "*", []string{}, []string{}},
+		{"regex", "https://a.com", "^https://[a-z]+\\.example\\.com$ invalid( \"", "", []string{"https://a.com"}, []string{"^https://[a-z]+\\.example\\.com$"}},
+	}
+
+	for _, test := range tests {
+		ing := buildIngress()
+		data := map[string]string{}
+		data[parser.GetAnnotationWithPrefix("enable-cors")] = "true"
+		data[parser.GetAnnotationWithPrefix("cors-allow-origin")] = test.origin
+		data[parser.GetAnnotationWithPrefix("cors-allow-origin-regex")] = test.regex
+		ing.SetAnnotations(data)
+
+		i, _ := NewParser(&resolver.Mock{}).Parse(ing)
+		config, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", test.title)
+		}
+		if config.CorsAllowOrigin != test.single {
+			t.Errorf("%v: expected origin %v but returned %v", test.title, test.single, config.CorsAllowOrigin)
+		}
+		if !reflect.DeepEqual(config.CorsAllowOrigins, test.origins) {
+			t.Errorf("%v: expected origins %v but returned %v", test.title, test.origins, config.CorsAllowOrigins)
+		}
+		if !reflect.DeepEqual(config.CorsAllowOriginRegex, test.exprs) {
+			t.Errorf("%v: expected expressions %v but returned %v", test.title, test.exprs, config.CorsAllowOriginRegex)
+		}
+	}
+}
+
+func TestIngressCorsPaths(t *testing.T) {
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("enable-cors")] = "true"
+	data[parser.GetAnnotationWithPrefix("cors-paths")] = "/api/, invalid,/v2"
+	ing.SetAnnotations(data)
+
+	i, _ := NewParser(&resolver.Mock{}).Parse(ing)
+	config, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+	if !reflect.DeepEqual(config.CorsPaths, []string{"/api/", "/v2"}) {
+		t.Errorf("expected paths /api/ and /v2 but returned %v", config.CorsPaths)
+	}
+
+	paths := map[string]bool{
+		"/":          false,
+		"/api":       false,
+		"/api/":      true,
+		"/api/users": true,
+		"/v2":        true,
+		"/v2/users":  true,
+		"/v20":       false,
+	}
+	for path, enabled := range paths {
+		if config.EnabledForPath(path) != enabled {
+			t.Errorf("expected CORS enabled %v in path %v", enabled, path)
+		}
+	}
+
+	config.CorsPaths = []string{}
+	if !config.EnabledForPath("/") {
+		t.Errorf("expected CORS enabled in all the paths")
+	}
+
+	config.CorsEnabled = false
+	if config.EnabledForPath("/api/") {
+		t.Errorf("expected CORS disabled")
+	}
+}
+
+func TestIngressCorsPathConfig(t *testing.T) {
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("enable-cors")] = "true"
+	data[parser.GetAnnotationWithPrefix("cors-allow-methods")] = "GET"
+	data[parser.GetAnnotationWithPrefix("cors-paths")] = "/v2"
+	data[parser.GetAnnotationWithPrefix("cors-path-config")] = `{
+		"/api": {"allow-origin": "https://app.example.com, https://admin.example.com", "allow-methods": "GET, POST"},
+		"/api/public": {"allow-origin": "*", "allow-headers": "X-Custom"},
+		"/invalid": {"allow-methods": "GET; POST", "allow-headers": "$pid"},
+		"relative": {"allow-methods": "PUT"}
+	}`
+	ing.SetAnnotations(data)
+
+	i, _ := NewParser(&resolver.Mock{}).Parse(ing)
+	config, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+	if !reflect.DeepEqual(config.CorsPaths, []string{"/v2", "/api", "/api/public", "/invalid"}) {
+		t.Errorf("expected paths /v2, /api, /api/public and /invalid but returned %v", config.CorsPaths)
+	}
+	if pc := config.CorsPathConfig["/invalid"]; pc != (PathConfig{}) {
+		t.Errorf("expected invalid settings to be ignored but returned %v", pc)
+	}
+
+	fooTests := []struct {
+		path    string
+		enabled bool
+		origin  string
+		origins []string
+		methods string
+		headers string
+	}{
+		{"/", false, "*", []string{}, "GET", defaultCorsHeaders},
+		{"/v2", true, "*", []string{}, "GET", defaultCorsHeaders},
+		{"/api/users", true, "", []string{"https://app.example.com", "https://admin.example.com"}, "GET, POST", defaultCorsHeaders},
+		{"/api/public/docs", true, "*", []string{}, "GET", "X-Custom"},
+		{"/invalid", true, "*", []string{}, "GET", defaultCorsHeaders},
+	}
+	for _, ft := range fooTests {
+		pc := config.ForPath(ft.path)
+		if pc.EnabledForPath(ft.path) != ft.enabled {
+			t.Errorf("%v: expected CORS enabled %v", ft.path, ft.enabled)
+		}
+		if pc.CorsAllowOrigin != ft.origin || !reflect.DeepEqual(pc.CorsAllowOrigins, ft.origins) {
+			t.Errorf("%v: expected origin %q %v but returned %q %v", ft.path, ft.origin, ft.origins, pc.CorsAllowOrigin, pc.CorsAllowOrigins)
+		}
+		if pc.CorsAllowMethods != ft.methods {
+			t.Errorf("%v: expected methods %v but returned %v", ft.path, ft.methods, pc.CorsAllowMethods)
+		}
+		if pc.CorsAllowHeaders != ft.headers {
+			t.Errorf("%v: expected headers %v but returned %v", ft.path, ft.headers, pc.CorsAllowHeaders)
+		}
+	}
+
+	data[parser.GetAnnotationWithPrefix("cors-path-config")] = "{invalid"
+	ing.SetAnnotations(data)
+	i, _ = NewParser(&resolver.Mock{}).Parse(ing)
+	config = i.(*Config)
+	if len(config.CorsPathConfig) != 0 || !reflect.DeepEqual(config.CorsPaths, []string{"/v2"}) {
+		t.Errorf("expected an invalid configuration to be ignored but returned %v", config.CorsPathConfig)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package countryfilter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// countryRegex matches ISO 3166-1 alpha-2 country codes
+var countryRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Config contains the countries allowed or blocked in a location,
+// using the country of the client obtained from the GeoIP database
+type Config struct {
+	// Allow contains the only countries allowed to access the location
+	Allow []string `json:"allow,omitempty"`
+	// Block contains the countries that cannot access the location
+	Block []string `json:"block,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if strings.Join(c1.Allow, ",") != strings.Join(c2.Allow, ",") {
+		return false
+	}
+	if strings.Join(c1.Block, ",") != strings.Join(c2.Block, ",") {
+		return false
+	}
+
+	return true
+}
+
+type countryFilter struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new country filter annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return countryFilter{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to allow or block the access from certain countries.
+// Multiple countries can be specified using commas as separator
+// e.g. `DE,FR`
+func (a countryFilter) Parse(ing *extensions.Ingress) (interface{}, error) {
+	allow, err := parseCountries("allow-countries", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	block, err := parseCountries("block-countries", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if len(allow) == 0 && len(block) == 0 {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	if len(allow) > 0 && len(block) > 0 {
+		return nil, ing_errors.NewLocationDenied("the annotations allow-countries and block-countries cannot be used at the same time")
+	}
+
+	return &Config{
+		Allow: allow,
+		Block: block,
+	}, nil
+}
+
+func parseCountries(name string, ing *extensions.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(name, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := []string{}
+	for _, country := range strings.Split(val, ",") {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if country == "" {
+			continue
+		}
+		if !countryRegex.MatchString(country) {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid country code %v", country))
+		}
+		countries = append(countries, country)
+	}
+
+	if len(countries) == 0 {
+		return nil, ing_errors.NewLocationDenied(fmt.Sprintf("the annotation %v does not contain a valid country code", name))
+	}
+
+	sort.Strings(countries)
+	return countries, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package countryfilter
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title  string
+		allow  string
+		block  string
+		config *Config
+		expErr bool
+	}{
+		{"allow", "de, FR,,", "", &Config{Allow: []string{"DE", "FR"}}, false},
+		{"block", "", "RU,CN", &Config{Block: []string{"CN", "RU"}}, false},
+		{"invalid country", "", "RUS", nil, true},
+		{"only separators", ",,", "", nil, true},
+		{"allow and block", "DE", "RU", nil, true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{}
+		if test.allow != "" {
+			data[parser.GetAnnotationWithPrefix("allow-countries")] = test.allow
+		}
+		if test.block != "" {
+			data[parser.GetAnnotationWithPrefix("block-countries")] = test.block
+		}
+		ing := buildIngress()
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
+
+func TestParseWithoutAnnotations(t *testing.T) {
+	_, err := NewParser(&resolver.Mock{}).Parse(buildIngress())
+	if !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotations error but returned %v", err)
+	}
+}
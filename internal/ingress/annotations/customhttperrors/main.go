@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customhttperrors
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type customhttperrors struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new custom http errors annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return customhttperrors{r}
+}
+
+// Parse parses the annotation contained in the ingress rule used to
+// define the list of HTTP status codes that must be intercepted and
+// served by the default backend
+func (e customhttperrors) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("custom-http-errors", ing)
+	if err != nil {
+		return []int{}, err
+	}
+
+	codes := []int{}
+	seen := map[int]bool{}
+	for _, c := range strings.Split(val, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+
+		code, err := strconv.Atoi(c)
+		if err != nil || code < 400 || code > 599 {
+			return []int{}, ing_errors.NewInvalidAnnotationContent("custom-http-errors", val)
+		}
+
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		return []int{}, ing_errors.NewInvalidAnnotationContent("custom-http-errors", val)
+	}
+
+	sort.Ints(codes)
+	return codes, nil
+}
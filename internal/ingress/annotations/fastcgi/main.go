@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fastcgi
+
+import (
+	"fmt"
+	"regexp"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	// indexRegex matches a valid file name used as fastcgi_index
+	indexRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// paramNameRegex matches a valid FastCGI parameter name
+	paramNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	// paramValueRegex matches a value that can be rendered between double quotes
+	paramValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+)
+
+// Config describes the parameters sent to a FastCGI server
+type Config struct {
+	// Index is the file name appended to the URIs ending with a slash
+	Index string `json:"index,omitempty"`
+	// Params contains the FastCGI parameters sent to the server
+	Params map[string]string `json:"params,omitempty"`
+	// ConfigMap is the name of the configmap that contains the parameters
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Index != c2.Index {
+		return false
+	}
+	if c1.ConfigMap != c2.ConfigMap {
+		return false
+	}
+	if len(c1.Params) != len(c2.Params) {
+		return false
+	}
+	for k, v := range c1.Params {
+		if v2, ok := c2.Params[k]; !ok || v != v2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+type fastcgi struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new FastCGI annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return fastcgi{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// configure the locations with the backend protocol FCGI.
+// The parameters are read from the configmap referenced in the annotation
+// fastcgi-params-configmap, one parameter per key
+func (a fastcgi) Parse(ing *extensions.Ingress) (interface{}, error) {
+	index, err := parser.GetStringAnnotation("fastcgi-index", ing)
+	cmName, cmErr := parser.GetStringAnnotation("fastcgi-params-configmap", ing)
+	if err != nil && cmErr != nil {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	config := &Config{
+		Params: map[string]string{},
+	}
+
+	if err == nil {
+		if !indexRegex.MatchString(index) {
+			return nil, ing_errors.NewInvalidAnnotationContent("fastcgi-index", index)
+		}
+		config.Index = index
+	}
+
+	if cmErr == nil {
+		config.ConfigMap = fmt.Sprintf("%v/%v", ing.Namespace, cmName)
+		cm, err := a.r.GetConfigMap(config.ConfigMap)
+		if err != nil || cm == nil {
+			return nil, ing_errors.NewInvalidAnnotationContent("fastcgi-params-configmap", cmName)
+		}
+
+		for name, value := range cm.Data {
+			if !paramNameRegex.MatchString(name) || !paramValueRegex.MatchString(value) {
+				return nil, ing_errors.NewInvalidAnnotationContent("fastcgi-params-configmap", fmt.Sprintf("%v: %v", name, value))
+			}
+			config.Params[name] = value
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geobackend
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// countryCodeRegex matches an ISO 3166-1 alpha-2 country code
+var countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Config contains the backends used to route the requests
+// using the country of the client
+type Config struct {
+	// Upstreams maps a country code to the name of an upstream
+	Upstreams map[string]string `json:"upstreams,omitempty"`
+	// Backends contains the services referenced in the annotation
+	Backends []extensions.IngressBackend `json:"-"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if len(c1.Upstreams) != len(c2.Upstreams) {
+		return false
+	}
+	for country, upstream := range c1.Upstreams {
+		if c2.Upstreams[country] != upstream {
+			return false
+		}
+	}
+
+	return true
+}
+
+type geoBackend struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new geo backend annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return geoBackend{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to route requests to a different backend depending on the
+// country of the client (i.e. DE=web-eu:80,US=web-us:http)
+func (a geoBackend) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("geo-backends", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		Upstreams: map[string]string{},
+		Backends:  []extensions.IngressBackend{},
+	}
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || !countryCodeRegex.MatchString(parts[0]) {
+			return nil, ing_errors.NewInvalidAnnotationContent("geo-backends", val)
+		}
+
+		svc := strings.SplitN(parts[1], ":", 2)
+		if len(svc) != 2 || svc[0] == "" || svc[1] == "" {
+			return nil, ing_errors.NewInvalidAnnotationContent("geo-backends", val)
+		}
+
+		backend := extensions.IngressBackend{
+			ServiceName: svc[0],
+			ServicePort: intstr.Parse(svc[1]),
+		}
+
+		config.Upstreams[parts[0]] = fmt.Sprintf("%v-%v-%v",
+			ing.GetNamespace(),
+			backend.ServiceName,
+			backend.ServicePort.String())
+		config.Backends = append(config.Backends, backend)
+	}
+
+	if len(config.Upstreams) == 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("geo-backends", val)
+	}
+
+	return config, nil
+}
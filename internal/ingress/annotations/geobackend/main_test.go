@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geobackend
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+}
+
+func TestParse(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("geo-backends")] = "DE=web-eu:80, FR=web-eu:80,US=web-us:http"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a valid annotation: %v", err)
+	}
+	c, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+
+	expected := map[string]string{
+		"DE": "default-web-eu-80",
+		"FR": "default-web-eu-80",
+		"US": "default-web-us-http",
+	}
+	if !c.Equal(&Config{Upstreams: expected}) {
+		t.Errorf("expected %v but returned %v", expected, c.Upstreams)
+	}
+	if len(c.Backends) != 3 {
+		t.Errorf("expected 3 backends but returned %v", len(c.Backends))
+	}
+	if c.Backends[2].ServicePort.String() != "http" {
+		t.Errorf("expected http as service port but returned %v", c.Backends[2].ServicePort.String())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	ing := buildIngress()
+
+	invalid := []string{
+		"",
+		"DE",
+		"de=web-eu:80",
+		"DEU=web-eu:80",
+		"DE=web-eu",
+		"DE=:80",
+	}
+
+	for _, value := range invalid {
+		data := map[string]string{}
+		data[parser.GetAnnotationWithPrefix("geo-backends")] = value
+		ing.SetAnnotations(data)
+
+		_, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if err == nil {
+			t.Errorf("expected error parsing an invalid annotation (%v)", value)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcweb
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type grpcWeb struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new gRPC-Web annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return grpcWeb{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to indicate if the gRPC-Web requests must be translated to gRPC
+func (a grpcWeb) Parse(ing *extensions.Ingress) (interface{}, error) {
+	return parser.GetBoolAnnotation("grpc-web", ing)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcweb
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix("grpc-web")
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    bool
+		expErr      bool
+	}{
+		{map[string]string{}, false, true},
+		{map[string]string{annotation: "true"}, true, false},
+		{map[string]string{annotation: "false"}, false, false},
+		{map[string]string{annotation: "yes"}, false, true},
+	}
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if testCase.expErr != (err != nil) {
+			t.Errorf("expected error: %v but returned %v for annotations %v", testCase.expErr, err, testCase.annotations)
+		}
+		if result != testCase.expected {
+			t.Errorf("expected %v but returned %v for annotations %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 type Config struct {
 	MaxFails    int `json:"maxFails"`
 	FailTimeout int `json:"failTimeout"`
+	MaxConns    int `json:"maxConns"`
 }
 
 type healthCheck struct {
@@ -44,7 +45,7 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (hc healthCheck) Parse(ing *extensions.Ingress) (interface{}, error) {
 	defBackend := hc.r.GetDefaultBackend()
 	if ing.GetAnnotations() == nil {
-		return &Config{defBackend.UpstreamMaxFails, defBackend.UpstreamFailTimeout}, nil
+		return &Config{defBackend.UpstreamMaxFails, defBackend.UpstreamFailTimeout, 0}, nil
 	}
 
 	mf, err := parser.GetIntAnnotation("upstream-max-fails", ing)
@@ -57,5 +58,10 @@ func (hc healthCheck) Parse(ing *extensions.Ingress) (interface{}, error) {
 		ft = defBackend.UpstreamFailTimeout
 	}
 
-	return &Config{mf, ft}, nil
+	mc, err := parser.GetIntAnnotation("max-conns", ing)
+	if err != nil || mc < 0 {
+		mc = 0
+	}
+
+	return &Config{mf, ft, mc}, nil
 }
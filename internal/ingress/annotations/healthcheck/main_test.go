@@ -77,6 +77,7 @@ func TestIngressHealthCheck(t *testing.T) {
 
 	data := map[string]string{}
 	data[parser.GetAnnotationWithPrefix("upstream-max-fails")] = "2"
+	data[parser.GetAnnotationWithPrefix("max-conns")] = "100"
 	ing.SetAnnotations(data)
 
 	hzi, _ := NewParser(mockBackend{}).Parse(ing)
@@ -92,4 +93,8 @@ func TestIngressHealthCheck(t *testing.T) {
 	if nginxHz.FailTimeout != 1 {
 		t.Errorf("expected 0 as fail-timeout but returned %v", nginxHz.FailTimeout)
 	}
+
+	if nginxHz.MaxConns != 100 {
+		t.Errorf("expected 100 as max-conns but returned %v", nginxHz.MaxConns)
+	}
 }
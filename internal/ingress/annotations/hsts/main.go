@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"strconv"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// Config contains the HTTP Strict Transport Security configuration of a server
+type Config struct {
+	Enable            bool   `json:"enable"`
+	MaxAge            string `json:"maxAge"`
+	IncludeSubdomains bool   `json:"includeSubdomains"`
+	Preload           bool   `json:"preload"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type hsts struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new HSTS annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return hsts{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to override the global HSTS configuration.
+// The settings without annotation use the value from the configmap
+func (a hsts) Parse(ing *extensions.Ingress) (interface{}, error) {
+	found := false
+	for _, name := range []string{"hsts", "hsts-max-age", "hsts-include-subdomains", "hsts-preload"} {
+		if _, err := parser.GetStringAnnotation(name, ing); err == nil {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	defBackend := a.r.GetDefaultBackend()
+
+	enable, err := parser.GetBoolAnnotation("hsts", ing)
+	if err != nil {
+		enable = defBackend.HSTS
+	}
+
+	maxAge, err := parser.GetStringAnnotation("hsts-max-age", ing)
+	if err != nil {
+		maxAge = defBackend.HSTSMaxAge
+	} else if i, err := strconv.Atoi(maxAge); err != nil || i < 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("hsts-max-age", maxAge)
+	}
+
+	includeSubdomains, err := parser.GetBoolAnnotation("hsts-include-subdomains", ing)
+	if err != nil {
+		includeSubdomains = defBackend.HSTSIncludeSubdomains
+	}
+
+	preload, err := parser.GetBoolAnnotation("hsts-preload", ing)
+	if err != nil {
+		preload = defBackend.HSTSPreload
+	}
+
+	return &Config{
+		Enable:            enable,
+		MaxAge:            maxAge,
+		IncludeSubdomains: includeSubdomains,
+		Preload:           preload,
+	}, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockBackend struct {
+	resolver.Mock
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{
+		HSTS:                  true,
+		HSTSMaxAge:            "15724800",
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           false,
+	}
+}
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"disabled", map[string]string{"hsts": "false"},
+			&Config{Enable: false, MaxAge: "15724800", IncludeSubdomains: true}, false},
+		{"preload", map[string]string{"hsts-max-age": "31536000", "hsts-preload": "true"},
+			&Config{Enable: true, MaxAge: "31536000", IncludeSubdomains: true, Preload: true}, false},
+		{"without subdomains", map[string]string{"hsts-include-subdomains": "false"},
+			&Config{Enable: true, MaxAge: "15724800"}, false},
+		{"invalid max-age", map[string]string{"hsts-max-age": "1y"}, nil, true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing := buildIngress()
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
+
+func TestParseWithoutAnnotations(t *testing.T) {
+	_, err := NewParser(mockBackend{}).Parse(buildIngress())
+	if !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotations error but returned %v", err)
+	}
+}
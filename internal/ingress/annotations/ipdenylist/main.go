@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type ipdenylist struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new denylist annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return ipdenylist{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to block access to certain client addresses or networks.
+// Multiple ranges can specified using commas as separator
+// e.g. `18.0.0.0/8,56.0.0.0/8`
+// The ranges can also be read from the key denylist-source-range of a
+// configmap located in the same namespace than the ingress rule.
+func (a ipdenylist) Parse(ing *extensions.Ingress) (interface{}, error) {
+	values, configMap, err := ipwhitelist.ReadSourceRanges(a.r, ing, "denylist-source-range")
+	if err != nil {
+		return &ipwhitelist.SourceRange{CIDR: []string{}, ConfigMap: configMap}, err
+	}
+
+	cidrs, err := ipwhitelist.ParseSourceRanges(values)
+	if err != nil {
+		return &ipwhitelist.SourceRange{CIDR: []string{}, ConfigMap: configMap}, err
+	}
+
+	return &ipwhitelist.SourceRange{CIDR: cidrs, ConfigMap: configMap}, nil
+}
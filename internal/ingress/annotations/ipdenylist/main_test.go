@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipdenylist
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockConfigMap struct {
+	resolver.Mock
+}
+
+func (m mockConfigMap) GetConfigMap(name string) (*api.ConfigMap, error) {
+	if name == "default/denylist" {
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"denylist-source-range": "10.0.0.0/24\n192.168.0.1",
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("configmap %v not found", name)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		expectCidr  []string
+		expectErr   bool
+	}{
+		{"missing annotations", map[string]string{}, nil, true},
+		{"multiple valid cidr", map[string]string{
+			"denylist-source-range": "2.2.2.2/32,1.1.1.1/32,3.3.3.0/24",
+		}, []string{"1.1.1.1/32", "2.2.2.2/32", "3.3.3.0/24"}, false},
+		{"configmap and annotation", map[string]string{
+			"denylist-source-range":           "1.1.1.1/32",
+			"denylist-source-range-configmap": "denylist",
+		}, []string{"1.1.1.1/32", "10.0.0.0/24", "192.168.0.1"}, false},
+		{"invalid net", map[string]string{
+			"denylist-source-range": "ww",
+		}, nil, true},
+		{"missing configmap", map[string]string{
+			"denylist-source-range-configmap": "missing",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockConfigMap{}).Parse(ing)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%v: expected error but nil returned", test.title)
+			}
+			if len(test.annotations) > 0 && !ing_errors.IsLocationDenied(err) {
+				t.Errorf("%v: expected the location to be denied but %v returned", test.title, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		sr, ok := i.(*ipwhitelist.SourceRange)
+		if !ok {
+			t.Errorf("%v: expected a SourceRange type", test.title)
+			continue
+		}
+		if !reflect.DeepEqual(sr.CIDR, test.expectCidr) {
+			t.Errorf("%v: expected %v CIDR but %v returned", test.title, test.expectCidr, sr.CIDR)
+		}
+	}
+}
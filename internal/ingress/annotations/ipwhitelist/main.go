@@ -17,8 +17,10 @@ limitations under the License.
 package ipwhitelist
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 
@@ -33,6 +35,8 @@ import (
 // SourceRange returns the CIDR
 type SourceRange struct {
 	CIDR []string `json:"cidr,omitEmpty"`
+	// ConfigMap is the name of the configmap that contains additional CIDRs
+	ConfigMap string `json:"configMap,omitempty"`
 }
 
 // Equal tests for equality between two SourceRange types
@@ -44,6 +48,10 @@ func (sr1 *SourceRange) Equal(sr2 *SourceRange) bool {
 		return false
 	}
 
+	if sr1.ConfigMap != sr2.ConfigMap {
+		return false
+	}
+
 	if len(sr1.CIDR) != len(sr2.CIDR) {
 		return false
 	}
@@ -77,20 +85,76 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // rule used to limit access to certain client addresses or networks.
 // Multiple ranges can specified using commas as separator
 // e.g. `18.0.0.0/8,56.0.0.0/8`
+// The ranges can also be read from the key whitelist-source-range of a
+// configmap located in the same namespace than the ingress rule.
 func (a ipwhitelist) Parse(ing *extensions.Ingress) (interface{}, error) {
 	defBackend := a.r.GetDefaultBackend()
 	sort.Strings(defBackend.WhitelistSourceRange)
 
-	val, err := parser.GetStringAnnotation("whitelist-source-range", ing)
+	values, configMap, err := ReadSourceRanges(a.r, ing, "whitelist-source-range")
 	// A missing annotation is not a problem, just use the default
-	if err == ing_errors.ErrMissingAnnotations {
+	if ing_errors.IsMissingAnnotations(err) {
 		return &SourceRange{CIDR: defBackend.WhitelistSourceRange}, nil
 	}
+	if err != nil {
+		return &SourceRange{CIDR: defBackend.WhitelistSourceRange, ConfigMap: configMap}, err
+	}
+
+	cidrs, err := ParseSourceRanges(values)
+	if err != nil {
+		return &SourceRange{CIDR: defBackend.WhitelistSourceRange}, err
+	}
+
+	return &SourceRange{CIDR: cidrs, ConfigMap: configMap}, nil
+}
+
+// ReadSourceRanges returns the source ranges of an annotation and of the
+// configmap referenced by the annotation with the -configmap suffix, read
+// from the key with the name of the annotation. The configmap is located
+// in the same namespace than the ingress rule. It also returns the name
+// of the configmap, if any
+func ReadSourceRanges(r resolver.Resolver, ing *extensions.Ingress, name string) ([]string, string, error) {
+	val, err := parser.GetStringAnnotation(name, ing)
+	cmName, cmErr := parser.GetStringAnnotation(name+"-configmap", ing)
+	if err == ing_errors.ErrMissingAnnotations && cmErr == ing_errors.ErrMissingAnnotations {
+		return nil, "", ing_errors.ErrMissingAnnotations
+	}
+
+	values := []string{}
+	if err == nil {
+		values = strings.Split(val, ",")
+	}
+
+	configMap := ""
+	if cmErr == nil {
+		configMap = fmt.Sprintf("%v/%v", ing.Namespace, cmName)
+		cm, err := r.GetConfigMap(configMap)
+		if err != nil || cm == nil {
+			return nil, configMap, ing_errors.LocationDenied{
+				Reason: fmt.Errorf("unexpected error reading configmap %v: %v", configMap, err),
+			}
+		}
+
+		values = append(values, strings.FieldsFunc(cm.Data[name], func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})...)
+	}
+
+	// an empty list would not apply the source ranges
+	if len(values) == 0 {
+		return nil, configMap, ing_errors.NewLocationDenied(
+			fmt.Sprintf("configmap %v does not contain a valid IP address or network", configMap))
+	}
+
+	return values, configMap, nil
+}
 
-	values := strings.Split(val, ",")
+// ParseSourceRanges returns the sorted CIDRs of a list of IP addresses
+// and networks. Invalid values are ignored unless no IP address is valid
+func ParseSourceRanges(values []string) ([]string, error) {
 	ipnets, ips, err := net.ParseIPNets(values...)
 	if err != nil && len(ips) == 0 {
-		return &SourceRange{CIDR: defBackend.WhitelistSourceRange}, ing_errors.LocationDenied{
+		return nil, ing_errors.LocationDenied{
 			Reason: errors.Wrap(err, "the annotation does not contain a valid IP address or network"),
 		}
 	}
@@ -105,5 +169,5 @@ func (a ipwhitelist) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 	sort.Strings(cidrs)
 
-	return &SourceRange{cidrs}, nil
+	return cidrs, nil
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package ipwhitelist
 
 import (
+	"fmt"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -191,6 +192,87 @@ func TestParseAnnotationsWithDefaultConfig(t *testing.T) {
 	}
 }
 
+type mockConfigMap struct {
+	resolver.Mock
+}
+
+func (m mockConfigMap) GetConfigMap(name string) (*api.ConfigMap, error) {
+	switch name {
+	case "default/whitelist":
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"whitelist-source-range": "10.0.0.0/24,\n 192.168.0.1\n172.16.0.0/16",
+			},
+		}, nil
+	case "default/empty":
+		return &api.ConfigMap{}, nil
+	}
+
+	return nil, fmt.Errorf("configmap %v not found", name)
+}
+
+func TestParseAnnotationsWithConfigMap(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]struct {
+		net        string
+		configMap  string
+		expectCidr []string
+		expectErr  bool
+	}{
+		"test parse configmap": {
+			configMap:  "whitelist",
+			expectCidr: []string{"10.0.0.0/24", "172.16.0.0/16", "192.168.0.1"},
+		},
+		"test parse configmap and annotation": {
+			net:        "1.1.1.1/32",
+			configMap:  "whitelist",
+			expectCidr: []string{"1.1.1.1/32", "10.0.0.0/24", "172.16.0.0/16", "192.168.0.1"},
+		},
+		"test parse missing configmap": {
+			configMap: "missing",
+			expectErr: true,
+		},
+		"test parse empty configmap": {
+			configMap: "empty",
+			expectErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		data := map[string]string{}
+		if test.net != "" {
+			data[parser.GetAnnotationWithPrefix("whitelist-source-range")] = test.net
+		}
+		data[parser.GetAnnotationWithPrefix("whitelist-source-range-configmap")] = test.configMap
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockConfigMap{}).Parse(ing)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%v:expected error but nil returned", testName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v:unexpected error: %v", testName, err)
+			continue
+		}
+
+		sr, ok := i.(*SourceRange)
+		if !ok {
+			t.Errorf("%v:expected a SourceRange type", testName)
+			continue
+		}
+		if !strsEquals(sr.CIDR, test.expectCidr) {
+			t.Errorf("%v:expected %v CIDR but %v returned", testName, test.expectCidr, sr.CIDR)
+		}
+		if sr.ConfigMap != "default/"+test.configMap {
+			t.Errorf("%v:expected configmap default/%v but %v returned", testName, test.configMap, sr.ConfigMap)
+		}
+	}
+}
+
 func strsEquals(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limitrate
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// Config contains the bandwidth limits applied to the responses
+// returned to the clients, in kilobytes. Zero disables the limit
+type Config struct {
+	// Rate is the rate of response transmission to a client per second
+	Rate int `json:"rate"`
+	// After is the amount of data sent to a client before the
+	// transmission is limited
+	After int `json:"after"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Rate != c2.Rate {
+		return false
+	}
+	if c1.After != c2.After {
+		return false
+	}
+
+	return true
+}
+
+type limitRate struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new bandwidth limit annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return limitRate{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// limit the bandwidth of the responses. The values of the configuration
+// configmap are used when the annotations are not present
+func (a limitRate) Parse(ing *extensions.Ingress) (interface{}, error) {
+	defBackend := a.r.GetDefaultBackend()
+
+	rate, err := parser.GetIntAnnotation("limit-rate", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return nil, err
+		}
+		rate = defBackend.LimitRate
+	}
+	if rate < 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("limit-rate", rate)
+	}
+
+	after, err := parser.GetIntAnnotation("limit-rate-after", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return nil, err
+		}
+		after = defBackend.LimitRateAfter
+	}
+	if after < 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("limit-rate-after", after)
+	}
+
+	return &Config{
+		Rate:  rate,
+		After: after,
+	}, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancing
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var validAlgorithms = map[string]bool{
+	"round_robin": true,
+	"least_conn":  true,
+	"ip_hash":     true,
+	"ewma":        true,
+}
+
+type loadbalancing struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new load balancing algorithm annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return loadbalancing{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the load balancing algorithm of the backend
+func (a loadbalancing) Parse(ing *extensions.Ingress) (interface{}, error) {
+	lb, err := parser.GetStringAnnotation("load-balance", ing)
+	if err != nil {
+		return "", err
+	}
+
+	if !validAlgorithms[lb] {
+		return "", ing_errors.NewInvalidAnnotationContent("load-balance", lb)
+	}
+
+	return lb, nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logformat
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type logFormat struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new log format parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return logFormat{r}
+}
+
+// Parse returns the format of the access log of the locations of the
+// ingress rule. The format is defined by the defaults of the namespace
+// or ingress class of the Ingress (NginxDefaults), it is empty if the
+// format of the ConfigMap must be used.
+func (a logFormat) Parse(ing *extensions.Ingress) (interface{}, error) {
+	return a.r.GetDefaultBackend().LogFormat, nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/net"
+)
+
+const (
+	// PageDirectory default directory used to store the maintenance pages
+	PageDirectory = "/etc/ingress-controller/maintenance"
+
+	// pageKey is the key of the configmap that contains the maintenance page
+	pageKey = "page.html"
+)
+
+var (
+	// headerNameRegex matches a valid HTTP header name
+	headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	// headerValueRegex matches a value that can be compared in a NGINX if directive
+	headerValueRegex = regexp.MustCompile(`^[^"\\$\s]+$`)
+)
+
+// Config contains the maintenance mode configuration of an Ingress rule.
+// Requests that do not match the bypass conditions receive a 503 response
+type Config struct {
+	// Enabled indicates if the maintenance mode is enabled
+	Enabled bool `json:"enabled"`
+	// ID is a unique identifier of the Ingress used to name the
+	// NGINX variables and locations
+	ID string `json:"id,omitempty"`
+	// Whitelist contains the source ranges that bypass the maintenance mode
+	Whitelist []string `json:"whitelist,omitempty"`
+	// BypassVariable is the NGINX variable of the header used to bypass
+	// the maintenance mode
+	BypassVariable string `json:"bypassVariable,omitempty"`
+	// BypassValue is the value of the header used to bypass the maintenance mode
+	BypassValue string `json:"bypassValue,omitempty"`
+	// PageFile contains the path of the page returned to the clients
+	PageFile string `json:"pageFile,omitempty"`
+	// PageSHA contains the SHA1 hash of the page
+	PageSHA string `json:"pageSha,omitempty"`
+	// ConfigMap is the name of the configmap that contains the page
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.ID != c2.ID {
+		return false
+	}
+	if len(c1.Whitelist) != len(c2.Whitelist) {
+		return false
+	}
+	for i := range c1.Whitelist {
+		if c1.Whitelist[i] != c2.Whitelist[i] {
+			return false
+		}
+	}
+	if c1.BypassVariable != c2.BypassVariable {
+		return false
+	}
+	if c1.BypassValue != c2.BypassValue {
+		return false
+	}
+	if c1.PageFile != c2.PageFile {
+		return false
+	}
+	if c1.PageSHA != c2.PageSHA {
+		return false
+	}
+	if c1.ConfigMap != c2.ConfigMap {
+		return false
+	}
+
+	return true
+}
+
+// PageFile returns the path of the maintenance page of an Ingress. The
+// underscore is not valid in the names of namespaces and Ingresses, so
+// the names of the pages do not collide
+func PageFile(pageDirectory string, ing *extensions.Ingress) string {
+	return fmt.Sprintf("%v/%v_%v.html", pageDirectory, ing.GetNamespace(), ing.GetName())
+}
+
+// RemovePage removes the maintenance page of an Ingress, if any
+func RemovePage(pageDirectory string, ing *extensions.Ingress) {
+	err := os.Remove(PageFile(pageDirectory, ing))
+	if err != nil && !os.IsNotExist(err) {
+		glog.Warningf("unexpected error removing the maintenance page of Ingress %v/%v: %v", ing.GetNamespace(), ing.GetName(), err)
+	}
+}
+
+type maintenance struct {
+	r             resolver.Resolver
+	pageDirectory string
+}
+
+// NewParser creates a new maintenance mode annotation parser
+func NewParser(pageDirectory string, r resolver.Resolver) parser.IngressAnnotation {
+	os.MkdirAll(pageDirectory, 0755)
+	return maintenance{r, pageDirectory}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// return a 503 response to the clients while an application is in
+// maintenance. Invalid settings deny the locations of the Ingress, so
+// the application is never exposed by mistake
+func (a maintenance) Parse(ing *extensions.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("maintenance-mode", ing)
+	if err != nil || !enabled {
+		RemovePage(a.pageDirectory, ing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return &Config{}, nil
+	}
+
+	name := fmt.Sprintf("%v/%v", ing.GetNamespace(), ing.GetName())
+	config := &Config{
+		Enabled: true,
+		ID:      fmt.Sprintf("%x", sha1.Sum([]byte(name)))[:12],
+	}
+
+	val, _ := parser.GetStringAnnotation("maintenance-whitelist", ing)
+	if val != "" {
+		ipnets, ips, err := net.ParseIPNets(strings.Split(val, ",")...)
+		if err != nil {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid maintenance-whitelist: %v", err))
+		}
+
+		for k := range ipnets {
+			config.Whitelist = append(config.Whitelist, k)
+		}
+		for k := range ips {
+			config.Whitelist = append(config.Whitelist, k)
+		}
+		sort.Strings(config.Whitelist)
+	}
+
+	val, _ = parser.GetStringAnnotation("maintenance-bypass-header", ing)
+	if val != "" {
+		parts := strings.SplitN(val, ":", 2)
+		if len(parts) != 2 {
+			return nil, ing_errors.NewLocationDenied("invalid maintenance-bypass-header, the format must be 'Name: value'")
+		}
+
+		header := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if !headerNameRegex.MatchString(header) || !headerValueRegex.MatchString(value) {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid maintenance-bypass-header %v", val))
+		}
+
+		config.BypassVariable = fmt.Sprintf("$http_%v", strings.Replace(strings.ToLower(header), "-", "_", -1))
+		config.BypassValue = value
+	}
+
+	page, err := parser.GetStringAnnotation("maintenance-page", ing)
+	if err != nil {
+		cmName, cmErr := parser.GetStringAnnotation("maintenance-page-configmap", ing)
+		if cmErr == nil {
+			config.ConfigMap = fmt.Sprintf("%v/%v", ing.GetNamespace(), cmName)
+			cm, err := a.r.GetConfigMap(config.ConfigMap)
+			if err != nil || cm == nil {
+				return nil, ing_errors.NewLocationDenied(fmt.Sprintf("unexpected error reading configmap %v", config.ConfigMap))
+			}
+
+			page = cm.Data[pageKey]
+			if page == "" {
+				return nil, ing_errors.NewLocationDenied(fmt.Sprintf("the configmap %v does not contain a key with value %v", config.ConfigMap, pageKey))
+			}
+		}
+	}
+
+	if page == "" {
+		RemovePage(a.pageDirectory, ing)
+	} else {
+		pageFile := PageFile(a.pageDirectory, ing)
+		err := ioutil.WriteFile(pageFile, []byte(page), 0644)
+		if err != nil {
+			return nil, ing_errors.LocationDenied{
+				Reason: errors.Wrap(err, "unexpected error creating maintenance page"),
+			}
+		}
+
+		config.PageFile = pageFile
+		config.PageSHA = file.SHA1(pageFile)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockConfigMap struct {
+	resolver.Mock
+}
+
+func (m mockConfigMap) GetConfigMap(name string) (*api.ConfigMap, error) {
+	switch name {
+	case "default/page":
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"page.html": "<h1>Back soon</h1>",
+			},
+		}, nil
+	case "default/empty":
+		return &api.ConfigMap{}, nil
+	}
+
+	return nil, fmt.Errorf("configmap %v not found", name)
+}
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pageFile := fmt.Sprintf("%v/default_foo.html", dir)
+
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		page        string
+		expErr      bool
+	}{
+		{"missing annotations", map[string]string{}, nil, "", true},
+		{"disabled", map[string]string{
+			"maintenance-mode": "false",
+		}, &Config{}, "", false},
+		{"enabled", map[string]string{
+			"maintenance-mode": "true",
+		}, &Config{Enabled: true, ID: "1aacb6e314b1"}, "", false},
+		{"bypass", map[string]string{
+			"maintenance-mode":          "true",
+			"maintenance-whitelist":     "10.0.0.0/8, 192.168.1.1",
+			"maintenance-bypass-header": "X-Maintenance-Bypass: s3cr3t",
+		}, &Config{
+			Enabled:        true,
+			ID:             "1aacb6e314b1",
+			Whitelist:      []string{"10.0.0.0/8", "192.168.1.1"},
+			BypassVariable: "$http_x_maintenance_bypass",
+			BypassValue:    "s3cr3t",
+		}, "", false},
+		{"page", map[string]string{
+			"maintenance-mode": "true",
+			"maintenance-page": "<h1>Maintenance</h1>",
+		}, &Config{
+			Enabled:  true,
+			ID:       "1aacb6e314b1",
+			PageFile: pageFile,
+			PageSHA:  "096ef463988e5c841e32552499d6cc7f6dbd977c",
+		}, "<h1>Maintenance</h1>", false},
+		{"page from configmap", map[string]string{
+			"maintenance-mode":           "true",
+			"maintenance-page-configmap": "page",
+		}, &Config{
+			Enabled:   true,
+			ID:        "1aacb6e314b1",
+			PageFile:  pageFile,
+			PageSHA:   "eaa885ec2f555227ebc0827eaed7748cf2f94df5",
+			ConfigMap: "default/page",
+		}, "<h1>Back soon</h1>", false},
+		{"invalid whitelist", map[string]string{
+			"maintenance-mode":      "true",
+			"maintenance-whitelist": "10.0.0.0/40",
+		}, nil, "", true},
+		{"invalid bypass header", map[string]string{
+			"maintenance-mode":          "true",
+			"maintenance-bypass-header": `X-Bypass: "$host"`,
+		}, nil, "", true},
+		{"missing configmap", map[string]string{
+			"maintenance-mode":           "true",
+			"maintenance-page-configmap": "missing",
+		}, nil, "", true},
+		{"configmap without page", map[string]string{
+			"maintenance-mode":           "true",
+			"maintenance-page-configmap": "empty",
+		}, nil, "", true},
+	}
+
+	for _, test := range tests {
+		ing := buildIngress()
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(dir, mockConfigMap{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		config := i.(*Config)
+		if test.page != "" {
+			b, err := ioutil.ReadFile(config.PageFile)
+			if err != nil || string(b) != test.page {
+				t.Errorf("%v: expected the page %v but returned %v (%v)", test.title, test.page, string(b), err)
+			}
+		}
+
+		if !reflect.DeepEqual(config, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, config)
+		}
+	}
+}
+
+func TestPageFile(t *testing.T) {
+	ing1 := buildIngress()
+	ing1.SetNamespace("a-b")
+	ing1.SetName("c")
+
+	ing2 := buildIngress()
+	ing2.SetNamespace("a")
+	ing2.SetName("b-c")
+
+	if PageFile("/dir", ing1) == PageFile("/dir", ing2) {
+		t.Errorf("expected different pages but both returned %v", PageFile("/dir", ing1))
+	}
+}
+
+func TestRemovePage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ing := buildIngress()
+	pageFile := PageFile(dir, ing)
+	p := NewParser(dir, mockConfigMap{})
+
+	tests := []struct {
+		title       string
+		annotations map[string]string
+	}{
+		{"disabled", map[string]string{
+			"maintenance-mode": "false",
+		}},
+		{"without page", map[string]string{
+			"maintenance-mode": "true",
+		}},
+		{"removed annotation", map[string]string{}},
+	}
+
+	for _, test := range tests {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("maintenance-mode"): "true",
+			parser.GetAnnotationWithPrefix("maintenance-page"): "<h1>Maintenance</h1>",
+		})
+		if _, err := p.Parse(ing); err != nil {
+			t.Fatalf("%v: unexpected error: %v", test.title, err)
+		}
+		if _, err := os.Stat(pageFile); err != nil {
+			t.Fatalf("%v: expected the page %v to exist: %v", test.title, pageFile, err)
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+		p.Parse(ing)
+
+		if _, err := os.Stat(pageFile); !os.IsNotExist(err) {
+			t.Errorf("%v: expected the page %v to be removed", test.title, pageFile)
+		}
+	}
+
+	// removing a missing page is not an error
+	RemovePage(dir, ing)
+}
+
+func TestInvalidSettingsDenyTheLocation(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"):      "true",
+		parser.GetAnnotationWithPrefix("maintenance-whitelist"): "invalid",
+	})
+
+	_, err := NewParser(PageDirectory, mockConfigMap{}).Parse(ing)
+	if !errors.IsLocationDenied(err) {
+		t.Errorf("expected a location denied error but %v was returned", err)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// hostRegex matches a hostname with an optional port
+var hostRegex = regexp.MustCompile(`^[a-zA-Z0-9\-.]+(:[0-9]+)?$`)
+
+// Config contains the URL that receives a copy of the requests
+// of a location. The responses of the mirror are ignored
+type Config struct {
+	// Target is the URL, with scheme, host and an optional path prefix,
+	// that receives the mirrored requests. The URI of the original
+	// request is appended to the URL
+	Target string `json:"target"`
+	// Host is the value of the Host header of the mirrored requests.
+	// By default the host of the target
+	Host string `json:"host"`
+	// RequestBody indicates if the body of the requests is mirrored
+	RequestBody bool `json:"requestBody"`
+}
+
+// Equal tests for equality between two Config types
+func (m1 *Config) Equal(m2 *Config) bool {
+	if m1 == m2 {
+		return true
+	}
+	if m1 == nil || m2 == nil {
+		return false
+	}
+	if m1.Target != m2.Target {
+		return false
+	}
+	if m1.Host != m2.Host {
+		return false
+	}
+	if m1.RequestBody != m2.RequestBody {
+		return false
+	}
+
+	return true
+}
+
+type mirror struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new mirror annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return mirror{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to mirror the requests to another URL
+func (a mirror) Parse(ing *extensions.Ingress) (interface{}, error) {
+	target, err := parser.GetStringAnnotation("mirror-target", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.RawQuery != "" || u.Fragment != "" || strings.ContainsAny(target, "$; \"'") {
+		return nil, ing_errors.NewLocationDenied("mirror-target must be an http or https URL without query")
+	}
+
+	host, err := parser.GetStringAnnotation("mirror-host", ing)
+	if err != nil {
+		host = u.Host
+	}
+	if !hostRegex.MatchString(host) {
+		return nil, ing_errors.NewInvalidAnnotationContent("mirror-host", host)
+	}
+
+	requestBody, err := parser.GetBoolAnnotation("mirror-request-body", ing)
+	if err != nil {
+		requestBody = true
+	}
+
+	return &Config{
+		Target:      strings.TrimSuffix(target, "/"),
+		Host:        host,
+		RequestBody: requestBody,
+	}, nil
+}
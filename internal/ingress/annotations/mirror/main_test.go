@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"without target", map[string]string{}, nil, true},
+		{"external URL", map[string]string{
+			"mirror-target": "https://staging.example.com/",
+		}, &Config{Target: "https://staging.example.com", Host: "staging.example.com", RequestBody: true}, false},
+		{"service with path prefix", map[string]string{
+			"mirror-target":       "http://analytics.monitoring.svc.cluster.local:8080/shadow",
+			"mirror-host":         "analytics",
+			"mirror-request-body": "false",
+		}, &Config{Target: "http://analytics.monitoring.svc.cluster.local:8080/shadow", Host: "analytics", RequestBody: false}, false},
+		{"invalid scheme", map[string]string{
+			"mirror-target": "ftp://staging.example.com",
+		}, nil, true},
+		{"without host", map[string]string{
+			"mirror-target": "/shadow",
+		}, nil, true},
+		{"with query", map[string]string{
+			"mirror-target": "https://staging.example.com/?debug=1",
+		}, nil, true},
+		{"with variables", map[string]string{
+			"mirror-target": "https://staging.example.com$request_uri",
+		}, nil, true},
+		{"invalid host", map[string]string{
+			"mirror-target": "https://staging.example.com",
+			"mirror-host":   "staging; return 200",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modsecurity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// configMapKey is the key of the configmap that contains the rules
+const configMapKey = "modsecurity-rules"
+
+// ruleIDRegex matches the ID of a ModSecurity rule
+var ruleIDRegex = regexp.MustCompile(`^[0-9]+$`)
+
+// Config contains the ModSecurity configuration of an Ingress rule
+type Config struct {
+	// Enable enables ModSecurity in the locations of the Ingress rule
+	Enable bool `json:"enable"`
+	// OWASPRules enables the OWASP ModSecurity Core Rule Set (CRS)
+	OWASPRules bool `json:"owaspRules"`
+	// Snippet contains custom ModSecurity rules
+	Snippet string `json:"snippet"`
+	// ConfigMap is the name of the configmap that contains custom ModSecurity rules
+	ConfigMap string `json:"configMap,omitempty"`
+	// ParanoiaLevel is the paranoia level of the OWASP Core Rule Set (1 to 4)
+	ParanoiaLevel int `json:"paranoiaLevel,omitempty"`
+	// InboundAnomalyThreshold is the anomaly score that blocks a request
+	InboundAnomalyThreshold int `json:"inboundAnomalyThreshold,omitempty"`
+	// OutboundAnomalyThreshold is the anomaly score that blocks a response
+	OutboundAnomalyThreshold int `json:"outboundAnomalyThreshold,omitempty"`
+	// ExcludedRules contains the IDs of the rules that must be removed
+	ExcludedRules []string `json:"excludedRules,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enable != c2.Enable {
+		return false
+	}
+	if c1.OWASPRules != c2.OWASPRules {
+		return false
+	}
+	if c1.Snippet != c2.Snippet {
+		return false
+	}
+	if c1.ConfigMap != c2.ConfigMap {
+		return false
+	}
+	if c1.ParanoiaLevel != c2.ParanoiaLevel {
+		return false
+	}
+	if c1.InboundAnomalyThreshold != c2.InboundAnomalyThreshold {
+		return false
+	}
+	if c1.OutboundAnomalyThreshold != c2.OutboundAnomalyThreshold {
+		return false
+	}
+	if strings.Join(c1.ExcludedRules, ",") != strings.Join(c2.ExcludedRules, ",") {
+		return false
+	}
+
+	return true
+}
+
+type modSecurity struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new ModSecurity annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return modSecurity{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to enable ModSecurity, configure custom rules and tune
+// the OWASP Core Rule Set
+func (a modSecurity) Parse(ing *extensions.Ingress) (interface{}, error) {
+	enable, _ := parser.GetBoolAnnotation("enable-modsecurity", ing)
+
+	owaspRules, _ := parser.GetBoolAnnotation("enable-owasp-core-rules", ing)
+
+	rules := []string{}
+	snippet, _ := parser.GetStringAnnotation("modsecurity-snippet", ing)
+	if snippet != "" {
+		rules = append(rules, snippet)
+	}
+
+	configMap := ""
+	cmName, _ := parser.GetStringAnnotation("modsecurity-rules-configmap", ing)
+	if cmName != "" {
+		configMap = fmt.Sprintf("%v/%v", ing.Namespace, cmName)
+		cm, err := a.r.GetConfigMap(configMap)
+		if err != nil || cm == nil {
+			return nil, ing_errors.LocationDenied{
+				Reason: fmt.Errorf("unexpected error reading configmap %v: %v", configMap, err),
+			}
+		}
+
+		if cmRules := cm.Data[configMapKey]; cmRules != "" {
+			rules = append(rules, cmRules)
+		}
+	}
+
+	// the rules are rendered between single quotes
+	if strings.Contains(strings.Join(rules, ""), "'") {
+		return nil, ing_errors.NewLocationDenied("the ModSecurity rules cannot contain the character '")
+	}
+
+	paranoiaLevel, err := parseInt("owasp-paranoia-level", 1, 4, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	inboundThreshold, err := parseInt("owasp-inbound-anomaly-threshold", 1, math.MaxInt32, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	outboundThreshold, err := parseInt("owasp-outbound-anomaly-threshold", 1, math.MaxInt32, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	excludedRules := []string{}
+	val, _ := parser.GetStringAnnotation("owasp-exclude-rules", ing)
+	for _, id := range strings.Split(val, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !ruleIDRegex.MatchString(id) {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid rule ID %v in annotation owasp-exclude-rules", id))
+		}
+		excludedRules = append(excludedRules, id)
+	}
+
+	config := &Config{
+		Enable:                   enable,
+		OWASPRules:               owaspRules,
+		Snippet:                  strings.Join(rules, "\n"),
+		ConfigMap:                configMap,
+		ParanoiaLevel:            paranoiaLevel,
+		InboundAnomalyThreshold:  inboundThreshold,
+		OutboundAnomalyThreshold: outboundThreshold,
+	}
+	if len(excludedRules) > 0 {
+		config.ExcludedRules = excludedRules
+	}
+
+	if config.Equal(&Config{}) {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	return config, nil
+}
+
+// parseInt returns the value of an annotation that must be a number
+// between min and max or zero if the annotation is not present.
+// Invalid values deny the access to the location instead of
+// ignoring the ModSecurity configuration.
+func parseInt(name string, min, max int, ing *extensions.Ingress) (int, error) {
+	val, err := parser.GetIntAnnotation(name, ing)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return 0, nil
+		}
+		return 0, ing_errors.NewLocationDenied(fmt.Sprintf("the annotation %v does not contain a valid number", name))
+	}
+
+	if val < min || val > max {
+		return 0, ing_errors.NewLocationDenied(fmt.Sprintf("the annotation %v must be a number between %v and %v", name, min, max))
+	}
+
+	return val, nil
+}
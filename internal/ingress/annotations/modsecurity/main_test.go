@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modsecurity
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockConfigMap struct {
+	resolver.Mock
+}
+
+func (m mockConfigMap) GetConfigMap(name string) (*api.ConfigMap, error) {
+	if name == "default/rules" {
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"modsecurity-rules": "SecRuleEngine On",
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("configmap %v not found", name)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"missing annotations", map[string]string{}, nil, true},
+		{"enable", map[string]string{
+			"enable-modsecurity": "true",
+		}, &Config{Enable: true}, false},
+		{"enable with owasp rules", map[string]string{
+			"enable-modsecurity":      "true",
+			"enable-owasp-core-rules": "true",
+		}, &Config{Enable: true, OWASPRules: true}, false},
+		{"snippet and configmap", map[string]string{
+			"enable-modsecurity":          "true",
+			"modsecurity-snippet":         "SecDebugLogLevel 9",
+			"modsecurity-rules-configmap": "rules",
+		}, &Config{Enable: true, Snippet: "SecDebugLogLevel 9\nSecRuleEngine On", ConfigMap: "default/rules"}, false},
+		{"invalid character", map[string]string{
+			"enable-modsecurity":  "true",
+			"modsecurity-snippet": "SecRule ARGS \"'\" \"id:1,deny\"",
+		}, nil, true},
+		{"owasp tuning", map[string]string{
+			"enable-owasp-core-rules":          "true",
+			"owasp-paranoia-level":             "2",
+			"owasp-inbound-anomaly-threshold":  "10",
+			"owasp-outbound-anomaly-threshold": "8",
+			"owasp-exclude-rules":              "942100, 920350",
+		}, &Config{
+			OWASPRules:               true,
+			ParanoiaLevel:            2,
+			InboundAnomalyThreshold:  10,
+			OutboundAnomalyThreshold: 8,
+			ExcludedRules:            []string{"942100", "920350"},
+		}, false},
+		{"invalid paranoia level", map[string]string{
+			"owasp-paranoia-level": "5",
+		}, nil, true},
+		{"invalid anomaly threshold", map[string]string{
+			"owasp-inbound-anomaly-threshold": "high",
+		}, nil, true},
+		{"invalid rule id", map[string]string{
+			"owasp-exclude-rules": "942100,all",
+		}, nil, true},
+		{"missing configmap", map[string]string{
+			"enable-modsecurity":          "true",
+			"modsecurity-rules-configmap": "missing",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockConfigMap{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pathtype
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	// Exact matches the path of the request exactly (location =)
+	Exact = "Exact"
+	// Prefix matches the beginning of the path of the request and has
+	// precedence over the regular expressions (location ^~)
+	Prefix = "Prefix"
+	// ImplementationSpecific uses a NGINX prefix location, the default
+	ImplementationSpecific = "ImplementationSpecific"
+)
+
+var validPathTypes = map[string]bool{
+	Exact:                  true,
+	Prefix:                 true,
+	ImplementationSpecific: true,
+}
+
+type pathType struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new path type annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return pathType{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define how the paths of the Ingress rule are matched.
+// The extensions/v1beta1 API does not contain the pathType of the
+// paths, so the annotation applies to all the paths of the Ingress
+func (a pathType) Parse(ing *extensions.Ingress) (interface{}, error) {
+	pt, err := parser.GetStringAnnotation("path-type", ing)
+	if err != nil {
+		return "", err
+	}
+
+	if !validPathTypes[pt] {
+		return "", ing_errors.NewInvalidAnnotationContent("path-type", pt)
+	}
+
+	return pt, nil
+}
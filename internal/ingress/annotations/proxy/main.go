@@ -17,12 +17,22 @@ limitations under the License.
 package proxy
 
 import (
+	"regexp"
+	"strings"
+
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+const pathBodySizePrefix = "paths="
+
+var (
+	// sizeRegex matches a valid nginx size (1000, 1k, 1K, 1m, 1M, 1g, 1G)
+	sizeRegex = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+)
+
 // Config returns the proxy timeout to use in the upstream server/s
 type Config struct {
 	BodySize          string `json:"bodySize"`
@@ -37,6 +47,11 @@ type Config struct {
 	ProxyRedirectFrom string `json:"proxyRedirectFrom"`
 	ProxyRedirectTo   string `json:"proxyRedirectTo"`
 	RequestBuffering  string `json:"requestBuffering"`
+	BuffersNumber     int    `json:"buffersNumber"`
+	ProxyBuffering    string `json:"proxyBuffering"`
+	// PathBodySize contains the maximum allowed size of the client
+	// request body for specific paths of the Ingress rule
+	PathBodySize map[string]string `json:"pathBodySize,omitempty"`
 }
 
 // Equal tests for equality between two Configuration types
@@ -83,6 +98,20 @@ func (l1 *Config) Equal(l2 *Config) bool {
 	if l1.ProxyRedirectTo != l2.ProxyRedirectTo {
 		return false
 	}
+	if l1.BuffersNumber != l2.BuffersNumber {
+		return false
+	}
+	if l1.ProxyBuffering != l2.ProxyBuffering {
+		return false
+	}
+	if len(l1.PathBodySize) != len(l2.PathBodySize) {
+		return false
+	}
+	for path, size := range l1.PathBodySize {
+		if l2.PathBodySize[path] != size {
+			return false
+		}
+	}
 
 	return true
 }
@@ -101,17 +130,17 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 	defBackend := a.r.GetDefaultBackend()
 	ct, err := parser.GetIntAnnotation("proxy-connect-timeout", ing)
-	if err != nil {
+	if err != nil || ct <= 0 {
 		ct = defBackend.ProxyConnectTimeout
 	}
 
 	st, err := parser.GetIntAnnotation("proxy-send-timeout", ing)
-	if err != nil {
+	if err != nil || st <= 0 {
 		st = defBackend.ProxySendTimeout
 	}
 
 	rt, err := parser.GetIntAnnotation("proxy-read-timeout", ing)
-	if err != nil {
+	if err != nil || rt <= 0 {
 		rt = defBackend.ProxyReadTimeout
 	}
 
@@ -120,6 +149,16 @@ func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 		bufs = defBackend.ProxyBufferSize
 	}
 
+	bufn, err := parser.GetIntAnnotation("proxy-buffers-number", ing)
+	if err != nil || bufn <= 0 {
+		bufn = defBackend.ProxyBuffersNumber
+	}
+
+	pb, err := parser.GetStringAnnotation("proxy-buffering", ing)
+	if err != nil || (pb != "on" && pb != "off") {
+		pb = defBackend.ProxyBuffering
+	}
+
 	cp, err := parser.GetStringAnnotation("proxy-cookie-path", ing)
 	if err != nil || cp == "" {
 		cp = defBackend.ProxyCookiePath
@@ -130,9 +169,13 @@ func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 		cd = defBackend.ProxyCookieDomain
 	}
 
+	var pbs map[string]string
 	bs, err := parser.GetStringAnnotation("proxy-body-size", ing)
 	if err != nil || bs == "" {
 		bs = defBackend.ProxyBodySize
+	} else if strings.HasPrefix(bs, pathBodySizePrefix) {
+		pbs = parsePathBodySize(strings.TrimPrefix(bs, pathBodySizePrefix))
+		bs = defBackend.ProxyBodySize
 	}
 
 	nu, err := parser.GetStringAnnotation("proxy-next-upstream", ing)
@@ -160,5 +203,36 @@ func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 		prt = defBackend.ProxyRedirectTo
 	}
 
-	return &Config{bs, ct, st, rt, bufs, cd, cp, nu, pp, prf, prt, rb}, nil
+	return &Config{bs, ct, st, rt, bufs, cd, cp, nu, pp, prf, prt, rb, bufn, pb, pbs}, nil
+}
+
+// parsePathBodySize parses a comma separated list of path:size pairs
+// (i.e. /upload:1g,/api:1m). Invalid entries are ignored.
+func parsePathBodySize(value string) map[string]string {
+	pbs := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		idx := strings.LastIndex(entry, ":")
+		if idx < 1 {
+			continue
+		}
+
+		path, size := entry[:idx], entry[idx+1:]
+		if !strings.HasPrefix(path, "/") || !sizeRegex.MatchString(size) {
+			continue
+		}
+
+		pbs[path] = size
+	}
+
+	return pbs
+}
+
+// BodySizeForPath returns the maximum allowed size of the client
+// request body for the given path
+func (c Config) BodySizeForPath(path string) string {
+	if bs, ok := c.PathBodySize[path]; ok {
+		return bs
+	}
+	return c.BodySize
 }
@@ -75,6 +75,8 @@ func (m mockBackend) GetDefaultBackend() defaults.Backend {
 		ProxySendTimeout:      15,
 		ProxyReadTimeout:      20,
 		ProxyBufferSize:       "10k",
+		ProxyBuffersNumber:    4,
+		ProxyBuffering:        "off",
 		ProxyBodySize:         "3k",
 		ProxyNextUpstream:     "error",
 		ProxyPassParams:       "nocanon keepalive=On",
@@ -90,6 +92,8 @@ func TestProxy(t *testing.T) {
 	data[parser.GetAnnotationWithPrefix("proxy-send-timeout")] = "2"
 	data[parser.GetAnnotationWithPrefix("proxy-read-timeout")] = "3"
 	data[parser.GetAnnotationWithPrefix("proxy-buffer-size")] = "1k"
+	data[parser.GetAnnotationWithPrefix("proxy-buffers-number")] = "8"
+	data[parser.GetAnnotationWithPrefix("proxy-buffering")] = "on"
 	data[parser.GetAnnotationWithPrefix("proxy-body-size")] = "2k"
 	data[parser.GetAnnotationWithPrefix("proxy-next-upstream")] = "off"
 	data[parser.GetAnnotationWithPrefix("proxy-pass-params")] = "smax=5 max=10"
@@ -116,6 +120,12 @@ func TestProxy(t *testing.T) {
 	if p.BufferSize != "1k" {
 		t.Errorf("expected 1k as buffer-size but returned %v", p.BufferSize)
 	}
+	if p.BuffersNumber != 8 {
+		t.Errorf("expected 8 as buffers-number but returned %v", p.BuffersNumber)
+	}
+	if p.ProxyBuffering != "on" {
+		t.Errorf("expected on as proxy-buffering but returned %v", p.ProxyBuffering)
+	}
 	if p.BodySize != "2k" {
 		t.Errorf("expected 2k as body-size but returned %v", p.BodySize)
 	}
@@ -156,6 +166,12 @@ func TestProxyWithNoAnnotation(t *testing.T) {
 	if p.BufferSize != "10k" {
 		t.Errorf("expected 10k as buffer-size but returned %v", p.BufferSize)
 	}
+	if p.BuffersNumber != 4 {
+		t.Errorf("expected 4 as buffers-number but returned %v", p.BuffersNumber)
+	}
+	if p.ProxyBuffering != "off" {
+		t.Errorf("expected off as proxy-buffering but returned %v", p.ProxyBuffering)
+	}
 	if p.BodySize != "3k" {
 		t.Errorf("expected 3k as body-size but returned %v", p.BodySize)
 	}
@@ -169,3 +185,71 @@ func TestProxyWithNoAnnotation(t *testing.T) {
 		t.Errorf("expected on as request-buffering but returned %v", p.RequestBuffering)
 	}
 }
+
+func TestProxyWithInvalidTimeouts(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("proxy-connect-timeout")] = "0"
+	data[parser.GetAnnotationWithPrefix("proxy-send-timeout")] = "-5"
+	data[parser.GetAnnotationWithPrefix("proxy-read-timeout")] = "abc"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a valid")
+	}
+	p, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+	if p.ConnectTimeout != 10 {
+		t.Errorf("expected 10 as connect-timeout but returned %v", p.ConnectTimeout)
+	}
+	if p.SendTimeout != 15 {
+		t.Errorf("expected 15 as send-timeout but returned %v", p.SendTimeout)
+	}
+	if p.ReadTimeout != 20 {
+		t.Errorf("expected 20 as read-timeout but returned %v", p.ReadTimeout)
+	}
+}
+
+func TestProxyWithPathBodySize(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("proxy-body-size")] = "paths=/upload:1g, /api:1m,/invalid:abc,nopath:1k"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a valid")
+	}
+	p, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+	if p.BodySize != "3k" {
+		t.Errorf("expected 3k as body-size but returned %v", p.BodySize)
+	}
+	if len(p.PathBodySize) != 2 {
+		t.Errorf("expected 2 paths with custom body-size but returned %v", len(p.PathBodySize))
+	}
+
+	fooTests := []struct {
+		path     string
+		expected string
+	}{
+		{"/upload", "1g"},
+		{"/api", "1m"},
+		{"/invalid", "3k"},
+		{"/", "3k"},
+	}
+
+	for _, ft := range fooTests {
+		bs := p.BodySizeForPath(ft.path)
+		if bs != ft.expected {
+			t.Errorf("expected %v as body-size for path %v but returned %v", ft.expected, ft.path, bs)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	zoneRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	// keyRegex rejects characters that could break the generated directive
+	keyRegex       = regexp.MustCompile(`^[^\s"\\;{}]+$`)
+	codeRegex      = regexp.MustCompile(`^(any|[1-5][0-9][0-9])$`)
+	timeRegex      = regexp.MustCompile(`^[0-9]+(ms|[smhdwMy])?$`)
+	conditionRegex = regexp.MustCompile(`^(\$[a-zA-Z0-9_]+|[0-9]+)$`)
+)
+
+// Config contains the proxy cache configuration of an Ingress rule
+type Config struct {
+	// Zone is the name of the cache zone defined in the configuration
+	// configmap with the proxy-cache-zones key
+	Zone string `json:"zone"`
+	// Key defines the key used to store the responses
+	Key string `json:"key,omitempty"`
+	// Valid contains the caching time of the responses per status code
+	// using the format [code ...] time
+	Valid []string `json:"valid,omitempty"`
+	// Bypass contains the conditions under which the response is not
+	// taken from the cache
+	Bypass string `json:"bypass,omitempty"`
+	// NoCache contains the conditions under which the response is not
+	// saved to the cache
+	NoCache string `json:"noCache,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Zone != c2.Zone {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+	if len(c1.Valid) != len(c2.Valid) {
+		return false
+	}
+	for i := range c1.Valid {
+		if c1.Valid[i] != c2.Valid[i] {
+			return false
+		}
+	}
+	if c1.Bypass != c2.Bypass {
+		return false
+	}
+	if c1.NoCache != c2.NoCache {
+		return false
+	}
+
+	return true
+}
+
+type proxyCache struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new proxy cache annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxyCache{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// cache the responses of the upstream servers in one of the cache zones
+func (a proxyCache) Parse(ing *extensions.Ingress) (interface{}, error) {
+	zone, err := parser.GetStringAnnotation("proxy-cache-zone", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	if !zoneRegex.MatchString(zone) {
+		return nil, ing_errors.NewInvalidAnnotationContent("proxy-cache-zone", zone)
+	}
+
+	config := &Config{Zone: zone}
+
+	key, err := parser.GetStringAnnotation("proxy-cache-key", ing)
+	if err == nil {
+		if !keyRegex.MatchString(key) {
+			return nil, ing_errors.NewInvalidAnnotationContent("proxy-cache-key", key)
+		}
+		config.Key = key
+	}
+
+	valid, err := parser.GetStringAnnotation("proxy-cache-valid", ing)
+	if err == nil {
+		for _, v := range strings.Split(valid, ",") {
+			fields := strings.Fields(v)
+			if len(fields) == 0 {
+				continue
+			}
+
+			if !timeRegex.MatchString(fields[len(fields)-1]) {
+				return nil, ing_errors.NewInvalidAnnotationContent("proxy-cache-valid", valid)
+			}
+
+			for _, code := range fields[:len(fields)-1] {
+				if !codeRegex.MatchString(code) {
+					return nil, ing_errors.NewInvalidAnnotationContent("proxy-cache-valid", valid)
+				}
+			}
+
+			config.Valid = append(config.Valid, strings.Join(fields, " "))
+		}
+	}
+
+	config.Bypass, err = parseConditions("proxy-cache-bypass", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	config.NoCache, err = parseConditions("proxy-no-cache", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parseConditions returns the list of variables or values, separated
+// by spaces, that are used to skip the cache
+func parseConditions(name string, ing *extensions.Ingress) (string, error) {
+	val, err := parser.GetStringAnnotation(name, ing)
+	if err != nil {
+		return "", nil
+	}
+
+	conditions := strings.Fields(val)
+	for _, condition := range conditions {
+		if !conditionRegex.MatchString(condition) {
+			return "", ing_errors.NewInvalidAnnotationContent(name, val)
+		}
+	}
+
+	return strings.Join(conditions, " "), nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"missing annotations", map[string]string{}, nil, true},
+		{"missing zone", map[string]string{
+			"proxy-cache-valid": "200 10m",
+		}, nil, true},
+		{"zone", map[string]string{
+			"proxy-cache-zone": "static",
+		}, &Config{Zone: "static"}, false},
+		{"all the annotations", map[string]string{
+			"proxy-cache-zone":   "static",
+			"proxy-cache-key":    "$host$request_uri",
+			"proxy-cache-valid":  "200 302  10m, 404 1m,,any 30s",
+			"proxy-cache-bypass": " $cookie_nocache  $arg_nocache ",
+			"proxy-no-cache":     "$http_pragma 0",
+		}, &Config{
+			Zone:    "static",
+			Key:     "$host$request_uri",
+			Valid:   []string{"200 302 10m", "404 1m", "any 30s"},
+			Bypass:  "$cookie_nocache $arg_nocache",
+			NoCache: "$http_pragma 0",
+		}, false},
+		{"invalid zone", map[string]string{
+			"proxy-cache-zone": "static; proxy_cache off",
+		}, nil, true},
+		{"invalid key", map[string]string{
+			"proxy-cache-zone": "static",
+			"proxy-cache-key":  `$host"; proxy_cache off;`,
+		}, nil, true},
+		{"backslash in key", map[string]string{
+			"proxy-cache-zone": "static",
+			"proxy-cache-key":  `$host\`,
+		}, nil, true},
+		{"invalid time", map[string]string{
+			"proxy-cache-zone":  "static",
+			"proxy-cache-valid": "200 forever",
+		}, nil, true},
+		{"invalid status code", map[string]string{
+			"proxy-cache-zone":  "static",
+			"proxy-cache-valid": "600 10m",
+		}, nil, true},
+		{"invalid bypass", map[string]string{
+			"proxy-cache-zone":   "static",
+			"proxy-cache-bypass": "$arg_nocache;",
+		}, nil, true},
+		{"invalid no-cache", map[string]string{
+			"proxy-cache-zone": "static",
+			"proxy-no-cache":   "{",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxyssl
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+const (
+	on  = "on"
+	off = "off"
+
+	defaultVerifyDepth = 1
+)
+
+// Config contains the client certificate presented to the HTTPS upstream
+// servers and the settings used to verify the certificates of the servers
+type Config struct {
+	resolver.AuthSSLCert
+	// CASecret is the name of the secret that contains the CA used to
+	// verify the upstream servers, if it is not the secret with the
+	// client certificate
+	CASecret string `json:"caSecret"`
+	// Verify enables the verification of the certificates of the
+	// upstream servers using the 'ca.crt' of the secret (on or off)
+	Verify string `json:"verify"`
+	// VerifyDepth is the depth of the verification of the certificate chain
+	VerifyDepth int `json:"verifyDepth"`
+	// Name is the name used to verify the certificates of the upstream
+	// servers. By default the host of the proxy_pass directive
+	Name string `json:"name"`
+	// ServerName enables the server name indication (SNI) in the
+	// connections with the upstream servers (on or off)
+	ServerName string `json:"serverName"`
+}
+
+// Equal tests for equality between two Config types
+func (pssl1 *Config) Equal(pssl2 *Config) bool {
+	if pssl1 == pssl2 {
+		return true
+	}
+	if pssl1 == nil || pssl2 == nil {
+		return false
+	}
+	if !(&pssl1.AuthSSLCert).Equal(&pssl2.AuthSSLCert) {
+		return false
+	}
+	if pssl1.CASecret != pssl2.CASecret {
+		return false
+	}
+	if pssl1.Verify != pssl2.Verify {
+		return false
+	}
+	if pssl1.VerifyDepth != pssl2.VerifyDepth {
+		return false
+	}
+	if pssl1.Name != pssl2.Name {
+		return false
+	}
+	if pssl1.ServerName != pssl2.ServerName {
+		return false
+	}
+
+	return true
+}
+
+type proxySSL struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new upstream TLS annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxySSL{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// configure the client certificate and the verification of the
+// certificates of the HTTPS upstream servers
+func (a proxySSL) Parse(ing *extensions.Ingress) (interface{}, error) {
+	config := &Config{
+		Verify:      off,
+		VerifyDepth: defaultVerifyDepth,
+		ServerName:  off,
+	}
+
+	secret, err := parser.GetStringAnnotation("proxy-ssl-secret", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+	if secret != "" {
+		_, _, err = k8s.ParseNameNS(secret)
+		if err != nil {
+			return &Config{}, ing_errors.NewLocationDenied(err.Error())
+		}
+
+		authCert, err := a.r.GetAuthCertificate(secret)
+		if err != nil {
+			return &Config{}, ing_errors.LocationDenied{
+				Reason: errors.Wrap(err, "error obtaining certificate"),
+			}
+		}
+		if authCert == nil {
+			return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("secret %v not found", secret))
+		}
+		config.AuthSSLCert = *authCert
+	}
+
+	// the CA of the secure-verify-ca-secret annotation enables the verification
+	caSecret, err := parser.GetStringAnnotation("secure-verify-ca-secret", ing)
+	if err == nil && caSecret != "" {
+		caSecret = fmt.Sprintf("%v/%v", ing.Namespace, caSecret)
+		caCert, err := a.r.GetAuthCertificate(caSecret)
+		if err != nil {
+			return &Config{}, ing_errors.LocationDenied{
+				Reason: errors.Wrap(err, "error obtaining CA certificate"),
+			}
+		}
+		if caCert == nil || caCert.CAFileName == "" {
+			return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("secret %v does not contain a 'ca.crt'", caSecret))
+		}
+
+		config.CASecret = caSecret
+		config.CAFileName = caCert.CAFileName
+		config.Verify = on
+	}
+
+	verify, err := parser.GetStringAnnotation("proxy-ssl-verify", ing)
+	if err == nil {
+		if verify != on && verify != off {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-ssl-verify", verify)
+		}
+		config.Verify = verify
+	}
+	if config.Verify == on && config.CAFileName == "" {
+		return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("the verification of the upstream certificates requires a 'ca.crt' in the secret %q", secret))
+	}
+
+	depth, err := parser.GetIntAnnotation("proxy-ssl-verify-depth", ing)
+	if err == nil {
+		if depth < 1 {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-ssl-verify-depth", depth)
+		}
+		config.VerifyDepth = depth
+	}
+
+	name, err := parser.GetStringAnnotation("proxy-ssl-name", ing)
+	if err == nil {
+		config.Name = name
+	}
+
+	serverName, err := parser.GetStringAnnotation("proxy-ssl-server-name", ing)
+	if err == nil {
+		if serverName != on && serverName != off {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-ssl-server-name", serverName)
+		}
+		config.ServerName = serverName
+	}
+
+	return config, nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxyssl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+}
+
+func (m mockSecret) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	switch name {
+	case "default/client":
+		return &resolver.AuthSSLCert{
+			Secret:      name,
+			PemFileName: "/etc/ingress-controller/ssl/default-client.pem",
+			PemSHA:      "abc",
+		}, nil
+	case "default/client-ca":
+		return &resolver.AuthSSLCert{
+			Secret:      name,
+			CAFileName:  "/etc/ingress-controller/ssl/default-client-ca.pem",
+			PemFileName: "/etc/ingress-controller/ssl/default-client-ca.pem",
+			PemSHA:      "def",
+		}, nil
+	case "default/ca":
+		return &resolver.AuthSSLCert{
+			Secret:     name,
+			CAFileName: "/etc/ingress-controller/ssl/default-ca.pem",
+			PemSHA:     "ghi",
+		}, nil
+	}
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"default values", map[string]string{}, &Config{Verify: "off", VerifyDepth: 1, ServerName: "off"}, false},
+		{"client certificate", map[string]string{
+			"proxy-ssl-secret": "default/client",
+		}, &Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				Secret:      "default/client",
+				PemFileName: "/etc/ingress-controller/ssl/default-client.pem",
+				PemSHA:      "abc",
+			},
+			Verify:      "off",
+			VerifyDepth: 1,
+			ServerName:  "off",
+		}, false},
+		{"verify", map[string]string{
+			"proxy-ssl-secret": "default/client-ca",
+			"proxy-ssl-verify": "on",
+			"proxy-ssl-name":   "backend.default.svc",
+		}, &Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				Secret:      "default/client-ca",
+				CAFileName:  "/etc/ingress-controller/ssl/default-client-ca.pem",
+				PemFileName: "/etc/ingress-controller/ssl/default-client-ca.pem",
+				PemSHA:      "def",
+			},
+			Verify:      "on",
+			VerifyDepth: 1,
+			Name:        "backend.default.svc",
+			ServerName:  "off",
+		}, false},
+		{"trusted CA and SNI", map[string]string{
+			"secure-verify-ca-secret": "ca",
+			"proxy-ssl-verify-depth":  "2",
+			"proxy-ssl-name":          "backend.default.svc",
+			"proxy-ssl-server-name":   "on",
+		}, &Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				CAFileName: "/etc/ingress-controller/ssl/default-ca.pem",
+			},
+			CASecret:    "default/ca",
+			Verify:      "on",
+			VerifyDepth: 2,
+			Name:        "backend.default.svc",
+			ServerName:  "on",
+		}, false},
+		{"client certificate and trusted CA", map[string]string{
+			"proxy-ssl-secret":        "default/client",
+			"secure-verify-ca-secret": "ca",
+			"proxy-ssl-verify":        "off",
+		}, &Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				Secret:      "default/client",
+				CAFileName:  "/etc/ingress-controller/ssl/default-ca.pem",
+				PemFileName: "/etc/ingress-controller/ssl/default-client.pem",
+				PemSHA:      "abc",
+			},
+			CASecret:    "default/ca",
+			Verify:      "off",
+			VerifyDepth: 1,
+			ServerName:  "off",
+		}, false},
+		{"CA secret without CA", map[string]string{
+			"secure-verify-ca-secret": "client",
+		}, nil, true},
+		{"invalid verify depth", map[string]string{
+			"proxy-ssl-verify-depth": "0",
+		}, nil, true},
+		{"invalid server name", map[string]string{
+			"proxy-ssl-server-name": "true",
+		}, nil, true},
+		{"verify without CA", map[string]string{
+			"proxy-ssl-secret": "default/client",
+			"proxy-ssl-verify": "on",
+		}, nil, true},
+		{"invalid verify", map[string]string{
+			"proxy-ssl-secret": "default/client-ca",
+			"proxy-ssl-verify": "yes",
+		}, nil, true},
+		{"secret without namespace", map[string]string{
+			"proxy-ssl-secret": "client",
+		}, nil, true},
+		{"missing secret", map[string]string{
+			"proxy-ssl-secret": "default/missing",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockSecret{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -19,12 +19,14 @@ package ratelimit
 import (
 	"encoding/base64"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/net"
 )
@@ -38,6 +40,9 @@ const (
 	defSharedSize = 5
 )
 
+// keyRegex matches one or more NGINX variables
+var keyRegex = regexp.MustCompile(`^(\$[a-zA-Z0-9_]+)+$`)
+
 // Config returns rate limit configuration for an Ingress rule limiting the
 // number of connections per IP address and/or connections per second.
 // If you both annotations are specified in a single Ingress rule, RPS limits
@@ -50,15 +55,22 @@ type Config struct {
 
 	RPM Zone `json:"rpm"`
 
-	LimitRate int `json:"limit-rate"`
-
-	LimitRateAfter int `json:"limit-rate-after"`
-
 	Name string `json:"name"`
 
 	ID string `json:"id"`
 
 	Whitelist []string `json:"whitelist"`
+
+	// Key is the NGINX variable used to identify a client. If empty
+	// the value of limit-conn-zone-variable in the configmap is used
+	Key string `json:"key,omitempty"`
+
+	// StatusCode is the status code returned to rejected requests
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// RetryAfter is the number of seconds sent in the Retry-After
+	// header of rejected requests
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
 // Equal tests for equality between two RateLimit types
@@ -78,16 +90,19 @@ func (rt1 *Config) Equal(rt2 *Config) bool {
 	if !(&rt1.RPS).Equal(&rt2.RPS) {
 		return false
 	}
-	if rt1.LimitRate != rt2.LimitRate {
+	if rt1.ID != rt2.ID {
 		return false
 	}
-	if rt1.LimitRateAfter != rt2.LimitRateAfter {
+	if rt1.Name != rt2.Name {
 		return false
 	}
-	if rt1.ID != rt2.ID {
+	if rt1.Key != rt2.Key {
 		return false
 	}
-	if rt1.Name != rt2.Name {
+	if rt1.StatusCode != rt2.StatusCode {
+		return false
+	}
+	if rt1.RetryAfter != rt2.RetryAfter {
 		return false
 	}
 	if len(rt1.Whitelist) != len(rt2.Whitelist) {
@@ -156,16 +171,6 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // ParseAnnotations parses the annotations contained in the ingress
 // rule used to rewrite the defined paths
 func (a ratelimit) Parse(ing *extensions.Ingress) (interface{}, error) {
-	defBackend := a.r.GetDefaultBackend()
-	lr, err := parser.GetIntAnnotation("limit-rate", ing)
-	if err != nil {
-		lr = defBackend.LimitRate
-	}
-	lra, err := parser.GetIntAnnotation("limit-rate-after", ing)
-	if err != nil {
-		lra = defBackend.LimitRateAfter
-	}
-
 	rpm, _ := parser.GetIntAnnotation("limit-rpm", ing)
 	rps, _ := parser.GetIntAnnotation("limit-rps", ing)
 	conn, _ := parser.GetIntAnnotation("limit-connections", ing)
@@ -177,13 +182,26 @@ func (a ratelimit) Parse(ing *extensions.Ingress) (interface{}, error) {
 		return nil, err
 	}
 
+	key, _ := parser.GetStringAnnotation("limit-key", ing)
+	if key != "" && !keyRegex.MatchString(key) {
+		return nil, ing_errors.NewInvalidAnnotationContent("limit-key", key)
+	}
+
+	sc, _ := parser.GetIntAnnotation("limit-status-code", ing)
+	if sc != 0 && (sc < 400 || sc > 599) {
+		return nil, ing_errors.NewInvalidAnnotationContent("limit-status-code", sc)
+	}
+
+	ra, _ := parser.GetIntAnnotation("limit-retry-after", ing)
+	if ra < 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("limit-retry-after", ra)
+	}
+
 	if rpm == 0 && rps == 0 && conn == 0 {
 		return &Config{
-			Connections:    Zone{},
-			RPS:            Zone{},
-			RPM:            Zone{},
-			LimitRate:      lr,
-			LimitRateAfter: lra,
+			Connections: Zone{},
+			RPS:         Zone{},
+			RPM:         Zone{},
 		}, nil
 	}
 
@@ -208,11 +226,12 @@ func (a ratelimit) Parse(ing *extensions.Ingress) (interface{}, error) {
 			Burst:      rpm * defBurst,
 			SharedSize: defSharedSize,
 		},
-		LimitRate:      lr,
-		LimitRateAfter: lra,
-		Name:           zoneName,
-		ID:             encode(zoneName),
-		Whitelist:      cidrs,
+		Name:       zoneName,
+		ID:         encode(zoneName),
+		Whitelist:  cidrs,
+		Key:        key,
+		StatusCode: sc,
+		RetryAfter: ra,
 	}, nil
 }
 
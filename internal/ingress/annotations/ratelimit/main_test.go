@@ -17,6 +17,7 @@ limitations under the License.
 package ratelimit
 
 import (
+	"reflect"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -101,8 +102,6 @@ func TestBadRateLimiting(t *testing.T) {
 	data[parser.GetAnnotationWithPrefix("limit-connections")] = "5"
 	data[parser.GetAnnotationWithPrefix("limit-rps")] = "100"
 	data[parser.GetAnnotationWithPrefix("limit-rpm")] = "10"
-	data[parser.GetAnnotationWithPrefix("limit-rate-after")] = "100"
-	data[parser.GetAnnotationWithPrefix("limit-rate")] = "10"
 
 	ing.SetAnnotations(data)
 
@@ -123,10 +122,96 @@ func TestBadRateLimiting(t *testing.T) {
 	if rateLimit.RPM.Limit != 10 {
 		t.Errorf("expected 10 in limit by rpm but %v was returend", rateLimit.RPM)
 	}
-	if rateLimit.LimitRateAfter != 100 {
-		t.Errorf("expected 100 in limit by limitrateafter but %v was returend", rateLimit.LimitRateAfter)
+}
+
+func TestRateLimitingKey(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("limit-rps")] = "10"
+	data[parser.GetAnnotationWithPrefix("limit-key")] = "$http_x_api_key"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	rateLimit, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a RateLimit type")
+	}
+	if rateLimit.Key != "$http_x_api_key" {
+		t.Errorf("expected $http_x_api_key as key but %v was returned", rateLimit.Key)
+	}
+
+	data[parser.GetAnnotationWithPrefix("limit-key")] = "$http_x_api_key; allow all"
+	ing.SetAnnotations(data)
+
+	_, err = NewParser(mockBackend{}).Parse(ing)
+	if err == nil {
+		t.Errorf("expected an error with an invalid key")
+	}
+}
+
+func TestRateLimitingWhitelist(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("limit-rps")] = "10"
+	data[parser.GetAnnotationWithPrefix("limit-whitelist")] = "192.168.0.0/16, 10.0.0.0/8,10.2.3.4"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	rateLimit, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a RateLimit type")
+	}
+	expected := []string{"10.0.0.0/8", "10.2.3.4", "192.168.0.0/16"}
+	if !reflect.DeepEqual(rateLimit.Whitelist, expected) {
+		t.Errorf("expected %v as whitelist but %v was returned", expected, rateLimit.Whitelist)
+	}
+
+	data[parser.GetAnnotationWithPrefix("limit-whitelist")] = "10.0.0.0/8,invalid"
+	ing.SetAnnotations(data)
+
+	_, err = NewParser(mockBackend{}).Parse(ing)
+	if err == nil {
+		t.Errorf("expected an error with an invalid whitelist")
+	}
+}
+
+func TestRateLimitingStatusCode(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("limit-rps")] = "10"
+	data[parser.GetAnnotationWithPrefix("limit-status-code")] = "429"
+	data[parser.GetAnnotationWithPrefix("limit-retry-after")] = "30"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	rateLimit, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a RateLimit type")
+	}
+	if rateLimit.StatusCode != 429 {
+		t.Errorf("expected 429 as status code but %v was returned", rateLimit.StatusCode)
+	}
+	if rateLimit.RetryAfter != 30 {
+		t.Errorf("expected 30 as retry after but %v was returned", rateLimit.RetryAfter)
 	}
-	if rateLimit.LimitRate != 10 {
-		t.Errorf("expected 10 in limit by limitrate but %v was returend", rateLimit.LimitRate)
+
+	data[parser.GetAnnotationWithPrefix("limit-status-code")] = "200"
+	ing.SetAnnotations(data)
+
+	_, err = NewParser(mockBackend{}).Parse(ing)
+	if err == nil {
+		t.Errorf("expected an error with an invalid status code")
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/golang/glog"
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -47,7 +48,9 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // Parse parses the annotations contained in the ingress
 // rule used to create a redirect in the paths defined in the rule.
 // If the Ingress contains both annotations the execution order is
-// temporal and then permanent
+// temporal and then permanent.
+// The default status codes (302 and 301) can be replaced using the
+// annotations temporal-redirect-code and permanent-redirect-code
 func (a redirect) Parse(ing *extensions.Ingress) (interface{}, error) {
 	r3w, _ := parser.GetBoolAnnotation("from-to-www-redirect", ing)
 
@@ -63,7 +66,7 @@ func (a redirect) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 		return &Config{
 			URL:       tr,
-			Code:      http.StatusFound,
+			Code:      getRedirectCode("temporal-redirect-code", http.StatusFound, ing),
 			FromToWWW: r3w,
 		}, nil
 	}
@@ -80,7 +83,7 @@ func (a redirect) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 		return &Config{
 			URL:       pr,
-			Code:      http.StatusMovedPermanently,
+			Code:      getRedirectCode("permanent-redirect-code", http.StatusMovedPermanently, ing),
 			FromToWWW: r3w,
 		}, nil
 	}
@@ -114,6 +117,24 @@ func (r1 *Config) Equal(r2 *Config) bool {
 	return true
 }
 
+// getRedirectCode returns the status code configured in the annotation
+// or the default value if the annotation is not present or the code is
+// not a valid redirection
+func getRedirectCode(name string, def int, ing *extensions.Ingress) int {
+	code, err := parser.GetIntAnnotation(name, ing)
+	if err != nil {
+		return def
+	}
+
+	if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+		glog.Warningf("invalid %v annotation in Ingress %v/%v (%v), using the default value %v",
+			name, ing.Namespace, ing.Name, code, def)
+		return def
+	}
+
+	return code
+}
+
 func isValidURL(s string) error {
 	u, err := url.Parse(s)
 	if err != nil {
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redirect
+
+import (
+	"net/http"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	tr := parser.GetAnnotationWithPrefix("temporal-redirect")
+	trc := parser.GetAnnotationWithPrefix("temporal-redirect-code")
+	pr := parser.GetAnnotationWithPrefix("permanent-redirect")
+	prc := parser.GetAnnotationWithPrefix("permanent-redirect-code")
+	www := parser.GetAnnotationWithPrefix("from-to-www-redirect")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{tr: "http://example.com"}, &Config{URL: "http://example.com", Code: http.StatusFound}},
+		{map[string]string{tr: "http://example.com", trc: "307"}, &Config{URL: "http://example.com", Code: http.StatusTemporaryRedirect}},
+		{map[string]string{pr: "https://example.com"}, &Config{URL: "https://example.com", Code: http.StatusMovedPermanently}},
+		{map[string]string{pr: "https://example.com", prc: "308"}, &Config{URL: "https://example.com", Code: http.StatusPermanentRedirect}},
+		{map[string]string{pr: "https://example.com", prc: "200"}, &Config{URL: "https://example.com", Code: http.StatusMovedPermanently}},
+		{map[string]string{pr: "https://example.com", prc: "abc"}, &Config{URL: "https://example.com", Code: http.StatusMovedPermanently}},
+		{map[string]string{tr: "http://a.com", pr: "http://b.com"}, &Config{URL: "http://a.com", Code: http.StatusFound}},
+		{map[string]string{pr: "https://example.com", www: "true"}, &Config{URL: "https://example.com", Code: http.StatusMovedPermanently, FromToWWW: true}},
+		{map[string]string{www: "true"}, &Config{FromToWWW: true}},
+		{map[string]string{pr: "ftp://example.com"}, nil},
+		{map[string]string{}, nil},
+		{nil, nil},
+	}
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		i, _ := ap.Parse(ing)
+		result, _ := i.(*Config)
+		if !result.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestheaders
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	// headerNameRegex matches a valid HTTP header name
+	headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	// headerValueRegex matches a value that can be rendered between double quotes
+	headerValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+)
+
+// Config contains the headers added to the requests sent to the upstream servers
+type Config struct {
+	// Headers contains the name and value of the headers
+	Headers map[string]string `json:"headers,omitempty"`
+	// ConfigMap is the name of the configmap that contains headers
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.ConfigMap != c2.ConfigMap {
+		return false
+	}
+	if len(c1.Headers) != len(c2.Headers) {
+		return false
+	}
+	for k, v := range c1.Headers {
+		if v2, ok := c2.Headers[k]; !ok || v != v2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+type requestHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new request headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return requestHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// add or override headers in the requests sent to the upstream servers.
+// The headers are read from a configmap (like the global proxy-set-headers)
+// and from the annotation request-headers, one "Name: value" pair per line.
+// The headers of the annotation take precedence over the configmap
+func (a requestHeaders) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("request-headers", ing)
+	cmName, cmErr := parser.GetStringAnnotation("request-headers-configmap", ing)
+	if err != nil && cmErr != nil {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	config := &Config{
+		Headers: map[string]string{},
+	}
+
+	if cmErr == nil {
+		config.ConfigMap = fmt.Sprintf("%v/%v", ing.Namespace, cmName)
+		cm, err := a.r.GetConfigMap(config.ConfigMap)
+		if err != nil || cm == nil {
+			return nil, ing_errors.NewInvalidAnnotationContent("request-headers-configmap", cmName)
+		}
+
+		for name, value := range cm.Data {
+			if err := addHeader(config.Headers, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err == nil {
+		for _, line := range strings.Split(val, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				return nil, ing_errors.NewInvalidAnnotationContent("request-headers", val)
+			}
+
+			if err := addHeader(config.Headers, parts[0], parts[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// addHeader validates and adds a header to the map. The names of the
+// headers are case insensitive so a previous definition is replaced
+func addHeader(headers map[string]string, name, value string) error {
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if !headerNameRegex.MatchString(name) || !headerValueRegex.MatchString(value) {
+		return ing_errors.NewInvalidAnnotationContent("request-headers", fmt.Sprintf("%v: %v", name, value))
+	}
+
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			delete(headers, k)
+		}
+	}
+	headers[name] = value
+
+	return nil
+}
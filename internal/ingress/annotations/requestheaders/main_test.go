@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestheaders
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockConfigMap struct {
+	resolver.Mock
+}
+
+func (m mockConfigMap) GetConfigMap(name string) (*api.ConfigMap, error) {
+	switch name {
+	case "default/headers":
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"X-Env":  "staging",
+				"X-Team": "payments",
+			},
+		}, nil
+	case "default/invalid":
+		return &api.ConfigMap{
+			Data: map[string]string{
+				"X Env": "staging",
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("configmap %v not found", name)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"missing annotations", map[string]string{}, nil, true},
+		{"headers", map[string]string{
+			"request-headers": "X-Env: staging\n\nX-Request-Start: t=${msec}\n",
+		}, &Config{Headers: map[string]string{"X-Env": "staging", "X-Request-Start": "t=${msec}"}}, false},
+		{"configmap", map[string]string{
+			"request-headers-configmap": "headers",
+		}, &Config{Headers: map[string]string{"X-Env": "staging", "X-Team": "payments"}, ConfigMap: "default/headers"}, false},
+		{"annotation overrides configmap", map[string]string{
+			"request-headers":           "x-env: production",
+			"request-headers-configmap": "headers",
+		}, &Config{Headers: map[string]string{"x-env": "production", "X-Team": "payments"}, ConfigMap: "default/headers"}, false},
+		{"missing colon", map[string]string{
+			"request-headers": "X-Env staging",
+		}, nil, true},
+		{"invalid name", map[string]string{
+			"request-headers": "X Env: staging",
+		}, nil, true},
+		{"invalid value", map[string]string{
+			"request-headers": `X-Env: "staging"`,
+		}, nil, true},
+		{"invalid configmap", map[string]string{
+			"request-headers-configmap": "invalid",
+		}, nil, true},
+		{"missing configmap", map[string]string{
+			"request-headers-configmap": "missing",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockConfigMap{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package responseheaders
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	// headerNameRegex matches a valid HTTP header name
+	headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	// headerValueRegex matches a value that can be rendered between double quotes
+	headerValueRegex = regexp.MustCompile(`^[^"\\\r\n]*$`)
+)
+
+// Config contains the headers added or removed from the responses
+// returned to the clients
+type Config struct {
+	// Headers contains the name and value of the headers added to the responses
+	Headers map[string]string `json:"headers,omitempty"`
+	// Hide contains the name of the headers of the upstream servers
+	// that must not be returned to the clients
+	Hide []string `json:"hide,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if len(c1.Headers) != len(c2.Headers) {
+		return false
+	}
+	for k, v := range c1.Headers {
+		if v2, ok := c2.Headers[k]; !ok || v != v2 {
+			return false
+		}
+	}
+	if strings.Join(c1.Hide, ",") != strings.Join(c2.Hide, ",") {
+		return false
+	}
+
+	return true
+}
+
+type responseHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new response headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return responseHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to add
+// headers to the responses (response-headers, one "Name: value" pair per
+// line) and to hide headers returned by the upstream servers
+// (hide-response-headers, a comma separated list of names)
+func (a responseHeaders) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("response-headers", ing)
+	hide, hideErr := parser.GetStringAnnotation("hide-response-headers", ing)
+	if err != nil && hideErr != nil {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	config := &Config{}
+
+	if err == nil {
+		config.Headers = map[string]string{}
+		for _, line := range strings.Split(val, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				return nil, ing_errors.NewInvalidAnnotationContent("response-headers", val)
+			}
+
+			name := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			if !headerNameRegex.MatchString(name) || !headerValueRegex.MatchString(value) {
+				return nil, ing_errors.NewInvalidAnnotationContent("response-headers", fmt.Sprintf("%v: %v", name, value))
+			}
+
+			config.Headers[name] = value
+		}
+	}
+
+	if hideErr == nil {
+		for _, name := range strings.Split(hide, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			if !headerNameRegex.MatchString(name) {
+				return nil, ing_errors.NewInvalidAnnotationContent("hide-response-headers", hide)
+			}
+
+			config.Hide = append(config.Hide, name)
+		}
+		sort.Strings(config.Hide)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package responseheaders
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"missing annotations", map[string]string{}, nil, true},
+		{"headers", map[string]string{
+			"response-headers": "X-Frame-Options: DENY\n\nCache-Control: no-cache, no-store\n",
+		}, &Config{Headers: map[string]string{"X-Frame-Options": "DENY", "Cache-Control": "no-cache, no-store"}}, false},
+		{"hide headers", map[string]string{
+			"hide-response-headers": "X-Powered-By, Server,",
+		}, &Config{Hide: []string{"Server", "X-Powered-By"}}, false},
+		{"headers and hide headers", map[string]string{
+			"response-headers":      "X-Backend: legacy",
+			"hide-response-headers": "X-Powered-By",
+		}, &Config{Headers: map[string]string{"X-Backend": "legacy"}, Hide: []string{"X-Powered-By"}}, false},
+		{"missing colon", map[string]string{
+			"response-headers": "X-Backend legacy",
+		}, nil, true},
+		{"invalid value", map[string]string{
+			"response-headers": `X-Backend: "legacy"`,
+		}, nil, true},
+		{"invalid hide header", map[string]string{
+			"hide-response-headers": "X Powered By",
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: api.NamespaceDefault,
+			},
+		}
+
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
@@ -38,6 +38,9 @@ type Config struct {
 	ForceSSLRedirect bool `json:"forceSSLRedirect"`
 	// AppRoot defines the Application Root that the Controller must redirect if it's in '/' context
 	AppRoot string `json:"appRoot"`
+	// UseRegex indicates if the paths of the Ingress are regular expressions
+	// and the Target can reference its capture groups ($1, $2...)
+	UseRegex bool `json:"useRegex"`
 }
 
 // Equal tests for equality between two Redirect types
@@ -66,6 +69,9 @@ func (r1 *Config) Equal(r2 *Config) bool {
 	if r1.AppRoot != r2.AppRoot {
 		return false
 	}
+	if r1.UseRegex != r2.UseRegex {
+		return false
+	}
 
 	return true
 }
@@ -94,6 +100,7 @@ func (a rewrite) Parse(ing *extensions.Ingress) (interface{}, error) {
 	abu, _ := parser.GetBoolAnnotation("add-base-url", ing)
 	bus, _ := parser.GetStringAnnotation("base-url-scheme", ing)
 	ar, _ := parser.GetStringAnnotation("app-root", ing)
+	ur, _ := parser.GetBoolAnnotation("use-regex", ing)
 
 	return &Config{
 		Target:           rt,
@@ -102,5 +109,6 @@ func (a rewrite) Parse(ing *extensions.Ingress) (interface{}, error) {
 		SSLRedirect:      sslRe,
 		ForceSSLRedirect: fSslRe,
 		AppRoot:          ar,
+		UseRegex:         ur,
 	}, nil
 }
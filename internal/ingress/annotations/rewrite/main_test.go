@@ -178,3 +178,24 @@ func TestAppRoot(t *testing.T) {
 		t.Errorf("Unexpected value got in AppRoot")
 	}
 }
+
+func TestUseRegex(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix("rewrite-target")] = "/$1"
+	data[parser.GetAnnotationWithPrefix("use-regex")] = "true"
+	ing.SetAnnotations(data)
+
+	i, _ := NewParser(mockBackend{redirect: true}).Parse(ing)
+	redirect, ok := i.(*Config)
+	if !ok {
+		t.Errorf("expected a Config type")
+	}
+	if !redirect.UseRegex {
+		t.Errorf("Unexpected value got in UseRegex")
+	}
+	if redirect.Target != "/$1" {
+		t.Errorf("Unexpected value got in Target")
+	}
+}
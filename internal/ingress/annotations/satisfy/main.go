@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package satisfy
+
+import (
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type satisfy struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new satisfy annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return satisfy{r}
+}
+
+// Parse parses the annotation contained in the ingress rule used to
+// define if all the access checks (all) or only one of them (any)
+// must allow the request
+func (a satisfy) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("satisfy", ing)
+	if err != nil {
+		return "", err
+	}
+
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val != "any" && val != "all" {
+		return "", ing_errors.NewInvalidAnnotationContent("satisfy", val)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securityheaders
+
+import (
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	defXFrameOptions       = "SAMEORIGIN"
+	defXContentTypeOptions = "nosniff"
+	defReferrerPolicy      = "strict-origin-when-cross-origin"
+	defPermissionsPolicy   = "camera=(), geolocation=(), microphone=()"
+)
+
+// Config contains the security related headers added to the
+// responses of a location. Empty values are not sent
+type Config struct {
+	XFrameOptions       string `json:"xFrameOptions,omitempty"`
+	XContentTypeOptions string `json:"xContentTypeOptions,omitempty"`
+	ReferrerPolicy      string `json:"referrerPolicy,omitempty"`
+	PermissionsPolicy   string `json:"permissionsPolicy,omitempty"`
+	// ContentSecurityPolicy contains the directives of the policy
+	// separated by semicolons
+	ContentSecurityPolicy string `json:"contentSecurityPolicy,omitempty"`
+	// CSPReportOnly sends the policy using the header
+	// Content-Security-Policy-Report-Only
+	CSPReportOnly bool `json:"cspReportOnly,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type securityHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new security headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return securityHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to add security headers to the responses.
+// The annotation enable-security-headers adds a default set of
+// headers that can be changed using the annotation of each header
+func (a securityHeaders) Parse(ing *extensions.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	enabled, _ := parser.GetBoolAnnotation("enable-security-headers", ing)
+	if enabled {
+		config = &Config{
+			XFrameOptions:       defXFrameOptions,
+			XContentTypeOptions: defXContentTypeOptions,
+			ReferrerPolicy:      defReferrerPolicy,
+			PermissionsPolicy:   defPermissionsPolicy,
+		}
+	}
+
+	headers := map[string]*string{
+		"x-frame-options":        &config.XFrameOptions,
+		"x-content-type-options": &config.XContentTypeOptions,
+		"referrer-policy":        &config.ReferrerPolicy,
+		"permissions-policy":     &config.PermissionsPolicy,
+	}
+
+	for name, value := range headers {
+		val, err := parser.GetStringAnnotation(name, ing)
+		if err != nil {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if strings.ContainsAny(val, "\"\\\n\r") {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, val)
+		}
+		*value = val
+	}
+
+	csp, err := parser.GetStringAnnotation("content-security-policy", ing)
+	if err == nil {
+		config.ContentSecurityPolicy = parseContentSecurityPolicy(csp)
+		config.CSPReportOnly, _ = parser.GetBoolAnnotation("content-security-policy-report-only", ing)
+	}
+
+	if config.Equal(&Config{}) {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	return config, nil
+}
+
+// parseContentSecurityPolicy assembles the directives of a policy,
+// one or more per line, in a single value
+func parseContentSecurityPolicy(val string) string {
+	directives := []string{}
+	for _, line := range strings.Split(val, "\n") {
+		for _, directive := range strings.Split(line, ";") {
+			directive = strings.Join(strings.Fields(directive), " ")
+			if directive == "" {
+				continue
+			}
+			directives = append(directives, directive)
+		}
+	}
+
+	return strings.Join(directives, "; ")
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securityheaders
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	defConfig := &Config{
+		XFrameOptions:       defXFrameOptions,
+		XContentTypeOptions: defXContentTypeOptions,
+		ReferrerPolicy:      defReferrerPolicy,
+		PermissionsPolicy:   defPermissionsPolicy,
+	}
+
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		config      *Config
+		expErr      bool
+	}{
+		{"enabled", map[string]string{"enable-security-headers": "true"}, defConfig, false},
+		{"disabled", map[string]string{"enable-security-headers": "false"}, nil, true},
+		{"override", map[string]string{
+			"enable-security-headers": "true",
+			"x-frame-options":         "DENY",
+			"permissions-policy":      "",
+		}, &Config{
+			XFrameOptions:       "DENY",
+			XContentTypeOptions: defXContentTypeOptions,
+			ReferrerPolicy:      defReferrerPolicy,
+		}, false},
+		{"single header", map[string]string{"referrer-policy": "no-referrer"}, &Config{ReferrerPolicy: "no-referrer"}, false},
+		{"content security policy", map[string]string{
+			"content-security-policy": "default-src 'self';\n  script-src   'self' https://cdn.example.com\n\nreport-uri /csp;",
+		}, &Config{ContentSecurityPolicy: "default-src 'self'; script-src 'self' https://cdn.example.com; report-uri /csp"}, false},
+		{"report only", map[string]string{
+			"content-security-policy":             "default-src 'self'",
+			"content-security-policy-report-only": "true",
+		}, &Config{ContentSecurityPolicy: "default-src 'self'", CSPReportOnly: true}, false},
+		{"report only without policy", map[string]string{"content-security-policy-report-only": "true"}, nil, true},
+		{"invalid value", map[string]string{
+			"enable-security-headers": "true",
+			"referrer-policy":         `no-referrer"; return 200 "`,
+		}, nil, true},
+		{"backslash", map[string]string{
+			"enable-security-headers": "true",
+			"referrer-policy":         `no-referrer\`,
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{}
+		for k, v := range test.annotations {
+			data[parser.GetAnnotationWithPrefix(k)] = v
+		}
+		ing := buildIngress()
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(i, test.config) {
+			t.Errorf("%v: expected %v but returned %v", test.title, test.config, i)
+		}
+	}
+}
+
+func TestParseWithoutAnnotations(t *testing.T) {
+	_, err := NewParser(&resolver.Mock{}).Parse(buildIngress())
+	if !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotations error but returned %v", err)
+	}
+}
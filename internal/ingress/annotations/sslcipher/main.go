@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sslcipher
+
+import (
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	// validProtocols contains the protocols supported by the directive ssl_protocols
+	validProtocols = sets.NewString("SSLv2", "SSLv3", "TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3")
+
+	// cipherRegex matches a list of ciphers in OpenSSL format
+	cipherRegex = regexp.MustCompile(`^[A-Za-z0-9!:+@=._-]+$`)
+)
+
+// Config contains the SSL protocols and ciphers of a server
+type Config struct {
+	Protocols string `json:"protocols,omitempty"`
+	Ciphers   string `json:"ciphers,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type sslCipher struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new SSL protocols and ciphers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return sslCipher{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the SSL protocols and ciphers of the server
+func (a sslCipher) Parse(ing *extensions.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	val, err := parser.GetStringAnnotation("ssl-protocols", ing)
+	if err == nil {
+		protocols := strings.Fields(val)
+		for _, protocol := range protocols {
+			if !validProtocols.Has(protocol) {
+				return nil, ing_errors.NewInvalidAnnotationContent("ssl-protocols", val)
+			}
+		}
+		config.Protocols = strings.Join(protocols, " ")
+	}
+
+	val, err = parser.GetStringAnnotation("ssl-ciphers", ing)
+	if err == nil {
+		if !cipherRegex.MatchString(val) {
+			return nil, ing_errors.NewInvalidAnnotationContent("ssl-ciphers", val)
+		}
+		config.Ciphers = val
+	}
+
+	if config.Equal(&Config{}) {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	return config, nil
+}
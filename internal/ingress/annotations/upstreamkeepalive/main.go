@@ -0,0 +1,50 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstreamkeepalive
+
+import (
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type upstreamKeepalive struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new upstream keepalive annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return upstreamKeepalive{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the maximum number of idle keepalive
+// connections to the servers of the backend
+func (a upstreamKeepalive) Parse(ing *extensions.Ingress) (interface{}, error) {
+	kc, err := parser.GetIntAnnotation("upstream-keepalive-connections", ing)
+	if err != nil {
+		return 0, err
+	}
+
+	if kc <= 0 {
+		return 0, ing_errors.NewInvalidAnnotationContent("upstream-keepalive-connections", kc)
+	}
+
+	return kc, nil
+}
@@ -17,12 +17,19 @@ limitations under the License.
 package upstreamvhost
 
 import (
+	"regexp"
+
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+// vhostRegex matches a hostname, optionally with a port, that can also
+// contain NGINX variables (i.e. $service_name.$namespace.svc.cluster.local)
+var vhostRegex = regexp.MustCompile(`^[a-zA-Z0-9\-\.\$_]+(:[0-9]+)?$`)
+
 type upstreamVhost struct {
 	r resolver.Resolver
 }
@@ -33,8 +40,16 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 }
 
 // Parse parses the annotations contained in the ingress rule
-// used to indicate if the location/s contains a fragment of
-// configuration to be included inside the paths of the rules
+// used to define the value of the Host header sent to the upstream
 func (a upstreamVhost) Parse(ing *extensions.Ingress) (interface{}, error) {
-	return parser.GetStringAnnotation("upstream-vhost", ing)
+	vhost, err := parser.GetStringAnnotation("upstream-vhost", ing)
+	if err != nil {
+		return "", err
+	}
+
+	if !vhostRegex.MatchString(vhost) {
+		return "", ing_errors.NewInvalidAnnotationContent("upstream-vhost", vhost)
+	}
+
+	return vhost, nil
 }
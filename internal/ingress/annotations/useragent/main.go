@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package useragent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// Config contains the User-Agent regular expressions of the clients
+// that cannot access a location
+type Config struct {
+	// Block contains case insensitive regular expressions matched
+	// against the User-Agent header of the request
+	Block []string `json:"block,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if len(c1.Block) != len(c2.Block) {
+		return false
+	}
+	for i := range c1.Block {
+		if c1.Block[i] != c2.Block[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type userAgent struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new User-Agent filter annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return userAgent{r}
+}
+
+// Parse parses the annotation contained in the ingress rule
+// used to block the access to clients using certain User-Agents.
+// Each line of the annotation contains a regular expression
+func (a userAgent) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("block-user-agents", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	block := []string{}
+	for _, line := range strings.Split(val, "\n") {
+		expr := strings.TrimSpace(line)
+		if expr == "" {
+			continue
+		}
+		// the expression is rendered inside a quoted string
+		if strings.Contains(expr, `"`) {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid User-Agent expression %v", expr))
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, ing_errors.NewLocationDenied(fmt.Sprintf("invalid User-Agent expression %v: %v", expr, err))
+		}
+		block = append(block, expr)
+	}
+
+	if len(block) == 0 {
+		return nil, ing_errors.NewLocationDenied("the annotation block-user-agents does not contain a valid expression")
+	}
+
+	return &Config{
+		Block: block,
+	}, nil
+}
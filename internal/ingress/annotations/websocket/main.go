@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"regexp"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// sizeRegex matches a size in the NGINX format (e.g. 8k)
+var sizeRegex = regexp.MustCompile(`^[1-9][0-9]*[kKmM]?$`)
+
+// Config contains the settings of the WebSocket connections of a location
+type Config struct {
+	// Timeout is the idle timeout, in seconds, of the connections.
+	// Zero uses the read and send timeouts of the location
+	Timeout int `json:"timeout"`
+	// BufferSize is the size of the buffers used to proxy the frames
+	// of the upgraded connections
+	BufferSize string `json:"bufferSize"`
+	// DisableUpgrade removes the Upgrade and Connection headers of the
+	// requests, rejecting the WebSocket connections in the location
+	DisableUpgrade bool `json:"disableUpgrade"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Timeout != c2.Timeout {
+		return false
+	}
+	if c1.BufferSize != c2.BufferSize {
+		return false
+	}
+	if c1.DisableUpgrade != c2.DisableUpgrade {
+		return false
+	}
+
+	return true
+}
+
+type websocket struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new WebSocket annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return websocket{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the WebSocket connections
+func (a websocket) Parse(ing *extensions.Ingress) (interface{}, error) {
+	timeout, err := parser.GetIntAnnotation("websocket-timeout", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if timeout < 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent("websocket-timeout", timeout)
+	}
+
+	bufferSize, err := parser.GetStringAnnotation("websocket-buffer-size", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if bufferSize != "" && !sizeRegex.MatchString(bufferSize) {
+		return nil, ing_errors.NewInvalidAnnotationContent("websocket-buffer-size", bufferSize)
+	}
+
+	upgrade, err := parser.GetBoolAnnotation("websocket-upgrade", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return nil, err
+		}
+		upgrade = true
+	}
+
+	return &Config{
+		Timeout:        timeout,
+		BufferSize:     bufferSize,
+		DisableUpgrade: !upgrade,
+	}, nil
+}
@@ -17,12 +17,22 @@ limitations under the License.
 package xforwardedprefix
 
 import (
+	"regexp"
+	"strconv"
+
 	extensions "k8s.io/api/extensions/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+// UseLocationPath is the value returned when the header must contain
+// the path of the location
+const UseLocationPath = "true"
+
+var prefixRegex = regexp.MustCompile(`^/[^\s"\\{};$]*$`)
+
 type xforwardedprefix struct {
 	r resolver.Resolver
 }
@@ -33,7 +43,25 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 }
 
 // Parse parses the annotations contained in the ingress rule
-// used to add an x-forwarded-prefix header to the request
+// used to add an x-forwarded-prefix header to the request.
+// The annotation accepts a boolean, to send the path of the location,
+// or the prefix that must be sent in the header
 func (cbbs xforwardedprefix) Parse(ing *extensions.Ingress) (interface{}, error) {
-	return parser.GetBoolAnnotation("x-forwarded-prefix", ing)
+	val, err := parser.GetStringAnnotation("x-forwarded-prefix", ing)
+	if err != nil {
+		return "", err
+	}
+
+	if b, err := strconv.ParseBool(val); err == nil {
+		if b {
+			return UseLocationPath, nil
+		}
+		return "", nil
+	}
+
+	if !prefixRegex.MatchString(val) {
+		return "", ing_errors.NewInvalidAnnotationContent("x-forwarded-prefix", val)
+	}
+
+	return val, nil
 }
@@ -35,13 +35,20 @@ func TestParse(t *testing.T) {
 
 	testCases := []struct {
 		annotations map[string]string
-		expected    bool
+		expected    string
 	}{
-		{map[string]string{annotation: "true"}, true},
-		{map[string]string{annotation: "1"}, true},
-		{map[string]string{annotation: ""}, false},
-		{map[string]string{}, false},
-		{nil, false},
+		{map[string]string{annotation: "true"}, UseLocationPath},
+		{map[string]string{annotation: "1"}, UseLocationPath},
+		{map[string]string{annotation: "false"}, ""},
+		{map[string]string{annotation: "/external/app"}, "/external/app"},
+		{map[string]string{annotation: "/"}, "/"},
+		{map[string]string{annotation: "external"}, ""},
+		{map[string]string{annotation: `/a"b`}, ""},
+		{map[string]string{annotation: `/a\`}, ""},
+		{map[string]string{annotation: "/a; b"}, ""},
+		{map[string]string{annotation: ""}, ""},
+		{map[string]string{}, ""},
+		{nil, ""},
 	}
 
 	ing := &extensions.Ingress{
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/glog"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+// sandboxDirectory is the directory in which the files written by the
+// annotation parsers of the Ingresses being checked are created
+var sandboxDirectory = file.AuthDirectory
+
+// CheckIngress checks if the configuration generated with the given Ingress,
+// replacing the version of the Ingress in the store, is valid. The snippets
+// of the other Ingresses are not included in the configuration to test.
+// No events are emitted and the running configuration is not modified.
+func (n *NGINXController) CheckIngress(ing *extensions.Ingress) error {
+	if !class.IsValid(ing) {
+		glog.V(3).Infof("skipping check of ingress %v/%v of another class", ing.Namespace, ing.Name)
+		return nil
+	}
+
+	ing = shard.Filter(ing)
+	if ing == nil {
+		return nil
+	}
+
+	candidate, cleanup, err := n.candidate(ing)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	err = candidate.testConfiguration(snippetsOf(ing))
+	if err == nil {
+		return nil
+	}
+
+	// the Ingress is not the cause of the error if the
+	// configuration without the Ingress is not valid either
+	if n.checker(n.store).testConfiguration(func(string, *extensions.Ingress) bool {
+		return false
+	}) != nil {
+		glog.Warningf("the configuration without ingress %v/%v is not valid, skipping check", ing.Namespace, ing.Name)
+		return nil
+	}
+
+	return err
+}
+
+// candidate returns a checker of the configuration generated with
+// the given Ingress, or an error if its snippets are not allowed.
+// The annotations are parsed in a temporary directory, so the files
+// read by NGINX are not modified, that is removed calling the
+// returned function once the checker is no longer used
+func (n *NGINXController) candidate(ing *extensions.Ingress) (*NGINXController, func(), error) {
+	dir, err := ioutil.TempDir(sandboxDirectory, "check-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+	}
+
+	anns := annotations.NewSandboxAnnotationExtractor(n.store, dir).Extract(ing)
+
+	blocklist := n.store.GetBackendConfiguration().SnippetDirectivesBlocklist
+	for _, snippet := range []string{anns.ServerSnippet, anns.ConfigurationSnippet, anns.ExternalAuth.AuthSnippet} {
+		if directive := blockedDirective(snippet, blocklist); directive != "" {
+			cleanup()
+			return nil, nil, fmt.Errorf("the directive %v is not allowed", directive)
+		}
+	}
+
+	return n.checker(&admissionStore{
+		Storer:      n.store,
+		ingress:     ing,
+		annotations: anns,
+	}), cleanup, nil
+}
+
+// checker returns a copy of the controller that reads the configuration from
+// the given store and that can be used without modifying the controller
+func (n *NGINXController) checker(s store.Storer) *NGINXController {
+	return &NGINXController{
+		cfg:           n.cfg,
+		store:         s,
+		recorder:      &record.FakeRecorder{},
+		t:             n.t,
+		binary:        n.binary,
+		resolver:      n.resolver,
+		isIPV6Enabled: n.isIPV6Enabled,
+		fileSystem:    n.fileSystem,
+	}
+}
+
+// testConfiguration runs the test of NGINX with the configuration
+// rendered with the snippets for which the function keep returns true
+func (n *NGINXController) testConfiguration(keep func(string, *extensions.Ingress) bool) error {
+	content, err := n.renderConfiguration(keep)
+	if err != nil {
+		return err
+	}
+
+	return n.testTemplate(content)
+}
+
+// renderConfiguration renders the configuration of the Ingresses of the
+// store only with the snippets for which the function keep returns true
+func (n *NGINXController) renderConfiguration(keep func(string, *extensions.Ingress) bool) ([]byte, error) {
+	cfg := n.store.GetBackendConfiguration()
+	cfg.Resolver = n.resolver
+
+	tc := n.templateConfig(cfg, n.getConfiguration(n.listIngresses()))
+	tc.Servers = filterSnippets(tc.Servers, keep)
+
+	return n.t.Write(tc)
+}
+
+// snippetsOf returns a function to keep only the snippets of the given Ingress
+func snippetsOf(ing *extensions.Ingress) func(string, *extensions.Ingress) bool {
+	return func(_ string, i *extensions.Ingress) bool {
+		return i != nil && i.Namespace == ing.Namespace && i.Name == ing.Name
+	}
+}
+
+// admissionStore is a store that contains the Ingress to check
+// instead of the version of the Ingress in the cluster
+type admissionStore struct {
+	store.Storer
+
+	ingress     *extensions.Ingress
+	annotations *annotations.Ingress
+}
+
+// ListIngresses returns the Ingresses of the store with the Ingress to check
+func (s *admissionStore) ListIngresses() []*extensions.Ingress {
+	var ings []*extensions.Ingress
+	for _, ing := range s.Storer.ListIngresses() {
+		if !s.isCandidate(ing) {
+			ings = append(ings, ing)
+		}
+	}
+
+	return append(ings, s.ingress)
+}
+
+// GetIngressAnnotations returns the annotations of an Ingress of the store
+func (s *admissionStore) GetIngressAnnotations(ing *extensions.Ingress) (*annotations.Ingress, error) {
+	if s.isCandidate(ing) {
+		return s.annotations, nil
+	}
+
+	return s.Storer.GetIngressAnnotations(ing)
+}
+
+func (s *admissionStore) isCandidate(ing *extensions.Ingress) bool {
+	return ing.Namespace == s.ingress.Namespace && ing.Name == s.ingress.Name
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type fakeIngressStore struct {
+	store.Storer
+
+	ingresses []*extensions.Ingress
+}
+
+func (s *fakeIngressStore) ListIngresses() []*extensions.Ingress {
+	return s.ingresses
+}
+
+func (s *fakeIngressStore) GetIngressAnnotations(ing *extensions.Ingress) (*annotations.Ingress, error) {
+	return &annotations.Ingress{ObjectMeta: ing.ObjectMeta}, nil
+}
+
+type fakeConfigurationStore struct {
+	store.Storer
+
+	cfg     ngx_config.Configuration
+	secrets map[string]*apiv1.Secret
+}
+
+func (s *fakeConfigurationStore) GetBackendConfiguration() ngx_config.Configuration {
+	return s.cfg
+}
+
+func (s *fakeConfigurationStore) GetDefaultBackend() defaults.Backend {
+	return s.cfg.Backend
+}
+
+func (s *fakeConfigurationStore) GetSecret(key string) (*apiv1.Secret, error) {
+	if secret, ok := s.secrets[key]; ok {
+		return secret, nil
+	}
+	return nil, fmt.Errorf("secret %v not found", key)
+}
+
+func (s *fakeConfigurationStore) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+func (s *fakeConfigurationStore) GetService(key string) (*apiv1.Service, error) {
+	return nil, fmt.Errorf("service %v not found", key)
+}
+
+func (s *fakeConfigurationStore) GetConfigMap(key string) (*apiv1.ConfigMap, error) {
+	return nil, fmt.Errorf("configmap %v not found", key)
+}
+
+func (s *fakeConfigurationStore) CanReferenceSecret(namespace, secret string) bool {
+	return true
+}
+
+// newSandboxIngress returns an Ingress with annotations whose parsers write
+// an authentication file and a maintenance page
+func newSandboxIngress(name string) (*extensions.Ingress, *fakeConfigurationStore) {
+	ing := newAdmissionIngress(name)
+	ing.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/auth-type":        "basic",
+		"nginx.ingress.kubernetes.io/auth-secret":      "auth",
+		"nginx.ingress.kubernetes.io/maintenance-mode": "true",
+		"nginx.ingress.kubernetes.io/maintenance-page": "<h1>maintenance</h1>",
+	}
+
+	return ing, &fakeConfigurationStore{
+		secrets: map[string]*apiv1.Secret{
+			"default/auth": {
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "auth"},
+				Data:       map[string][]byte{"auth": []byte("foo:$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0")},
+			},
+		},
+	}
+}
+
+// withSandboxDirectory replaces the directory of the files written while
+// checking Ingresses with a temporary directory
+func withSandboxDirectory(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "sandbox")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporary directory: %v", err)
+	}
+
+	previous := sandboxDirectory
+	sandboxDirectory = dir
+	return dir, func() {
+		sandboxDirectory = previous
+		os.RemoveAll(dir)
+	}
+}
+
+func newAdmissionIngress(name string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+}
+
+func TestAdmissionStore(t *testing.T) {
+	candidate := newAdmissionIngress("app")
+	candidate.ResourceVersion = "candidate"
+
+	s := &admissionStore{
+		Storer: &fakeIngressStore{
+			ingresses: []*extensions.Ingress{newAdmissionIngress("other"), newAdmissionIngress("app")},
+		},
+		ingress:     candidate,
+		annotations: &annotations.Ingress{ServerSnippet: "return 200;"},
+	}
+
+	ings := s.ListIngresses()
+	if len(ings) != 2 {
+		t.Fatalf("expected 2 ingresses but got %v", len(ings))
+	}
+	if ings[0].Name != "other" || ings[1] != candidate {
+		t.Errorf("expected the candidate to replace the ingress of the store")
+	}
+
+	anns, _ := s.GetIngressAnnotations(newAdmissionIngress("app"))
+	if anns.ServerSnippet != "return 200;" {
+		t.Errorf("expected the annotations of the candidate")
+	}
+
+	anns, _ = s.GetIngressAnnotations(newAdmissionIngress("other"))
+	if anns.ServerSnippet != "" || anns.Name != "other" {
+		t.Errorf("expected the annotations of the store")
+	}
+}
+
+func TestCandidateBlockedDirective(t *testing.T) {
+	_, restore := withSandboxDirectory(t)
+	defer restore()
+
+	n := &NGINXController{
+		store: &fakeConfigurationStore{
+			cfg: ngx_config.Configuration{SnippetDirectivesBlocklist: []string{"*_by_lua_block"}},
+		},
+	}
+
+	testCases := map[string]map[string]string{
+		"server snippet":        {"nginx.ingress.kubernetes.io/server-snippet": "content_by_lua_block { ngx.exit(200) }"},
+		"configuration snippet": {"nginx.ingress.kubernetes.io/configuration-snippet": "access_by_lua_block { ngx.exit(200) }"},
+		"auth snippet": {
+			"nginx.ingress.kubernetes.io/auth-url":     "http://auth.example.com",
+			"nginx.ingress.kubernetes.io/auth-snippet": "access_by_lua_block { ngx.exit(200) }",
+		},
+	}
+
+	for title, anns := range testCases {
+		ing := newAdmissionIngress("app")
+		ing.Annotations = anns
+
+		if _, _, err := n.candidate(ing); err == nil {
+			t.Errorf("%v: expected the blocked directive to be rejected", title)
+		}
+	}
+}
+
+func TestCandidateSandbox(t *testing.T) {
+	dir, restore := withSandboxDirectory(t)
+	defer restore()
+
+	ing, s := newSandboxIngress("app")
+	n := &NGINXController{store: s}
+
+	candidate, cleanup, err := n.candidate(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	anns, _ := candidate.store.GetIngressAnnotations(ing)
+	for _, f := range []string{anns.BasicDigestAuth.File, anns.Maintenance.PageFile} {
+		if !strings.HasPrefix(f, dir+"/") {
+			t.Errorf("expected the file %v to be created in the directory %v", f, dir)
+		}
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("expected the file %v to exist: %v", f, err)
+		}
+	}
+
+	cleanup()
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected the files of the candidate to be removed")
+	}
+}
+
+func TestCheckIngressOfAnotherClass(t *testing.T) {
+	ing := newAdmissionIngress("app")
+	ing.Annotations = map[string]string{class.IngressKey: "other"}
+
+	n := &NGINXController{}
+	if err := n.CheckIngress(ing); err != nil {
+		t.Errorf("expected ingresses of another class to be accepted but got %v", err)
+	}
+}
@@ -31,6 +31,16 @@ func (n NGINXController) Name() string {
 	return "nginx-ingress-controller"
 }
 
+// Ready returns an error if the ingress controller is shutting down, to
+// remove the pod from the endpoints of the services, or is not healthy
+func (n *NGINXController) Ready(r *http.Request) error {
+	if n.isShuttingDown {
+		return fmt.Errorf("ingress controller is shutting down")
+	}
+
+	return n.Check(r)
+}
+
 // Check returns if the nginx healthz endpoint is returning ok (status code 200)
 func (n *NGINXController) Check(_ *http.Request) error {
 	res, err := http.Get(fmt.Sprintf("http://0.0.0.0:%v%v", n.cfg.ListenPorts.Status, ngxHealthPath))
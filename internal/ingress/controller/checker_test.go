@@ -92,6 +92,22 @@ func TestNginxCheck(t *testing.T) {
 		}
 	})
 
+	t.Run("ready until shutting down", func(t *testing.T) {
+		if err := n.Ready(nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		n.isShuttingDown = true
+		defer func() { n.isShuttingDown = false }()
+
+		if err := n.Ready(nil); err == nil {
+			t.Errorf("expected an error but none returned")
+		}
+		if err := callHealthz(false, mux); err != nil {
+			t.Errorf("expected the health check to succeed while shutting down: %v", err)
+		}
+	})
+
 	pidFile, err = fs.Create("/run/nginx.pid")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -24,6 +24,7 @@ import (
 	"github.com/golang/glog"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
@@ -143,6 +144,17 @@ type Configuration struct {
 	// DisableIpv6 disable listening on ipv6 address
 	DisableIpv6 bool `json:"disable-ipv6,omitempty"`
 
+	// DisableIpv6DNS disables the resolution of the IPv6 addresses (AAAA records)
+	// of the names resolved by NGINX, like the hosts of the external authentication
+	// and the ExternalName services
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#resolver
+	DisableIpv6DNS bool `json:"disable-ipv6-dns,omitempty"`
+
+	// ResolverValid is the time NGINX caches the resolved names, overriding
+	// the TTL of the records
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#resolver
+	ResolverValid string `json:"resolver-valid,omitempty"`
+
 	// EnableUnderscoresInHeaders enables underscores in header names
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#underscores_in_headers
 	// By default this is disabled
@@ -190,23 +202,6 @@ type Configuration struct {
 	// HTTP2MaxHeaderSize Limits the maximum size of the entire request header list after HPACK decompression
 	HTTP2MaxHeaderSize string `json:"http2-max-header-size,omitempty"`
 
-	// Enables or disables the header HSTS in servers running SSL
-	HSTS bool `json:"hsts,omitempty"`
-
-	// Enables or disables the use of HSTS in all the subdomains of the servername
-	// Default: true
-	HSTSIncludeSubdomains bool `json:"hsts-include-subdomains,omitempty"`
-
-	// HTTP Strict Transport Security (often abbreviated as HSTS) is a security feature (HTTP header)
-	// that tell browsers that it should only be communicated with using HTTPS, instead of using HTTP.
-	// https://developer.mozilla.org/en-US/docs/Web/Security/HTTP_strict_transport_security
-	// max-age is the time, in seconds, that the browser should remember that this site is only to be
-	// accessed using HTTPS.
-	HSTSMaxAge string `json:"hsts-max-age,omitempty"`
-
-	// Enables or disables the preload attribute in HSTS feature
-	HSTSPreload bool `json:"hsts-preload,omitempty"`
-
 	// Time during which a keep-alive client connection will stay open on the server side.
 	// The zero value disables keep-alive client connections
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#keepalive_timeout
@@ -352,6 +347,10 @@ type Configuration struct {
 	// Responses with the “text/html” type are always compressed if UseGzip is enabled
 	GzipTypes string `json:"gzip-types,omitempty"`
 
+	// Compression level of the gzip module (1 to 9)
+	// http://nginx.org/en/docs/http/ngx_http_gzip_module.html#gzip_comp_level
+	GzipLevel int `json:"gzip-level,omitempty"`
+
 	// Defines the number of worker processes. By default auto means number of available CPU cores
 	// http://nginx.org/en/docs/ngx_core_module.html#worker_processes
 	WorkerProcesses string `json:"worker-processes,omitempty"`
@@ -383,6 +382,16 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_map_module.html#variables_hash_max_size
 	LimitConnZoneVariable string `json:"limit-conn-zone-variable,omitempty"`
 
+	// Sets the status code to return in response to rejected requests
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html#limit_req_status
+	// Default: 503
+	LimitReqStatusCode int `json:"limit-req-status-code,omitempty"`
+
+	// Sets the status code to return in response to rejected connections
+	// http://nginx.org/en/docs/http/ngx_http_limit_conn_module.html#limit_conn_status
+	// Default: 503
+	LimitConnStatusCode int `json:"limit-conn-status-code,omitempty"`
+
 	// Sets the timeout between two successive read or write operations on client or proxied server connections.
 	// If no data is transmitted within this time, the connection is closed.
 	// http://nginx.org/en/docs/stream/ngx_stream_proxy_module.html#proxy_timeout
@@ -467,6 +476,30 @@ type Configuration struct {
 	// server to the client response
 	// Default: empty
 	HideHeaders []string `json:"hide-headers"`
+
+	// ProxyCacheZones defines the cache zones that can be used in the
+	// Ingress rules with the annotation proxy-cache-zone
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	// Default: empty
+	ProxyCacheZones []ProxyCacheZone `json:"proxy-cache-zones"`
+
+	// SnippetDirectivesBlocklist contains the directives that cannot be used
+	// in the configuration-snippet and server-snippet annotations.
+	// The wildcard * matches any sequence of characters, like lua_*
+	// Default: empty
+	SnippetDirectivesBlocklist []string `json:"snippet-directives-blocklist"`
+}
+
+// ProxyCacheZone describes a zone used to cache the responses of the upstream servers
+type ProxyCacheZone struct {
+	// Name of the shared memory zone
+	Name string `json:"name"`
+	// Size of the shared memory zone that contains the keys and metadata
+	Size string `json:"size"`
+	// MaxSize is the maximum size of the cached data in disk
+	MaxSize string `json:"maxSize,omitempty"`
+	// Inactive is the time after which the data not accessed is removed
+	Inactive string `json:"inactive"`
 }
 
 // NewDefault returns the default nginx configuration
@@ -492,12 +525,10 @@ func NewDefault() Configuration {
 		HTTP2MaxFieldSize:          "4k",
 		HTTP2MaxHeaderSize:         "16k",
 		HTTPRedirectCode:           308,
-		HSTS:                       true,
-		HSTSIncludeSubdomains:      true,
-		HSTSMaxAge:                 hstsMaxAge,
-		HSTSPreload:                false,
 		IgnoreInvalidHeaders:       true,
+		ResolverValid:              "30s",
 		GzipTypes:                  gzipTypes,
+		GzipLevel:                  5,
 		KeepAlive:                  75,
 		KeepAliveRequests:          100,
 		LargeClientHeaderBuffers:   "4 8k",
@@ -537,12 +568,18 @@ func NewDefault() Configuration {
 			ProxyReadTimeout:      60,
 			ProxySendTimeout:      60,
 			ProxyBufferSize:       "4k",
+			ProxyBuffersNumber:    4,
+			ProxyBuffering:        "off",
 			ProxyCookieDomain:     "off",
 			ProxyCookiePath:       "off",
 			ProxyNextUpstream:     "error timeout invalid_header http_502 http_503 http_504",
 			ProxyRequestBuffering: "on",
 			ProxyRedirectFrom:     "off",
 			SSLRedirect:           true,
+			HSTS:                  true,
+			HSTSIncludeSubdomains: true,
+			HSTSMaxAge:            hstsMaxAge,
+			HSTSPreload:           false,
 			CustomHTTPErrors:      []int{},
 			WhitelistSourceRange:  []string{},
 			SkipAccessLogURLs:     []string{},
@@ -551,6 +588,8 @@ func NewDefault() Configuration {
 		},
 		UpstreamKeepaliveConnections: 32,
 		LimitConnZoneVariable:        defaultLimitConnZoneVariable,
+		LimitReqStatusCode:           503,
+		LimitConnStatusCode:          503,
 		BindAddressIpv4:              defBindAddress,
 		BindAddressIpv6:              defBindAddress,
 		ZipkinCollectorPort:          9411,
@@ -595,9 +634,30 @@ type TemplateConfig struct {
 	Cfg                     Configuration
 	IsIPV6Enabled           bool
 	IsSSLPassthroughEnabled bool
-	RedirectServers         map[string]string
+	RedirectServers         map[string]*RedirectServer
 	ListenPorts             *ListenPorts
 	PublishService          *apiv1.Service
+	// DynamicConfigurationEnabled indicates if the Lua balancer is used
+	DynamicConfigurationEnabled bool
+	// DynamicBackends contains the name of the backends with endpoints
+	// configured by the Lua balancer (no upstream block is rendered)
+	DynamicBackends sets.String
+	// DynamicCertificatesEnabled indicates if the SSL certificates of the
+	// servers are loaded by Lua
+	DynamicCertificatesEnabled bool
+	// DynamicServersEnabled indicates if the requests of the servers not
+	// included in Servers are routed by Lua in the default server
+	DynamicServersEnabled bool
+}
+
+// RedirectServer describes a server used to redirect the requests
+// from a hostname to a different one (from/to www)
+type RedirectServer struct {
+	// To is the hostname where the requests are redirected
+	To string
+	// SSLCertificate path to the SSL certificate on disk used to
+	// accept HTTPS requests. If empty only HTTP requests are accepted
+	SSLCertificate string
 }
 
 // ListenPorts describe the ports required to run the
@@ -609,4 +669,7 @@ type ListenPorts struct {
 	Health   int
 	Default  int
 	SSLProxy int
+	// Configuration is the port of the configuration endpoint of the
+	// Lua balancer, only reachable using the loopback interface
+	Configuration int
 }
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+// configurationChecksum identifies a configuration loaded by NGINX
+type configurationChecksum struct {
+	checksum string
+	time     time.Time
+}
+
+// setConfigurationChecksum records the checksum of the configuration
+// loaded by NGINX after a successful reload
+func (n *NGINXController) setConfigurationChecksum(content []byte) {
+	checksum := fmt.Sprintf("%x", sha256.Sum256(content))
+	now := time.Now()
+
+	n.configChecksum.Store(configurationChecksum{checksum, now})
+	setConfigHash(checksum, now)
+}
+
+// ConfigurationChecksum returns the SHA256 checksum of the configuration
+// loaded by NGINX and the time of the reload. The checksum is empty
+// before the first reload
+func (n *NGINXController) ConfigurationChecksum() (string, time.Time) {
+	c, ok := n.configChecksum.Load().(configurationChecksum)
+	if !ok {
+		return "", time.Time{}
+	}
+
+	return c.checksum, c.time
+}
+
+// ConfigurationDiff returns the unified diff between the configuration
+// loaded by NGINX and the configuration generated with the current state
+// of the cluster, without reloading NGINX. The snippets with blocked
+// directives are not included in the generated configuration.
+func (n *NGINXController) ConfigurationDiff() ([]byte, error) {
+	blocklist := n.store.GetBackendConfiguration().SnippetDirectivesBlocklist
+
+	content, err := n.checker(n.store).renderConfiguration(func(snippet string, _ *extensions.Ingress) bool {
+		return blockedDirective(snippet, blocklist) == ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if n.cfg.SplitServerConfiguration {
+		main, files := splitServers(content, serversDir)
+		return diffServerFiles(cfgPath, serversDir, main, files)
+	}
+
+	return diffConfiguration(cfgPath, content)
+}
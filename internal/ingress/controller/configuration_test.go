@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func TestConfigurationChecksum(t *testing.T) {
+	n := &NGINXController{}
+
+	if checksum, reload := n.ConfigurationChecksum(); checksum != "" || !reload.IsZero() {
+		t.Errorf("expected an empty checksum before the first reload but got %v", checksum)
+	}
+
+	content := []byte("events {}")
+	n.setConfigurationChecksum(content)
+
+	checksum, reload := n.ConfigurationChecksum()
+	if expected := fmt.Sprintf("%x", sha256.Sum256(content)); checksum != expected {
+		t.Errorf("expected the checksum %v but got %v", expected, checksum)
+	}
+	if reload.IsZero() {
+		t.Errorf("expected the time of the reload")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// priorityAnnotation defines the precedence of an Ingress over the other
+// Ingresses that declare the same host and path. Higher values win
+const priorityAnnotation = "priority"
+
+// ingressPriority returns the value of the priority annotation of an
+// Ingress, zero by default
+func ingressPriority(ing *extensions.Ingress) int {
+	priority, err := parser.GetIntAnnotation(priorityAnnotation, ing)
+	if err != nil {
+		return 0
+	}
+
+	return priority
+}
+
+// sortIngresses sorts the Ingresses by precedence. When several Ingresses
+// declare the same host and path the first one wins: the Ingress with the
+// highest priority annotation or, with the same priority, the oldest one.
+// The namespace and name break the ties, so the order does not depend on
+// the order of the informers and does not change when the controller restarts
+func sortIngresses(ings []*extensions.Ingress) {
+	sort.SliceStable(ings, func(i, j int) bool {
+		ip := ingressPriority(ings[i])
+		jp := ingressPriority(ings[j])
+		if ip != jp {
+			return ip > jp
+		}
+
+		it := ings[i].CreationTimestamp
+		jt := ings[j].CreationTimestamp
+		if !it.Equal(&jt) {
+			return it.Before(&jt)
+		}
+
+		if ings[i].Namespace != ings[j].Namespace {
+			return ings[i].Namespace < ings[j].Namespace
+		}
+
+		return ings[i].Name < ings[j].Name
+	})
+}
+
+// reportConflict emits a warning event in an Ingress that declares a host
+// and path already declared by an Ingress with precedence
+func (n *NGINXController) reportConflict(ing *extensions.Ingress, host, path string, winner *extensions.Ingress) {
+	if winner == nil || winner == ing {
+		return
+	}
+
+	glog.Warningf("ignoring path %v of host %v in ingress %v/%v: already defined in ingress %v/%v",
+		path, host, ing.Namespace, ing.Name, winner.Namespace, winner.Name)
+
+	n.recorder.Event(ing, apiv1.EventTypeWarning, "HostPathConflict",
+		fmt.Sprintf("path %v of host %v is ignored because it is already defined in ingress %v/%v", path, host, winner.Namespace, winner.Name))
+}
+
+// serverAnnotationOwners records the Ingress that configures each server
+// level annotation (e.g. HSTS or server-snippet) of the servers. Only the
+// Ingress with precedence configures the annotation of a host
+type serverAnnotationOwners map[string]*extensions.Ingress
+
+func (o serverAnnotationOwners) set(host, annotation string, ing *extensions.Ingress) {
+	o[host+" "+annotation] = ing
+}
+
+func (o serverAnnotationOwners) get(host, annotation string) *extensions.Ingress {
+	return o[host+" "+annotation]
+}
+
+// reportServerAnnotationConflict emits a warning event in an Ingress that
+// configures a server level annotation of a host with a value different
+// from the value of the Ingress with precedence
+func (n *NGINXController) reportServerAnnotationConflict(ing *extensions.Ingress, host, annotation string, winner *extensions.Ingress) {
+	if winner == nil || winner == ing {
+		return
+	}
+
+	glog.Warningf("ignoring annotation %v of host %v in ingress %v/%v: already configured by ingress %v/%v",
+		annotation, host, ing.Namespace, ing.Name, winner.Namespace, winner.Name)
+
+	n.recorder.Event(ing, apiv1.EventTypeWarning, "ServerAnnotationConflict",
+		fmt.Sprintf("annotation %v of host %v is ignored because it is already configured by ingress %v/%v", annotation, host, winner.Namespace, winner.Name))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+func newConflictingIngress(namespace, name string, created time.Time, priority string) *extensions.Ingress {
+	ing := &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+
+	if priority != "" {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix(priorityAnnotation): priority,
+		})
+	}
+
+	return ing
+}
+
+func TestSortIngresses(t *testing.T) {
+	now := time.Now()
+	ings := []*extensions.Ingress{
+		newConflictingIngress("default", "newest", now, ""),
+		newConflictingIngress("default", "b", now.Add(-time.Hour), ""),
+		newConflictingIngress("default", "a", now.Add(-time.Hour), ""),
+		newConflictingIngress("another", "a", now.Add(-time.Hour), ""),
+		newConflictingIngress("default", "priority", now, "10"),
+		newConflictingIngress("default", "oldest", now.Add(-2*time.Hour), ""),
+		newConflictingIngress("default", "negative", now.Add(-3*time.Hour), "-1"),
+	}
+
+	sortIngresses(ings)
+
+	expected := []string{
+		"default/priority",
+		"default/oldest",
+		"another/a",
+		"default/a",
+		"default/b",
+		"default/newest",
+		"default/negative",
+	}
+	for i, ing := range ings {
+		key := ing.Namespace + "/" + ing.Name
+		if key != expected[i] {
+			t.Errorf("expected %v in position %v but returned %v", expected[i], i, key)
+		}
+	}
+}
+
+func TestReportConflict(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	n := &NGINXController{recorder: recorder}
+
+	winner := newConflictingIngress("default", "winner", time.Now(), "")
+	loser := newConflictingIngress("default", "loser", time.Now(), "")
+
+	n.reportConflict(winner, "foo.bar", "/", winner)
+	n.reportConflict(loser, "foo.bar", "/", nil)
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no events but %v were emitted", len(recorder.Events))
+	}
+
+	n.reportConflict(loser, "foo.bar", "/", winner)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but %v were emitted", len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	expected := "Warning HostPathConflict path / of host foo.bar is ignored because it is already defined in ingress default/winner"
+	if event != expected {
+		t.Errorf("expected event %q but returned %q", expected, event)
+	}
+}
+
+func TestReportServerAnnotationConflict(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	n := &NGINXController{recorder: recorder}
+
+	winner := newConflictingIngress("default", "winner", time.Now(), "")
+	loser := newConflictingIngress("default", "loser", time.Now(), "")
+
+	owners := serverAnnotationOwners{}
+	owners.set("foo.bar", "hsts", winner)
+
+	n.reportServerAnnotationConflict(winner, "foo.bar", "hsts", owners.get("foo.bar", "hsts"))
+	n.reportServerAnnotationConflict(loser, "foo.bar", "server-snippet", owners.get("foo.bar", "server-snippet"))
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no events but %v were emitted", len(recorder.Events))
+	}
+
+	n.reportServerAnnotationConflict(loser, "foo.bar", "hsts", owners.get("foo.bar", "hsts"))
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but %v were emitted", len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	expected := "Warning ServerAnnotationConflict annotation hsts of host foo.bar is ignored because it is already configured by ingress default/winner"
+	if event != expected {
+		t.Errorf("expected event %q but returned %q", expected, event)
+	}
+}
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"net"
 	"reflect"
@@ -34,10 +35,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/task"
@@ -61,6 +65,11 @@ type Configuration struct {
 	DefaultService string
 
 	Namespace string
+	// NamespaceSelector is a label selector of the namespaces to watch
+	NamespaceSelector string
+	// Namespaces contains the namespaces to watch, resolved from Namespace
+	// or NamespaceSelector. Namespace is used if the list is empty
+	Namespaces []string
 
 	ForceNamespaceIsolation bool
 
@@ -69,11 +78,54 @@ type Configuration struct {
 	// optional
 	UDPConfigMapName string
 
+	EnableStreamRoutes bool
+	// EnableEndpointSlices discovers the endpoints using the EndpointSlices
+	EnableEndpointSlices bool
+	// EnableIngressClassResource reads the defaults of the configuration
+	// from the parameters of the IngressClass
+	EnableIngressClassResource bool
+	// TemplatePath is the path of the NGINX template. The default template
+	// is used if the path is empty
+	TemplatePath string
+	// TemplateIncludeDir is the directory of the partials included in the
+	// extension points of the NGINX template
+	TemplateIncludeDir string
+	// TemplateConfigMap is the ConfigMap that contains the NGINX template,
+	// in the form namespace/name. It takes precedence over TemplatePath
+	TemplateConfigMap string
+	// StreamRouteClient is the REST client of the StreamRoute custom resources
+	StreamRouteClient rest.Interface
+	// EnableReferenceGrants allows the Ingresses to reference the Secrets
+	// of other namespaces shared using ReferenceGrant custom resources
+	EnableReferenceGrants bool
+	// ReferenceGrantClient is the REST client of the ReferenceGrant custom
+	// resources. The Secrets of other namespaces cannot be referenced if
+	// the client is nil
+	ReferenceGrantClient rest.Interface
+	// EnableNginxDefaults reads the defaults of the locations of the
+	// Ingresses from the NginxDefaults custom resources
+	EnableNginxDefaults bool
+	// NginxDefaultsClient is the REST client of the NginxDefaults custom
+	// resources. The defaults of the ConfigMap are used if it is nil
+	NginxDefaultsClient rest.Interface
+	// EnableGatewayAPI configures the routes of the Gateway API resources
+	// (gateway.networking.k8s.io) like the Ingresses
+	EnableGatewayAPI bool
+	// GatewayClient is the REST client of the Gateway API resources. The
+	// Gateway API is not used if the client is nil
+	GatewayClient rest.Interface
+	// EndpointSliceClient is the REST client of the EndpointSlices. The
+	// Endpoints are used if the client is nil
+	EndpointSliceClient rest.Interface
+
 	DefaultHealthzURL     string
 	DefaultSSLCertificate string
 
-	// optional
+	// optional, comma-separated list of services
 	PublishService string
+	// PublishStatusAddress contains the addresses published in the
+	// status of the Ingresses instead of the addresses of the services
+	PublishStatusAddress string
 
 	UpdateStatus           bool
 	UseNodeInternalIP      bool
@@ -84,7 +136,18 @@ type Configuration struct {
 
 	ListenPorts *ngx_config.ListenPorts
 
-	EnableSSLPassthrough bool
+	EnableSSLPassthrough         bool
+	SSLPassthroughMaxConnections int
+
+	// DynamicConfigurationEnabled configures the endpoints of the backends
+	// using the Lua balancer instead of reloading NGINX
+	DynamicConfigurationEnabled bool
+	// DynamicCertificatesEnabled configures the SSL certificates using
+	// ssl_certificate_by_lua instead of reloading NGINX
+	DynamicCertificatesEnabled bool
+	// DynamicServersEnabled routes the requests of the servers without
+	// annotations using Lua instead of rendering the server blocks
+	DynamicServersEnabled bool
 
 	EnableProfiling bool
 
@@ -94,11 +157,41 @@ type Configuration struct {
 	FakeCertificateSHA  string
 
 	SyncRateLimit float32
+	// SyncWorkers is the number of workers of the sync queue
+	SyncWorkers int
+	// EndpointsSyncRateLimit limits the rate of the syncs caused by changes
+	// of Endpoints, so they cannot delay the changes of Ingresses and Secrets
+	EndpointsSyncRateLimit float32
+	// SyncBatchWindow is the time the changes are accumulated before
+	// syncing, coalescing bursts of updates in a single sync
+	SyncBatchWindow time.Duration
+	// MinReloadInterval is the minimum time between two NGINX reloads
+	MinReloadInterval time.Duration
+	// SplitServerConfiguration writes the server blocks to a configuration
+	// file per server, included from the main configuration file. Only the
+	// files of the servers that changed are written
+	SplitServerConfiguration bool
+	// LintConfiguration is the mode of the lint of the duplicated and
+	// conflicting directives of the configuration (off, warn or reject)
+	LintConfiguration string
+
+	// ShutdownGracePeriod is the time to wait, failing the readiness
+	// probe, before stopping NGINX when the controller is stopped
+	ShutdownGracePeriod time.Duration
+
+	// ValidationWebhook is the address of the validating admission webhook
+	// that rejects the Ingresses that generate an invalid configuration.
+	// The webhook is disabled if the address is empty
+	ValidationWebhook         string
+	ValidationWebhookCertPath string
+	ValidationWebhookKeyPath  string
 }
 
-// GetPublishService returns the configured service used to set ingress status
+// GetPublishService returns the configured service used to set ingress status.
+// The first service is returned if several services are configured
 func (n NGINXController) GetPublishService() *apiv1.Service {
-	s, err := n.store.GetService(n.cfg.PublishService)
+	key := strings.TrimSpace(strings.Split(n.cfg.PublishService, ",")[0])
+	s, err := n.store.GetService(key)
 	if err != nil {
 		return nil
 	}
@@ -124,13 +217,94 @@ func (n *NGINXController) syncIngress(item interface{}) error {
 		}
 	}
 
-	// Sort ingress rules using the ResourceVersion field
-	ings := n.store.ListIngresses()
-	sort.SliceStable(ings, func(i, j int) bool {
-		ir := ings[i].ResourceVersion
-		jr := ings[j].ResourceVersion
-		return ir < jr
-	})
+	// the configuration is generated concurrently by the workers and the
+	// configurations generated before the last one processed are discarded
+	generation := atomic.AddUint64(n.syncGeneration, 1)
+	pcfg := n.getConfiguration(n.listIngresses())
+
+	n.syncLock.Lock()
+	defer n.syncLock.Unlock()
+
+	if generation < n.appliedGeneration {
+		glog.V(3).Infof("skipping sync, a newer configuration was generated")
+		return nil
+	}
+	n.appliedGeneration = generation
+
+	previousTemplate := n.syncTemplateConfigMap()
+
+	if !n.isForceReload() && n.runningConfig.Equal(&pcfg) {
+		glog.V(3).Infof("skipping backend reload (no changes detected)")
+		return nil
+	}
+
+	algorithm := n.store.GetBackendConfiguration().LoadBalanceAlgorithm
+	if n.cfg.DynamicConfigurationEnabled && !n.isForceReload() && n.isDynamicConfigurationEnough(&pcfg, algorithm) {
+		glog.Infof("only the endpoints of dynamic backends changed, skipping backend reload")
+	} else {
+		glog.Infof("backend reload required")
+
+		n.waitMinReloadInterval()
+		err := n.OnUpdate(pcfg)
+		n.lastReload = time.Now()
+		if err != nil {
+			incReloadErrorCount()
+			setLastReloadSuccessful(false)
+			glog.Errorf("unexpected failure restarting the backend: \n%v", err)
+			if previousTemplate != nil {
+				n.restoreTemplate(previousTemplate)
+			}
+			return err
+		}
+
+		glog.Infof("ingress backend successfully reloaded...")
+		incReloadCount()
+		setLastReloadSuccessful(true)
+	}
+
+	setSSLExpireTime(pcfg.Servers)
+
+	if n.cfg.DynamicConfigurationEnabled {
+		err := configureDynamically(&pcfg, algorithm, n.cfg.ListenPorts.Configuration)
+		if err != nil {
+			glog.Errorf("unexpected failure configuring the backends dynamically: %v", err)
+			return err
+		}
+	}
+
+	if n.cfg.DynamicCertificatesEnabled {
+		err := configureCertificates(&pcfg, n.cfg.ListenPorts.Configuration)
+		if err != nil {
+			glog.Errorf("unexpected failure configuring the SSL certificates dynamically: %v", err)
+			return err
+		}
+	}
+
+	if n.cfg.DynamicServersEnabled {
+		dynamic := dynamicServers(&pcfg, dynamicBackends(&pcfg, algorithm), n.cfg.DynamicCertificatesEnabled)
+		err := configureRoutes(&pcfg, dynamic, n.cfg.ListenPorts.Configuration)
+		if err != nil {
+			glog.Errorf("unexpected failure configuring the servers dynamically: %v", err)
+			return err
+		}
+	}
+
+	n.runningConfig = &pcfg
+	n.SetForceReload(false)
+
+	return nil
+}
+
+// listIngresses returns the Ingresses of the store and the Ingresses
+// translated from the Gateway API resources
+func (n *NGINXController) listIngresses() []*extensions.Ingress {
+	return append(n.store.ListIngresses(), n.store.ListGatewayIngresses()...)
+}
+
+// getConfiguration returns the configuration of the backend
+// generated from the given Ingresses and the stream services
+func (n *NGINXController) getConfiguration(ings []*extensions.Ingress) ingress.Configuration {
+	sortIngresses(ings)
 
 	upstreams, servers := n.getBackendServers(ings)
 	var passUpstreams []*ingress.SSLPassthroughBackend
@@ -155,39 +329,22 @@ func (n *NGINXController) syncIngress(item interface{}) error {
 		}
 	}
 
-	pcfg := ingress.Configuration{
+	return ingress.Configuration{
 		Backends:            upstreams,
 		Servers:             servers,
 		TCPEndpoints:        n.getStreamServices(n.cfg.TCPConfigMapName, apiv1.ProtocolTCP),
 		UDPEndpoints:        n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
 		PassthroughBackends: passUpstreams,
 	}
-
-	if !n.isForceReload() && n.runningConfig.Equal(&pcfg) {
-		glog.V(3).Infof("skipping backend reload (no changes detected)")
-		return nil
-	}
-
-	glog.Infof("backend reload required")
-
-	err := n.OnUpdate(pcfg)
-	if err != nil {
-		incReloadErrorCount()
-		glog.Errorf("unexpected failure restarting the backend: \n%v", err)
-		return err
-	}
-
-	glog.Infof("ingress backend successfully reloaded...")
-	incReloadCount()
-	setSSLExpireTime(servers)
-
-	n.runningConfig = &pcfg
-	n.SetForceReload(false)
-
-	return nil
 }
 
 func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Protocol) []ingress.L4Service {
+	svcs := n.getConfigMapStreamServices(configmapName, proto)
+	return append(svcs, n.getStreamRouteServices(svcs, proto)...)
+}
+
+// getConfigMapStreamServices returns the TCP or UDP services defined in a configmap
+func (n *NGINXController) getConfigMapStreamServices(configmapName string, proto apiv1.Protocol) []ingress.L4Service {
 	glog.V(3).Infof("obtaining information about stream services of type %v located in configmap %v", proto, configmapName)
 	if configmapName == "" {
 		// no configmap configured
@@ -207,19 +364,10 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 	}
 
 	var svcs []ingress.L4Service
-	var svcProxyProtocol ingress.ProxyProtocol
 	// k -> port to expose
 	// v -> <namespace>/<service name>:<port from service to be used>
 
-	rp := []int{
-		n.cfg.ListenPorts.HTTP,
-		n.cfg.ListenPorts.HTTPS,
-		n.cfg.ListenPorts.SSLProxy,
-		n.cfg.ListenPorts.Status,
-		n.cfg.ListenPorts.Health,
-		n.cfg.ListenPorts.Default,
-	}
-	reserverdPorts := sets.NewInt(rp...)
+	reserverdPorts := n.reservedStreamPorts()
 
 	for k, v := range configmap.Data {
 		externalPort, err := strconv.Atoi(k)
@@ -241,8 +389,17 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 
 		nsName := nsSvcPort[0]
 		svcPort := nsSvcPort[1]
-		svcProxyProtocol.Decode = false
-		svcProxyProtocol.Encode = false
+		svcProxyProtocol := ingress.ProxyProtocol{}
+
+		// UDP services accept the options responses=<number>, timeout=<time> and reuseport
+		udpOptions := ingress.L4Backend{}
+		if proto == apiv1.ProtocolUDP {
+			err := parseUDPOptions(nsSvcPort[2:], &udpOptions)
+			if err != nil {
+				glog.Warningf("invalid format (namespace/name:port:[responses=<number>]:[timeout=<time>]:[reuseport]) '%v': %v", k, err)
+				continue
+			}
+		}
 
 		// Proxy protocol is possible if the service is TCP
 		if len(nsSvcPort) >= 3 && proto == apiv1.ProtocolTCP {
@@ -252,6 +409,10 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 			if len(nsSvcPort) == 4 && strings.ToUpper(nsSvcPort[3]) == "PROXY" {
 				svcProxyProtocol.Encode = true
 			}
+			if len(nsSvcPort) == 4 && strings.ToUpper(nsSvcPort[3]) == "PROXYV2" {
+				svcProxyProtocol.Encode = true
+				svcProxyProtocol.Version = 2
+			}
 		}
 
 		svcNs, svcName, err := k8s.ParseNameNS(nsName)
@@ -266,30 +427,7 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 			continue
 		}
 
-		var endps []ingress.Endpoint
-		targetPort, err := strconv.Atoi(svcPort)
-		if err != nil {
-			glog.V(3).Infof("searching service %v endpoints using the name '%v'", svcNs, svcName, svcPort)
-			for _, sp := range svc.Spec.Ports {
-				if sp.Name == svcPort {
-					if sp.Protocol == proto {
-						endps = n.getEndpoints(svc, &sp, proto, &healthcheck.Config{})
-						break
-					}
-				}
-			}
-		} else {
-			// we need to use the TargetPort (where the endpoints are running)
-			glog.V(3).Infof("searching service %v/%v endpoints using the target port '%v'", svcNs, svcName, targetPort)
-			for _, sp := range svc.Spec.Ports {
-				if sp.Port == int32(targetPort) {
-					if sp.Protocol == proto {
-						endps = n.getEndpoints(svc, &sp, proto, &healthcheck.Config{})
-						break
-					}
-				}
-			}
-		}
+		endps := n.getStreamEndpoints(svc, svcPort, proto)
 
 		// stream services cannot contain empty upstreams and there is no
 		// default backend equivalent
@@ -301,19 +439,72 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 		svcs = append(svcs, ingress.L4Service{
 			Port: externalPort,
 			Backend: ingress.L4Backend{
-				Name:          svcName,
-				Namespace:     svcNs,
-				Port:          intstr.FromString(svcPort),
-				Protocol:      proto,
-				ProxyProtocol: svcProxyProtocol,
+				Name:           svcName,
+				Namespace:      svcNs,
+				Port:           intstr.FromString(svcPort),
+				Protocol:       proto,
+				ProxyProtocol:  svcProxyProtocol,
+				ProxyTimeout:   udpOptions.ProxyTimeout,
+				ProxyResponses: udpOptions.ProxyResponses,
+				ReusePort:      udpOptions.ReusePort,
 			},
 			Endpoints: endps,
 		})
 	}
 
+	// the data of the ConfigMap is a map without order
+	sort.SliceStable(svcs, func(i, j int) bool {
+		return svcs[i].Port < svcs[j].Port
+	})
+
 	return svcs
 }
 
+// reservedStreamPorts returns the ports used by the ingress controller
+// that cannot be used to expose TCP or UDP services
+func (n *NGINXController) reservedStreamPorts() sets.Int {
+	return sets.NewInt(
+		n.cfg.ListenPorts.HTTP,
+		n.cfg.ListenPorts.HTTPS,
+		n.cfg.ListenPorts.SSLProxy,
+		n.cfg.ListenPorts.Status,
+		n.cfg.ListenPorts.Health,
+		n.cfg.ListenPorts.Default,
+	)
+}
+
+// getStreamEndpoints returns the endpoints of a service port, using
+// the name or the number of the port, for a TCP or UDP service
+func (n *NGINXController) getStreamEndpoints(svc *apiv1.Service, svcPort string, proto apiv1.Protocol) []ingress.Endpoint {
+	var endps []ingress.Endpoint
+	svcNs, svcName := svc.Namespace, svc.Name
+	targetPort, err := strconv.Atoi(svcPort)
+	if err != nil {
+		glog.V(3).Infof("searching service %v/%v endpoints using the name '%v'", svcNs, svcName, svcPort)
+		for _, sp := range svc.Spec.Ports {
+			if sp.Name == svcPort {
+				if sp.Protocol == proto {
+					endps = n.getEndpoints(svc, &sp, proto, &healthcheck.Config{})
+					break
+				}
+			}
+		}
+	} else {
+		// we need to use the TargetPort (where the endpoints are running)
+		glog.V(3).Infof("searching service %v/%v endpoints using the target port '%v'", svcNs, svcName, targetPort)
+		for _, sp := range svc.Spec.Ports {
+			if sp.Port == int32(targetPort) {
+				if sp.Protocol == proto {
+					endps = n.getEndpoints(svc, &sp, proto, &healthcheck.Config{})
+					break
+				}
+			}
+		}
+	}
+
+	return endps
+}
+
 // getDefaultUpstream returns an upstream associated with the
 // default backend service. In case of error retrieving information
 // configure the upstream to return http code 503.
@@ -347,6 +538,9 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 	upstreams := n.createUpstreams(ingresses, du)
 	servers := n.createServers(ingresses, upstreams, du)
 
+	// Ingresses that configure the mutual authentication of the servers
+	owners := serverAnnotationOwners{}
+
 	for _, ing := range ingresses {
 		anns, err := n.store.GetIngressAnnotations(ing)
 		if err != nil {
@@ -374,10 +568,14 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 				// It is possible that no CAFileName is found in the secret
 				if server.CertificateAuth.CAFileName == "" {
 					glog.V(3).Infof("secret %v does not contain 'ca.crt', mutual authentication not enabled - ingress rule %v/%v.", server.CertificateAuth.Secret, ing.Namespace, ing.Name)
-
+				} else {
+					owners.set(server.Hostname, "auth-tls-secret", ing)
 				}
 			} else {
 				glog.V(3).Infof("server %v already contains a mutual authentication configuration - ingress rule %v/%v", server.Hostname, ing.Namespace, ing.Name)
+				if anns.CertificateAuth.CAFileName != "" && !server.CertificateAuth.Equal(&anns.CertificateAuth) {
+					n.reportServerAnnotationConflict(ing, server.Hostname, "auth-tls-secret", owners.get(server.Hostname, "auth-tls-secret"))
+				}
 			}
 
 			for _, path := range rule.HTTP.Paths {
@@ -401,6 +599,7 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 
 						if !loc.IsDefBackend {
 							glog.V(3).Infof("avoiding replacement of ingress rule %v/%v location %v upstream %v (%v)", ing.Namespace, ing.Name, loc.Path, ups.Name, loc.Backend)
+							n.reportConflict(ing, server.Hostname, nginxPath, loc.Ingress)
 							break
 						}
 
@@ -414,18 +613,44 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 						loc.BasicDigestAuth = anns.BasicDigestAuth
 						loc.ClientBodyBufferSize = anns.ClientBodyBufferSize
 						loc.ConfigurationSnippet = anns.ConfigurationSnippet
-						loc.CorsConfig = anns.CorsConfig
+						loc.CorsConfig = anns.CorsConfig.ForPath(nginxPath)
 						loc.ExternalAuth = anns.ExternalAuth
 						loc.Proxy = anns.Proxy
+						loc.Proxy.BodySize = anns.Proxy.BodySizeForPath(nginxPath)
 						loc.RateLimit = anns.RateLimit
 						loc.Redirect = anns.Redirect
 						loc.Rewrite = anns.Rewrite
 						loc.UpstreamVhost = anns.UpstreamVhost
 						loc.VtsFilterKey = anns.VtsFilterKey
 						loc.Whitelist = anns.Whitelist
+						loc.Denylist = anns.Denylist
+						loc.CountryFilter = anns.CountryFilter
 						loc.Denied = anns.Denied
 						loc.XForwardedPrefix = anns.XForwardedPrefix
+						loc.PathType = anns.PathType
 						loc.UsePortInRedirects = anns.UsePortInRedirects
+						loc.LogFormat = anns.LogFormat
+						loc.Mirror = anns.Mirror
+						loc.ProxySSL = anns.ProxySSL
+						loc.WebSocket = anns.WebSocket
+						loc.FastCGI = anns.FastCGI
+						loc.GRPCWeb = anns.GRPCWeb
+						loc.BackendProtocol = anns.BackendProtocol
+						loc.Maintenance = anns.Maintenance
+						loc.LimitRate = anns.LimitRate
+						loc.ProxyCache = anns.ProxyCache
+						loc.Brotli = anns.Brotli
+						loc.Gzip = anns.Gzip
+						loc.ResponseHeaders = anns.ResponseHeaders
+						loc.RequestHeaders = anns.RequestHeaders
+						loc.CustomHTTPErrors = anns.CustomHTTPErrors
+						loc.DefaultBackend = anns.DefaultBackend
+						loc.Satisfy = anns.Satisfy
+						loc.SecurityHeaders = anns.SecurityHeaders
+						loc.UserAgent = anns.UserAgent
+						loc.ModSecurity = anns.ModSecurity
+						loc.GeoBackend = anns.GeoBackend
+						loc.ABTesting = anns.ABTesting
 
 						if loc.Redirect.FromToWWW {
 							server.RedirectFromToWWW = true
@@ -446,7 +671,7 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 						BasicDigestAuth:      anns.BasicDigestAuth,
 						ClientBodyBufferSize: anns.ClientBodyBufferSize,
 						ConfigurationSnippet: anns.ConfigurationSnippet,
-						CorsConfig:           anns.CorsConfig,
+						CorsConfig:           anns.CorsConfig.ForPath(nginxPath),
 						ExternalAuth:         anns.ExternalAuth,
 						Proxy:                anns.Proxy,
 						RateLimit:            anns.RateLimit,
@@ -455,11 +680,38 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 						UpstreamVhost:        anns.UpstreamVhost,
 						VtsFilterKey:         anns.VtsFilterKey,
 						Whitelist:            anns.Whitelist,
+						Denylist:             anns.Denylist,
+						CountryFilter:        anns.CountryFilter,
 						Denied:               anns.Denied,
 						XForwardedPrefix:     anns.XForwardedPrefix,
+						PathType:             anns.PathType,
 						UsePortInRedirects:   anns.UsePortInRedirects,
+						LogFormat:            anns.LogFormat,
+						GeoBackend:           anns.GeoBackend,
+						ABTesting:            anns.ABTesting,
+						ModSecurity:          anns.ModSecurity,
+						UserAgent:            anns.UserAgent,
+						SecurityHeaders:      anns.SecurityHeaders,
+						Satisfy:              anns.Satisfy,
+						CustomHTTPErrors:     anns.CustomHTTPErrors,
+						DefaultBackend:       anns.DefaultBackend,
+						RequestHeaders:       anns.RequestHeaders,
+						ResponseHeaders:      anns.ResponseHeaders,
+						Gzip:                 anns.Gzip,
+						Brotli:               anns.Brotli,
+						ProxyCache:           anns.ProxyCache,
+						LimitRate:            anns.LimitRate,
+						Maintenance:          anns.Maintenance,
+						BackendProtocol:      anns.BackendProtocol,
+						GRPCWeb:              anns.GRPCWeb,
+						FastCGI:              anns.FastCGI,
+						WebSocket:            anns.WebSocket,
+						ProxySSL:             anns.ProxySSL,
+						Mirror:               anns.Mirror,
 					}
 
+					loc.Proxy.BodySize = anns.Proxy.BodySizeForPath(nginxPath)
+
 					if loc.Redirect.FromToWWW {
 						server.RedirectFromToWWW = true
 					}
@@ -541,12 +793,48 @@ func (n *NGINXController) getBackendServers(ingresses []*extensions.Ingress) ([]
 		aUpstreams = append(aUpstreams, upstream)
 	}
 
-	if n.cfg.SortBackends {
-		sort.SliceStable(aUpstreams, func(a, b int) bool {
-			return aUpstreams[a].Name < aUpstreams[b].Name
-		})
+	// configure the upstream used to serve the custom HTTP errors of each location
+	errorUpstreams := map[string]bool{}
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if len(location.CustomHTTPErrors) == 0 {
+				continue
+			}
+
+			location.DefaultBackendUpstreamName = defUpstreamName
+			svc := location.DefaultBackend
+			if svc == nil || len(svc.Spec.Ports) == 0 {
+				continue
+			}
+
+			name := fmt.Sprintf("custom-default-backend-%v-%v", svc.Namespace, svc.Name)
+			if !errorUpstreams[name] {
+				sp := svc.Spec.Ports[0]
+				endps := n.getEndpoints(svc, &sp, apiv1.ProtocolTCP, &healthcheck.Config{})
+				if len(endps) == 0 {
+					glog.Warningf("custom default backend %v/%v does not have any active endpoints, using the default backend for the custom errors of server %v location %v",
+						svc.Namespace, svc.Name, server.Hostname, location.Path)
+					continue
+				}
+
+				nb := newUpstream(name)
+				nb.Service = svc
+				nb.Port = intstr.FromInt(int(sp.Port))
+				nb.Endpoints = endps
+				aUpstreams = append(aUpstreams, nb)
+				errorUpstreams[name] = true
+			}
+
+			location.DefaultBackendUpstreamName = name
+		}
 	}
 
+	// the upstreams are sorted, like the servers, so the configuration
+	// does not change if the state of the cluster does not change
+	sort.SliceStable(aUpstreams, func(a, b int) bool {
+		return aUpstreams[a].Name < aUpstreams[b].Name
+	})
+
 	aServers := make([]*ingress.Server, 0, len(servers))
 	for _, value := range servers {
 		sort.SliceStable(value.Locations, func(i, j int) bool {
@@ -592,6 +880,12 @@ func (n *NGINXController) createUpstreams(data []*extensions.Ingress, du *ingres
 			if upstreams[defBackend].UpstreamHashBy == "" {
 				upstreams[defBackend].UpstreamHashBy = anns.UpstreamHashBy
 			}
+			if upstreams[defBackend].LoadBalancing == "" {
+				upstreams[defBackend].LoadBalancing = anns.LoadBalancing
+			}
+			if upstreams[defBackend].UpstreamKeepaliveConnections == 0 {
+				upstreams[defBackend].UpstreamKeepaliveConnections = anns.UpstreamKeepalive
+			}
 
 			svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), ing.Spec.Backend.ServiceName)
 
@@ -603,6 +897,7 @@ func (n *NGINXController) createUpstreams(data []*extensions.Ingress, du *ingres
 					glog.Errorf("Failed to get service cluster endpoint for service %s: %v", svcKey, err)
 				} else {
 					upstreams[defBackend].Endpoints = []ingress.Endpoint{endpoint}
+					upstreams[defBackend].ServiceUpstream = true
 				}
 			}
 
@@ -616,67 +911,86 @@ func (n *NGINXController) createUpstreams(data []*extensions.Ingress, du *ingres
 
 		}
 
+		// services referenced in the rules and in the annotations
+		backends := []extensions.IngressBackend{}
 		for _, rule := range ing.Spec.Rules {
 			if rule.HTTP == nil {
 				continue
 			}
 
 			for _, path := range rule.HTTP.Paths {
-				name := fmt.Sprintf("%v-%v-%v",
-					ing.GetNamespace(),
-					path.Backend.ServiceName,
-					path.Backend.ServicePort.String())
+				backends = append(backends, path.Backend)
+			}
+		}
+		backends = append(backends, anns.GeoBackend.Backends...)
+		if anns.ABTesting.Backend != nil {
+			backends = append(backends, *anns.ABTesting.Backend)
+		}
 
-				if _, ok := upstreams[name]; ok {
-					continue
-				}
+		for _, backend := range backends {
+			name := fmt.Sprintf("%v-%v-%v",
+				ing.GetNamespace(),
+				backend.ServiceName,
+				backend.ServicePort.String())
 
-				glog.V(3).Infof("creating upstream %v", name)
-				upstreams[name] = newUpstream(name)
-				upstreams[name].Port = path.Backend.ServicePort
+			if _, ok := upstreams[name]; ok {
+				continue
+			}
 
-				if !upstreams[name].Secure {
-					upstreams[name].Secure = anns.SecureUpstream.Secure
-				}
+			glog.V(3).Infof("creating upstream %v", name)
+			upstreams[name] = newUpstream(name)
+			upstreams[name].Port = backend.ServicePort
 
-				if upstreams[name].SecureCACert.Secret == "" {
-					upstreams[name].SecureCACert = anns.SecureUpstream.CACert
-				}
+			if !upstreams[name].Secure {
+				upstreams[name].Secure = anns.SecureUpstream.Secure
+			}
 
-				if upstreams[name].UpstreamHashBy == "" {
-					upstreams[name].UpstreamHashBy = anns.UpstreamHashBy
-				}
+			if upstreams[name].SecureCACert.Secret == "" {
+				upstreams[name].SecureCACert = anns.SecureUpstream.CACert
+			}
 
-				svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), path.Backend.ServiceName)
+			if upstreams[name].UpstreamHashBy == "" {
+				upstreams[name].UpstreamHashBy = anns.UpstreamHashBy
+			}
 
-				// Add the service cluster endpoint as the upstream instead of individual endpoints
-				// if the serviceUpstream annotation is enabled
-				if anns.ServiceUpstream {
-					endpoint, err := n.getServiceClusterEndpoint(svcKey, &path.Backend)
-					if err != nil {
-						glog.Errorf("failed to get service cluster endpoint for service %s: %v", svcKey, err)
-					} else {
-						upstreams[name].Endpoints = []ingress.Endpoint{endpoint}
-					}
-				}
+			if upstreams[name].LoadBalancing == "" {
+				upstreams[name].LoadBalancing = anns.LoadBalancing
+			}
 
-				if len(upstreams[name].Endpoints) == 0 {
-					endp, err := n.serviceEndpoints(svcKey, path.Backend.ServicePort.String(), &anns.HealthCheck)
-					if err != nil {
-						glog.Warningf("error obtaining service endpoints: %v", err)
-						continue
-					}
-					upstreams[name].Endpoints = endp
+			if upstreams[name].UpstreamKeepaliveConnections == 0 {
+				upstreams[name].UpstreamKeepaliveConnections = anns.UpstreamKeepalive
+			}
+
+			svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), backend.ServiceName)
+
+			// Add the service cluster endpoint as the upstream instead of individual endpoints
+			// if the serviceUpstream annotation is enabled
+			if anns.ServiceUpstream {
+				endpoint, err := n.getServiceClusterEndpoint(svcKey, &backend)
+				if err != nil {
+					glog.Errorf("failed to get service cluster endpoint for service %s: %v", svcKey, err)
+				} else {
+					upstreams[name].Endpoints = []ingress.Endpoint{endpoint}
+					upstreams[name].ServiceUpstream = true
 				}
+			}
 
-				s, err := n.store.GetService(svcKey)
+			if len(upstreams[name].Endpoints) == 0 {
+				endp, err := n.serviceEndpoints(svcKey, backend.ServicePort.String(), &anns.HealthCheck)
 				if err != nil {
-					glog.Warningf("error obtaining service: %v", err)
+					glog.Warningf("error obtaining service endpoints: %v", err)
 					continue
 				}
+				upstreams[name].Endpoints = endp
+			}
 
-				upstreams[name].Service = s
+			s, err := n.store.GetService(svcKey)
+			if err != nil {
+				glog.Warningf("error obtaining service: %v", err)
+				continue
 			}
+
+			upstreams[name].Service = s
 		}
 	}
 
@@ -739,17 +1053,6 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string,
 				glog.Warningf("service %v does not have any active endpoints", svcKey)
 			}
 
-			if n.cfg.SortBackends {
-				sort.SliceStable(endps, func(i, j int) bool {
-					iName := endps[i].Address
-					jName := endps[j].Address
-					if iName != jName {
-						return iName < jName
-					}
-
-					return endps[i].Port < endps[j].Port
-				})
-			}
 			upstreams = append(upstreams, endps...)
 			break
 		}
@@ -779,16 +1082,40 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string,
 	}
 
 	if !n.cfg.SortBackends {
-		rand.Seed(time.Now().UnixNano())
-		for i := range upstreams {
-			j := rand.Intn(i + 1)
-			upstreams[i], upstreams[j] = upstreams[j], upstreams[i]
-		}
+		shuffleEndpoints(upstreams, fmt.Sprintf("%v-%v", n.replicaID, svcKey))
 	}
 
 	return upstreams, nil
 }
 
+// sortEndpoints sorts the endpoints by address and port
+func sortEndpoints(endps []ingress.Endpoint) {
+	sort.SliceStable(endps, func(i, j int) bool {
+		iName := endps[i].Address
+		jName := endps[j].Address
+		if iName != jName {
+			return iName < jName
+		}
+
+		return endps[i].Port < endps[j].Port
+	})
+}
+
+// shuffleEndpoints shuffles the sorted endpoints in an order given by the
+// seed. The seed includes the name of the replica of the ingress controller,
+// so the replicas do not send the first requests to the same endpoints, but
+// the order does not change between syncs, which would reload NGINX.
+func shuffleEndpoints(endps []ingress.Endpoint, seed string) {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	for i := range endps {
+		j := r.Intn(i + 1)
+		endps[i], endps[j] = endps[j], endps[i]
+	}
+}
+
 // createServers initializes a map that contains information about the list of
 // FDQN referenced by ingress rules and the common name field in the referenced
 // SSL certificates. Each server is configured with location / using a default
@@ -816,6 +1143,17 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 		ProxyRedirectFrom: bdef.ProxyRedirectFrom,
 	}
 
+	defHSTS := hsts.Config{
+		Enable:            bdef.HSTS,
+		MaxAge:            bdef.HSTSMaxAge,
+		IncludeSubdomains: bdef.HSTSIncludeSubdomains,
+		Preload:           bdef.HSTSPreload,
+	}
+	// servers with a HSTS configuration from annotations
+	hstsServers := sets.NewString()
+	// Ingresses that configure the server level annotations
+	owners := serverAnnotationOwners{}
+
 	// generated on Start() with createDefaultSSLCertificate()
 	defaultPemFileName := n.cfg.FakeCertificatePath
 	defaultPemSHA := n.cfg.FakeCertificateSHA
@@ -833,6 +1171,7 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 		Hostname:       defServerName,
 		SSLCertificate: defaultPemFileName,
 		SSLPemChecksum: defaultPemSHA,
+		HSTS:           defHSTS,
 		Locations: []*ingress.Location{
 			{
 				Path:         rootLocation,
@@ -873,9 +1212,10 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 					defLoc.BasicDigestAuth = anns.BasicDigestAuth
 					defLoc.ClientBodyBufferSize = anns.ClientBodyBufferSize
 					defLoc.ConfigurationSnippet = anns.ConfigurationSnippet
-					defLoc.CorsConfig = anns.CorsConfig
+					defLoc.CorsConfig = anns.CorsConfig.ForPath(defLoc.Path)
 					defLoc.ExternalAuth = anns.ExternalAuth
 					defLoc.Proxy = anns.Proxy
+					defLoc.LogFormat = anns.LogFormat
 					defLoc.RateLimit = anns.RateLimit
 					// TODO: Redirect and rewrite can affect the catch all behavior. Don't use this annotations for now
 					// defLoc.Redirect = anns.Redirect
@@ -883,7 +1223,30 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 					defLoc.UpstreamVhost = anns.UpstreamVhost
 					defLoc.VtsFilterKey = anns.VtsFilterKey
 					defLoc.Whitelist = anns.Whitelist
+					defLoc.Denylist = anns.Denylist
+					defLoc.CountryFilter = anns.CountryFilter
 					defLoc.Denied = anns.Denied
+					defLoc.Mirror = anns.Mirror
+					defLoc.ProxySSL = anns.ProxySSL
+					defLoc.WebSocket = anns.WebSocket
+					defLoc.FastCGI = anns.FastCGI
+					defLoc.GRPCWeb = anns.GRPCWeb
+					defLoc.BackendProtocol = anns.BackendProtocol
+					defLoc.Maintenance = anns.Maintenance
+					defLoc.LimitRate = anns.LimitRate
+					defLoc.ProxyCache = anns.ProxyCache
+					defLoc.Brotli = anns.Brotli
+					defLoc.Gzip = anns.Gzip
+					defLoc.ResponseHeaders = anns.ResponseHeaders
+					defLoc.RequestHeaders = anns.RequestHeaders
+					defLoc.CustomHTTPErrors = anns.CustomHTTPErrors
+					defLoc.DefaultBackend = anns.DefaultBackend
+					defLoc.Satisfy = anns.Satisfy
+					defLoc.SecurityHeaders = anns.SecurityHeaders
+					defLoc.UserAgent = anns.UserAgent
+					defLoc.ModSecurity = anns.ModSecurity
+					defLoc.GeoBackend = anns.GeoBackend
+					defLoc.ABTesting = anns.ABTesting
 				}
 			}
 		}
@@ -910,6 +1273,7 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 					},
 				},
 				SSLPassthrough: anns.SSLPassthrough,
+				HSTS:           defHSTS,
 			}
 		}
 	}
@@ -927,28 +1291,51 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 				host = defServerName
 			}
 
+			// the server level annotations are configured by the first Ingress
+			// (in order of precedence) of the host that defines them
+
 			// setup server aliases
 			if anns.Alias != "" {
 				if servers[host].Alias == "" {
 					servers[host].Alias = anns.Alias
+					owners.set(host, "server-alias", ing)
 					if _, ok := aliases["Alias"]; !ok {
 						aliases["Alias"] = host
 					}
-				} else {
-					glog.Warningf("ingress %v/%v for host %v contains an Alias but one has already been configured.",
-						ing.Namespace, ing.Name, host)
+				} else if servers[host].Alias != anns.Alias {
+					n.reportServerAnnotationConflict(ing, host, "server-alias", owners.get(host, "server-alias"))
 				}
 			}
 
-			//notifying the user that it has already been configured.
-			if servers[host].ServerSnippet != "" && anns.ServerSnippet != "" {
-				glog.Warningf("ingress %v/%v for host %v contains a Server Snippet section that it has already been configured.",
-					ing.Namespace, ing.Name, host)
+			// only add a server snippet if the server does not have one previously configured
+			if anns.ServerSnippet != "" {
+				if servers[host].ServerSnippet == "" {
+					servers[host].ServerSnippet = anns.ServerSnippet
+					owners.set(host, "server-snippet", ing)
+				} else if servers[host].ServerSnippet != anns.ServerSnippet {
+					n.reportServerAnnotationConflict(ing, host, "server-snippet", owners.get(host, "server-snippet"))
+				}
 			}
 
-			// only add a server snippet if the server does not have one previously configured
-			if servers[host].ServerSnippet == "" && anns.ServerSnippet != "" {
-				servers[host].ServerSnippet = anns.ServerSnippet
+			// only configure the SSL protocols and ciphers if the server does not have them previously configured
+			if anns.SSLCipher != (sslcipher.Config{}) {
+				if servers[host].SSLCipher == (sslcipher.Config{}) {
+					servers[host].SSLCipher = anns.SSLCipher
+					owners.set(host, "ssl-ciphers", ing)
+				} else if servers[host].SSLCipher != anns.SSLCipher {
+					n.reportServerAnnotationConflict(ing, host, "ssl-ciphers", owners.get(host, "ssl-ciphers"))
+				}
+			}
+
+			// the HSTS configuration is empty if the ingress does not contains HSTS annotations
+			if anns.HSTS != (hsts.Config{}) {
+				if !hstsServers.Has(host) {
+					servers[host].HSTS = anns.HSTS
+					hstsServers.Insert(host)
+					owners.set(host, "hsts", ing)
+				} else if servers[host].HSTS != anns.HSTS {
+					n.reportServerAnnotationConflict(ing, host, "hsts", owners.get(host, "hsts"))
+				}
 			}
 
 			// only add a certificate if the server does not have one previously configured
@@ -983,7 +1370,12 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 				continue
 			}
 
-			key := fmt.Sprintf("%v/%v", ing.Namespace, tlsSecretName)
+			key := k8s.ReferenceKey(ing.Namespace, tlsSecretName)
+			if !n.store.CanReferenceSecret(ing.Namespace, key) {
+				glog.Warningf("ssl certificate %v is not shared with the namespace of the ingress %v/%v", key, ing.Namespace, ing.Name)
+				continue
+			}
+
 			cert, err := n.store.GetLocalSecret(key)
 			if err != nil {
 				glog.Warningf("ssl certificate \"%v\" does not exist in local store", key)
@@ -1006,6 +1398,7 @@ func (n *NGINXController) createServers(data []*extensions.Ingress,
 			servers[host].SSLCertificate = cert.PemFileName
 			servers[host].SSLFullChainCertificate = cert.FullChainPemFileName
 			servers[host].SSLPemChecksum = cert.PemSHA
+			servers[host].SSLCertificateNames = cert.CN
 			servers[host].SSLExpireTime = cert.ExpireTime
 
 			if cert.ExpireTime.Before(time.Now().Add(240 * time.Hour)) {
@@ -1062,6 +1455,7 @@ func (n *NGINXController) getEndpoints(
 			Port:        fmt.Sprintf("%v", targetPort),
 			MaxFails:    hz.MaxFails,
 			FailTimeout: hz.FailTimeout,
+			MaxConns:    hz.MaxConns,
 		})
 	}
 
@@ -1103,6 +1497,7 @@ func (n *NGINXController) getEndpoints(
 					Port:        fmt.Sprintf("%v", targetPort),
 					MaxFails:    hz.MaxFails,
 					FailTimeout: hz.FailTimeout,
+					MaxConns:    hz.MaxConns,
 					Target:      epAddress.TargetRef,
 				}
 				upsServers = append(upsServers, ups)
@@ -1111,6 +1506,9 @@ func (n *NGINXController) getEndpoints(
 		}
 	}
 
+	// the order of the endpoints must not change the configuration
+	sortEndpoints(upsServers)
+
 	glog.V(3).Infof("endpoints found: %v", upsServers)
 	return upsServers
 }
@@ -1128,3 +1526,19 @@ func (n *NGINXController) SetForceReload(shouldReload bool) {
 		atomic.StoreInt32(&n.forceReload, 0)
 	}
 }
+
+// waitMinReloadInterval delays the reload of NGINX until the minimum interval
+// between reloads elapsed since the last one
+func (n *NGINXController) waitMinReloadInterval() {
+	if n.lastReload.IsZero() {
+		return
+	}
+
+	wait := n.cfg.MinReloadInterval - time.Since(n.lastReload)
+	if wait <= 0 {
+		return
+	}
+
+	glog.Infof("delaying backend reload %v (minimum interval between reloads)", wait)
+	time.Sleep(wait)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+func TestSortEndpoints(t *testing.T) {
+	endps := []ingress.Endpoint{
+		{Address: "10.0.0.2", Port: "80"},
+		{Address: "10.0.0.1", Port: "8080"},
+		{Address: "10.0.0.1", Port: "80"},
+	}
+	expected := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "80"},
+		{Address: "10.0.0.1", Port: "8080"},
+		{Address: "10.0.0.2", Port: "80"},
+	}
+
+	sortEndpoints(endps)
+	if !reflect.DeepEqual(endps, expected) {
+		t.Errorf("expected %v but returned %v", expected, endps)
+	}
+}
+
+func TestShuffleEndpoints(t *testing.T) {
+	newEndpoints := func() []ingress.Endpoint {
+		endps := []ingress.Endpoint{}
+		for _, address := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"} {
+			endps = append(endps, ingress.Endpoint{Address: address, Port: "80"})
+		}
+		return endps
+	}
+
+	first := newEndpoints()
+	shuffleEndpoints(first, "nginx-ingress-controller-1-default/app")
+
+	second := newEndpoints()
+	shuffleEndpoints(second, "nginx-ingress-controller-1-default/app")
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same order with the same seed but returned %v and %v", first, second)
+	}
+
+	// the order of some of the replicas must be different
+	different := false
+	for _, replica := range []string{"2", "3", "4", "5"} {
+		other := newEndpoints()
+		shuffleEndpoints(other, "nginx-ingress-controller-"+replica+"-default/app")
+		if !reflect.DeepEqual(first, other) {
+			different = true
+		}
+	}
+	if !different {
+		t.Errorf("expected a different order in other replicas")
+	}
+}
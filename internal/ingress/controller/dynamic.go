@@ -0,0 +1,499 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// dynamicConfigurationTimeout is the maximum time to wait until NGINX
+// accepts the configuration of the backends
+var dynamicConfigurationTimeout = 10 * time.Second
+
+// dynamicLoadBalancers contains the load balancing algorithms supported by
+// the Lua balancer. ewma is only supported by the Lua balancer
+var dynamicLoadBalancers = sets.NewString("round_robin", "least_conn", "ewma")
+
+// dynamicBackends returns the name of the backends whose endpoints can be
+// configured by the Lua balancer. Backends using features implemented in the
+// upstream blocks (sticky sessions, consistent hashing, max_conns) or
+// referenced by name in the configuration (custom default backends, GeoIP
+// and A/B testing routing) require a reload when the endpoints change.
+func dynamicBackends(pcfg *ingress.Configuration, algorithm string) sets.String {
+	static := sets.NewString(defUpstreamName)
+	for _, server := range pcfg.Servers {
+		for _, location := range server.Locations {
+			if location.DefaultBackendUpstreamName != "" {
+				static.Insert(location.DefaultBackendUpstreamName)
+			}
+
+			if len(location.GeoBackend.Upstreams) > 0 || location.ABTesting.Upstream != "" {
+				static.Insert(locationBackends(location)...)
+			}
+		}
+	}
+
+	dynamic := sets.NewString()
+	for _, backend := range pcfg.Backends {
+		if static.Has(backend.Name) || !isDynamicBackend(backend, algorithm) {
+			continue
+		}
+		dynamic.Insert(backend.Name)
+	}
+
+	return dynamic
+}
+
+// locationBackends returns the name of the backends used by the location
+func locationBackends(location *ingress.Location) []string {
+	backends := []string{location.Backend}
+	if location.DefaultBackendUpstreamName != "" {
+		backends = append(backends, location.DefaultBackendUpstreamName)
+	}
+	if location.ABTesting.Upstream != "" {
+		backends = append(backends, location.ABTesting.Upstream)
+	}
+	for _, upstream := range location.GeoBackend.Upstreams {
+		backends = append(backends, upstream)
+	}
+
+	return backends
+}
+
+// isDynamicBackend checks if the Lua balancer supports the configuration of
+// the backend. The upstream of the Lua balancer uses the keepalive of the
+// configuration configmap, so the backends with a different number of
+// keepalive connections (upstream-keepalive-connections annotation) are static.
+// The backends using the ClusterIP of the service (service-upstream
+// annotation) are also static, because the endpoint does not change when
+// the pods change and the connections are balanced by kube-proxy
+func isDynamicBackend(backend *ingress.Backend, algorithm string) bool {
+	if backend.SSLPassthrough ||
+		backend.SessionAffinity.AffinityType == "cookie" ||
+		backend.UpstreamHashBy != "" ||
+		backend.UpstreamKeepaliveConnections != 0 ||
+		backend.ServiceUpstream {
+		return false
+	}
+
+	if backend.Service != nil && backend.Service.Spec.Type == apiv1.ServiceTypeExternalName {
+		return false
+	}
+
+	if !dynamicLoadBalancers.Has(loadBalanceAlgorithm(backend, algorithm)) {
+		return false
+	}
+
+	for _, endpoint := range backend.Endpoints {
+		if endpoint.MaxConns > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadBalanceAlgorithm returns the load balancing algorithm of the backend,
+// the one defined in the annotation or the global default
+func loadBalanceAlgorithm(backend *ingress.Backend, algorithm string) string {
+	if backend.LoadBalancing != "" {
+		return backend.LoadBalancing
+	}
+	return algorithm
+}
+
+// isDynamicConfigurationEnough checks if the differences between the running
+// configuration and the new one are limited to the endpoints of dynamic
+// backends (the content of the SSL certificates when the certificates are
+// dynamic and the dynamic servers), which can be updated without a reload
+func (n *NGINXController) isDynamicConfigurationEnough(pcfg *ingress.Configuration, algorithm string) bool {
+	running := n.runningConfig
+	if running == nil {
+		return false
+	}
+
+	copyOfRunningConfig, runningDynamic := n.staticConfiguration(running, algorithm)
+	copyOfPcfg, dynamic := n.staticConfiguration(pcfg, algorithm)
+	if !runningDynamic.Equal(dynamic) {
+		return false
+	}
+
+	return copyOfRunningConfig.Equal(copyOfPcfg)
+}
+
+// staticConfiguration returns a copy of the configuration without the
+// information configured dynamically, the part of the configuration used to
+// render the configuration file, and the name of the dynamic backends
+// included in the copy
+func (n *NGINXController) staticConfiguration(pcfg *ingress.Configuration, algorithm string) (*ingress.Configuration, sets.String) {
+	dynamic := dynamicBackends(pcfg, algorithm)
+
+	c := *pcfg
+	if n.cfg.DynamicServersEnabled {
+		servers := dynamicServers(pcfg, dynamic, n.cfg.DynamicCertificatesEnabled)
+		c.Servers = withoutServers(pcfg.Servers, servers)
+
+		// the dynamic backends only used by dynamic servers are not
+		// included in the configuration file
+		used := sets.NewString(defUpstreamName)
+		for _, server := range c.Servers {
+			for _, location := range server.Locations {
+				used.Insert(locationBackends(location)...)
+			}
+		}
+
+		c.Backends = []*ingress.Backend{}
+		for _, backend := range pcfg.Backends {
+			if used.Has(backend.Name) || !dynamic.Has(backend.Name) {
+				c.Backends = append(c.Backends, backend)
+			}
+		}
+	}
+
+	c.Backends = withoutEndpoints(c.Backends, dynamic)
+
+	if n.cfg.DynamicCertificatesEnabled {
+		c.Servers = withoutCertificateContent(c.Servers)
+	}
+
+	included := sets.NewString()
+	for _, backend := range c.Backends {
+		if dynamic.Has(backend.Name) {
+			included.Insert(backend.Name)
+		}
+	}
+
+	return &c, included
+}
+
+// dynamicServers returns the hostname of the servers routed by Lua. Only the
+// servers defined in Ingress rules without annotations, using dynamic backends
+// and the default configuration (from the configuration ConfigMap) can be
+// routed by Lua. Servers with a SSL certificate require dynamic certificates.
+func dynamicServers(pcfg *ingress.Configuration, backends sets.String, certificates bool) sets.String {
+	dynamic := sets.NewString()
+	for _, server := range pcfg.Servers {
+		if isDynamicServer(server, backends, certificates) {
+			dynamic.Insert(server.Hostname)
+		}
+	}
+
+	return dynamic
+}
+
+// isDynamicServer checks if the server can be routed by Lua
+func isDynamicServer(server *ingress.Server, backends sets.String, certificates bool) bool {
+	if server.Hostname == defServerName || server.SSLPassthrough {
+		return false
+	}
+
+	if server.SSLCertificate != "" && !certificates {
+		return false
+	}
+
+	for _, location := range server.Locations {
+		if location.IsDefBackend && location.Backend == defUpstreamName {
+			continue
+		}
+
+		if !backends.Has(location.Backend) || hasAnnotations(location.Ingress) {
+			return false
+		}
+
+		// settings of the configuration ConfigMap not supported by Lua
+		if len(location.Whitelist.CIDR) > 0 ||
+			location.Rewrite.AppRoot != "" ||
+			location.LimitRate.Rate > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasAnnotations checks if the Ingress contains annotations with the
+// annotations prefix
+func hasAnnotations(ing *extensions.Ingress) bool {
+	if ing == nil {
+		return false
+	}
+
+	for name := range ing.GetAnnotations() {
+		if strings.HasPrefix(name, parser.AnnotationsPrefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutServers returns the servers not contained in hostnames
+func withoutServers(servers []*ingress.Server, hostnames sets.String) []*ingress.Server {
+	result := []*ingress.Server{}
+	for _, server := range servers {
+		if !hostnames.Has(server.Hostname) {
+			result = append(result, server)
+		}
+	}
+
+	return result
+}
+
+// withoutEndpoints returns a copy of the backends removing the endpoints of
+// the backends contained in names
+func withoutEndpoints(backends []*ingress.Backend, names sets.String) []*ingress.Backend {
+	result := make([]*ingress.Backend, len(backends))
+	for i, backend := range backends {
+		if !names.Has(backend.Name) {
+			result[i] = backend
+			continue
+		}
+
+		b := *backend
+		b.Endpoints = nil
+		result[i] = &b
+	}
+
+	return result
+}
+
+// withoutCertificateContent returns a copy of the servers removing the
+// information about the content of the SSL certificates. The path of the
+// certificates is kept, because it is used in the configuration file.
+func withoutCertificateContent(servers []*ingress.Server) []*ingress.Server {
+	result := make([]*ingress.Server, len(servers))
+	for i, server := range servers {
+		if !hasDynamicCertificate(server) {
+			result[i] = server
+			continue
+		}
+
+		s := *server
+		s.SSLPemChecksum = ""
+		s.SSLExpireTime = time.Time{}
+		s.SSLCertificateNames = nil
+		result[i] = &s
+	}
+
+	return result
+}
+
+// hasDynamicCertificate checks if the SSL certificate of the server is
+// loaded by Lua. The default server always uses the configured certificate.
+func hasDynamicCertificate(server *ingress.Server) bool {
+	return server.SSLCertificate != "" && !server.SSLPassthrough && server.Hostname != defServerName
+}
+
+// configureDynamically sends the endpoints of the dynamic backends to the
+// Lua balancer using the configuration endpoint
+func configureDynamically(pcfg *ingress.Configuration, algorithm string, port int) error {
+	dynamic := dynamicBackends(pcfg, algorithm)
+
+	backends := []*ingress.Backend{}
+	for _, backend := range pcfg.Backends {
+		if !dynamic.Has(backend.Name) {
+			continue
+		}
+
+		endpoints := []ingress.Endpoint{}
+		for _, endpoint := range backend.Endpoints {
+			endpoints = append(endpoints, ingress.Endpoint{
+				Address:     endpoint.Address,
+				Port:        endpoint.Port,
+				MaxFails:    endpoint.MaxFails,
+				FailTimeout: endpoint.FailTimeout,
+			})
+		}
+
+		backends = append(backends, &ingress.Backend{
+			Name:          backend.Name,
+			Port:          backend.Port,
+			Endpoints:     endpoints,
+			LoadBalancing: loadBalanceAlgorithm(backend, algorithm),
+		})
+	}
+
+	buf, err := json.Marshal(backends)
+	if err != nil {
+		return err
+	}
+
+	err = postConfiguration(port, "backends", buf)
+	if err != nil {
+		return err
+	}
+
+	glog.V(2).Infof("dynamic configuration of %v backends applied", len(backends))
+	return nil
+}
+
+// configureCertificates sends the SSL certificates (and keys) of the servers
+// to the Lua certificate module, indexed by hostname
+func configureCertificates(pcfg *ingress.Configuration, port int) error {
+	certificates := map[string]string{}
+	for _, server := range pcfg.Servers {
+		if !hasDynamicCertificate(server) {
+			continue
+		}
+
+		pem, err := ioutil.ReadFile(server.SSLCertificate)
+		if err != nil {
+			glog.Warningf("unexpected error reading SSL certificate %v of server %v: %v", server.SSLCertificate, server.Hostname, err)
+			continue
+		}
+
+		certificates[server.Hostname] = string(pem)
+	}
+
+	buf, err := json.Marshal(certificates)
+	if err != nil {
+		return err
+	}
+
+	err = postConfiguration(port, "servers", buf)
+	if err != nil {
+		return err
+	}
+
+	glog.V(2).Infof("dynamic configuration of %v SSL certificates applied", len(certificates))
+	return nil
+}
+
+// dynamicRoute contains the locations of a dynamic server
+type dynamicRoute struct {
+	Locations []dynamicLocation `json:"locations"`
+}
+
+// dynamicLocation contains the information required to route the requests
+// of a location of a dynamic server
+type dynamicLocation struct {
+	Path        string `json:"path"`
+	Backend     string `json:"backend"`
+	Namespace   string `json:"namespace"`
+	Ingress     string `json:"ingress"`
+	Service     string `json:"service"`
+	SSLRedirect bool   `json:"sslRedirect"`
+}
+
+// configureRoutes sends the locations of the dynamic servers to the Lua
+// router, indexed by hostname
+func configureRoutes(pcfg *ingress.Configuration, servers sets.String, port int) error {
+	routes := map[string]*dynamicRoute{}
+	for _, server := range pcfg.Servers {
+		if !servers.Has(server.Hostname) {
+			continue
+		}
+
+		route := &dynamicRoute{Locations: []dynamicLocation{}}
+		for _, location := range server.Locations {
+			if location.IsDefBackend && location.Backend == defUpstreamName {
+				// requests without a location use the default backend
+				continue
+			}
+
+			l := dynamicLocation{
+				Path:        location.Path,
+				Backend:     location.Backend,
+				SSLRedirect: location.Rewrite.ForceSSLRedirect || (server.SSLCertificate != "" && location.Rewrite.SSLRedirect),
+			}
+			if location.Ingress != nil {
+				l.Namespace = location.Ingress.Namespace
+				l.Ingress = location.Ingress.Name
+			}
+			if location.Service != nil {
+				l.Service = location.Service.Name
+			}
+
+			route.Locations = append(route.Locations, l)
+		}
+
+		// the longest path has precedence, like NGINX prefix locations
+		sort.SliceStable(route.Locations, func(i, j int) bool {
+			return len(route.Locations[i].Path) > len(route.Locations[j].Path)
+		})
+
+		routes[server.Hostname] = route
+	}
+
+	buf, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+
+	err = postConfiguration(port, "routes", buf)
+	if err != nil {
+		return err
+	}
+
+	glog.V(2).Infof("dynamic configuration of %v servers applied", len(routes))
+	return nil
+}
+
+// postConfiguration sends the configuration to the configuration endpoint
+// (only reachable using the loopback interface), retrying while NGINX is
+// starting (first sync) or reloading
+func postConfiguration(port int, kind string, body []byte) error {
+	url := fmt.Sprintf("http://127.0.0.1:%v/configuration/%v", port, kind)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	var lastErr error
+	err := wait.PollImmediate(time.Second, dynamicConfigurationTimeout, func() (bool, error) {
+		lastErr = post(client, url, body)
+		if lastErr != nil {
+			glog.V(2).Infof("unable to configure the %v dynamically: %v", kind, lastErr)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("unexpected error configuring the %v dynamically: %v", kind, lastErr)
+	}
+
+	return nil
+}
+
+func post(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response code %v: %s", resp.StatusCode, msg)
+	}
+
+	return nil
+}
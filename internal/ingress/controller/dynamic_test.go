@@ -0,0 +1,383 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/abtesting"
+)
+
+func newDynamicConfiguration() *ingress.Configuration {
+	return &ingress.Configuration{
+		Backends: []*ingress.Backend{
+			{
+				Name:      "default-app-80",
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "8080"}},
+			},
+			{
+				Name:          "default-hash-80",
+				LoadBalancing: "ip_hash",
+			},
+			{
+				Name:            "default-sticky-80",
+				SessionAffinity: ingress.SessionAffinityConfig{AffinityType: "cookie"},
+			},
+			{
+				Name:      "default-conns-80",
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.2", Port: "8080", MaxConns: 10}},
+			},
+			{
+				Name:                         "default-keepalive-80",
+				UpstreamKeepaliveConnections: 8,
+			},
+			{
+				Name:            "default-cluster-ip-80",
+				Endpoints:       []ingress.Endpoint{{Address: "10.96.0.10", Port: "80"}},
+				ServiceUpstream: true,
+			},
+			{Name: "default-ab-80"},
+			{Name: "default-canary-80"},
+			{Name: defUpstreamName},
+		},
+		Servers: []*ingress.Server{
+			{
+				Hostname: "example.com",
+				Locations: []*ingress.Location{
+					{Path: "/", Backend: "default-app-80"},
+					{
+						Path:      "/ab",
+						Backend:   "default-ab-80",
+						ABTesting: abtesting.Config{Upstream: "default-canary-80"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDynamicBackends(t *testing.T) {
+	pcfg := newDynamicConfiguration()
+
+	dynamic := dynamicBackends(pcfg, "round_robin")
+	if !dynamic.Equal(sets.NewString("default-app-80")) {
+		t.Errorf("unexpected dynamic backends: %v", dynamic.List())
+	}
+
+	dynamic = dynamicBackends(pcfg, "ip_hash")
+	if dynamic.Len() != 0 {
+		t.Errorf("expected no dynamic backends but returned %v", dynamic.List())
+	}
+
+	pcfg.Backends[0].LoadBalancing = "least_conn"
+	dynamic = dynamicBackends(pcfg, "ip_hash")
+	if !dynamic.Equal(sets.NewString("default-app-80")) {
+		t.Errorf("unexpected dynamic backends: %v", dynamic.List())
+	}
+
+	pcfg.Backends[0].LoadBalancing = "ewma"
+	dynamic = dynamicBackends(pcfg, "ip_hash")
+	if !dynamic.Equal(sets.NewString("default-app-80")) {
+		t.Errorf("unexpected dynamic backends: %v", dynamic.List())
+	}
+}
+
+func TestIsDynamicConfigurationEnough(t *testing.T) {
+	n := &NGINXController{
+		cfg:           &Configuration{},
+		runningConfig: newDynamicConfiguration(),
+	}
+
+	pcfg := newDynamicConfiguration()
+	pcfg.Backends[0].Endpoints = append(pcfg.Backends[0].Endpoints, ingress.Endpoint{Address: "10.0.0.3", Port: "8080"})
+	if !n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a dynamic configuration for a change in the endpoints of a dynamic backend")
+	}
+
+	pcfg = newDynamicConfiguration()
+	pcfg.Backends[3].Endpoints[0].Address = "10.0.0.3"
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload for a change in the endpoints of a backend using max_conns")
+	}
+
+	pcfg = newDynamicConfiguration()
+	pcfg.Backends[0].Endpoints = nil
+	pcfg.Backends[0].SessionAffinity = ingress.SessionAffinityConfig{
+		AffinityType:          "cookie",
+		CookieSessionAffinity: ingress.CookieSessionAffinity{Name: "route"},
+	}
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload when a backend is not dynamic anymore")
+	}
+
+	pcfg = newDynamicConfiguration()
+	pcfg.Servers[0].Locations[0].Path = "/app"
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload for a change in the servers")
+	}
+
+	n.runningConfig.Servers[0].SSLCertificate = "/etc/ingress-controller/ssl/default-example.pem"
+	n.runningConfig.Servers[0].SSLPemChecksum = "1"
+	pcfg = newDynamicConfiguration()
+	pcfg.Servers[0].SSLCertificate = "/etc/ingress-controller/ssl/default-example.pem"
+	pcfg.Servers[0].SSLPemChecksum = "2"
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload for a change in a certificate without dynamic certificates")
+	}
+
+	n.cfg.DynamicCertificatesEnabled = true
+	if !n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a dynamic configuration for a change in a certificate")
+	}
+
+	pcfg.Servers[0].SSLCertificate = "/etc/ingress-controller/ssl/default-other.pem"
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload for a change in the path of a certificate")
+	}
+}
+
+func TestConfigureDynamically(t *testing.T) {
+	var backends []*ingress.Backend
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/configuration/backends" {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("unexpected error reading the body: %v", err)
+		}
+		if err := json.Unmarshal(body, &backends); err != nil {
+			t.Errorf("unexpected error decoding the backends: %v", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	_, p, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(p)
+
+	err := configureDynamically(newDynamicConfiguration(), "least_conn", port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(backends) != 1 {
+		t.Fatalf("expected 1 backend but %v returned", len(backends))
+	}
+
+	b := backends[0]
+	if b.Name != "default-app-80" || b.LoadBalancing != "least_conn" || len(b.Endpoints) != 1 {
+		t.Errorf("unexpected backend: %v", b)
+	}
+	if b.Endpoints[0].Address != "10.0.0.1" || b.Endpoints[0].Port != "8080" {
+		t.Errorf("unexpected endpoint: %v", b.Endpoints[0])
+	}
+}
+
+func TestConfigureCertificates(t *testing.T) {
+	var certificates map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/configuration/servers" {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL.Path)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&certificates); err != nil {
+			t.Errorf("unexpected error decoding the certificates: %v", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	_, p, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(p)
+
+	pem, err := ioutil.TempFile("", "certificate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(pem.Name())
+	pem.WriteString("certificate and key")
+	pem.Close()
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: defServerName, SSLCertificate: pem.Name()},
+			{Hostname: "example.com", SSLCertificate: pem.Name()},
+			{Hostname: "passthrough.example.com", SSLCertificate: pem.Name(), SSLPassthrough: true},
+			{Hostname: "http.example.com"},
+			{Hostname: "missing.example.com", SSLCertificate: "/non-existent.pem"},
+		},
+	}
+
+	err = configureCertificates(pcfg, port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"example.com": "certificate and key"}
+	if !reflect.DeepEqual(certificates, expected) {
+		t.Errorf("expected %v but returned %v", expected, certificates)
+	}
+}
+
+func newDynamicServer(hostname, path, backend string, annotations map[string]string) *ingress.Server {
+	return &ingress.Server{
+		Hostname: hostname,
+		Locations: []*ingress.Location{
+			{
+				Path:    path,
+				Backend: backend,
+				Ingress: &extensions.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "app",
+						Namespace:   "default",
+						Annotations: annotations,
+					},
+				},
+				Service: &apiv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+			},
+			{Path: "/", Backend: defUpstreamName, IsDefBackend: true},
+		},
+	}
+}
+
+func TestDynamicServers(t *testing.T) {
+	pcfg := newDynamicConfiguration()
+	pcfg.Servers = append(pcfg.Servers,
+		newDynamicServer("app.example.com", "/app", "default-app-80", nil),
+		newDynamicServer("annotations.example.com", "/app", "default-app-80",
+			map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"}),
+		newDynamicServer("static.example.com", "/app", "default-hash-80", nil),
+		newDynamicServer("tls.example.com", "/app", "default-app-80", nil),
+	)
+	pcfg.Servers[len(pcfg.Servers)-1].SSLCertificate = "/etc/ingress-controller/ssl/default-tls.pem"
+
+	backends := dynamicBackends(pcfg, "round_robin")
+
+	servers := dynamicServers(pcfg, backends, false)
+	if !servers.Equal(sets.NewString("app.example.com")) {
+		t.Errorf("unexpected dynamic servers: %v", servers.List())
+	}
+
+	servers = dynamicServers(pcfg, backends, true)
+	if !servers.Equal(sets.NewString("app.example.com", "tls.example.com")) {
+		t.Errorf("unexpected dynamic servers: %v", servers.List())
+	}
+}
+
+func TestIsDynamicConfigurationEnoughWithDynamicServers(t *testing.T) {
+	n := &NGINXController{
+		cfg:           &Configuration{DynamicServersEnabled: true},
+		runningConfig: newDynamicConfiguration(),
+	}
+
+	pcfg := newDynamicConfiguration()
+	pcfg.Backends = append(pcfg.Backends, &ingress.Backend{Name: "default-new-80"})
+	pcfg.Servers = append(pcfg.Servers, newDynamicServer("new.example.com", "/", "default-new-80", nil))
+	if !n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a dynamic configuration for a new dynamic server")
+	}
+
+	pcfg.Servers[len(pcfg.Servers)-1].Locations[0].Ingress.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/rewrite-target": "/",
+	}
+	if n.isDynamicConfigurationEnough(pcfg, "round_robin") {
+		t.Errorf("expected a reload for a new server with annotations")
+	}
+}
+
+func TestConfigureRoutes(t *testing.T) {
+	var routes map[string]*dynamicRoute
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/configuration/routes" {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL.Path)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&routes); err != nil {
+			t.Errorf("unexpected error decoding the routes: %v", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	_, p, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(p)
+
+	pcfg := newDynamicConfiguration()
+	app := newDynamicServer("app.example.com", "/", "default-app-80", nil)
+	app.Locations[1].Path = "/api"
+	app.Locations[1].Backend = "default-app-80"
+	app.Locations[1].IsDefBackend = false
+	app.SSLCertificate = "/etc/ingress-controller/ssl/default-app.pem"
+	app.Locations[0].Rewrite.SSLRedirect = true
+	pcfg.Servers = append(pcfg.Servers, app)
+
+	err := configureRoutes(pcfg, sets.NewString("app.example.com"), port)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]*dynamicRoute{
+		"app.example.com": {
+			Locations: []dynamicLocation{
+				{Path: "/api", Backend: "default-app-80"},
+				{Path: "/", Backend: "default-app-80", Namespace: "default", Ingress: "app", Service: "app", SSLRedirect: true},
+			},
+		},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected %+v but returned %+v", expected["app.example.com"], routes["app.example.com"])
+	}
+}
+
+func TestConfigureDynamicallyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, p, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(p)
+
+	timeout := dynamicConfigurationTimeout
+	dynamicConfigurationTimeout = 2 * time.Second
+	defer func() { dynamicConfigurationTimeout = timeout }()
+
+	err := configureDynamically(newDynamicConfiguration(), "round_robin", port)
+	if err == nil {
+		t.Errorf("expected an error but none returned")
+	}
+}
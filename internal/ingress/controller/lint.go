@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/controller/lint"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+const (
+	// LintDisabled disables the lint of the configuration
+	LintDisabled = "off"
+	// LintWarn logs the duplicated and conflicting directives
+	LintWarn = "warn"
+	// LintReject rejects the snippets that contain duplicated or
+	// conflicting directives
+	LintReject = "reject"
+)
+
+// lintConfiguration logs the duplicated and conflicting directives of the
+// configuration. In reject mode, the snippets that contain them are rejected
+// and the configuration is rendered again without them. The directives
+// generated by the template are only logged.
+func (n *NGINXController) lintConfiguration(tc ngx_config.TemplateConfig, content []byte) ([]byte, error) {
+	if n.cfg.LintConfiguration == "" || n.cfg.LintConfiguration == LintDisabled {
+		return content, nil
+	}
+
+	rejected := 0
+	for _, issue := range lint.Lint(content) {
+		glog.Warningf("%v\n%v", issue, ngx_template.Excerpt(string(content), issue.Line))
+
+		if n.cfg.LintConfiguration != LintReject {
+			continue
+		}
+
+		snippet, ing := snippetOfIssue(content, tc.Servers, issue)
+		if snippet == "" {
+			continue
+		}
+
+		if n.rejectedSnippets.has(ing, snippet) {
+			continue
+		}
+
+		n.rejectedSnippets.insert(ing, snippetID(ing, snippet))
+		n.rejectSnippet(ing, fmt.Sprintf("the snippet contains a %v", issue))
+		rejected++
+	}
+
+	if rejected == 0 {
+		return content, nil
+	}
+
+	tc.Servers = n.checkSnippets(tc.Servers, nil)
+	content, err := n.t.Write(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, n.testTemplate(content)
+}
+
+// snippetOfIssue returns the snippet that contains the line, or the previous
+// definition, of a duplicated or conflicting directive and the Ingress that
+// defines it. An empty snippet is returned if the directive is generated by
+// the template
+func snippetOfIssue(content []byte, servers []*ingress.Server, issue lint.Issue) (string, *extensions.Ingress) {
+	hostname := serverOfLine(content, issue.Line)
+	if hostname == "" {
+		return "", nil
+	}
+
+	for _, server := range servers {
+		if server.Hostname != hostname {
+			continue
+		}
+
+		for _, line := range []int{issue.Line, issue.Previous} {
+			directive := strings.TrimSpace(lineOf(content, line))
+			if directive == "" {
+				continue
+			}
+
+			for _, location := range server.Locations {
+				if strings.Contains(location.ConfigurationSnippet, directive) {
+					return location.ConfigurationSnippet, location.Ingress
+				}
+				if strings.Contains(location.ExternalAuth.AuthSnippet, directive) {
+					return location.ExternalAuth.AuthSnippet, location.Ingress
+				}
+			}
+
+			if strings.Contains(server.ServerSnippet, directive) {
+				return server.ServerSnippet, serverSnippetIngress(server)
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint detects the duplicated and conflicting directives of the
+// server and location blocks of an NGINX configuration, which NGINX accepts
+// but are usually the result of a snippet repeating a directive of the
+// template.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue is a duplicated or conflicting directive
+type Issue struct {
+	// Line is the line of the directive (starting at 1)
+	Line int
+	// Previous is the line of the previous definition of the directive
+	Previous int
+	// Directive is the name of the directive
+	Directive string
+	// Block is the block that contains the directive, like "location /"
+	Block string
+	// Conflict is true if the directive defines a different value for
+	// the same key (e.g. a header) instead of repeating the directive
+	Conflict bool
+}
+
+func (i Issue) String() string {
+	kind := "duplicated"
+	if i.Conflict {
+		kind = "conflicting"
+	}
+
+	return fmt.Sprintf("%v directive %v in %v at line %v (previous definition at line %v)",
+		kind, i.Directive, i.Block, i.Line, i.Previous)
+}
+
+// lintedBlocks are the blocks whose directives are checked
+var lintedBlocks = map[string]bool{
+	"server":   true,
+	"location": true,
+}
+
+// keyedDirectives are the directives that can be repeated with different
+// keys, like the name of a header. The value is the position of the key in
+// the arguments
+var keyedDirectives = map[string]int{
+	"add_header":       0,
+	"fastcgi_param":    0,
+	"grpc_set_header":  0,
+	"proxy_set_header": 0,
+	"scgi_param":       0,
+	"set":              0,
+	"uwsgi_param":      0,
+}
+
+// headersMoreDirectives are the directives of the headers-more module,
+// whose key is the name of the header in the last argument
+var headersMoreDirectives = map[string]bool{
+	"more_set_headers":       true,
+	"more_set_input_headers": true,
+}
+
+// Lint returns the duplicated and conflicting directives of the server and
+// location blocks of the configuration, sorted by line
+func Lint(content []byte) []Issue {
+	issues := []Issue{}
+	for _, b := range parse(content) {
+		if !lintedBlocks[b.name] {
+			continue
+		}
+
+		issues = append(issues, lintBlock(b)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues
+}
+
+// lintBlock returns the issues of the directives of a block
+func lintBlock(b *block) []Issue {
+	issues := []Issue{}
+	name := strings.TrimSpace(strings.Join(append([]string{b.name}, b.args...), " "))
+
+	// the first line of every directive, and of the key of the keyed ones
+	directives := map[string]*directive{}
+	keys := map[string]*directive{}
+	for _, d := range b.directives {
+		id := strings.Join(append([]string{d.name}, d.args...), " ")
+		if previous, ok := directives[id]; ok {
+			issues = append(issues, Issue{
+				Line:      d.line,
+				Previous:  previous.line,
+				Directive: d.name,
+				Block:     name,
+			})
+			continue
+		}
+		directives[id] = d
+
+		key := directiveKey(d)
+		if key == "" {
+			continue
+		}
+
+		if previous, ok := keys[key]; ok {
+			issues = append(issues, Issue{
+				Line:      d.line,
+				Previous:  previous.line,
+				Directive: d.name,
+				Block:     name,
+				Conflict:  true,
+			})
+			continue
+		}
+		keys[key] = d
+	}
+
+	return issues
+}
+
+// directiveKey returns the name and key of a keyed directive, or an empty
+// string if the directive cannot be repeated with different keys
+func directiveKey(d *directive) string {
+	if pos, ok := keyedDirectives[d.name]; ok {
+		if len(d.args) <= pos {
+			return ""
+		}
+		return fmt.Sprintf("%v %v", d.name, strings.ToLower(d.args[pos]))
+	}
+
+	if headersMoreDirectives[d.name] && len(d.args) > 0 {
+		// the options, like the status codes, are part of the key
+		last := len(d.args) - 1
+		header := strings.Trim(d.args[last], `"'`)
+		if i := strings.Index(header, ":"); i > 0 {
+			header = header[:i]
+		}
+		options := append([]string{d.name}, d.args[:last]...)
+		return fmt.Sprintf("%v %v", strings.Join(options, " "), strings.ToLower(strings.TrimSpace(header)))
+	}
+
+	return ""
+}
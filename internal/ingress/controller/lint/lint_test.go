@@ -0,0 +1,125 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	testCases := map[string]struct {
+		content  string
+		expected []Issue
+	}{
+		"no issues": {
+			content: `
+server {
+    server_name foo.bar;
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Request-ID $req_id;
+    }
+}`,
+			expected: []Issue{},
+		},
+		"duplicated directive": {
+			content: `
+server {
+    location /foo {
+        proxy_buffering off;
+        proxy_buffering off;
+    }
+}`,
+			expected: []Issue{{Line: 5, Previous: 4, Directive: "proxy_buffering", Block: "location /foo"}},
+		},
+		"conflicting header": {
+			content: `
+server {
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header host "example.com";
+    }
+}`,
+			expected: []Issue{{Line: 5, Previous: 4, Directive: "proxy_set_header", Block: "location /", Conflict: true}},
+		},
+		"conflicting more_set_headers": {
+			content: `
+server {
+    more_set_headers "Server: foo";
+    more_set_headers -s 404 "Server: bar";
+    more_set_headers "server: baz";
+}`,
+			expected: []Issue{{Line: 5, Previous: 3, Directive: "more_set_headers", Block: "server", Conflict: true}},
+		},
+		"nested blocks are separated": {
+			content: `
+server {
+    set $proxy_upstream_name "-";
+    location / {
+        set $proxy_upstream_name "default-foo-80";
+        if ($arg_debug) {
+            return 403;
+        }
+        return 404;
+    }
+}`,
+			expected: []Issue{},
+		},
+		"blocks other than server and location": {
+			content: `
+http {
+    include mime.types;
+    include mime.types;
+    map $http_upgrade $connection_upgrade {
+        default upgrade;
+        default close;
+    }
+}`,
+			expected: []Issue{},
+		},
+		"lua blocks, comments and quotes": {
+			content: `
+server {
+    # proxy_buffering off;
+    proxy_buffering off;
+    access_by_lua_block {
+        local s = "}"
+        ngx.var.foo = "bar;"
+    }
+    add_header X-Foo "a;b # c";
+    add_header X-Foo "a;b # d";
+}`,
+			expected: []Issue{{Line: 10, Previous: 9, Directive: "add_header", Block: "server", Conflict: true}},
+		},
+	}
+
+	for title, tc := range testCases {
+		issues := Lint([]byte(tc.content))
+		if !reflect.DeepEqual(issues, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", title, tc.expected, issues)
+		}
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	issue := Issue{Line: 12, Previous: 10, Directive: "proxy_set_header", Block: "location /", Conflict: true}
+	expected := "conflicting directive proxy_set_header in location / at line 12 (previous definition at line 10)"
+	if issue.String() != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, issue.String())
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+)
+
+// directive is a simple directive of a block
+type directive struct {
+	name string
+	args []string
+	line int
+}
+
+// block is a block directive, like server or location
+type block struct {
+	name       string
+	args       []string
+	line       int
+	directives []*directive
+}
+
+// rawBlockSuffix is the suffix of the blocks whose content is not an NGINX
+// configuration, like the Lua code of content_by_lua_block
+const rawBlockSuffix = "_by_lua_block"
+
+// parse returns all the blocks of the configuration, in the order of their
+// opening brace. It does not validate the syntax, which is the job of
+// nginx -t
+func parse(content []byte) []*block {
+	blocks := []*block{}
+	// the main context, for the directives outside of any block
+	stack := []*block{{}}
+
+	words := []string{}
+	wordsLine := 0
+	var word strings.Builder
+	inWord := false
+
+	line := 1
+	flush := func() {
+		if !inWord {
+			return
+		}
+		if len(words) == 0 {
+			wordsLine = line
+		}
+		words = append(words, word.String())
+		word.Reset()
+		inWord = false
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\n':
+			flush()
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && !inWord:
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '"' || c == '\'':
+			if !inWord && len(words) == 0 {
+				wordsLine = line
+			}
+			inWord = true
+			end := skipQuoted(content, i)
+			quoted := content[i:end]
+			line += strings.Count(string(quoted), "\n")
+			word.Write(quoted)
+			i = end - 1
+		case c == ';':
+			flush()
+			if len(words) > 0 {
+				current := stack[len(stack)-1]
+				current.directives = append(current.directives, &directive{
+					name: words[0],
+					args: append([]string{}, words[1:]...),
+					line: wordsLine,
+				})
+			}
+			words = words[:0]
+		case c == '{':
+			flush()
+			b := &block{line: line}
+			if len(words) > 0 {
+				b.name = words[0]
+				b.args = append([]string{}, words[1:]...)
+				b.line = wordsLine
+			}
+			words = words[:0]
+
+			if strings.HasSuffix(b.name, rawBlockSuffix) {
+				end := skipRawBlock(content, i)
+				line += strings.Count(string(content[i:end]), "\n")
+				i = end - 1
+				continue
+			}
+
+			blocks = append(blocks, b)
+			stack = append(stack, b)
+		case c == '}':
+			flush()
+			words = words[:0]
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			if !inWord && len(words) == 0 {
+				wordsLine = line
+			}
+			inWord = true
+			word.WriteByte(c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				word.WriteByte(content[i])
+			}
+		}
+	}
+
+	return blocks
+}
+
+// skipQuoted returns the position after the closing quote of the string
+// that starts at the position start
+func skipQuoted(content []byte, start int) int {
+	quote := content[start]
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+
+	return len(content)
+}
+
+// skipRawBlock returns the position after the brace that closes the block
+// whose opening brace is at the position start
+func skipRawBlock(content []byte, start int) int {
+	depth := 0
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '"', '\'':
+			i = skipQuoted(content, i) - 1
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(content)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/lint"
+)
+
+func TestSnippetOfIssue(t *testing.T) {
+	content := []byte(`http {
+    ## start server foo.bar
+    server {
+        server_name foo.bar ;
+        more_set_headers "Server: foo";
+        location / {
+            proxy_set_header Host $host;
+            proxy_set_header Host "example.com";
+        }
+        location /api {
+            proxy_buffering off;
+            proxy_buffering off;
+            more_set_headers "Server: bar";
+        }
+    }
+    ## end server foo.bar
+}
+`)
+
+	app := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	api := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api", Annotations: map[string]string{
+		"nginx.ingress.kubernetes.io/server-snippet": `more_set_headers "Server: foo";`,
+	}}}
+	servers := []*ingress.Server{
+		{Hostname: "foo.bar", ServerSnippet: `more_set_headers "Server: foo";`, Locations: []*ingress.Location{
+			{Path: "/", Ingress: app, ConfigurationSnippet: `proxy_set_header Host "example.com";`},
+			{Path: "/api", Ingress: api},
+		}},
+	}
+
+	testCases := []struct {
+		title   string
+		issue   lint.Issue
+		snippet string
+		ing     *extensions.Ingress
+	}{
+		{"configuration snippet", lint.Issue{Line: 8, Previous: 7}, `proxy_set_header Host "example.com";`, app},
+		{"template", lint.Issue{Line: 12, Previous: 11}, "", nil},
+		{"previous definition", lint.Issue{Line: 13, Previous: 5}, `more_set_headers "Server: foo";`, api},
+		{"outside of a server", lint.Issue{Line: 17, Previous: 1}, "", nil},
+	}
+
+	for _, tc := range testCases {
+		snippet, ing := snippetOfIssue(content, servers, tc.issue)
+		if snippet != tc.snippet {
+			t.Errorf("%v: expected the snippet %q but got %q", tc.title, tc.snippet, snippet)
+		}
+		if ing != tc.ing {
+			t.Errorf("%v: expected the ingress %v but got %v", tc.title, tc.ing, ing)
+		}
+	}
+}
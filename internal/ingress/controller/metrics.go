@@ -17,6 +17,9 @@ limitations under the License.
 package controller
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"k8s.io/ingress-nginx/internal/ingress"
@@ -28,12 +31,26 @@ const (
 	reloadLabel    = "reloads"
 	sslLabelExpire = "ssl_expire_time_seconds"
 	sslLabelHost   = "host"
+
+	passthroughStatus    = "status"
+	passthroughAccepted  = "accepted"
+	passthroughRejected  = "rejected"
+	passthroughFailed    = "failed"
+	passthroughDirection = "direction"
+	passthroughSent      = "sent"
+	passthroughReceived  = "received"
 )
 
 func init() {
 	prometheus.MustRegister(reloadOperation)
 	prometheus.MustRegister(reloadOperationErrors)
+	prometheus.MustRegister(lastReloadSuccessful)
+	prometheus.MustRegister(configHash)
+	prometheus.MustRegister(configLastReloadTime)
 	prometheus.MustRegister(sslExpireTime)
+	prometheus.MustRegister(sslPassthroughConnections)
+	prometheus.MustRegister(sslPassthroughActiveConnections)
+	prometheus.MustRegister(sslPassthroughBytes)
 }
 
 var (
@@ -53,6 +70,28 @@ var (
 		},
 		[]string{operation},
 	)
+	lastReloadSuccessful = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful (1) or the previous " +
+				"configuration is still in use (0)",
+		},
+	)
+	configHash = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "config_hash",
+			Help:      "Hash of the configuration loaded by NGINX (first 48 bits of the SHA256 checksum)",
+		},
+	)
+	configLastReloadTime = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "config_last_reload_successful_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload",
+		},
+	)
 	sslExpireTime = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: ns,
@@ -62,6 +101,30 @@ var (
 		},
 		[]string{sslLabelHost},
 	)
+	sslPassthroughConnections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "ssl_passthrough_connections_total",
+			Help: "Cumulative number of connections handled by the SSL passthrough proxy, by status: " +
+				"accepted, rejected (connection limit reached) or failed",
+		},
+		[]string{passthroughStatus},
+	)
+	sslPassthroughActiveConnections = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "ssl_passthrough_active_connections",
+			Help:      "Number of connections currently proxied by the SSL passthrough proxy",
+		},
+	)
+	sslPassthroughBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "ssl_passthrough_bytes_total",
+			Help:      "Cumulative number of bytes sent to and received from the servers by the SSL passthrough proxy",
+		},
+		[]string{passthroughDirection},
+	)
 )
 
 func incReloadCount() {
@@ -72,6 +135,26 @@ func incReloadErrorCount() {
 	reloadOperationErrors.WithLabelValues(reloadLabel).Inc()
 }
 
+func setLastReloadSuccessful(ok bool) {
+	if ok {
+		lastReloadSuccessful.Set(1)
+		return
+	}
+	lastReloadSuccessful.Set(0)
+}
+
+// setConfigHash exposes the checksum of the loaded configuration. Only 48
+// bits are used, so the value is represented exactly as a float64
+func setConfigHash(checksum string, reload time.Time) {
+	if len(checksum) >= 12 {
+		hash, err := strconv.ParseUint(checksum[:12], 16, 64)
+		if err == nil {
+			configHash.Set(float64(hash))
+		}
+	}
+	configLastReloadTime.Set(float64(reload.Unix()))
+}
+
 func setSSLExpireTime(servers []*ingress.Server) {
 	for _, s := range servers {
 		if s.Hostname != defServerName {
@@ -22,11 +22,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -35,21 +37,27 @@ import (
 	proxyproto "github.com/armon/go-proxyproto"
 	apiv1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/kubernetes/pkg/util/filesystem"
 
+	adm_controller "k8s.io/ingress-nginx/internal/admission/controller"
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/process"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/status"
+	"k8s.io/ingress-nginx/internal/k8s"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/net/dns"
 	"k8s.io/ingress-nginx/internal/net/ssl"
@@ -81,10 +89,20 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 		ngx = nginxBinary
 	}
 
+	namespaces := config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{config.Namespace}
+	}
+
+	eventsNamespace := apiv1.NamespaceAll
+	if len(namespaces) == 1 {
+		eventsNamespace = namespaces[0]
+	}
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
 	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
-		Interface: config.Client.CoreV1().Events(config.Namespace),
+		Interface: config.Client.CoreV1().Events(eventsNamespace),
 	})
 
 	h, err := dns.GetSystemNameServers()
@@ -92,6 +110,12 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 		glog.Warningf("unexpected error reading system nameservers: %v", err)
 	}
 
+	// the hostname of the pod is the name of the pod
+	replicaID, err := os.Hostname()
+	if err != nil {
+		glog.Warningf("unexpected error reading the hostname: %v", err)
+	}
+
 	n := &NGINXController{
 		binary: ngx,
 
@@ -109,37 +133,66 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 		updateCh: make(chan store.Event, 1024),
 
 		stopLock: &sync.Mutex{},
+		syncLock: &sync.Mutex{},
+
+		syncGeneration: new(uint64),
 
 		fileSystem: fs,
 
 		// create an empty configuration.
 		runningConfig: &ingress.Configuration{},
 
+		configBuffer:     &bytes.Buffer{},
+		replicaID:        replicaID,
+		rejectedSnippets: newRejectedSnippets(),
+
+		proxyProtocolV2: newProxyProtocolV2(),
+
+		serverFiles: newServerFiles(serversDir),
+
 		Proxy: &TCPProxy{},
 	}
 
 	n.store = store.New(
 		config.EnableSSLChainCompletion,
-		config.Namespace,
+		namespaces,
 		config.ConfigMapName,
 		config.TCPConfigMapName,
 		config.UDPConfigMapName,
 		config.DefaultSSLCertificate,
+		config.TemplateConfigMap,
 		config.ResyncPeriod,
 		config.Client,
+		config.StreamRouteClient,
+		config.ReferenceGrantClient,
+		config.NginxDefaultsClient,
+		config.GatewayClient,
+		config.EndpointSliceClient,
 		fs,
 		n.updateCh)
 
 	n.stats = newStatsCollector(config.Namespace, class.IngressClass, n.binary, n.cfg.ListenPorts.Status)
 
-	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+	n.syncQueue = task.NewPoolTaskQueue(n.syncIngress, config.SyncBatchWindow, config.SyncWorkers, config.EndpointsSyncRateLimit)
 
 	n.annotations = annotations.NewAnnotationExtractor(n.store)
 
+	if config.ValidationWebhook != "" {
+		n.validationWebhookServer = &http.Server{
+			Addr: config.ValidationWebhook,
+			Handler: adm_controller.NewAdmissionControllerServer(&adm_controller.IngressAdmission{
+				Checker: n,
+			}),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+	}
+
 	if config.UpdateStatus {
 		n.syncStatus = status.NewStatusSyncer(status.Config{
 			Client:                 config.Client,
 			PublishService:         config.PublishService,
+			PublishStatusAddress:   config.PublishStatusAddress,
 			IngressLister:          n.store,
 			ElectionID:             config.ElectionID,
 			IngressClass:           class.IngressClass,
@@ -151,9 +204,17 @@ func NewNGINXController(config *Configuration, fs file.Filesystem) *NGINXControl
 		glog.Warning("Update of ingress status is disabled (flag --update-status=false was specified)")
 	}
 
+	templatePath := tmplPath
+	if config.TemplatePath != "" {
+		templatePath = config.TemplatePath
+	}
+
 	var onChange func()
 	onChange = func() {
-		template, err := ngx_template.NewTemplate(tmplPath, fs)
+		template, err := ngx_template.NewTemplate(templatePath, fs)
+		if err == nil {
+			err = template.LoadPartials(config.TemplateIncludeDir, fs)
+		}
 		if err != nil {
 			// this error is different from the rest because it must be clear why nginx is not working
 			glog.Errorf(`
@@ -169,20 +230,30 @@ Error loading new template : %v
 		n.SetForceReload(true)
 	}
 
-	ngxTpl, err := ngx_template.NewTemplate(tmplPath, fs)
+	ngxTpl, err := ngx_template.NewTemplate(templatePath, fs)
 	if err != nil {
 		glog.Fatalf("invalid NGINX template: %v", err)
 	}
 
+	err = ngxTpl.LoadPartials(config.TemplateIncludeDir, fs)
+	if err != nil {
+		glog.Fatalf("invalid NGINX template partials: %v", err)
+	}
+
 	n.t = ngxTpl
 
+	// the template of the ConfigMap replaces the template file in the first sync
+	if config.TemplateConfigMap != "" {
+		return n
+	}
+
 	// TODO: refactor
 	if _, ok := fs.(filesystem.DefaultFs); !ok {
-		watch.NewDummyFileWatcher(tmplPath, onChange)
+		watch.NewDummyFileWatcher(templatePath, onChange)
 	} else {
-		_, err = watch.NewFileWatcher(tmplPath, onChange)
+		_, err = watch.NewFileWatcher(templatePath, onChange)
 		if err != nil {
-			glog.Fatalf("unexpected error watching template %v: %v", tmplPath, err)
+			glog.Fatalf("unexpected error watching template %v: %v", templatePath, err)
 		}
 	}
 
@@ -203,6 +274,26 @@ type NGINXController struct {
 
 	syncRateLimiter flowcontrol.RateLimiter
 
+	// syncLock serializes the reloads of the workers of syncQueue
+	syncLock *sync.Mutex
+
+	// syncGeneration orders the configurations generated by the workers
+	syncGeneration *uint64
+	// appliedGeneration is the generation of the last configuration
+	// processed while holding syncLock
+	appliedGeneration uint64
+
+	// templateChecksum is the checksum of the last content
+	// of the template ConfigMap loaded
+	templateChecksum string
+
+	// lastReload is the time of the last reload of NGINX
+	lastReload time.Time
+
+	// configChecksum contains the checksum of the configuration
+	// loaded by NGINX (configurationChecksum)
+	configChecksum atomic.Value
+
 	// stopLock is used to enforce only a single call to Stop is active.
 	// Needed because we allow stopping through an http endpoint and
 	// allowing concurrent stoppers leads to stack traces.
@@ -217,6 +308,26 @@ type NGINXController struct {
 	// runningConfig contains the running configuration in the Backend
 	runningConfig *ingress.Configuration
 
+	// configBuffer contains the configuration rendered in the last sync.
+	// It is reused by the syncs, which are serialized by syncLock
+	configBuffer *bytes.Buffer
+
+	// replicaID identifies the replica of the ingress controller in the
+	// order of the endpoints of the upstreams
+	replicaID string
+
+	// rejectedSnippets contains the snippets of the
+	// Ingresses that generated an invalid configuration
+	rejectedSnippets rejectedSnippets
+
+	// proxyProtocolV2 sends the version 2 of the PROXY protocol header
+	// to the endpoints of the TCP services that require it
+	proxyProtocolV2 *proxyProtocolV2
+
+	// serverFiles writes the configuration file of each server if the
+	// configuration is split per server
+	serverFiles *serverFiles
+
 	forceReload int32
 
 	t *ngx_template.Template
@@ -237,6 +348,9 @@ type NGINXController struct {
 	store store.Storer
 
 	fileSystem filesystem.Filesystem
+
+	// validationWebhookServer serves the validating admission webhook
+	validationWebhookServer *http.Server
 }
 
 // Start start a new NGINX master process running in foreground.
@@ -245,6 +359,16 @@ func (n *NGINXController) Start() {
 
 	n.store.Run(n.stopCh)
 
+	if n.validationWebhookServer != nil {
+		glog.Infof("starting validating admission webhook on %v", n.validationWebhookServer.Addr)
+		go func() {
+			err := n.validationWebhookServer.ListenAndServeTLS(n.cfg.ValidationWebhookCertPath, n.cfg.ValidationWebhookKeyPath)
+			if err != http.ErrServerClosed {
+				glog.Fatalf("unexpected error serving the validating admission webhook: %v", err)
+			}
+		}()
+	}
+
 	if n.syncStatus != nil {
 		go n.syncStatus.Run()
 	}
@@ -299,13 +423,68 @@ func (n *NGINXController) Start() {
 				n.SetForceReload(true)
 			}
 
-			n.syncQueue.Enqueue(evt.Obj)
+			if ing := deletedIngress(evt); ing != nil {
+				n.rejectedSnippets.forget(k8s.MetaNamespaceKey(ing))
+				maintenance.RemovePage(maintenance.PageDirectory, ing)
+			}
+
+			if isLowPriorityEvent(evt) {
+				n.syncQueue.EnqueueLowPriority(evt.Obj)
+			} else {
+				n.syncQueue.Enqueue(evt.Obj)
+			}
 		case <-n.stopCh:
 			break
 		}
 	}
 }
 
+// isLowPriorityEvent returns true if the event only changes the endpoints of
+// a Service. These events are rate limited separately from the changes of
+// Ingresses, Secrets and ConfigMaps.
+func isLowPriorityEvent(evt store.Event) bool {
+	if evt.Type == store.ConfigurationEvent {
+		return false
+	}
+
+	obj := evt.Obj
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	switch obj.(type) {
+	case *apiv1.Endpoints, *discovery.EndpointSlice:
+		return true
+	}
+
+	return false
+}
+
+// deletedIngress returns the Ingress removed by an event, if any
+func deletedIngress(evt store.Event) *extensions.Ingress {
+	if evt.Type != store.DeleteEvent {
+		return nil
+	}
+
+	obj := evt.Obj
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	ing, _ := obj.(*extensions.Ingress)
+	return ing
+}
+
+// LeaderElection returns the state of the leader election of the status
+// updates, or false if the status of the Ingresses is not updated
+func (n *NGINXController) LeaderElection() (status.ElectionState, bool) {
+	if n.syncStatus == nil {
+		return status.ElectionState{}, false
+	}
+
+	return n.syncStatus.Election(), true
+}
+
 // Stop gracefully stops the NGINX master process.
 func (n *NGINXController) Stop() error {
 	n.isShuttingDown = true
@@ -325,6 +504,18 @@ func (n *NGINXController) Stop() error {
 		n.syncStatus.Shutdown()
 	}
 
+	if n.validationWebhookServer != nil {
+		glog.Info("stopping validating admission webhook")
+		n.validationWebhookServer.Close()
+	}
+
+	// the readiness probe fails during the grace period, so the load
+	// balancers stop sending new connections before NGINX is stopped
+	if n.cfg.ShutdownGracePeriod > 0 {
+		glog.Infof("waiting %v before stopping NGINX", n.cfg.ShutdownGracePeriod)
+		time.Sleep(n.cfg.ShutdownGracePeriod)
+	}
+
 	// Send stop signal to Nginx
 	glog.Info("stopping NGINX process...")
 	cmd := exec.Command(n.binary, "-c", cfgPath, "-s", "quit")
@@ -335,7 +526,8 @@ func (n *NGINXController) Stop() error {
 		return err
 	}
 
-	// Wait for the Nginx process disappear
+	// Wait for the Nginx processes, including the workers
+	// draining the connections, to disappear
 	timer := time.NewTicker(time.Second * 1)
 	for range timer.C {
 		if !process.IsNginxRunning() {
@@ -382,7 +574,7 @@ func (n NGINXController) testTemplate(cfg []byte) error {
 		return err
 	}
 	defer tmpfile.Close()
-	err = ioutil.WriteFile(tmpfile.Name(), cfg, 0644)
+	_, err = tmpfile.Write(cfg)
 	if err != nil {
 		return err
 	}
@@ -448,9 +640,12 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 			})
 		}
 
-		n.Proxy.ServerList = servers
+		n.Proxy.SetServers(servers)
 	}
 
+	// the sockets must exist before NGINX sends connections to them
+	n.proxyProtocolV2.Update(ingressCfg.TCPEndpoints)
+
 	// we need to check if the status module configuration changed
 	if cfg.EnableVtsStatus {
 		n.setupMonitor(vtsStatusModule)
@@ -458,6 +653,131 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		n.setupMonitor(defaultStatusModule)
 	}
 
+	tc := n.templateConfig(cfg, ingressCfg)
+	tc.Servers = n.checkSnippets(tc.Servers, cfg.SnippetDirectivesBlocklist)
+
+	// the configuration is rendered in the same buffer in every sync,
+	// avoiding the allocation of the whole configuration each time
+	n.configBuffer.Reset()
+	err := n.t.WriteTo(n.configBuffer, tc)
+	if err != nil {
+		n.reportTemplateError(tc, err)
+		return err
+	}
+	content := n.configBuffer.Bytes()
+
+	err = n.testTemplate(content)
+	if err != nil {
+		testErr := err
+		invalid := content
+		content, err = n.testSnippets(tc, err)
+		if err != nil {
+			n.reportInvalidConfiguration(invalid, tc.Servers, testErr)
+			return err
+		}
+	}
+
+	content, err = n.lintConfiguration(tc, content)
+	if err != nil {
+		return err
+	}
+
+	// the previous configuration is restored if the reload fails
+	src, _ := ioutil.ReadFile(cfgPath)
+
+	main, files := content, map[string][]byte(nil)
+	if n.cfg.SplitServerConfiguration {
+		main, files = splitServers(content, n.serverFiles.dir)
+	}
+
+	if glog.V(2) {
+		var diffOutput []byte
+		if files != nil {
+			diffOutput, err = diffServerFiles(cfgPath, n.serverFiles.dir, main, files)
+		} else if !bytes.Equal(src, content) {
+			diffOutput, err = diffConfiguration(cfgPath, content)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(diffOutput) > 0 {
+			glog.Infof("NGINX configuration diff\n")
+			glog.Infof("%v\n", string(diffOutput))
+		}
+	}
+
+	restoreServers := func() {}
+	if files != nil {
+		restoreServers, err = n.serverFiles.write(files)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ioutil.WriteFile(cfgPath, main, 0644)
+	if err != nil {
+		restoreServers()
+		return err
+	}
+
+	o, err := exec.Command(n.binary, "-s", "reload", "-c", cfgPath).CombinedOutput()
+	if err != nil {
+		if len(src) > 0 {
+			glog.Warningf("reload failed, restoring the previous configuration")
+			if werr := ioutil.WriteFile(cfgPath, src, 0644); werr != nil {
+				glog.Errorf("unexpected error restoring the previous configuration: %v", werr)
+			}
+			restoreServers()
+		}
+		return fmt.Errorf("%v\n%v", err, string(o))
+	}
+
+	n.setConfigurationChecksum(content)
+
+	return nil
+}
+
+// diffConfiguration returns the unified diff between the
+// configuration file in path and the given configuration
+func diffConfiguration(path string, content []byte) ([]byte, error) {
+	tmpfile, err := ioutil.TempFile("", "new-nginx-cfg")
+	if err != nil {
+		return nil, err
+	}
+	defer tmpfile.Close()
+	err = ioutil.WriteFile(tmpfile.Name(), content, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// executing diff can return exit code != 0. A missing
+	// file (e.g. the file of a new server) is treated as empty
+	diffOutput, _ := exec.Command("diff", "-uN", path, tmpfile.Name()).CombinedOutput()
+
+	// Do not use defer to remove the temporal file.
+	// This is helpful when there is an error in the
+	// temporal configuration (we can manually inspect the file).
+	// Only remove the file when no error occurred.
+	os.Remove(tmpfile.Name())
+
+	return diffOutput, nil
+}
+
+// templateConfig returns the configuration used to render the template
+// of the given ingress configuration, including the snippets of all the servers
+func (n *NGINXController) templateConfig(cfg ngx_config.Configuration, ingressCfg ingress.Configuration) ngx_config.TemplateConfig {
+	dynamicBackendNames := sets.NewString()
+	if n.cfg.DynamicConfigurationEnabled {
+		dynamicBackendNames = dynamicBackends(&ingressCfg, cfg.LoadBalanceAlgorithm)
+	}
+
+	if n.cfg.DynamicServersEnabled {
+		// the dynamic servers are routed by Lua in the default server
+		servers := dynamicServers(&ingressCfg, dynamicBackendNames, n.cfg.DynamicCertificatesEnabled)
+		ingressCfg.Servers = withoutServers(ingressCfg.Servers, servers)
+	}
+
 	// NGINX cannot resize the hash tables used to store server names.
 	// For this reason we check if the defined size defined is correct
 	// for the FQDN defined in the ingress rules adjusting the value
@@ -466,34 +786,13 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 	// https://trac.nginx.org/nginx/ticket/631
 	var longestName int
 	var serverNameBytes int
-	redirectServers := make(map[string]string)
 	for _, srv := range ingressCfg.Servers {
 		if longestName < len(srv.Hostname) {
 			longestName = len(srv.Hostname)
 		}
 		serverNameBytes += len(srv.Hostname)
-		if srv.RedirectFromToWWW {
-			var n string
-			if strings.HasPrefix(srv.Hostname, "www.") {
-				n = strings.TrimLeft(srv.Hostname, "www.")
-			} else {
-				n = fmt.Sprintf("www.%v", srv.Hostname)
-			}
-			glog.V(3).Infof("creating redirect from %v to %v", srv.Hostname, n)
-			if _, ok := redirectServers[n]; !ok {
-				found := false
-				for _, esrv := range ingressCfg.Servers {
-					if esrv.Hostname == n {
-						found = true
-						break
-					}
-				}
-				if !found {
-					redirectServers[n] = srv.Hostname
-				}
-			}
-		}
 	}
+	redirectServers := buildRedirectServers(ingressCfg.Servers)
 	if cfg.ServerNameHashBucketSize == 0 {
 		nameHashBucketSize := nginxHashBucketSize(longestName)
 		glog.V(3).Infof("adjusting ServerNameHashBucketSize variable to %v", nameHashBucketSize)
@@ -581,57 +880,14 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		IsSSLPassthroughEnabled: n.cfg.EnableSSLPassthrough,
 		ListenPorts:             n.cfg.ListenPorts,
 		PublishService:          n.GetPublishService(),
-	}
-
-	content, err := n.t.Write(tc)
 
-	if err != nil {
-		return err
+		DynamicConfigurationEnabled: n.cfg.DynamicConfigurationEnabled,
+		DynamicBackends:             dynamicBackendNames,
+		DynamicCertificatesEnabled:  n.cfg.DynamicCertificatesEnabled,
+		DynamicServersEnabled:       n.cfg.DynamicServersEnabled,
 	}
 
-	err = n.testTemplate(content)
-	if err != nil {
-		return err
-	}
-
-	if glog.V(2) {
-		src, _ := ioutil.ReadFile(cfgPath)
-		if !bytes.Equal(src, content) {
-			tmpfile, err := ioutil.TempFile("", "new-nginx-cfg")
-			if err != nil {
-				return err
-			}
-			defer tmpfile.Close()
-			err = ioutil.WriteFile(tmpfile.Name(), content, 0644)
-			if err != nil {
-				return err
-			}
-
-			// executing diff can return exit code != 0
-			diffOutput, _ := exec.Command("diff", "-u", cfgPath, tmpfile.Name()).CombinedOutput()
-
-			glog.Infof("NGINX configuration diff\n")
-			glog.Infof("%v\n", string(diffOutput))
-
-			// Do not use defer to remove the temporal file.
-			// This is helpful when there is an error in the
-			// temporal configuration (we can manually inspect the file).
-			// Only remove the file when no error occurred.
-			os.Remove(tmpfile.Name())
-		}
-	}
-
-	err = ioutil.WriteFile(cfgPath, content, 0644)
-	if err != nil {
-		return err
-	}
-
-	o, err := exec.Command(n.binary, "-s", "reload", "-c", cfgPath).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%v\n%v", err, string(o))
-	}
-
-	return nil
+	return tc
 }
 
 // nginxHashBucketSize computes the correct nginx hash_bucket_size for a hash with the given longest key
@@ -670,6 +926,7 @@ func (n *NGINXController) setupSSLProxy() {
 			Port:          proxyPort,
 			ProxyProtocol: true,
 		},
+		MaxConnections: int64(n.cfg.SSLPassthroughMaxConnections),
 	}
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%v", sslPort))
@@ -703,3 +960,57 @@ func (n *NGINXController) setupSSLProxy() {
 		}
 	}()
 }
+
+// buildRedirectServers returns the servers required to redirect the requests
+// from/to www in the servers with the annotation from-to-www-redirect.
+// The redirect accepts HTTPS requests only if the SSL certificate of the
+// server is also valid for the redirected hostname.
+func buildRedirectServers(servers []*ingress.Server) map[string]*ngx_config.RedirectServer {
+	hostnames := sets.NewString()
+	for _, srv := range servers {
+		hostnames.Insert(srv.Hostname)
+	}
+
+	redirectServers := make(map[string]*ngx_config.RedirectServer)
+	for _, srv := range servers {
+		if !srv.RedirectFromToWWW {
+			continue
+		}
+
+		var from string
+		if strings.HasPrefix(srv.Hostname, "www.") {
+			from = strings.TrimPrefix(srv.Hostname, "www.")
+		} else {
+			from = fmt.Sprintf("www.%v", srv.Hostname)
+		}
+
+		if hostnames.Has(from) {
+			glog.Warningf("ignoring redirect from %v to %v because a server for %v already exists", from, srv.Hostname, from)
+			continue
+		}
+
+		if _, ok := redirectServers[from]; ok {
+			continue
+		}
+
+		glog.V(3).Infof("creating redirect from %v to %v", from, srv.Hostname)
+		rs := &ngx_config.RedirectServer{
+			To: srv.Hostname,
+		}
+
+		for _, name := range srv.SSLCertificateNames {
+			if matchHostnames(toLowerCaseASCII(name), toLowerCaseASCII(from)) {
+				rs.SSLCertificate = srv.SSLCertificate
+				break
+			}
+		}
+
+		if rs.SSLCertificate == "" && srv.SSLCertificate != "" {
+			glog.Warningf("the SSL certificate of server %v is not valid for %v, the redirect only accepts HTTP requests", srv.Hostname, from)
+		}
+
+		redirectServers[from] = rs
+	}
+
+	return redirectServers
+}
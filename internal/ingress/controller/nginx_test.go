@@ -16,7 +16,19 @@ limitations under the License.
 
 package controller
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
 
 func TestNginxHashBucketSize(t *testing.T) {
 	tests := []struct {
@@ -113,3 +125,58 @@ func TestNextPowerOf2(t *testing.T) {
 		t.Errorf("TestNextPowerOf2: expected %d but returned %d.", 0, actual)
 	}
 }
+
+func TestBuildRedirectServers(t *testing.T) {
+	servers := []*ingress.Server{
+		{Hostname: "foo.bar", RedirectFromToWWW: true},
+		{
+			Hostname:            "www.example.com",
+			RedirectFromToWWW:   true,
+			SSLCertificate:      "/etc/ingress-controller/ssl/default-example.pem",
+			SSLCertificateNames: []string{"example.com", "www.example.com"},
+		},
+		{
+			Hostname:            "wildcard.com",
+			RedirectFromToWWW:   true,
+			SSLCertificate:      "/etc/ingress-controller/ssl/default-wildcard.pem",
+			SSLCertificateNames: []string{"*.wildcard.com"},
+		},
+		{Hostname: "www.web.com", RedirectFromToWWW: true},
+		{Hostname: "web.com"},
+		{Hostname: "other.com"},
+	}
+
+	expected := map[string]*ngx_config.RedirectServer{
+		"www.foo.bar": {To: "foo.bar"},
+		"example.com": {To: "www.example.com", SSLCertificate: "/etc/ingress-controller/ssl/default-example.pem"},
+		"www.wildcard.com": {
+			To:             "wildcard.com",
+			SSLCertificate: "/etc/ingress-controller/ssl/default-wildcard.pem",
+		},
+	}
+
+	redirects := buildRedirectServers(servers)
+	if !reflect.DeepEqual(redirects, expected) {
+		t.Errorf("expected %v but returned %v", expected, redirects)
+	}
+}
+
+func TestIsLowPriorityEvent(t *testing.T) {
+	testCases := []struct {
+		event    store.Event
+		expected bool
+	}{
+		{store.Event{Type: store.UpdateEvent, Obj: &apiv1.Endpoints{}}, true},
+		{store.Event{Type: store.CreateEvent, Obj: &discovery.EndpointSlice{}}, true},
+		{store.Event{Type: store.DeleteEvent, Obj: cache.DeletedFinalStateUnknown{Obj: &apiv1.Endpoints{}}}, true},
+		{store.Event{Type: store.ConfigurationEvent, Obj: &apiv1.Endpoints{}}, false},
+		{store.Event{Type: store.UpdateEvent, Obj: &apiv1.Secret{}}, false},
+		{store.Event{Type: store.UpdateEvent, Obj: &extensions.Ingress{}}, false},
+	}
+
+	for _, tc := range testCases {
+		if result := isLowPriorityEvent(tc.event); result != tc.expected {
+			t.Errorf("expected %v but returned %v for event %v", tc.expected, result, tc.event)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/net/proxyprotocol"
+)
+
+const (
+	// proxyProtocolHeaderTimeout is the time to wait for the header sent by NGINX
+	proxyProtocolHeaderTimeout = 5 * time.Second
+	// proxyProtocolConnectTimeout is the timeout to establish a connection with
+	// an endpoint (the default value of proxy_connect_timeout in NGINX)
+	proxyProtocolConnectTimeout = 60 * time.Second
+)
+
+// proxyProtocolV2 translates the version 1 PROXY protocol header sent by NGINX
+// (the only version it is able to send) to the version 2. The connections of
+// the TCP services that require the version 2 are sent by NGINX to a unix
+// socket for each endpoint and the ingress controller sends them to the
+// endpoint replacing the header.
+type proxyProtocolV2 struct {
+	mu        *sync.Mutex
+	listeners map[string]*proxyProtocolV2Listener
+}
+
+// proxyProtocolV2Listener accepts the connections of the unix socket of an endpoint
+type proxyProtocolV2Listener struct {
+	listener net.Listener
+	target   string
+	// tlvs contains the []proxyprotocol.TLV added to the header
+	tlvs   atomic.Value
+	stopCh chan struct{}
+}
+
+func newProxyProtocolV2() *proxyProtocolV2 {
+	return &proxyProtocolV2{
+		mu:        &sync.Mutex{},
+		listeners: make(map[string]*proxyProtocolV2Listener),
+	}
+}
+
+// Update creates the unix sockets of the endpoints of the TCP services that
+// require the version 2 of the protocol and removes the sockets not used.
+func (p *proxyProtocolV2) Update(services []ingress.L4Service) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	active := sets.NewString()
+	for _, svc := range services {
+		if !svc.Backend.ProxyProtocol.Encode || svc.Backend.ProxyProtocol.Version != 2 {
+			continue
+		}
+
+		var tlvs []proxyprotocol.TLV
+		for _, tlv := range svc.Backend.ProxyProtocol.TLVs {
+			tlvs = append(tlvs, proxyprotocol.TLV{
+				Type:  byte(tlv.Type),
+				Value: []byte(tlv.Value),
+			})
+		}
+
+		for _, ep := range svc.Endpoints {
+			path := proxyprotocol.SocketPath(svc.Port, ep.Address, ep.Port)
+			active.Insert(path)
+
+			l, ok := p.listeners[path]
+			if !ok {
+				var err error
+				l, err = newProxyProtocolV2Listener(path, net.JoinHostPort(ep.Address, ep.Port))
+				if err != nil {
+					glog.Errorf("unexpected error creating the PROXY protocol socket %v: %v", path, err)
+					continue
+				}
+				p.listeners[path] = l
+			}
+
+			l.tlvs.Store(tlvs)
+		}
+	}
+
+	for path, l := range p.listeners {
+		if !active.Has(path) {
+			l.Close()
+			delete(p.listeners, path)
+		}
+	}
+}
+
+func newProxyProtocolV2Listener(path, target string) (*proxyProtocolV2Listener, error) {
+	err := os.MkdirAll(proxyprotocol.SocketDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// remove the socket of a previous execution
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the NGINX workers do not run as root
+	err = os.Chmod(path, 0777)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	l := &proxyProtocolV2Listener{
+		listener: listener,
+		target:   target,
+		stopCh:   make(chan struct{}),
+	}
+	l.tlvs.Store([]proxyprotocol.TLV{})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				select {
+				case <-l.stopCh:
+					return
+				default:
+				}
+				glog.Warningf("unexpected error accepting connection in %v: %v", path, err)
+				continue
+			}
+
+			go l.handle(conn)
+		}
+	}()
+
+	return l, nil
+}
+
+// Close stops accepting connections
+func (l *proxyProtocolV2Listener) Close() {
+	close(l.stopCh)
+	l.listener.Close()
+}
+
+// handle replaces the header sent by NGINX and sends the connection to the endpoint
+func (l *proxyProtocolV2Listener) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(proxyProtocolHeaderTimeout))
+	src, dst, err := proxyprotocol.ReadV1Header(r)
+	if err != nil {
+		glog.Warningf("unexpected error reading PROXY protocol header: %v", err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	upstream, err := net.DialTimeout("tcp", l.target, proxyProtocolConnectTimeout)
+	if err != nil {
+		glog.Warningf("unexpected error connecting to %v: %v", l.target, err)
+		return
+	}
+	defer upstream.Close()
+
+	tlvs := l.tlvs.Load().([]proxyprotocol.TLV)
+	_, err = upstream.Write(proxyprotocol.V2Header(src, dst, tlvs))
+	if err != nil {
+		glog.Warningf("unexpected error writing PROXY protocol header to %v: %v", l.target, err)
+		return
+	}
+
+	done := make(chan bool, 2)
+	go func() {
+		io.Copy(upstream, r)
+		done <- true
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- true
+	}()
+
+	<-done
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+// maxErrorExcerpt is the maximum length of the NGINX error
+// included in the events of the Ingresses
+const maxErrorExcerpt = 512
+
+// maxEventMessage is the maximum length of the message of the events
+// emitted when the configuration is not valid
+const maxEventMessage = 1024
+
+// nginxErrorRegex matches the errors of "nginx -t" that include the
+// line of the configuration, like:
+// nginx: [emerg] unknown directive "foo" in /tmp/nginx-cfg123:42
+var nginxErrorRegex = regexp.MustCompile(`\[emerg\] (.*) in \S+:(\d+)`)
+
+// reportInvalidConfiguration logs the lines of the configuration around the
+// error of "nginx -t" and emits a warning event in the Ingresses that define
+// the server where the test of the configuration failed. The running
+// configuration of NGINX is not modified.
+func (n *NGINXController) reportInvalidConfiguration(content []byte, servers []*ingress.Server, err error) {
+	invalid := describeInvalidConfiguration(content, servers, err)
+	if invalid == nil {
+		return
+	}
+
+	glog.Warningf("invalid NGINX configuration at line %v: %v\n%v", invalid.line, invalid.excerpt, invalid.context)
+	if len(invalid.ingresses) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("the configuration is not valid, the previous configuration is kept: %v", invalid.excerpt)
+	if invalid.annotation != "" {
+		message = fmt.Sprintf("%v (generated by the annotation %v)", message, invalid.annotation)
+	}
+	message = fmt.Sprintf("%v\n%v", message, invalid.context)
+	if len(message) > maxEventMessage {
+		message = message[:maxEventMessage]
+	}
+
+	for _, ing := range invalid.ingresses {
+		glog.Warningf("ingress %v/%v generates an invalid configuration: %v", ing.Namespace, ing.Name, invalid.excerpt)
+		n.recorder.Event(ing, apiv1.EventTypeWarning, "InvalidConfiguration", message)
+	}
+}
+
+// reportTemplateError emits a warning event in the Ingresses of the servers
+// that cannot be rendered by the template. Each server is rendered alone to
+// find them, as the error of the template does not identify the server.
+func (n *NGINXController) reportTemplateError(tc ngx_config.TemplateConfig, err error) {
+	glog.Errorf("unexpected error rendering the NGINX template: %v", err)
+
+	servers := tc.Servers
+	for _, server := range servers {
+		tc.Servers = []*ingress.Server{server}
+		serverErr := n.t.Check(tc)
+		if serverErr == nil {
+			continue
+		}
+
+		message := fmt.Sprintf("the configuration cannot be rendered, the previous configuration is kept: %v", serverErr)
+		if len(message) > maxEventMessage {
+			message = message[:maxEventMessage]
+		}
+
+		for _, ing := range serverIngresses(server) {
+			glog.Warningf("ingress %v/%v cannot be rendered by the template", ing.Namespace, ing.Name)
+			n.recorder.Event(ing, apiv1.EventTypeWarning, "InvalidConfiguration", message)
+		}
+	}
+}
+
+// invalidConfiguration describes the error of the test of a configuration
+type invalidConfiguration struct {
+	// excerpt is the error reported by NGINX
+	excerpt string
+	// line is the line of the configuration reported in the error
+	line int
+	// context contains the lines of the configuration around line
+	context string
+	// ingresses define the server of the line of the error
+	ingresses []*extensions.Ingress
+	// annotation is the snippet annotation that generated the line
+	annotation string
+}
+
+// describeInvalidConfiguration returns the line of the configuration
+// reported in the error of "nginx -t", the lines around it, and the
+// Ingresses and annotation that generated it. No Ingresses are returned if
+// the error is not located in a server block, and nil is returned if the
+// error does not include a line
+func describeInvalidConfiguration(content []byte, servers []*ingress.Server, err error) *invalidConfiguration {
+	match := nginxErrorRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil
+	}
+
+	excerpt := match[1]
+	if len(excerpt) > maxErrorExcerpt {
+		excerpt = excerpt[:maxErrorExcerpt]
+	}
+
+	line, _ := strconv.Atoi(match[2])
+	invalid := &invalidConfiguration{
+		excerpt: excerpt,
+		line:    line,
+		context: ngx_template.Excerpt(string(content), line),
+	}
+
+	hostname := serverOfLine(content, line)
+	if hostname == "" {
+		return invalid
+	}
+
+	directive := strings.TrimSpace(lineOf(content, line))
+	for _, server := range servers {
+		if server.Hostname != hostname {
+			continue
+		}
+
+		// the locations of the snippet that contains the line
+		var snippetLocations []*ingress.Location
+		for _, location := range server.Locations {
+			if directive != "" && strings.Contains(location.ConfigurationSnippet, directive) {
+				snippetLocations = append(snippetLocations, location)
+			}
+		}
+
+		switch {
+		case len(snippetLocations) > 0:
+			invalid.annotation = parser.GetAnnotationWithPrefix("configuration-snippet")
+			invalid.ingresses = locationIngresses(snippetLocations)
+		case directive != "" && strings.Contains(server.ServerSnippet, directive):
+			invalid.annotation = parser.GetAnnotationWithPrefix("server-snippet")
+			invalid.ingresses = serverIngresses(server)
+		default:
+			invalid.ingresses = serverIngresses(server)
+		}
+	}
+
+	return invalid
+}
+
+// serverIngresses returns the Ingresses that define the locations of a server
+func serverIngresses(server *ingress.Server) []*extensions.Ingress {
+	return locationIngresses(server.Locations)
+}
+
+// locationIngresses returns the Ingresses that define the locations
+func locationIngresses(locations []*ingress.Location) []*extensions.Ingress {
+	var ings []*extensions.Ingress
+	names := sets.NewString()
+	for _, location := range locations {
+		ing := location.Ingress
+		if ing == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+		if names.Has(key) {
+			continue
+		}
+
+		names.Insert(key)
+		ings = append(ings, ing)
+	}
+
+	return ings
+}
+
+// lineOf returns the given line (starting at 1) of the configuration
+func lineOf(content []byte, line int) string {
+	lines := bytes.Split(content, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return string(lines[line-1])
+}
+
+// serverOfLine returns the hostname of the server block that contains
+// the given line (starting at 1) of the configuration, using the comments
+// "## start server" and "## end server" of the template
+func serverOfLine(content []byte, line int) string {
+	lines := bytes.Split(content, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	for i := line - 1; i >= 0; i-- {
+		l := strings.TrimSpace(string(lines[i]))
+		if strings.HasPrefix(l, "## end server ") {
+			return ""
+		}
+		if strings.HasPrefix(l, "## start server ") {
+			return strings.TrimPrefix(l, "## start server ")
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+func TestInvalidConfigurationIngresses(t *testing.T) {
+	content := []byte(`http {
+    ## start server _
+    server {
+        server_name _ ;
+    }
+    ## end server _
+
+    ## start server foo.bar
+    server {
+        server_name foo.bar ;
+        location / {
+            foo;
+        }
+    }
+    ## end server foo.bar
+}
+`)
+
+	app := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	api := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}}
+	servers := []*ingress.Server{
+		{Hostname: "_", Locations: []*ingress.Location{{Path: "/"}}},
+		{Hostname: "foo.bar", Locations: []*ingress.Location{
+			{Path: "/", Ingress: app, ConfigurationSnippet: "foo;"},
+			{Path: "/api", Ingress: api},
+			{Path: "/app", Ingress: app},
+		}},
+	}
+
+	testCases := []struct {
+		err        string
+		ings       []*extensions.Ingress
+		excerpt    string
+		annotation string
+	}{
+		{`nginx: [emerg] unknown directive "foo" in /tmp/nginx-cfg123:12`, []*extensions.Ingress{app}, `unknown directive "foo"`,
+			"nginx.ingress.kubernetes.io/configuration-snippet"},
+		{`nginx: [emerg] invalid server name in /tmp/nginx-cfg123:10`, []*extensions.Ingress{app, api}, `invalid server name`, ""},
+		{`nginx: [emerg] unknown directive "foo" in /tmp/nginx-cfg123:4`, nil, `unknown directive "foo"`, ""},
+		{`nginx: [emerg] unknown directive "foo" in /tmp/nginx-cfg123:17`, nil, `unknown directive "foo"`, ""},
+	}
+
+	for _, tc := range testCases {
+		invalid := describeInvalidConfiguration(content, servers, errors.New(tc.err))
+		if invalid == nil {
+			t.Fatalf("%v: expected a description of the error", tc.err)
+		}
+		if !reflect.DeepEqual(invalid.ingresses, tc.ings) {
+			t.Errorf("%v: expected the ingresses %v but got %v", tc.err, tc.ings, invalid.ingresses)
+		}
+		if invalid.excerpt != tc.excerpt {
+			t.Errorf("%v: expected the excerpt %q but got %q", tc.err, tc.excerpt, invalid.excerpt)
+		}
+		if invalid.annotation != tc.annotation {
+			t.Errorf("%v: expected the annotation %q but got %q", tc.err, tc.annotation, invalid.annotation)
+		}
+	}
+
+	invalid := describeInvalidConfiguration(content, servers, errors.New(testCases[0].err))
+	if !strings.Contains(invalid.context, ">    12 |             foo;") {
+		t.Errorf("expected the line of the error in the context but got\n%v", invalid.context)
+	}
+
+	if invalid := describeInvalidConfiguration(content, servers, errors.New(`nginx: [emerg] no "events" section in configuration`)); invalid != nil {
+		t.Errorf("expected no description of an error without line but got %v", invalid)
+	}
+}
+
+func TestReportTemplateError(t *testing.T) {
+	tmpl, err := ngx_template.NewTemplateFromData([]byte(`events {}
+{{ range $server := .Servers }}
+location {{ (index $server.Locations 1).Path }};
+{{ end }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	n := &NGINXController{recorder: recorder, t: tmpl}
+
+	app := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	api := &extensions.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"}}
+	tc := ngx_config.TemplateConfig{
+		Servers: []*ingress.Server{
+			{Hostname: "foo.bar", Locations: []*ingress.Location{{Path: "/", Ingress: api}, {Path: "/api", Ingress: api}}},
+			{Hostname: "bar.baz", Locations: []*ingress.Location{{Path: "/", Ingress: app}}},
+		},
+	}
+
+	err = tmpl.Check(tc)
+	if err == nil {
+		t.Fatalf("expected an error rendering the template")
+	}
+	templateErr, ok := err.(*ngx_template.Error)
+	if !ok {
+		t.Fatalf("expected a template error but returned %T", err)
+	}
+	if templateErr.Line != 3 {
+		t.Errorf("expected the error in the line 3 of the template but returned %v", templateErr.Line)
+	}
+
+	n.reportTemplateError(tc, err)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but %v were emitted", len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning InvalidConfiguration the configuration cannot be rendered") ||
+		!strings.Contains(event, "location {{ (index $server.Locations 1).Path }};") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+// RenderIngress returns the server blocks of the NGINX configuration that
+// would be generated with the given Ingress, replacing the version of the
+// Ingress in the store. The configuration is tested but NGINX is not reloaded,
+// and the snippets of the other Ingresses are not included. The files written
+// by the annotations, like the authentication files and the maintenance pages,
+// are created in a temporary directory, so the files read by NGINX are not
+// modified. The servers routed by Lua when the dynamic servers are enabled
+// are not rendered.
+func (n *NGINXController) RenderIngress(ing *extensions.Ingress) ([]byte, error) {
+	if !class.IsValid(ing) {
+		return nil, fmt.Errorf("ingress %v/%v does not belong to the class %v", ing.Namespace, ing.Name, class.IngressClass)
+	}
+
+	filtered := shard.Filter(ing)
+	if filtered == nil {
+		return nil, fmt.Errorf("ingress %v/%v does not belong to the shard %v", ing.Namespace, ing.Name, shard.Index)
+	}
+
+	candidate, cleanup, err := n.candidate(filtered)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	content, err := candidate.renderConfiguration(snippetsOf(filtered))
+	if err != nil {
+		return nil, err
+	}
+
+	err = candidate.testTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return serverBlocks(content, ingressHosts(filtered)), nil
+}
+
+// ingressHosts returns the hostnames of the servers configured by an Ingress.
+// The catch-all server is named "_"
+func ingressHosts(ing *extensions.Ingress) sets.String {
+	hosts := sets.NewString()
+	if ing.Spec.Backend != nil {
+		hosts.Insert(defServerName)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			hosts.Insert(defServerName)
+			continue
+		}
+		hosts.Insert(rule.Host)
+	}
+
+	return hosts
+}
+
+// serverBlocks returns the server blocks of the given hostnames of
+// a configuration, delimited by the comments "## start server" and
+// "## end server" of the template
+func serverBlocks(content []byte, hosts sets.String) []byte {
+	var out bytes.Buffer
+
+	inServer := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if !inServer && strings.HasPrefix(trimmed, "## start server ") {
+			inServer = hosts.Has(strings.TrimPrefix(trimmed, "## start server "))
+		}
+
+		if inServer {
+			out.WriteString(line)
+			out.WriteByte('\n')
+		}
+
+		if inServer && strings.HasPrefix(trimmed, "## end server ") {
+			inServer = false
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+type fakeRenderStore struct {
+	*fakeConfigurationStore
+
+	ingresses []*extensions.Ingress
+}
+
+func (s *fakeRenderStore) ListIngresses() []*extensions.Ingress {
+	return s.ingresses
+}
+
+func (s *fakeRenderStore) ListGatewayIngresses() []*extensions.Ingress {
+	return nil
+}
+
+func (s *fakeRenderStore) ListStreamRoutes() []*v1alpha1.StreamRoute {
+	return nil
+}
+
+func (s *fakeRenderStore) GetLocalSecret(name string) (*ingress.SSLCert, error) {
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+// fileNames returns the names of the files of a directory
+func fileNames(dir string) []string {
+	var names []string
+	files, _ := ioutil.ReadDir(dir)
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+func TestServerBlocks(t *testing.T) {
+	content := []byte(`http {
+    ## start server _
+    server {
+        server_name _ ;
+    }
+    ## end server _
+
+    ## start server foo.bar
+    server {
+        server_name foo.bar ;
+    }
+    ## end server foo.bar
+
+    ## start server bar.baz
+    server {
+        server_name bar.baz ;
+    }
+    ## end server bar.baz
+}
+`)
+
+	ing := newAdmissionIngress("app")
+	ing.Spec.Rules = []extensions.IngressRule{{Host: "foo.bar"}}
+
+	expected := `    ## start server foo.bar
+    server {
+        server_name foo.bar ;
+    }
+    ## end server foo.bar
+
+`
+	if out := string(serverBlocks(content, ingressHosts(ing))); out != expected {
+		t.Errorf("unexpected server blocks:\n%v", out)
+	}
+
+	ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{})
+	if hosts := ingressHosts(ing); !hosts.HasAll("foo.bar", "_") || hosts.Len() != 2 {
+		t.Errorf("expected the hosts foo.bar and _ but got %v", hosts.List())
+	}
+}
+
+func TestRenderIngressFiles(t *testing.T) {
+	dir, restore := withSandboxDirectory(t)
+	defer restore()
+
+	tmpl, err := ngx_template.NewTemplateFromData([]byte(`{{ range $server := .Servers }}
+## start server {{ $server.Hostname }}
+{{ range $location := $server.Locations }}auth_basic_user_file {{ $location.BasicDigestAuth.File }};
+{{ end }}## end server {{ $server.Hostname }}
+{{ end }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ing, s := newSandboxIngress("app")
+	ing.Spec.Rules = []extensions.IngressRule{{
+		Host: "foo.bar",
+		IngressRuleValue: extensions.IngressRuleValue{
+			HTTP: &extensions.HTTPIngressRuleValue{
+				Paths: []extensions.HTTPIngressPath{{
+					Path:    "/",
+					Backend: extensions.IngressBackend{ServiceName: "app", ServicePort: intstr.FromInt(80)},
+				}},
+			},
+		},
+	}}
+
+	n := &NGINXController{
+		cfg:    &Configuration{ListenPorts: &ngx_config.ListenPorts{}},
+		store:  &fakeRenderStore{fakeConfigurationStore: s},
+		t:      tmpl,
+		binary: "true",
+	}
+
+	authFiles := fileNames(auth.AuthDirectory)
+	pages := fileNames(maintenance.PageDirectory)
+
+	content, err := n.RenderIngress(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(content), "auth_basic_user_file "+dir+"/") {
+		t.Errorf("expected the authentication file to be created in the directory %v:\n%s", dir, content)
+	}
+
+	if after := fileNames(auth.AuthDirectory); strings.Join(after, ",") != strings.Join(authFiles, ",") {
+		t.Errorf("expected the files of %v to be unchanged but got %v", auth.AuthDirectory, after)
+	}
+	if after := fileNames(maintenance.PageDirectory); strings.Join(after, ",") != strings.Join(pages, ",") {
+		t.Errorf("expected the files of %v to be unchanged but got %v", maintenance.PageDirectory, after)
+	}
+	if after := fileNames(dir); len(after) != 0 {
+		t.Errorf("expected the files of the render to be removed but got %v", after)
+	}
+}
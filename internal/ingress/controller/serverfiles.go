@@ -0,0 +1,226 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// serversDir is the directory of the configuration files of the servers
+// when the configuration is split per server
+var serversDir = "/etc/nginx/servers"
+
+// serverConfigurationFile returns the name of the configuration file of a
+// server. The wildcard is replaced because the underscore is not valid in
+// the hosts of the Ingresses, so the names of the files do not collide
+func serverConfigurationFile(hostname string) string {
+	return strings.Replace(hostname, "*", "_", -1) + ".conf"
+}
+
+// splitServers replaces the server blocks of the configuration, delimited
+// by the "## start server" and "## end server" comments, with the include
+// directive of the configuration file of each server in dir. It returns
+// the main configuration and the content of the files of the servers
+func splitServers(content []byte, dir string) ([]byte, map[string][]byte) {
+	var main bytes.Buffer
+	files := map[string][]byte{}
+
+	var server *bytes.Buffer
+	name := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case server == nil && strings.HasPrefix(trimmed, "## start server "):
+			name = serverConfigurationFile(strings.TrimPrefix(trimmed, "## start server "))
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			fmt.Fprintf(&main, "%v\n%vinclude %v;\n", line, indent, filepath.Join(dir, name))
+			server = &bytes.Buffer{}
+		case server != nil && strings.HasPrefix(trimmed, "## end server "):
+			files[name] = server.Bytes()
+			server = nil
+			fmt.Fprintf(&main, "%v\n", line)
+		case server != nil:
+			fmt.Fprintf(server, "%v\n", line)
+		default:
+			fmt.Fprintf(&main, "%v\n", line)
+		}
+	}
+
+	return main.Bytes(), files
+}
+
+// serverFiles writes the configuration files of the servers, keeping the
+// checksum of each file to rewrite only the files of the servers that
+// changed since the last write
+type serverFiles struct {
+	dir string
+	// checksums contains the SHA256 checksum of the files in the directory.
+	// It is nil until the first write
+	checksums map[string]string
+}
+
+func newServerFiles(dir string) *serverFiles {
+	return &serverFiles{dir: dir}
+}
+
+// write writes the files of the servers that changed and removes the files
+// of the servers that no longer exist. It returns a function that restores
+// the previous files, used if NGINX fails to load the new configuration
+func (f *serverFiles) write(files map[string][]byte) (func(), error) {
+	if f.checksums == nil {
+		err := os.MkdirAll(f.dir, 0755)
+		if err != nil {
+			return nil, err
+		}
+
+		// files of a previous execution, removed if they are not used
+		existing, err := filepath.Glob(filepath.Join(f.dir, "*.conf"))
+		if err != nil {
+			return nil, err
+		}
+
+		f.checksums = map[string]string{}
+		for _, path := range existing {
+			f.checksums[filepath.Base(path)] = ""
+		}
+	}
+
+	type previousFile struct {
+		content  []byte
+		checksum string
+		exists   bool
+	}
+
+	previous := map[string]previousFile{}
+	restore := func() {
+		for name, p := range previous {
+			path := filepath.Join(f.dir, name)
+			if !p.exists {
+				os.Remove(path)
+				delete(f.checksums, name)
+				continue
+			}
+
+			err := ioutil.WriteFile(path, p.content, 0644)
+			if err != nil {
+				glog.Errorf("unexpected error restoring the configuration file %v: %v", path, err)
+			}
+			f.checksums[name] = p.checksum
+		}
+	}
+
+	save := func(name string) {
+		checksum, exists := f.checksums[name]
+		content, _ := ioutil.ReadFile(filepath.Join(f.dir, name))
+		previous[name] = previousFile{content, checksum, exists}
+	}
+
+	for name := range f.checksums {
+		if _, ok := files[name]; ok {
+			continue
+		}
+
+		save(name)
+		err := os.Remove(filepath.Join(f.dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			restore()
+			return nil, err
+		}
+		delete(f.checksums, name)
+	}
+
+	for name, content := range files {
+		checksum := fmt.Sprintf("%x", sha256.Sum256(content))
+		if f.checksums[name] == checksum {
+			continue
+		}
+
+		save(name)
+		err := ioutil.WriteFile(filepath.Join(f.dir, name), content, 0644)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		f.checksums[name] = checksum
+	}
+
+	glog.V(2).Infof("%v server configuration files changed (%v servers)", len(previous), len(files))
+	return restore, nil
+}
+
+// diffServerFiles returns the unified diff between the configuration files
+// on disk and the given main configuration and files of the servers. Only
+// the files that changed are compared
+func diffServerFiles(path, dir string, main []byte, files map[string][]byte) ([]byte, error) {
+	var out []byte
+
+	src, _ := ioutil.ReadFile(path)
+	if !bytes.Equal(src, main) {
+		diffOutput, err := diffConfiguration(path, main)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, diffOutput...)
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	for _, file := range existing {
+		if _, ok := files[filepath.Base(file)]; !ok {
+			names = append(names, filepath.Base(file))
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		src, _ := ioutil.ReadFile(file)
+		if bytes.Equal(src, files[name]) {
+			continue
+		}
+
+		diffOutput, err := diffConfiguration(file, files[name])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, diffOutput...)
+	}
+
+	return out, nil
+}
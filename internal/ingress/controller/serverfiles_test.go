@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const splitConfiguration = `http {
+    ## start server _
+    server {
+        server_name _;
+    }
+    ## end server _
+
+    ## start server *.example.com
+    server {
+        server_name *.example.com;
+    }
+    ## end server *.example.com
+}
+`
+
+func TestSplitServers(t *testing.T) {
+	main, files := splitServers([]byte(splitConfiguration), "/etc/nginx/servers")
+
+	expectedMain := `http {
+    ## start server _
+    include /etc/nginx/servers/_.conf;
+    ## end server _
+
+    ## start server *.example.com
+    include /etc/nginx/servers/_.example.com.conf;
+    ## end server *.example.com
+}
+`
+	if string(main) != expectedMain {
+		t.Errorf("expected main configuration\n%v\nbut returned\n%v", expectedMain, string(main))
+	}
+
+	expectedFiles := map[string][]byte{
+		"_.conf":             []byte("    server {\n        server_name _;\n    }\n"),
+		"_.example.com.conf": []byte("    server {\n        server_name *.example.com;\n    }\n"),
+	}
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected files %q but returned %q", expectedFiles, files)
+	}
+}
+
+func TestServerFilesWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "servers")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// file of a previous execution
+	stale := filepath.Join(dir, "stale.example.com.conf")
+	if err := ioutil.WriteFile(stale, []byte("server {}\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sf := newServerFiles(dir)
+	_, err = sf.write(map[string][]byte{
+		"a.conf": []byte("a"),
+		"b.conf": []byte("b"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected the file %v to be removed", stale)
+	}
+
+	// the modification time of the unchanged files is kept
+	a := filepath.Join(dir, "a.conf")
+	time0 := time.Unix(0, 0)
+	if err := os.Chtimes(a, time0, time0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restore, err := sf.write(map[string][]byte{
+		"a.conf": []byte("a"),
+		"c.conf": []byte("c"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertServerFiles(t, dir, map[string]string{"a.conf": "a", "c.conf": "c"})
+	if info, err := os.Stat(a); err != nil || !info.ModTime().Equal(time0) {
+		t.Errorf("expected the file %v not to be written", a)
+	}
+
+	restore()
+	assertServerFiles(t, dir, map[string]string{"a.conf": "a", "b.conf": "b"})
+
+	_, err = sf.write(map[string][]byte{
+		"a.conf": []byte("a"),
+		"b.conf": []byte("b"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertServerFiles(t, dir, map[string]string{"a.conf": "a", "b.conf": "b"})
+}
+
+func assertServerFiles(t *testing.T, dir string, expected map[string]string) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		files[filepath.Base(path)] = string(content)
+	}
+
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected files %v but returned %v", expected, files)
+	}
+}
@@ -0,0 +1,381 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// checkSnippets returns the servers without the snippets that contain
+// blocked directives or that generated an invalid configuration before
+func (n *NGINXController) checkSnippets(servers []*ingress.Server, blocklist []string) []*ingress.Server {
+	return filterSnippets(servers, func(snippet string, ing *extensions.Ingress) bool {
+		if directive := blockedDirective(snippet, blocklist); directive != "" {
+			n.rejectSnippet(ing, fmt.Sprintf("the directive %v is not allowed", directive))
+			return false
+		}
+
+		return !n.rejectedSnippets.has(ing, snippet)
+	})
+}
+
+// testSnippets is used when the test of the configuration fails. If the
+// configuration without the snippets of the Ingress rules is valid, every
+// snippet is tested separately and the snippets that generate an invalid
+// configuration are rejected. Returns the configuration with the valid snippets
+func (n *NGINXController) testSnippets(tc ngx_config.TemplateConfig, testErr error) ([]byte, error) {
+	servers := tc.Servers
+
+	candidates := []string{}
+	ingresses := map[string]*extensions.Ingress{}
+	tc.Servers = filterSnippets(servers, func(snippet string, ing *extensions.Ingress) bool {
+		id := snippetID(ing, snippet)
+		if _, ok := ingresses[id]; !ok {
+			candidates = append(candidates, id)
+			ingresses[id] = ing
+		}
+		return false
+	})
+
+	if len(candidates) == 0 {
+		return nil, testErr
+	}
+
+	content, err := n.t.Write(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.testTemplate(content) != nil {
+		// the snippets are not the cause of the error
+		return nil, testErr
+	}
+
+	glog.Warningf("the configuration is only valid without snippets, testing %v snippets", len(candidates))
+
+	accepted := sets.NewString()
+	for _, id := range candidates {
+		tc.Servers = filterSnippets(servers, func(snippet string, ing *extensions.Ingress) bool {
+			return snippetID(ing, snippet) == id
+		})
+
+		content, err := n.t.Write(tc)
+		if err == nil {
+			err = n.testTemplate(content)
+		}
+
+		if err != nil {
+			n.rejectedSnippets.insert(ingresses[id], id)
+			n.rejectSnippet(ingresses[id], fmt.Sprintf("the snippet generates an invalid configuration: %v", err))
+			continue
+		}
+
+		accepted.Insert(id)
+	}
+
+	tc.Servers = filterSnippets(servers, func(snippet string, ing *extensions.Ingress) bool {
+		return accepted.Has(snippetID(ing, snippet))
+	})
+
+	content, err = n.t.Write(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, n.testTemplate(content)
+}
+
+// rejectSnippet notifies the reason why a snippet of an Ingress
+// is not included in the configuration
+func (n *NGINXController) rejectSnippet(ing *extensions.Ingress, reason string) {
+	if ing == nil {
+		glog.Warningf("snippet rejected: %v", reason)
+		return
+	}
+
+	glog.Warningf("snippet of Ingress %v/%v rejected: %v", ing.Namespace, ing.Name, reason)
+	n.recorder.Event(ing, apiv1.EventTypeWarning, "RejectedSnippet", reason)
+}
+
+// snippetID returns an identifier of the content of a snippet of an Ingress
+func snippetID(ing *extensions.Ingress, snippet string) string {
+	return fmt.Sprintf("%v/%x", ingressKey(ing), sha1.Sum([]byte(snippet)))
+}
+
+// ingressKey returns the key of the Ingress of a snippet,
+// or an empty string if the Ingress is not known
+func ingressKey(ing *extensions.Ingress) string {
+	if ing == nil {
+		return ""
+	}
+	return k8s.MetaNamespaceKey(ing)
+}
+
+// ingressVersion returns the resource version of the Ingress of a snippet
+func ingressVersion(ing *extensions.Ingress) string {
+	if ing == nil {
+		return ""
+	}
+	return ing.ResourceVersion
+}
+
+// rejectedSnippets contains the identifiers of the snippets that generated
+// an invalid configuration by Ingress. Only the snippets of the last version
+// of each Ingress are kept, so the snippets are tested again once the
+// Ingress changes
+type rejectedSnippets struct {
+	lock     *sync.Mutex
+	snippets map[string]*versionSnippets
+}
+
+// versionSnippets contains the rejected snippets of a version of an Ingress
+type versionSnippets struct {
+	version string
+	ids     sets.String
+}
+
+func newRejectedSnippets() rejectedSnippets {
+	return rejectedSnippets{
+		lock:     &sync.Mutex{},
+		snippets: map[string]*versionSnippets{},
+	}
+}
+
+// has returns true if the snippet of the Ingress was rejected
+func (r rejectedSnippets) has(ing *extensions.Ingress, snippet string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rejected, ok := r.snippets[ingressKey(ing)]
+	if !ok || rejected.version != ingressVersion(ing) {
+		return false
+	}
+
+	return rejected.ids.Has(snippetID(ing, snippet))
+}
+
+// insert rejects the snippet with the given identifier of the Ingress,
+// removing the snippets rejected in the previous versions of the Ingress
+func (r rejectedSnippets) insert(ing *extensions.Ingress, id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := ingressKey(ing)
+	rejected, ok := r.snippets[key]
+	if !ok || rejected.version != ingressVersion(ing) {
+		rejected = &versionSnippets{version: ingressVersion(ing), ids: sets.NewString()}
+		r.snippets[key] = rejected
+	}
+	rejected.ids.Insert(id)
+}
+
+// forget removes the rejected snippets of the Ingress with the given key
+func (r rejectedSnippets) forget(key string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.snippets, key)
+}
+
+// blockedDirective returns the first directive of a snippet that matches
+// one of the patterns of the blocklist or an empty string if none matches
+func blockedDirective(snippet string, blocklist []string) string {
+	if len(blocklist) == 0 {
+		return ""
+	}
+
+	for _, name := range directiveNames(snippet) {
+		for _, pattern := range blocklist {
+			if ok, _ := path.Match(pattern, name); ok {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// directiveNames returns the names of the directives of a snippet. The
+// snippet is split in tokens like NGINX does, so the comments start only
+// at the beginning of a token and the quotes and escaped characters are
+// removed from the tokens before reading the names
+func directiveNames(snippet string) []string {
+	var names []string
+
+	var token []byte
+	inToken := false
+	statement := true
+	var quote byte
+
+	endToken := func() {
+		if !inToken {
+			return
+		}
+		if statement {
+			names = append(names, string(token))
+			statement = false
+		}
+		token = token[:0]
+		inToken = false
+	}
+
+	for i := 0; i < len(snippet); i++ {
+		ch := snippet[i]
+
+		if ch == '\\' && i+1 < len(snippet) {
+			i++
+			token = appendEscaped(token, snippet[i])
+			inToken = true
+			continue
+		}
+
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+				endToken()
+				continue
+			}
+			token = append(token, ch)
+			continue
+		}
+
+		switch ch {
+		case ' ', '\t', '\r', '\n':
+			endToken()
+		case ';', '{', '}':
+			endToken()
+			statement = true
+		case '#', '"', '\'':
+			if inToken {
+				token = append(token, ch)
+				continue
+			}
+
+			if ch != '#' {
+				quote = ch
+				inToken = true
+				continue
+			}
+
+			for i < len(snippet) && snippet[i] != '\n' {
+				i++
+			}
+		default:
+			token = append(token, ch)
+			inToken = true
+		}
+	}
+	endToken()
+
+	return names
+}
+
+// appendEscaped appends the character escaped with a backslash
+// to a token, replacing the escape sequences NGINX supports
+func appendEscaped(token []byte, ch byte) []byte {
+	switch ch {
+	case '"', '\'', '\\':
+		return append(token, ch)
+	case 't':
+		return append(token, '\t')
+	case 'r':
+		return append(token, '\r')
+	case 'n':
+		return append(token, '\n')
+	}
+
+	return append(token, '\\', ch)
+}
+
+// filterSnippets returns the servers without the snippets (server,
+// configuration and auth snippets) for which the function keep returns
+// false. The servers and locations are copied before removing a snippet
+// because they are part of the running configuration
+func filterSnippets(servers []*ingress.Server, keep func(string, *extensions.Ingress) bool) []*ingress.Server {
+	filtered := make([]*ingress.Server, 0, len(servers))
+	for _, server := range servers {
+		srv := server
+		if server.ServerSnippet != "" && !keep(server.ServerSnippet, serverSnippetIngress(server)) {
+			s := *server
+			s.ServerSnippet = ""
+			srv = &s
+		}
+
+		var locations []*ingress.Location
+		for i, location := range server.Locations {
+			keepConfiguration := location.ConfigurationSnippet == "" || keep(location.ConfigurationSnippet, location.Ingress)
+			keepAuth := location.ExternalAuth.AuthSnippet == "" || keep(location.ExternalAuth.AuthSnippet, location.Ingress)
+			if keepConfiguration && keepAuth {
+				continue
+			}
+
+			if srv == server {
+				s := *server
+				srv = &s
+			}
+
+			if locations == nil {
+				locations = make([]*ingress.Location, len(server.Locations))
+				copy(locations, server.Locations)
+				srv.Locations = locations
+			}
+
+			loc := *location
+			if !keepConfiguration {
+				loc.ConfigurationSnippet = ""
+			}
+			if !keepAuth {
+				loc.ExternalAuth.AuthSnippet = ""
+			}
+			locations[i] = &loc
+		}
+
+		filtered = append(filtered, srv)
+	}
+
+	return filtered
+}
+
+// serverSnippetIngress returns the Ingress that defines the server snippet of a server
+func serverSnippetIngress(server *ingress.Server) *extensions.Ingress {
+	for _, location := range server.Locations {
+		if location.Ingress == nil {
+			continue
+		}
+
+		snippet, err := parser.GetStringAnnotation("server-snippet", location.Ingress)
+		if err == nil && snippet == server.ServerSnippet {
+			return location.Ingress
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+)
+
+func TestBlockedDirective(t *testing.T) {
+	blocklist := []string{"lua_*", "*_by_lua_block", "load_module", "root"}
+
+	testCases := map[string]struct {
+		snippet   string
+		blocklist []string
+		expected  string
+	}{
+		"empty blocklist":       {"root /etc;", []string{}, ""},
+		"allowed directives":    {"more_set_headers \"Request-Id: $req_id\";\nproxy_buffering off;", blocklist, ""},
+		"blocked directive":     {"proxy_buffering off;\nroot /etc;", blocklist, "root"},
+		"wildcard":              {"lua_need_request_body on;", blocklist, "lua_need_request_body"},
+		"nested block":          {"if ($arg_debug) { access_by_lua_block { ngx.exit(403) } }", blocklist, "access_by_lua_block"},
+		"same line":             {"proxy_buffering off; root /etc;", blocklist, "root"},
+		"comment":               {"# root /etc;\nproxy_buffering off;", blocklist, ""},
+		"directive as argument": {"set $root /etc;", blocklist, ""},
+		"quoted comment":        {"add_header X \"#\"; root /etc;", blocklist, "root"},
+		"single quoted comment": {"add_header X '# {'; root /etc;", blocklist, "root"},
+		"comment in token":      {"set $a b#c; root /etc;", blocklist, "root"},
+		"quoted directive":      {"\"root\" /etc;", blocklist, "root"},
+		"escaped quote":         {"add_header X \"\\\"#\"; root /etc;", blocklist, "root"},
+		"escaped separator":     {"set $a b\\;root; proxy_buffering off;", blocklist, ""},
+		"escaped directive":     {"r\\oot /etc;", blocklist, ""},
+	}
+
+	for title, tc := range testCases {
+		directive := blockedDirective(tc.snippet, tc.blocklist)
+		if directive != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", title, tc.expected, directive)
+		}
+	}
+}
+
+func TestRejectedSnippets(t *testing.T) {
+	r := newRejectedSnippets()
+
+	app := newAdmissionIngress("app")
+	app.ResourceVersion = "1"
+	other := newAdmissionIngress("other")
+	other.ResourceVersion = "1"
+
+	r.insert(app, snippetID(app, "root /etc;"))
+	if !r.has(app, "root /etc;") {
+		t.Errorf("expected the snippet to be rejected")
+	}
+	if r.has(other, "root /etc;") {
+		t.Errorf("expected the same snippet of another ingress not to be rejected")
+	}
+
+	updated := app.DeepCopy()
+	updated.ResourceVersion = "2"
+	if r.has(updated, "root /etc;") {
+		t.Errorf("expected the snippet to be tested again once the ingress changes")
+	}
+
+	r.insert(updated, snippetID(updated, "return 200;"))
+	if r.has(app, "root /etc;") || len(r.snippets) != 1 {
+		t.Errorf("expected the snippets of the previous version of the ingress to be removed")
+	}
+
+	r.forget("default/app")
+	if len(r.snippets) != 0 {
+		t.Errorf("expected the snippets of the deleted ingress to be removed")
+	}
+}
+
+func TestFilterSnippets(t *testing.T) {
+	ing := &extensions.Ingress{}
+	ing.SetAnnotations(map[string]string{
+		"nginx.ingress.kubernetes.io/server-snippet": "root /etc;",
+	})
+
+	servers := []*ingress.Server{
+		{
+			Hostname:      "example.com",
+			ServerSnippet: "root /etc;",
+			Locations: []*ingress.Location{
+				{Path: "/", Ingress: ing, ConfigurationSnippet: "proxy_buffering off;"},
+				{Path: "/api", Ingress: ing, ConfigurationSnippet: "lua_need_request_body on;"},
+				{
+					Path:         "/auth",
+					Ingress:      ing,
+					ExternalAuth: authreq.Config{URL: "http://auth.example.com", AuthSnippet: "access_by_lua_block { ngx.exit(200) }"},
+				},
+			},
+		},
+		{
+			Hostname: "other.com",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+			},
+		},
+	}
+
+	var rejected []*extensions.Ingress
+	filtered := filterSnippets(servers, func(snippet string, i *extensions.Ingress) bool {
+		if snippet == "proxy_buffering off;" {
+			return true
+		}
+
+		rejected = append(rejected, i)
+		return false
+	})
+
+	if len(rejected) != 3 || rejected[0] != ing || rejected[1] != ing || rejected[2] != ing {
+		t.Errorf("expected three snippets of the Ingress rejected but returned %v", rejected)
+	}
+
+	if filtered[0].ServerSnippet != "" {
+		t.Errorf("expected an empty server snippet but returned '%v'", filtered[0].ServerSnippet)
+	}
+	if filtered[0].Locations[0] != servers[0].Locations[0] {
+		t.Errorf("expected the same location when the snippet is accepted")
+	}
+	if filtered[0].Locations[1].ConfigurationSnippet != "" {
+		t.Errorf("expected an empty snippet but returned '%v'", filtered[0].Locations[1].ConfigurationSnippet)
+	}
+	if filtered[0].Locations[2].ExternalAuth.AuthSnippet != "" {
+		t.Errorf("expected an empty auth snippet but returned '%v'", filtered[0].Locations[2].ExternalAuth.AuthSnippet)
+	}
+	if filtered[0].Locations[2].ExternalAuth.URL == "" {
+		t.Errorf("expected the external authentication without the auth snippet")
+	}
+	if filtered[1] != servers[1] {
+		t.Errorf("expected the same server when there are no snippets")
+	}
+
+	// the running configuration must not be modified
+	if servers[0].ServerSnippet == "" || servers[0].Locations[1].ConfigurationSnippet == "" || servers[0].Locations[2].ExternalAuth.AuthSnippet == "" {
+		t.Errorf("unexpected modification of the original servers")
+	}
+}
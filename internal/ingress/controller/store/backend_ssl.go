@@ -83,6 +83,7 @@ func (s k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error)
 	cert, okcert := secret.Data[apiv1.TLSCertKey]
 	key, okkey := secret.Data[apiv1.TLSPrivateKeyKey]
 	ca := secret.Data["ca.crt"]
+	crl := secret.Data["ca.crl"]
 
 	// namespace/secretName -> namespace-secretName
 	nsSecName := strings.Replace(secretName, "/", "-", -1)
@@ -118,8 +119,25 @@ func (s k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error)
 		// this does not enable Certificate Authentication
 		glog.V(3).Infof("found only 'ca.crt', configuring %v as an Certificate Authentication Secret", secretName)
 
+	} else if crl != nil {
+		sslCert = &ingress.SSLCert{}
+
+		// makes this secret in 'syncSecret' to be used as a Certificate Revocation List
+		// in the 'nginx.ingress.kubernetes.io/auth-tls-crl-secret' annotation
+		glog.V(3).Infof("found only 'ca.crl', configuring %v as a Certificate Revocation List Secret", secretName)
+
 	} else {
-		return nil, fmt.Errorf("no keypair or CA cert could be found in %v", secretName)
+		return nil, fmt.Errorf("no keypair, CA cert or CRL could be found in %v", secretName)
+	}
+
+	if crl != nil {
+		crlFileName, err := ssl.AddCertRevocationList(nsSecName, crl, s.filesystem)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected error creating CRL file: %v", err)
+		}
+
+		sslCert.CRLFileName = crlFileName
+		sslCert.CRLSHA = file.SHA1(crlFileName)
 	}
 
 	sslCert.Name = secret.Name
@@ -179,16 +197,35 @@ func (s k8sStore) checkSSLChainIssues() {
 	}
 }
 
+// syncDefaultSSLCertificate updates the local copy of the default SSL
+// certificate (flag --default-ssl-certificate) when its secret is created
+// or updated, rotating the certificate without restarting the controller.
+// It returns false if the secret does not contain the default certificate.
+func (s k8sStore) syncDefaultSSLCertificate(sec *apiv1.Secret) bool {
+	key := k8s.MetaNamespaceKey(sec)
+	if key != s.defaultSSLCertificate {
+		return false
+	}
+
+	glog.Infof("secret %v of the default SSL certificate changed", key)
+	s.syncSecret(key)
+	return true
+}
+
 // checkMissingSecrets verifies if one or more ingress rules contains
 // a reference to a secret that is not present in the local secret store.
 func (s k8sStore) checkMissingSecrets() {
-	for _, ing := range s.ListIngresses() {
+	for _, ing := range append(s.ListIngresses(), s.ListGatewayIngresses()...) {
 		for _, tls := range ing.Spec.TLS {
 			if tls.SecretName == "" {
 				continue
 			}
 
-			key := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
+			key := k8s.ReferenceKey(ing.Namespace, tls.SecretName)
+			if !s.CanReferenceSecret(ing.Namespace, key) {
+				continue
+			}
+
 			if _, ok := s.sslStore.Get(key); !ok {
 				s.syncSecret(key)
 			}
@@ -212,11 +249,26 @@ func (s k8sStore) ReadSecrets(ing *extensions.Ingress) {
 			continue
 		}
 
-		key := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
+		key := k8s.ReferenceKey(ing.Namespace, tls.SecretName)
+		if !s.CanReferenceSecret(ing.Namespace, key) {
+			glog.Warningf("ignoring TLS secret %v of ingress %v/%v: the secret is not shared with the namespace of the ingress", key, ing.Namespace, ing.Name)
+			continue
+		}
+
+		s.syncSecret(key)
+	}
+
+	key, _ := parser.GetStringAnnotation("auth-tls-crl-secret", ing)
+	if key != "" {
+		s.syncSecret(key)
+	}
+
+	key, _ = parser.GetStringAnnotation("proxy-ssl-secret", ing)
+	if key != "" {
 		s.syncSecret(key)
 	}
 
-	key, _ := parser.GetStringAnnotation("auth-tls-secret", ing)
+	key, _ = parser.GetStringAnnotation("auth-tls-secret", ing)
 	if key == "" {
 		return
 	}
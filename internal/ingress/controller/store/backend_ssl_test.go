@@ -18,6 +18,8 @@ package store
 
 import (
 	"encoding/base64"
+	"sync"
+	"testing"
 
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -221,3 +223,41 @@ func TestGetPemCertificate(t *testing.T) {
 	}
 }
 */
+
+func TestSyncDefaultSSLCertificate(t *testing.T) {
+	dCrt, dKey, _, err := buildCrtKeyAndCA()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := k8sStore{
+		listers:               &Lister{Secret: buildSecrListerForBackendSSL()},
+		sslStore:              NewSSLCertTracker(),
+		filesystem:            newFS(t),
+		updateCh:              make(chan Event, 10),
+		mu:                    &sync.Mutex{},
+		defaultSSLCertificate: "default/foo_secret",
+	}
+
+	other := buildSecretForBackendSSL()
+	other.Name = "other"
+	if s.syncDefaultSSLCertificate(other) {
+		t.Errorf("expected the secret %v to be ignored", other.Name)
+	}
+
+	secret := buildSecretForBackendSSL()
+	secret.Data = map[string][]byte{apiv1.TLSCertKey: dCrt, apiv1.TLSPrivateKeyKey: dKey}
+	s.listers.Secret.Add(secret)
+
+	if !s.syncDefaultSSLCertificate(secret) {
+		t.Fatalf("expected the default SSL certificate to be synced")
+	}
+
+	if _, err := s.GetLocalSecret("default/foo_secret"); err != nil {
+		t.Errorf("expected the default SSL certificate in the local store: %v", err)
+	}
+
+	if len(s.updateCh) != 1 {
+		t.Errorf("expected an update event but %v were sent", len(s.updateCh))
+	}
+}
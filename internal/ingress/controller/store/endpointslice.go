@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+)
+
+const (
+	// endpointSliceServiceIndex is the name of the index of the
+	// EndpointSlices by namespace and name of the service
+	endpointSliceServiceIndex = "service"
+
+	// nodeNameTopologyKey is the topology label with the name of the node
+	nodeNameTopologyKey = "kubernetes.io/hostname"
+)
+
+// EndpointSliceLister makes an Indexer that lists EndpointSlices.
+type EndpointSliceLister struct {
+	cache.Indexer
+}
+
+// endpointSliceServiceIndexFunc indexes the EndpointSlices using the
+// namespace and the name of the service in the kubernetes.io/service-name label
+func endpointSliceServiceIndexFunc(obj interface{}) ([]string, error) {
+	slice, ok := obj.(*discovery.EndpointSlice)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object of type %T", obj)
+	}
+
+	name := slice.Labels[discovery.LabelServiceName]
+	if name == "" {
+		return []string{}, nil
+	}
+
+	return []string{fmt.Sprintf("%v/%v", slice.Namespace, name)}, nil
+}
+
+// GetServiceEndpoints returns the endpoints of a service, assembled from the
+// EndpointSlices labeled with the service name. Every slice is converted to a
+// subset of the endpoints.
+func (s *EndpointSliceLister) GetServiceEndpoints(svc *apiv1.Service) (*apiv1.Endpoints, error) {
+	key := fmt.Sprintf("%v/%v", svc.Namespace, svc.Name)
+	objs, err := s.ByIndex(endpointSliceServiceIndex, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("could not find endpoint slices for service %v", key)
+	}
+
+	var slices []*discovery.EndpointSlice
+	for _, obj := range objs {
+		slices = append(slices, obj.(*discovery.EndpointSlice))
+	}
+	// the order of the indexer is random
+	sort.SliceStable(slices, func(i, j int) bool {
+		return slices[i].Name < slices[j].Name
+	})
+
+	eps := &apiv1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: svc.Namespace,
+			Name:      svc.Name,
+		},
+	}
+
+	for _, slice := range slices {
+		subset := endpointSliceToSubset(slice)
+		if len(subset.Addresses) == 0 && len(subset.NotReadyAddresses) == 0 {
+			continue
+		}
+		eps.Subsets = append(eps.Subsets, subset)
+	}
+
+	return eps, nil
+}
+
+// endpointSliceToSubset converts the endpoints and ports of an EndpointSlice
+// to a subset of an Endpoints object
+func endpointSliceToSubset(slice *discovery.EndpointSlice) apiv1.EndpointSubset {
+	subset := apiv1.EndpointSubset{}
+
+	// the addresses of type FQDN are not supported by the Endpoints
+	if slice.AddressType == discovery.AddressTypeFQDN {
+		return subset
+	}
+
+	for _, port := range slice.Ports {
+		epPort := apiv1.EndpointPort{
+			Protocol: apiv1.ProtocolTCP,
+		}
+		if port.Name != nil {
+			epPort.Name = *port.Name
+		}
+		if port.Port != nil {
+			epPort.Port = *port.Port
+		}
+		if port.Protocol != nil {
+			epPort.Protocol = *port.Protocol
+		}
+		subset.Ports = append(subset.Ports, epPort)
+	}
+
+	for _, ep := range slice.Endpoints {
+		for _, address := range ep.Addresses {
+			epAddress := apiv1.EndpointAddress{
+				IP:        address,
+				TargetRef: ep.TargetRef,
+			}
+			if ep.Hostname != nil {
+				epAddress.Hostname = *ep.Hostname
+			}
+			if nodeName, ok := ep.Topology[nodeNameTopologyKey]; ok {
+				epAddress.NodeName = &nodeName
+			}
+
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				subset.Addresses = append(subset.Addresses, epAddress)
+			} else {
+				subset.NotReadyAddresses = append(subset.NotReadyAddresses, epAddress)
+			}
+		}
+	}
+
+	return subset
+}
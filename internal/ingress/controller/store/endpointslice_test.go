@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+)
+
+func newEndpointSlice(name, service string, addressType discovery.AddressType, endpoints []discovery.Endpoint) *discovery.EndpointSlice {
+	portName := "http"
+	port := int32(8080)
+	return &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels: map[string]string{
+				discovery.LabelServiceName: service,
+			},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+		Ports: []discovery.EndpointPort{
+			{Name: &portName, Port: &port},
+		},
+	}
+}
+
+func TestEndpointSliceGetServiceEndpoints(t *testing.T) {
+	ready := true
+	notReady := false
+	lister := EndpointSliceLister{
+		cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{endpointSliceServiceIndex: endpointSliceServiceIndexFunc}),
+	}
+
+	lister.Add(newEndpointSlice("echo-b", "echo", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		{Addresses: []string{"10.0.0.2"}, Conditions: discovery.EndpointConditions{Ready: &notReady}},
+	}))
+	lister.Add(newEndpointSlice("echo-a", "echo", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discovery.EndpointConditions{Ready: &ready},
+			Topology:   map[string]string{nodeNameTopologyKey: "node-1"},
+		},
+		{Addresses: []string{"10.0.0.3"}},
+	}))
+	lister.Add(newEndpointSlice("echo-fqdn", "echo", discovery.AddressTypeFQDN, []discovery.Endpoint{
+		{Addresses: []string{"echo.example.com"}},
+	}))
+	lister.Add(newEndpointSlice("other", "other", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		{Addresses: []string{"10.0.1.1"}},
+	}))
+
+	svc := &apiv1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "echo"}}
+	eps, err := lister.GetServiceEndpoints(svc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	nodeName := "node-1"
+	ports := []apiv1.EndpointPort{{Name: "http", Port: 8080, Protocol: apiv1.ProtocolTCP}}
+	expected := []apiv1.EndpointSubset{
+		{
+			Addresses: []apiv1.EndpointAddress{
+				{IP: "10.0.0.1", NodeName: &nodeName},
+				{IP: "10.0.0.3"},
+			},
+			Ports: ports,
+		},
+		{
+			NotReadyAddresses: []apiv1.EndpointAddress{{IP: "10.0.0.2"}},
+			Ports:             ports,
+		},
+	}
+	if !reflect.DeepEqual(eps.Subsets, expected) {
+		t.Errorf("expected subsets %+v but returned %+v", expected, eps.Subsets)
+	}
+
+	svc.Name = "missing"
+	_, err = lister.GetServiceEndpoints(svc)
+	if err == nil {
+		t.Errorf("expected an error for a service without endpoint slices")
+	}
+}
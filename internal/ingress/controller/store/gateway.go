@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sync"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/apis/gateway/v1alpha2"
+	"k8s.io/ingress-nginx/internal/ingress/gateway"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+// GatewayClassLister makes a Store that lists GatewayClasses.
+type GatewayClassLister struct {
+	cache.Store
+}
+
+// GatewayLister makes a Store that lists Gateways.
+type GatewayLister struct {
+	cache.Store
+}
+
+// HTTPRouteLister makes a Store that lists HTTPRoutes.
+type HTTPRouteLister struct {
+	cache.Store
+}
+
+// TLSRouteLister makes a Store that lists TLSRoutes.
+type TLSRouteLister struct {
+	cache.Store
+}
+
+// gatewayIngresses contains the Ingresses translated from the
+// Gateway API resources
+type gatewayIngresses struct {
+	sync.RWMutex
+	ingresses []*extensions.Ingress
+}
+
+// ListGatewayIngresses returns the Ingresses translated from the
+// Gateway API resources
+func (s k8sStore) ListGatewayIngresses() []*extensions.Ingress {
+	s.gatewayIngresses.RLock()
+	defer s.gatewayIngresses.RUnlock()
+
+	var ingresses []*extensions.Ingress
+	for _, ing := range s.gatewayIngresses.ingresses {
+		ing = shard.Filter(ing)
+		if ing == nil {
+			continue
+		}
+
+		ingresses = append(ingresses, ing)
+	}
+
+	return ingresses
+}
+
+// syncGateways translates again the Gateway API resources into Ingresses
+// after a change of any of them
+func (s *k8sStore) syncGateways(obj interface{}) {
+	var classes []*v1alpha2.GatewayClass
+	for _, item := range s.listers.GatewayClass.List() {
+		classes = append(classes, item.(*v1alpha2.GatewayClass))
+	}
+
+	var gateways []*v1alpha2.Gateway
+	for _, item := range s.listers.Gateway.List() {
+		gateways = append(gateways, item.(*v1alpha2.Gateway))
+	}
+
+	var httpRoutes []*v1alpha2.HTTPRoute
+	for _, item := range s.listers.HTTPRoute.List() {
+		httpRoutes = append(httpRoutes, item.(*v1alpha2.HTTPRoute))
+	}
+
+	var tlsRoutes []*v1alpha2.TLSRoute
+	for _, item := range s.listers.TLSRoute.List() {
+		tlsRoutes = append(tlsRoutes, item.(*v1alpha2.TLSRoute))
+	}
+
+	ingresses := gateway.Ingresses(classes, gateways, httpRoutes, tlsRoutes)
+
+	current := make(map[string]bool)
+	for _, ing := range ingresses {
+		s.extractAnnotations(ing)
+		s.ReadSecrets(ing)
+		current[ing.Namespace+"/"+ing.Name] = true
+	}
+
+	s.gatewayIngresses.Lock()
+	previous := s.gatewayIngresses.ingresses
+	s.gatewayIngresses.ingresses = ingresses
+	s.gatewayIngresses.Unlock()
+
+	for _, ing := range previous {
+		if !current[ing.Namespace+"/"+ing.Name] {
+			s.listers.IngressAnnotation.Delete(ing)
+		}
+	}
+
+	s.updateCh <- Event{
+		Type: UpdateEvent,
+		Obj:  obj,
+	}
+}
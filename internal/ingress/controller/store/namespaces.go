@@ -0,0 +1,307 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newNamespacedInformer creates an informer of the resource in the given
+// namespaces. Watching a list of namespaces requires an informer per
+// namespace, which are combined in a single indexer and controller.
+func newNamespacedInformer(client cache.Getter, resource string, namespaces []string,
+	objType runtime.Object, resyncPeriod time.Duration,
+	h cache.ResourceEventHandler, indexers cache.Indexers) (cache.Indexer, cache.Controller) {
+	return newTransformedInformer(client, resource, namespaces, objType, resyncPeriod, h, indexers, nil)
+}
+
+// newTransformedInformer creates an informer like newNamespacedInformer that
+// applies the transform function to the objects before they are cached.
+func newTransformedInformer(client cache.Getter, resource string, namespaces []string,
+	objType runtime.Object, resyncPeriod time.Duration,
+	h cache.ResourceEventHandler, indexers cache.Indexers, transform transformFunc) (cache.Indexer, cache.Controller) {
+
+	if indexers == nil {
+		indexers = cache.Indexers{}
+	}
+
+	if len(namespaces) == 1 {
+		return cache.NewIndexerInformer(
+			newTransformedListWatch(cache.NewListWatchFromClient(client, resource, namespaces[0], fields.Everything()), transform),
+			objType, resyncPeriod, h, indexers)
+	}
+
+	indexer := &multiNamespaceIndexer{
+		indexers: make(map[string]cache.Indexer),
+	}
+	var controllers multiNamespaceController
+	for _, ns := range namespaces {
+		i, c := cache.NewIndexerInformer(
+			newTransformedListWatch(cache.NewListWatchFromClient(client, resource, ns, fields.Everything()), transform),
+			objType, resyncPeriod, h, indexers)
+		indexer.namespaces = append(indexer.namespaces, ns)
+		indexer.indexers[ns] = i
+		controllers = append(controllers, c)
+	}
+
+	return indexer, controllers
+}
+
+// multiNamespaceIndexer combines the indexers of several namespaces,
+// routing every object to the indexer of its namespace
+type multiNamespaceIndexer struct {
+	// namespaces keeps the order of the namespaces
+	namespaces []string
+	indexers   map[string]cache.Indexer
+}
+
+func (m *multiNamespaceIndexer) indexerOf(obj interface{}) (cache.Indexer, error) {
+	if key, ok := obj.(cache.ExplicitKey); ok {
+		return m.indexerOfKey(string(key))
+	}
+
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := m.indexers[o.GetNamespace()]
+	if !ok {
+		return nil, fmt.Errorf("namespace %v is not watched", o.GetNamespace())
+	}
+	return i, nil
+}
+
+func (m *multiNamespaceIndexer) indexerOfKey(key string) (cache.Indexer, error) {
+	ns, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := m.indexers[ns]
+	if !ok {
+		return nil, fmt.Errorf("namespace %v is not watched", ns)
+	}
+	return i, nil
+}
+
+// Add adds an object to the indexer of its namespace
+func (m *multiNamespaceIndexer) Add(obj interface{}) error {
+	i, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return i.Add(obj)
+}
+
+// Update updates an object in the indexer of its namespace
+func (m *multiNamespaceIndexer) Update(obj interface{}) error {
+	i, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return i.Update(obj)
+}
+
+// Delete deletes an object from the indexer of its namespace
+func (m *multiNamespaceIndexer) Delete(obj interface{}) error {
+	i, err := m.indexerOf(obj)
+	if err != nil {
+		return err
+	}
+	return i.Delete(obj)
+}
+
+// List returns the objects of all the namespaces
+func (m *multiNamespaceIndexer) List() []interface{} {
+	var list []interface{}
+	for _, ns := range m.namespaces {
+		list = append(list, m.indexers[ns].List()...)
+	}
+	return list
+}
+
+// ListKeys returns the keys of the objects of all the namespaces
+func (m *multiNamespaceIndexer) ListKeys() []string {
+	var keys []string
+	for _, ns := range m.namespaces {
+		keys = append(keys, m.indexers[ns].ListKeys()...)
+	}
+	return keys
+}
+
+// Get returns an object from the indexer of its namespace
+func (m *multiNamespaceIndexer) Get(obj interface{}) (interface{}, bool, error) {
+	i, err := m.indexerOf(obj)
+	if err != nil {
+		return nil, false, nil
+	}
+	return i.Get(obj)
+}
+
+// GetByKey returns an object using the namespace and name as key. Objects
+// of namespaces that are not watched do not exist.
+func (m *multiNamespaceIndexer) GetByKey(key string) (interface{}, bool, error) {
+	i, err := m.indexerOfKey(key)
+	if err != nil {
+		return nil, false, nil
+	}
+	return i.GetByKey(key)
+}
+
+// Replace replaces the content of the indexers of all the namespaces
+func (m *multiNamespaceIndexer) Replace(list []interface{}, resourceVersion string) error {
+	byNamespace := make(map[string][]interface{})
+	for _, obj := range list {
+		o, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		byNamespace[o.GetNamespace()] = append(byNamespace[o.GetNamespace()], obj)
+	}
+
+	for ns := range byNamespace {
+		if _, ok := m.indexers[ns]; !ok {
+			return fmt.Errorf("namespace %v is not watched", ns)
+		}
+	}
+
+	for _, ns := range m.namespaces {
+		err := m.indexers[ns].Replace(byNamespace[ns], resourceVersion)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resync resyncs the indexers of all the namespaces
+func (m *multiNamespaceIndexer) Resync() error {
+	for _, ns := range m.namespaces {
+		err := m.indexers[ns].Resync()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Index returns the objects of all the namespaces matching the index of the object
+func (m *multiNamespaceIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var list []interface{}
+	for _, ns := range m.namespaces {
+		items, err := m.indexers[ns].Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+	return list, nil
+}
+
+// IndexKeys returns the keys of the objects of all the namespaces with the index key
+func (m *multiNamespaceIndexer) IndexKeys(indexName, indexKey string) ([]string, error) {
+	var keys []string
+	for _, ns := range m.namespaces {
+		items, err := m.indexers[ns].IndexKeys(indexName, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, items...)
+	}
+	return keys, nil
+}
+
+// ListIndexFuncValues returns the values of the index in all the namespaces
+func (m *multiNamespaceIndexer) ListIndexFuncValues(indexName string) []string {
+	var values []string
+	for _, ns := range m.namespaces {
+		values = append(values, m.indexers[ns].ListIndexFuncValues(indexName)...)
+	}
+	return values
+}
+
+// ByIndex returns the objects of all the namespaces with the index key
+func (m *multiNamespaceIndexer) ByIndex(indexName, indexKey string) ([]interface{}, error) {
+	var list []interface{}
+	for _, ns := range m.namespaces {
+		items, err := m.indexers[ns].ByIndex(indexName, indexKey)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+	return list, nil
+}
+
+// GetIndexers returns the indexers, which are the same in all the namespaces
+func (m *multiNamespaceIndexer) GetIndexers() cache.Indexers {
+	return m.indexers[m.namespaces[0]].GetIndexers()
+}
+
+// AddIndexers adds the indexers in all the namespaces
+func (m *multiNamespaceIndexer) AddIndexers(newIndexers cache.Indexers) error {
+	for _, ns := range m.namespaces {
+		err := m.indexers[ns].AddIndexers(newIndexers)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multiNamespaceController runs the controllers of several namespaces
+type multiNamespaceController []cache.Controller
+
+// Run runs the controllers of all the namespaces until stopCh is closed
+func (m multiNamespaceController) Run(stopCh <-chan struct{}) {
+	for _, c := range m {
+		go c.Run(stopCh)
+	}
+	<-stopCh
+}
+
+// HasSynced returns true if the controllers of all the namespaces synced
+func (m multiNamespaceController) HasSynced() bool {
+	for _, c := range m {
+		if !c.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion is not meaningful across namespaces
+func (m multiNamespaceController) LastSyncResourceVersion() string {
+	return ""
+}
+
+// eventsNamespace returns the namespace of the client used to create the
+// events, which is not restricted if several namespaces are watched
+func eventsNamespace(namespaces []string) string {
+	if len(namespaces) == 1 {
+		return namespaces[0]
+	}
+	return apiv1.NamespaceAll
+}
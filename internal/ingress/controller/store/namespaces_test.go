@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sort"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newMultiNamespaceIndexer(namespaces ...string) *multiNamespaceIndexer {
+	m := &multiNamespaceIndexer{
+		indexers: make(map[string]cache.Indexer),
+	}
+	for _, ns := range namespaces {
+		m.namespaces = append(m.namespaces, ns)
+		m.indexers[ns] = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	}
+	return m
+}
+
+func newService(ns, name string) *apiv1.Service {
+	return &apiv1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}}
+}
+
+func TestMultiNamespaceIndexer(t *testing.T) {
+	m := newMultiNamespaceIndexer("frontend", "backend")
+
+	for _, svc := range []*apiv1.Service{newService("frontend", "web"), newService("backend", "api")} {
+		if err := m.Add(svc); err != nil {
+			t.Fatalf("unexpected error adding service %v: %v", svc.Name, err)
+		}
+	}
+	if err := m.Add(newService("monitoring", "prometheus")); err == nil {
+		t.Errorf("expected an error adding a service of a namespace that is not watched")
+	}
+
+	keys := m.ListKeys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "backend/api" || keys[1] != "frontend/web" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	_, exists, err := m.GetByKey("backend/api")
+	if err != nil || !exists {
+		t.Errorf("expected service backend/api to exist (err: %v)", err)
+	}
+	_, exists, err = m.GetByKey("monitoring/prometheus")
+	if err != nil || exists {
+		t.Errorf("expected service monitoring/prometheus to not exist (err: %v)", err)
+	}
+
+	err = m.Replace([]interface{}{newService("frontend", "static")}, "")
+	if err != nil {
+		t.Fatalf("unexpected error replacing the services: %v", err)
+	}
+	keys = m.ListKeys()
+	if len(keys) != 1 || keys[0] != "frontend/static" {
+		t.Errorf("unexpected keys after replace %v", keys)
+	}
+
+	if err := m.Delete(newService("frontend", "static")); err != nil {
+		t.Errorf("unexpected error deleting service: %v", err)
+	}
+	if len(m.List()) != 0 {
+		t.Errorf("expected no services but returned %v", m.List())
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sort"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// NginxDefaultsLister makes a Store that lists NginxDefaults.
+type NginxDefaultsLister struct {
+	cache.Store
+}
+
+// defaultsResolver is a resolver that returns the defaults of an Ingress,
+// the configuration of the ConfigMap overridden by its NginxDefaults
+type defaultsResolver struct {
+	resolver.Resolver
+
+	backend defaults.Backend
+}
+
+// GetDefaultBackend returns the defaults of the Ingress
+func (r defaultsResolver) GetDefaultBackend() defaults.Backend {
+	return r.backend
+}
+
+// ingressDefaults returns the NginxDefaults of an Ingress in order of
+// precedence: first the defaults of the namespace of the ConfigMap, which
+// apply to all the Ingresses, and then the defaults of the namespace of the
+// Ingress. The defaults of a namespace are sorted by name.
+func (s k8sStore) ingressDefaults(ing *extensions.Ingress) []*v1alpha1.NginxDefaults {
+	ingClass := ing.Annotations[class.IngressKey]
+	if ingClass == "" {
+		ingClass = class.DefaultClass
+	}
+
+	var global, local []*v1alpha1.NginxDefaults
+	for _, item := range s.listers.NginxDefaults.List() {
+		nd := item.(*v1alpha1.NginxDefaults)
+		if nd.Namespace != s.defaultsNamespace && nd.Namespace != ing.Namespace {
+			continue
+		}
+
+		if nd.Spec.IngressClass != "" && nd.Spec.IngressClass != ingClass {
+			continue
+		}
+
+		if err := nd.Validate(); err != nil {
+			glog.Warningf("ignoring defaults %v/%v: %v", nd.Namespace, nd.Name, err)
+			continue
+		}
+
+		if nd.Namespace == s.defaultsNamespace {
+			global = append(global, nd)
+		} else {
+			local = append(local, nd)
+		}
+	}
+
+	for _, nds := range [][]*v1alpha1.NginxDefaults{global, local} {
+		sort.Slice(nds, func(i, j int) bool {
+			return nds[i].Name < nds[j].Name
+		})
+	}
+
+	return append(global, local...)
+}
+
+// applyDefaults overrides the defaults of a backend with the non empty
+// fields of the specification of NginxDefaults
+func applyDefaults(backend defaults.Backend, spec v1alpha1.NginxDefaultsSpec) defaults.Backend {
+	if spec.ProxyConnectTimeout > 0 {
+		backend.ProxyConnectTimeout = spec.ProxyConnectTimeout
+	}
+	if spec.ProxySendTimeout > 0 {
+		backend.ProxySendTimeout = spec.ProxySendTimeout
+	}
+	if spec.ProxyReadTimeout > 0 {
+		backend.ProxyReadTimeout = spec.ProxyReadTimeout
+	}
+	if spec.ProxyBodySize != "" {
+		backend.ProxyBodySize = spec.ProxyBodySize
+	}
+	if spec.ProxyBufferSize != "" {
+		backend.ProxyBufferSize = spec.ProxyBufferSize
+	}
+	if spec.ProxyBuffersNumber > 0 {
+		backend.ProxyBuffersNumber = spec.ProxyBuffersNumber
+	}
+	if spec.ProxyBuffering != "" {
+		backend.ProxyBuffering = spec.ProxyBuffering
+	}
+	if spec.ProxyRequestBuffering != "" {
+		backend.ProxyRequestBuffering = spec.ProxyRequestBuffering
+	}
+	if spec.LogFormat != "" {
+		backend.LogFormat = spec.LogFormat
+	}
+
+	return backend
+}
+
+// annotationExtractor returns the extractor of the annotations of an
+// Ingress, which uses the NginxDefaults of the Ingress as defaults
+func (s *k8sStore) annotationExtractor(ing *extensions.Ingress) annotations.Extractor {
+	nds := s.ingressDefaults(ing)
+	if len(nds) == 0 {
+		return s.annotations
+	}
+
+	backend := s.GetDefaultBackend()
+	for _, nd := range nds {
+		backend = applyDefaults(backend, nd.Spec)
+	}
+
+	return annotations.NewAnnotationExtractor(defaultsResolver{s, backend})
+}
+
+// syncDefaults parses again the annotations of the Ingresses after a
+// change of NginxDefaults
+func (s *k8sStore) syncDefaults(obj interface{}) {
+	for _, ing := range append(s.ListIngresses(), s.ListGatewayIngresses()...) {
+		s.extractAnnotations(ing)
+	}
+
+	s.updateCh <- Event{
+		Type: ConfigurationEvent,
+		Obj:  obj,
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func TestAnnotationExtractorWithDefaults(t *testing.T) {
+	s := &k8sStore{
+		listers: &Lister{
+			NginxDefaults: NginxDefaultsLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		},
+		backendConfig:     ngx_config.NewDefault(),
+		defaultsNamespace: "ingress-nginx",
+	}
+	s.annotations = annotations.NewAnnotationExtractor(s)
+
+	s.listers.NginxDefaults.Add(&v1alpha1.NginxDefaults{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ingress-nginx", Name: "global"},
+		Spec: v1alpha1.NginxDefaultsSpec{
+			ProxyReadTimeout: 120,
+			ProxyBodySize:    "8m",
+			LogFormat:        "$remote_addr $status",
+		},
+	})
+	s.listers.NginxDefaults.Add(&v1alpha1.NginxDefaults{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "timeouts"},
+		Spec: v1alpha1.NginxDefaultsSpec{
+			ProxyReadTimeout: 300,
+		},
+	})
+	s.listers.NginxDefaults.Add(&v1alpha1.NginxDefaults{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "other-class"},
+		Spec: v1alpha1.NginxDefaultsSpec{
+			IngressClass:    "other",
+			ProxyBufferSize: "64k",
+		},
+	})
+	s.listers.NginxDefaults.Add(&v1alpha1.NginxDefaults{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "invalid"},
+		Spec: v1alpha1.NginxDefaultsSpec{
+			ProxyBodySize: "8 MB",
+		},
+	})
+
+	def := s.GetDefaultBackend()
+
+	tests := []struct {
+		title       string
+		namespace   string
+		annotations map[string]string
+		readTimeout int
+		bodySize    string
+		bufferSize  string
+		logFormat   string
+	}{
+		{"namespace defaults", "team-a", nil, 300, "8m", def.ProxyBufferSize, "$remote_addr $status"},
+		{"global defaults", "team-b", nil, 120, "8m", def.ProxyBufferSize, "$remote_addr $status"},
+		{"annotations", "team-a", map[string]string{
+			"nginx.ingress.kubernetes.io/proxy-read-timeout": "30",
+		}, 30, "8m", def.ProxyBufferSize, "$remote_addr $status"},
+		{"class defaults", "team-a", map[string]string{
+			class.IngressKey: "other",
+		}, 300, "8m", "64k", "$remote_addr $status"},
+	}
+
+	for _, test := range tests {
+		ing := &extensions.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   test.namespace,
+				Name:        "foo",
+				Annotations: test.annotations,
+			},
+		}
+
+		anns := s.annotationExtractor(ing).Extract(ing)
+		if anns.Proxy.ReadTimeout != test.readTimeout {
+			t.Errorf("%v: expected read timeout %v but returned %v", test.title, test.readTimeout, anns.Proxy.ReadTimeout)
+		}
+		if anns.Proxy.BodySize != test.bodySize {
+			t.Errorf("%v: expected body size %v but returned %v", test.title, test.bodySize, anns.Proxy.BodySize)
+		}
+		if anns.Proxy.BufferSize != test.bufferSize {
+			t.Errorf("%v: expected buffer size %v but returned %v", test.title, test.bufferSize, anns.Proxy.BufferSize)
+		}
+		if anns.LogFormat != test.logFormat {
+			t.Errorf("%v: expected log format %v but returned %v", test.title, test.logFormat, anns.LogFormat)
+		}
+	}
+
+	// without defaults the ConfigMap is used
+	ing := &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-c", Name: "foo"},
+	}
+	s.listers.NginxDefaults.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	anns := s.annotationExtractor(ing).Extract(ing)
+	if anns.Proxy.ReadTimeout != def.ProxyReadTimeout || anns.LogFormat != "" {
+		t.Errorf("expected the defaults of the ConfigMap but returned %v and log format %v", anns.Proxy, anns.LogFormat)
+	}
+}
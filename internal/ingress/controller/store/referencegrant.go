@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// ReferenceGrantLister makes a Store that lists ReferenceGrants.
+type ReferenceGrantLister struct {
+	cache.Store
+}
+
+// CanReferenceSecret returns true if the Ingresses of a namespace can
+// reference a Secret (namespace/name). The Secrets of other namespaces
+// can only be referenced if a ReferenceGrant of the namespace of the
+// Secret allows it.
+func (s k8sStore) CanReferenceSecret(namespace, secret string) bool {
+	ns, name, err := k8s.ParseNameNS(secret)
+	if err != nil {
+		return false
+	}
+
+	if ns == namespace {
+		return true
+	}
+
+	for _, item := range s.listers.ReferenceGrant.List() {
+		grant := item.(*v1alpha1.ReferenceGrant)
+		if grant.Namespace == ns && grantAllows(grant, namespace, name) {
+			return true
+		}
+	}
+
+	glog.V(3).Infof("secret %v is not shared with namespace %v", secret, namespace)
+	return false
+}
+
+// grantAllows returns true if the grant allows the Ingresses of a
+// namespace to reference a Secret of the namespace of the grant
+func grantAllows(grant *v1alpha1.ReferenceGrant, namespace, secret string) bool {
+	from := false
+	for _, f := range grant.Spec.From {
+		if f.Namespace == namespace {
+			from = true
+			break
+		}
+	}
+
+	if !from {
+		return false
+	}
+
+	if len(grant.Spec.To) == 0 {
+		return true
+	}
+
+	for _, t := range grant.Spec.To {
+		if t.Name == secret {
+			return true
+		}
+	}
+
+	return false
+}
+
+// syncReferences parses again the Ingresses and reads their Secrets after
+// a change of a ReferenceGrant, which can allow or deny the references to
+// the Secrets of other namespaces
+func (s *k8sStore) syncReferences(obj interface{}) {
+	for _, ing := range append(s.ListIngresses(), s.ListGatewayIngresses()...) {
+		s.extractAnnotations(ing)
+		s.ReadSecrets(ing)
+	}
+
+	s.updateCh <- Event{
+		Type: ConfigurationEvent,
+		Obj:  obj,
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+)
+
+func TestCanReferenceSecret(t *testing.T) {
+	s := k8sStore{
+		listers: &Lister{
+			ReferenceGrant: ReferenceGrantLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		},
+	}
+
+	s.listers.ReferenceGrant.Add(&v1alpha1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "certificates", Name: "wildcard"},
+		Spec: v1alpha1.ReferenceGrantSpec{
+			From: []v1alpha1.ReferenceGrantFrom{{Namespace: "team-a"}},
+			To:   []v1alpha1.ReferenceGrantTo{{Name: "wildcard"}},
+		},
+	})
+	s.listers.ReferenceGrant.Add(&v1alpha1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "auth", Name: "all"},
+		Spec: v1alpha1.ReferenceGrantSpec{
+			From: []v1alpha1.ReferenceGrantFrom{{Namespace: "team-a"}, {Namespace: "team-b"}},
+		},
+	})
+
+	tests := []struct {
+		namespace string
+		secret    string
+		expected  bool
+	}{
+		{"team-a", "team-a/tls", true},
+		{"team-a", "certificates/wildcard", true},
+		{"team-a", "certificates/other", false},
+		{"team-b", "certificates/wildcard", false},
+		{"team-b", "auth/users", true},
+		{"team-c", "auth/users", false},
+		{"team-a", "invalid", false},
+	}
+
+	for _, test := range tests {
+		allowed := s.CanReferenceSecret(test.namespace, test.secret)
+		if allowed != test.expected {
+			t.Errorf("%v referencing %v: expected %v but returned %v", test.namespace, test.secret, test.expected, allowed)
+		}
+	}
+}
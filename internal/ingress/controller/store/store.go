@@ -28,17 +28,21 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	cache_client "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+	gateway_v1alpha2 "k8s.io/ingress-nginx/internal/apis/gateway/v1alpha2"
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
@@ -48,6 +52,7 @@ import (
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
 	"k8s.io/ingress-nginx/internal/k8s"
 )
 
@@ -96,6 +101,17 @@ type Storer interface {
 
 	// ReadSecrets extracts information about secrets from an Ingress rule
 	ReadSecrets(*extensions.Ingress)
+
+	// ListStreamRoutes returns the list of StreamRoutes
+	ListStreamRoutes() []*v1alpha1.StreamRoute
+
+	// CanReferenceSecret returns true if the Ingresses of a namespace can
+	// reference a Secret (namespace/name) of another namespace
+	CanReferenceSecret(namespace, secret string) bool
+
+	// ListGatewayIngresses returns the Ingresses translated from the
+	// Gateway API resources
+	ListGatewayIngresses() []*extensions.Ingress
 }
 
 // EventType type of event associated with an informer
@@ -123,18 +139,37 @@ type Lister struct {
 	Ingress           IngressLister
 	Service           ServiceLister
 	Endpoint          EndpointLister
+	EndpointSlice     EndpointSliceLister
 	Secret            SecretLister
 	ConfigMap         ConfigMapLister
 	IngressAnnotation IngressAnnotationsLister
+	StreamRoute       StreamRouteLister
+	ReferenceGrant    ReferenceGrantLister
+	NginxDefaults     NginxDefaultsLister
+	GatewayClass      GatewayClassLister
+	Gateway           GatewayLister
+	HTTPRoute         HTTPRouteLister
+	TLSRoute          TLSRouteLister
 }
 
 // Controller defines the required controllers that interact agains the api server
 type Controller struct {
-	Ingress   cache.Controller
+	Ingress cache.Controller
+	// Endpoint watches the EndpointSlices instead of the Endpoints
+	// if the EndpointSlices are enabled
 	Endpoint  cache.Controller
 	Service   cache.Controller
 	Secret    cache.Controller
 	Configmap cache.Controller
+	// StreamRoute is nil if the custom resources are not enabled
+	StreamRoute cache.Controller
+	// ReferenceGrant is nil if the custom resources are not enabled
+	ReferenceGrant cache.Controller
+	// NginxDefaults is nil if the custom resources are not enabled
+	NginxDefaults cache.Controller
+	// Gateways contains the controllers of the Gateway API resources,
+	// it is empty if the Gateway API is not enabled
+	Gateways []cache.Controller
 }
 
 // Run initiates the synchronization of the controllers against the api server
@@ -144,13 +179,35 @@ func (c *Controller) Run(stopCh chan struct{}) {
 	go c.Secret.Run(stopCh)
 	go c.Configmap.Run(stopCh)
 
-	// Wait for all involved caches to be synced, before processing items from the queue is started
-	if !cache.WaitForCacheSync(stopCh,
+	synced := []cache.InformerSynced{
 		c.Endpoint.HasSynced,
 		c.Service.HasSynced,
 		c.Secret.HasSynced,
 		c.Configmap.HasSynced,
-	) {
+	}
+
+	if c.StreamRoute != nil {
+		go c.StreamRoute.Run(stopCh)
+		synced = append(synced, c.StreamRoute.HasSynced)
+	}
+
+	if c.ReferenceGrant != nil {
+		go c.ReferenceGrant.Run(stopCh)
+		synced = append(synced, c.ReferenceGrant.HasSynced)
+	}
+
+	if c.NginxDefaults != nil {
+		go c.NginxDefaults.Run(stopCh)
+		synced = append(synced, c.NginxDefaults.HasSynced)
+	}
+
+	for _, gc := range c.Gateways {
+		go gc.Run(stopCh)
+		synced = append(synced, gc.HasSynced)
+	}
+
+	// Wait for all involved caches to be synced, before processing items from the queue is started
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		runtime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 	}
 
@@ -191,6 +248,13 @@ type k8sStore struct {
 	// secret in the annotations.
 	secretIngressMap map[string]sets.String
 
+	// configMapIngressMap contains information about which ingress references
+	// a configmap in the annotations.
+	configMapIngressMap map[string]sets.String
+	// configMapIngressLock protects configMapIngressMap, updated by the
+	// ingress informer and read by the configmap informer
+	configMapIngressLock *sync.Mutex
+
 	filesystem file.Filesystem
 
 	// updateCh
@@ -200,13 +264,27 @@ type k8sStore struct {
 	mu *sync.Mutex
 
 	defaultSSLCertificate string
+
+	// gatewayIngresses contains the Ingresses translated from the
+	// Gateway API resources
+	gatewayIngresses *gatewayIngresses
+
+	// defaultsNamespace is the namespace of the ConfigMap, whose
+	// NginxDefaults apply to all the Ingresses
+	defaultsNamespace string
 }
 
 // New creates a new object store to be used in the ingress controller
 func New(checkOCSP bool,
-	namespace, configmap, tcp, udp, defaultSSLCertificate string,
+	namespaces []string,
+	configmap, tcp, udp, defaultSSLCertificate, templateConfigMap string,
 	resyncPeriod time.Duration,
 	client clientset.Interface,
+	streamRouteClient rest.Interface,
+	referenceGrantClient rest.Interface,
+	nginxDefaultsClient rest.Interface,
+	gatewayClient rest.Interface,
+	endpointSliceClient rest.Interface,
 	fs file.Filesystem,
 	updateCh chan Event) Storer {
 
@@ -220,13 +298,18 @@ func New(checkOCSP bool,
 		backendConfig:         ngx_config.NewDefault(),
 		mu:                    &sync.Mutex{},
 		secretIngressMap:      make(map[string]sets.String),
+		configMapIngressMap:   make(map[string]sets.String),
+		configMapIngressLock:  &sync.Mutex{},
 		defaultSSLCertificate: defaultSSLCertificate,
+		gatewayIngresses:      &gatewayIngresses{},
 	}
 
+	store.defaultsNamespace, _, _ = k8s.ParseNameNS(configmap)
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
 	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
-		Interface: client.CoreV1().Events(namespace),
+		Interface: client.CoreV1().Events(eventsNamespace(namespaces)),
 	})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, apiv1.EventSource{
 		Component: "nginx-ingress-controller",
@@ -243,6 +326,10 @@ func New(checkOCSP bool,
 				glog.Infof("ignoring add for ingress %v based on annotation %v with value %v", addIng.Name, class.IngressKey, a)
 				return
 			}
+			if !shard.IsValid(addIng) {
+				glog.V(3).Infof("ignoring add for ingress %v/%v of another shard", addIng.Namespace, addIng.Name)
+				return
+			}
 
 			store.extractAnnotations(addIng)
 			recorder.Eventf(addIng, apiv1.EventTypeNormal, "CREATE", fmt.Sprintf("Ingress %s/%s", addIng.Namespace, addIng.Name))
@@ -270,8 +357,13 @@ func New(checkOCSP bool,
 				glog.Infof("ignoring delete for ingress %v based on annotation %v", delIng.Name, class.IngressKey)
 				return
 			}
+			if !shard.IsValid(delIng) {
+				glog.V(3).Infof("ignoring delete for ingress %v/%v of another shard", delIng.Namespace, delIng.Name)
+				return
+			}
 			recorder.Eventf(delIng, apiv1.EventTypeNormal, "DELETE", fmt.Sprintf("Ingress %s/%s", delIng.Namespace, delIng.Name))
 			store.listers.IngressAnnotation.Delete(delIng)
+			store.updateConfigMapIngressMap(k8s.MetaNamespaceKey(delIng), nil)
 			updateCh <- Event{
 				Type: DeleteEvent,
 				Obj:  obj,
@@ -280,8 +372,8 @@ func New(checkOCSP bool,
 		UpdateFunc: func(old, cur interface{}) {
 			oldIng := old.(*extensions.Ingress)
 			curIng := cur.(*extensions.Ingress)
-			validOld := class.IsValid(oldIng)
-			validCur := class.IsValid(curIng)
+			validOld := class.IsValid(oldIng) && shard.IsValid(oldIng)
+			validCur := class.IsValid(curIng) && shard.IsValid(curIng)
 			if !validOld && validCur {
 				glog.Infof("creating ingress %v based on annotation %v", curIng.Name, class.IngressKey)
 				recorder.Eventf(curIng, apiv1.EventTypeNormal, "CREATE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
@@ -301,11 +393,16 @@ func New(checkOCSP bool,
 	}
 
 	secrEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			store.syncDefaultSSLCertificate(obj.(*apiv1.Secret))
+		},
 		UpdateFunc: func(old, cur interface{}) {
 			if !reflect.DeepEqual(old, cur) {
 				sec := cur.(*apiv1.Secret)
 				key := fmt.Sprintf("%v/%v", sec.Namespace, sec.Name)
 
+				store.syncDefaultSSLCertificate(sec)
+
 				// parse the ingress annotations (again)
 				if set, ok := store.secretIngressMap[key]; ok {
 					glog.Infof("secret %v changed and it is used in ingress annotations. Parsing...", key)
@@ -395,6 +492,32 @@ func New(checkOCSP bool,
 		},
 	}
 
+	sliceEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			updateCh <- Event{
+				Type: CreateEvent,
+				Obj:  obj,
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			updateCh <- Event{
+				Type: DeleteEvent,
+				Obj:  obj,
+			}
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldSlice := old.(*discovery.EndpointSlice)
+			curSlice := cur.(*discovery.EndpointSlice)
+			if !reflect.DeepEqual(oldSlice.Endpoints, curSlice.Endpoints) ||
+				!reflect.DeepEqual(oldSlice.Ports, curSlice.Ports) {
+				updateCh <- Event{
+					Type: UpdateEvent,
+					Obj:  cur,
+				}
+			}
+		},
+	}
+
 	mapEventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			m := obj.(*apiv1.ConfigMap)
@@ -407,6 +530,44 @@ func New(checkOCSP bool,
 					Obj:  obj,
 				}
 			}
+			if mapKey == templateConfigMap {
+				glog.V(2).Infof("adding template configmap %v to backend", mapKey)
+				updateCh <- Event{
+					Type: ConfigurationEvent,
+					Obj:  obj,
+				}
+			}
+			// parse the ingress annotations (again)
+			if store.syncConfigMapIngresses(mapKey) {
+				updateCh <- Event{
+					Type: ConfigurationEvent,
+					Obj:  obj,
+				}
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			m, ok := obj.(*apiv1.ConfigMap)
+			if !ok {
+				// If we reached here it means the configmap was deleted but its final state is unrecorded.
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					glog.Errorf("couldn't get object from tombstone %#v", obj)
+					return
+				}
+				m, ok = tombstone.Obj.(*apiv1.ConfigMap)
+				if !ok {
+					glog.Errorf("Tombstone contained object that is not a ConfigMap: %#v", obj)
+					return
+				}
+			}
+			// parse the ingress annotations (again)
+			mapKey := fmt.Sprintf("%s/%s", m.Namespace, m.Name)
+			if store.syncConfigMapIngresses(mapKey) {
+				updateCh <- Event{
+					Type: ConfigurationEvent,
+					Obj:  m,
+				}
+			}
 		},
 		UpdateFunc: func(old, cur interface{}) {
 			if !reflect.DeepEqual(old, cur) {
@@ -421,38 +582,179 @@ func New(checkOCSP bool,
 					}
 				}
 				// updates to configuration configmaps can trigger an update
-				if mapKey == tcp || mapKey == udp {
+				if mapKey == tcp || mapKey == udp || mapKey == templateConfigMap {
 					recorder.Eventf(m, apiv1.EventTypeNormal, "UPDATE", fmt.Sprintf("ConfigMap %v", mapKey))
 					updateCh <- Event{
 						Type: ConfigurationEvent,
 						Obj:  cur,
 					}
 				}
+				// parse the ingress annotations (again)
+				if store.syncConfigMapIngresses(mapKey) {
+					updateCh <- Event{
+						Type: ConfigurationEvent,
+						Obj:  cur,
+					}
+				}
+			}
+		},
+	}
+
+	routeEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			updateCh <- Event{
+				Type: CreateEvent,
+				Obj:  obj,
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			updateCh <- Event{
+				Type: DeleteEvent,
+				Obj:  obj,
+			}
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldRoute := old.(*v1alpha1.StreamRoute)
+			curRoute := cur.(*v1alpha1.StreamRoute)
+			if !reflect.DeepEqual(oldRoute.Spec, curRoute.Spec) {
+				updateCh <- Event{
+					Type: UpdateEvent,
+					Obj:  cur,
+				}
+			}
+		},
+	}
+
+	grantEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			store.syncReferences(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			store.syncReferences(obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldGrant := old.(*v1alpha1.ReferenceGrant)
+			curGrant := cur.(*v1alpha1.ReferenceGrant)
+			if !reflect.DeepEqual(oldGrant.Spec, curGrant.Spec) {
+				store.syncReferences(cur)
+			}
+		},
+	}
+
+	defaultsEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			store.syncDefaults(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			store.syncDefaults(obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldDefaults := old.(*v1alpha1.NginxDefaults)
+			curDefaults := cur.(*v1alpha1.NginxDefaults)
+			if !reflect.DeepEqual(oldDefaults.Spec, curDefaults.Spec) {
+				store.syncDefaults(cur)
+			}
+		},
+	}
+
+	gatewayEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			store.syncGateways(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			store.syncGateways(obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldMeta, _ := meta.Accessor(old)
+			curMeta, _ := meta.Accessor(cur)
+			if oldMeta.GetResourceVersion() != curMeta.GetResourceVersion() {
+				store.syncGateways(cur)
 			}
 		},
 	}
 
 	store.listers.IngressAnnotation.Store = cache_client.NewStore(cache_client.DeletionHandlingMetaNamespaceKeyFunc)
 
-	store.listers.Ingress.Store, store.cache.Ingress = cache.NewInformer(
-		cache.NewListWatchFromClient(client.ExtensionsV1beta1().RESTClient(), "ingresses", namespace, fields.Everything()),
-		&extensions.Ingress{}, resyncPeriod, ingEventHandler)
+	store.listers.Ingress.Store, store.cache.Ingress = newNamespacedInformer(
+		client.ExtensionsV1beta1().RESTClient(), "ingresses", namespaces,
+		&extensions.Ingress{}, resyncPeriod, ingEventHandler, nil)
+
+	if endpointSliceClient != nil {
+		store.listers.EndpointSlice.Indexer, store.cache.Endpoint = newTransformedInformer(
+			endpointSliceClient, "endpointslices", namespaces,
+			&discovery.EndpointSlice{}, resyncPeriod, sliceEventHandler,
+			cache.Indexers{endpointSliceServiceIndex: endpointSliceServiceIndexFunc}, transformEndpointSlice)
+		store.listers.Endpoint.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	} else {
+		store.listers.Endpoint.Store, store.cache.Endpoint = newTransformedInformer(
+			client.CoreV1().RESTClient(), "endpoints", namespaces,
+			&apiv1.Endpoints{}, resyncPeriod, eventHandler, nil, transformEndpoints)
+	}
+
+	store.listers.Secret.Store, store.cache.Secret = newTransformedInformer(
+		client.CoreV1().RESTClient(), "secrets", namespaces,
+		&apiv1.Secret{}, resyncPeriod, secrEventHandler, nil, transformSecret)
 
-	store.listers.Endpoint.Store, store.cache.Endpoint = cache.NewInformer(
-		cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "endpoints", namespace, fields.Everything()),
-		&apiv1.Endpoints{}, resyncPeriod, eventHandler)
+	store.listers.ConfigMap.Store, store.cache.Configmap = newTransformedInformer(
+		client.CoreV1().RESTClient(), "configmaps", namespaces,
+		&apiv1.ConfigMap{}, resyncPeriod, mapEventHandler, nil, transformConfigMap)
 
-	store.listers.Secret.Store, store.cache.Secret = cache.NewInformer(
-		cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "secrets", namespace, fields.Everything()),
-		&apiv1.Secret{}, resyncPeriod, secrEventHandler)
+	store.listers.Service.Store, store.cache.Service = newTransformedInformer(
+		client.CoreV1().RESTClient(), "services", namespaces,
+		&apiv1.Service{}, resyncPeriod, cache.ResourceEventHandlerFuncs{}, nil, transformService)
 
-	store.listers.ConfigMap.Store, store.cache.Configmap = cache.NewInformer(
-		cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "configmaps", namespace, fields.Everything()),
-		&apiv1.ConfigMap{}, resyncPeriod, mapEventHandler)
+	if streamRouteClient != nil {
+		store.listers.StreamRoute.Store, store.cache.StreamRoute = newNamespacedInformer(
+			streamRouteClient, "streamroutes", namespaces,
+			&v1alpha1.StreamRoute{}, resyncPeriod, routeEventHandler, nil)
+	} else {
+		store.listers.StreamRoute.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	}
 
-	store.listers.Service.Store, store.cache.Service = cache.NewInformer(
-		cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "services", namespace, fields.Everything()),
-		&apiv1.Service{}, resyncPeriod, cache.ResourceEventHandlerFuncs{})
+	if referenceGrantClient != nil {
+		store.listers.ReferenceGrant.Store, store.cache.ReferenceGrant = newNamespacedInformer(
+			referenceGrantClient, "referencegrants", namespaces,
+			&v1alpha1.ReferenceGrant{}, resyncPeriod, grantEventHandler, nil)
+	} else {
+		store.listers.ReferenceGrant.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	}
+
+	if nginxDefaultsClient != nil {
+		store.listers.NginxDefaults.Store, store.cache.NginxDefaults = newNamespacedInformer(
+			nginxDefaultsClient, "nginxdefaults", namespaces,
+			&v1alpha1.NginxDefaults{}, resyncPeriod, defaultsEventHandler, nil)
+	} else {
+		store.listers.NginxDefaults.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	}
+
+	if gatewayClient != nil {
+		var c cache.Controller
+		// the GatewayClasses are not namespaced
+		store.listers.GatewayClass.Store, c = newNamespacedInformer(
+			gatewayClient, "gatewayclasses", []string{""},
+			&gateway_v1alpha2.GatewayClass{}, resyncPeriod, gatewayEventHandler, nil)
+		store.cache.Gateways = append(store.cache.Gateways, c)
+
+		store.listers.Gateway.Store, c = newNamespacedInformer(
+			gatewayClient, "gateways", namespaces,
+			&gateway_v1alpha2.Gateway{}, resyncPeriod, gatewayEventHandler, nil)
+		store.cache.Gateways = append(store.cache.Gateways, c)
+
+		store.listers.HTTPRoute.Store, c = newNamespacedInformer(
+			gatewayClient, "httproutes", namespaces,
+			&gateway_v1alpha2.HTTPRoute{}, resyncPeriod, gatewayEventHandler, nil)
+		store.cache.Gateways = append(store.cache.Gateways, c)
+
+		store.listers.TLSRoute.Store, c = newNamespacedInformer(
+			gatewayClient, "tlsroutes", namespaces,
+			&gateway_v1alpha2.TLSRoute{}, resyncPeriod, gatewayEventHandler, nil)
+		store.cache.Gateways = append(store.cache.Gateways, c)
+	} else {
+		store.listers.GatewayClass.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+		store.listers.Gateway.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+		store.listers.HTTPRoute.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+		store.listers.TLSRoute.Store = cache_client.NewStore(cache_client.MetaNamespaceKeyFunc)
+	}
 
 	return store
 }
@@ -463,7 +765,7 @@ func (s *k8sStore) extractAnnotations(ing *extensions.Ingress) {
 	key := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
 	glog.V(3).Infof("updating annotations information for ingres %v", key)
 
-	anns := s.annotations.Extract(ing)
+	anns := s.annotationExtractor(ing).Extract(ing)
 
 	secName := anns.BasicDigestAuth.Secret
 	if secName != "" {
@@ -487,12 +789,139 @@ func (s *k8sStore) extractAnnotations(ing *extensions.Ingress) {
 		}
 	}
 
+	secName = anns.CertificateAuth.CRLSecret
+	if secName != "" {
+		if _, ok := s.secretIngressMap[secName]; !ok {
+			s.secretIngressMap[secName] = sets.NewString()
+		}
+		v := s.secretIngressMap[secName]
+		if !v.Has(key) {
+			v.Insert(key)
+		}
+	}
+
+	secName = anns.ProxySSL.Secret
+	if secName != "" {
+		if _, ok := s.secretIngressMap[secName]; !ok {
+			s.secretIngressMap[secName] = sets.NewString()
+		}
+		v := s.secretIngressMap[secName]
+		if !v.Has(key) {
+			v.Insert(key)
+		}
+	}
+
+	secName = anns.ProxySSL.CASecret
+	if secName != "" {
+		if _, ok := s.secretIngressMap[secName]; !ok {
+			s.secretIngressMap[secName] = sets.NewString()
+		}
+		v := s.secretIngressMap[secName]
+		if !v.Has(key) {
+			v.Insert(key)
+		}
+	}
+
+	s.updateConfigMapIngressMap(key, configMapsOf(ing))
+
 	err := s.listers.IngressAnnotation.Update(anns)
 	if err != nil {
 		glog.Error(err)
 	}
 }
 
+// configMapAnnotations contains the annotations that reference
+// a configmap of the namespace of the ingress
+var configMapAnnotations = []string{
+	"whitelist-source-range-configmap",
+	"denylist-source-range-configmap",
+	"modsecurity-rules-configmap",
+	"request-headers-configmap",
+	"maintenance-page-configmap",
+	"fastcgi-params-configmap",
+}
+
+// configMapsOf returns the configmaps referenced in the annotations.
+// The values of the annotations are read instead of the parsed
+// annotations, that do not contain the configmaps that are missing
+// or invalid, so the ingress is synced when they are fixed
+func configMapsOf(ing *extensions.Ingress) []string {
+	var configMaps []string
+	for _, name := range configMapAnnotations {
+		cmName, err := parser.GetStringAnnotation(name, ing)
+		if err == nil && cmName != "" {
+			configMaps = append(configMaps, fmt.Sprintf("%v/%v", ing.Namespace, cmName))
+		}
+	}
+
+	return configMaps
+}
+
+// updateConfigMapIngressMap replaces the configmaps referenced by the
+// ingress, removing the references the ingress does not use anymore.
+// The ingresses that were deleted do not reference any configmap
+func (s *k8sStore) updateConfigMapIngressMap(key string, configMaps []string) {
+	s.configMapIngressLock.Lock()
+	defer s.configMapIngressLock.Unlock()
+
+	referenced := sets.NewString()
+	for _, cmName := range configMaps {
+		if cmName != "" {
+			referenced.Insert(cmName)
+		}
+	}
+
+	for cmName, ingresses := range s.configMapIngressMap {
+		if referenced.Has(cmName) {
+			continue
+		}
+
+		ingresses.Delete(key)
+		if ingresses.Len() == 0 {
+			delete(s.configMapIngressMap, cmName)
+		}
+	}
+
+	for _, cmName := range referenced.List() {
+		if _, ok := s.configMapIngressMap[cmName]; !ok {
+			s.configMapIngressMap[cmName] = sets.NewString()
+		}
+		s.configMapIngressMap[cmName].Insert(key)
+	}
+}
+
+// configMapIngresses returns the ingresses that reference the configmap
+func (s *k8sStore) configMapIngresses(key string) []string {
+	s.configMapIngressLock.Lock()
+	defer s.configMapIngressLock.Unlock()
+
+	set, ok := s.configMapIngressMap[key]
+	if !ok {
+		return nil
+	}
+
+	return set.List()
+}
+
+// syncConfigMapIngresses parses again the annotations of the ingresses
+// that reference the configmap. Returns true if the configmap is used.
+func (s *k8sStore) syncConfigMapIngresses(key string) bool {
+	ingresses := s.configMapIngresses(key)
+	if len(ingresses) == 0 {
+		return false
+	}
+
+	glog.Infof("configmap %v changed and it is used in ingress annotations. Parsing...", key)
+	for _, name := range ingresses {
+		ing, _ := s.GetIngress(name)
+		if ing != nil {
+			s.extractAnnotations(ing)
+		}
+	}
+
+	return true
+}
+
 // GetSecret returns a Secret using the namespace and name as key
 func (s k8sStore) GetSecret(key string) (*apiv1.Secret, error) {
 	return s.listers.Secret.ByKey(key)
@@ -510,6 +939,16 @@ func (s k8sStore) ListLocalSecrets() []*ingress.SSLCert {
 	return certs
 }
 
+// ListStreamRoutes returns the list of StreamRoutes
+func (s k8sStore) ListStreamRoutes() []*v1alpha1.StreamRoute {
+	var routes []*v1alpha1.StreamRoute
+	for _, item := range s.listers.StreamRoute.List() {
+		routes = append(routes, item.(*v1alpha1.StreamRoute))
+	}
+
+	return routes
+}
+
 // GetService returns a Service using the namespace and name as key
 func (s k8sStore) GetService(key string) (*apiv1.Service, error) {
 	return s.listers.Service.ByKey(key)
@@ -530,6 +969,11 @@ func (s k8sStore) ListIngresses() []*extensions.Ingress {
 			continue
 		}
 
+		ing = shard.Filter(ing)
+		if ing == nil {
+			continue
+		}
+
 		ingresses = append(ingresses, ing)
 	}
 
@@ -559,6 +1003,10 @@ func (s k8sStore) GetConfigMap(key string) (*apiv1.ConfigMap, error) {
 }
 
 func (s k8sStore) GetServiceEndpoints(svc *apiv1.Service) (*apiv1.Endpoints, error) {
+	if s.listers.EndpointSlice.Indexer != nil {
+		return s.listers.EndpointSlice.GetServiceEndpoints(svc)
+	}
+
 	return s.listers.Endpoint.GetServiceEndpoints(svc)
 }
 
@@ -573,11 +1021,19 @@ func (s k8sStore) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error)
 		return nil, err
 	}
 
-	return &resolver.AuthSSLCert{
-		Secret:     name,
-		CAFileName: cert.CAFileName,
-		PemSHA:     cert.PemSHA,
-	}, nil
+	authCert := &resolver.AuthSSLCert{
+		Secret:      name,
+		CAFileName:  cert.CAFileName,
+		PemSHA:      cert.PemSHA,
+		CRLFileName: cert.CRLFileName,
+		CRLSHA:      cert.CRLSHA,
+	}
+	// the secrets with only a CA do not contain a certificate
+	if cert.Certificate != nil {
+		authCert.PemFileName = cert.PemFileName
+	}
+
+	return authCert, nil
 }
 
 // GetDefaultBackend returns the default backend
@@ -611,7 +1067,7 @@ func (s k8sStore) Run(stopCh chan struct{}) {
 
 	// initial sync of secrets to avoid unnecessary reloads
 	glog.Info("running initial sync of secrets")
-	for _, ing := range s.ListIngresses() {
+	for _, ing := range append(s.ListIngresses(), s.ListGatewayIngresses()...) {
 		s.ReadSecrets(ing)
 	}
 
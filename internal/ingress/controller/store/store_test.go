@@ -19,6 +19,8 @@ package store
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -29,12 +31,82 @@ import (
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/test/e2e/framework"
 )
 
+func TestConfigMapsOf(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "app",
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/whitelist-source-range-configmap": "whitelist",
+				"nginx.ingress.kubernetes.io/denylist-source-range-configmap":  "denylist",
+				"nginx.ingress.kubernetes.io/maintenance-mode":                 "true",
+				"nginx.ingress.kubernetes.io/maintenance-page-configmap":       "page",
+			},
+		},
+	}
+
+	// the configmaps are referenced even if they do not exist yet
+	expected := []string{"default/whitelist", "default/denylist", "default/page"}
+	if cms := configMapsOf(ing); !reflect.DeepEqual(cms, expected) {
+		t.Errorf("expected the configmaps %v but got %v", expected, cms)
+	}
+}
+
+func TestUpdateConfigMapIngressMap(t *testing.T) {
+	s := &k8sStore{
+		configMapIngressMap:  make(map[string]sets.String),
+		configMapIngressLock: &sync.Mutex{},
+	}
+
+	s.updateConfigMapIngressMap("default/app", []string{"default/whitelist", "", "default/headers"})
+	s.updateConfigMapIngressMap("default/other", []string{"default/whitelist"})
+
+	if ings := s.configMapIngresses("default/whitelist"); !reflect.DeepEqual(ings, []string{"default/app", "default/other"}) {
+		t.Errorf("expected the two ingresses referencing the configmap but returned %v", ings)
+	}
+
+	// the ingress does not reference the headers anymore
+	s.updateConfigMapIngressMap("default/app", []string{"default/whitelist"})
+	if ings := s.configMapIngresses("default/headers"); len(ings) != 0 {
+		t.Errorf("expected no ingresses referencing the configmap but returned %v", ings)
+	}
+	if _, ok := s.configMapIngressMap["default/headers"]; ok {
+		t.Errorf("expected the configmap without references to be removed")
+	}
+
+	// deleted ingress
+	s.updateConfigMapIngressMap("default/other", nil)
+	if ings := s.configMapIngresses("default/whitelist"); !reflect.DeepEqual(ings, []string{"default/app"}) {
+		t.Errorf("expected only the ingress that was not deleted but returned %v", ings)
+	}
+
+	// the ingress and configmap informers use the map concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.updateConfigMapIngressMap(fmt.Sprintf("default/app-%v", i), []string{"default/whitelist"})
+		}(i)
+		go func() {
+			defer wg.Done()
+			s.configMapIngresses("default/whitelist")
+		}()
+	}
+	wg.Wait()
+
+	if ings := s.configMapIngresses("default/whitelist"); len(ings) != 11 {
+		t.Errorf("expected 11 ingresses referencing the configmap but returned %v", ings)
+	}
+}
+
 func TestStore(t *testing.T) {
 	// TODO: find a way to avoid the need to use a real api server
 	home := os.Getenv("HOME")
@@ -66,13 +138,19 @@ func TestStore(t *testing.T) {
 
 		fs := newFS(t)
 		storer := New(true,
-			ns.Name,
+			[]string{ns.Name},
 			fmt.Sprintf("%v/config", ns.Name),
 			fmt.Sprintf("%v/tcp", ns.Name),
 			fmt.Sprintf("%v/udp", ns.Name),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
 			fs,
 			updateCh)
 
@@ -152,13 +230,19 @@ func TestStore(t *testing.T) {
 
 		fs := newFS(t)
 		storer := New(true,
-			ns.Name,
+			[]string{ns.Name},
 			fmt.Sprintf("%v/config", ns.Name),
 			fmt.Sprintf("%v/tcp", ns.Name),
 			fmt.Sprintf("%v/udp", ns.Name),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
 			fs,
 			updateCh)
 
@@ -292,13 +376,19 @@ func TestStore(t *testing.T) {
 
 		fs := newFS(t)
 		storer := New(true,
-			ns.Name,
+			[]string{ns.Name},
 			fmt.Sprintf("%v/config", ns.Name),
 			fmt.Sprintf("%v/tcp", ns.Name),
 			fmt.Sprintf("%v/udp", ns.Name),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
 			fs,
 			updateCh)
 
@@ -377,13 +467,19 @@ func TestStore(t *testing.T) {
 
 		fs := newFS(t)
 		storer := New(true,
-			ns.Name,
+			[]string{ns.Name},
 			fmt.Sprintf("%v/config", ns.Name),
 			fmt.Sprintf("%v/tcp", ns.Name),
 			fmt.Sprintf("%v/udp", ns.Name),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
 			fs,
 			updateCh)
 
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+)
+
+// transformFunc removes from an object the fields not used by the
+// controller before it is cached, reducing the memory used by the informers
+type transformFunc func(obj runtime.Object)
+
+// secretKeys are the keys of the secrets read by the controller
+var secretKeys = []string{
+	apiv1.TLSCertKey,
+	apiv1.TLSPrivateKeyKey,
+	"ca.crt",
+	"ca.crl",
+	"auth",
+	"dhparam.pem",
+}
+
+// newTransformedListWatch returns a ListerWatcher that applies the transform
+// function to the objects listed and watched with lw
+func newTransformedListWatch(lw *cache.ListWatch, transform transformFunc) cache.ListerWatcher {
+	if transform == nil {
+		return lw
+	}
+
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+
+			err = meta.EachListItem(list, func(obj runtime.Object) error {
+				transform(obj)
+				return nil
+			})
+			if err != nil {
+				glog.Warningf("unexpected error transforming the list: %v", err)
+			}
+
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, func(evt watch.Event) (watch.Event, bool) {
+				if evt.Type != watch.Error {
+					transform(evt.Object)
+				}
+				return evt, true
+			}), nil
+		},
+	}
+}
+
+// stripObjectMeta removes the metadata not used by the controller, like the
+// last applied configuration annotation of kubectl that duplicates the object
+func stripObjectMeta(m *metav1.ObjectMeta) {
+	m.Annotations = nil
+	m.Labels = nil
+	m.OwnerReferences = nil
+	m.Finalizers = nil
+	m.Initializers = nil
+}
+
+func transformEndpoints(obj runtime.Object) {
+	if ep, ok := obj.(*apiv1.Endpoints); ok {
+		stripObjectMeta(&ep.ObjectMeta)
+	}
+}
+
+func transformEndpointSlice(obj runtime.Object) {
+	if slice, ok := obj.(*discovery.EndpointSlice); ok {
+		// the label with the name of the service is used by the indexer
+		labels := slice.Labels
+		stripObjectMeta(&slice.ObjectMeta)
+		if name, ok := labels[discovery.LabelServiceName]; ok {
+			slice.Labels = map[string]string{discovery.LabelServiceName: name}
+		}
+	}
+}
+
+func transformService(obj runtime.Object) {
+	if svc, ok := obj.(*apiv1.Service); ok {
+		stripObjectMeta(&svc.ObjectMeta)
+	}
+}
+
+func transformConfigMap(obj runtime.Object) {
+	if cm, ok := obj.(*apiv1.ConfigMap); ok {
+		stripObjectMeta(&cm.ObjectMeta)
+	}
+}
+
+// transformSecret keeps only the keys of the secret that contain
+// certificates or authentication files, dropping for instance the release
+// information stored by Helm in secrets
+func transformSecret(obj runtime.Object) {
+	secret, ok := obj.(*apiv1.Secret)
+	if !ok {
+		return
+	}
+
+	stripObjectMeta(&secret.ObjectMeta)
+
+	data := secret.Data
+	secret.Data = nil
+	for _, key := range secretKeys {
+		if value, ok := data[key]; ok {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+			secret.Data[key] = value
+		}
+	}
+}
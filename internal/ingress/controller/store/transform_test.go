@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	discovery "k8s.io/ingress-nginx/internal/apis/discovery/v1beta1"
+)
+
+func newTestObjectMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      "test",
+		Namespace: "default",
+		Annotations: map[string]string{
+			"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		},
+		Labels:     map[string]string{"app": "test"},
+		Finalizers: []string{"test"},
+	}
+}
+
+func TestTransformSecret(t *testing.T) {
+	secret := &apiv1.Secret{
+		ObjectMeta: newTestObjectMeta(),
+		Data: map[string][]byte{
+			apiv1.TLSCertKey:       []byte("cert"),
+			apiv1.TLSPrivateKeyKey: []byte("key"),
+			"auth":                 []byte("auth"),
+			"release":              []byte("release"),
+		},
+	}
+
+	transformSecret(secret)
+
+	expected := map[string][]byte{
+		apiv1.TLSCertKey:       []byte("cert"),
+		apiv1.TLSPrivateKeyKey: []byte("key"),
+		"auth":                 []byte("auth"),
+	}
+	if !reflect.DeepEqual(secret.Data, expected) {
+		t.Errorf("expected %v but returned %v", expected, secret.Data)
+	}
+	if secret.Annotations != nil || secret.Labels != nil || secret.Finalizers != nil {
+		t.Errorf("expected the metadata to be stripped but returned %v", secret.ObjectMeta)
+	}
+	if secret.Name != "test" || secret.Namespace != "default" {
+		t.Errorf("expected the name and namespace to be kept but returned %v", secret.ObjectMeta)
+	}
+
+	other := &apiv1.Secret{
+		ObjectMeta: newTestObjectMeta(),
+		Data:       map[string][]byte{"release": []byte("release")},
+	}
+	transformSecret(other)
+	if other.Data != nil {
+		t.Errorf("expected no data but returned %v", other.Data)
+	}
+}
+
+func TestTransformEndpointSlice(t *testing.T) {
+	slice := &discovery.EndpointSlice{
+		ObjectMeta: newTestObjectMeta(),
+	}
+	slice.Labels[discovery.LabelServiceName] = "svc"
+
+	transformEndpointSlice(slice)
+
+	expected := map[string]string{discovery.LabelServiceName: "svc"}
+	if !reflect.DeepEqual(slice.Labels, expected) {
+		t.Errorf("expected %v but returned %v", expected, slice.Labels)
+	}
+	if slice.Annotations != nil {
+		t.Errorf("expected no annotations but returned %v", slice.Annotations)
+	}
+}
+
+func TestTransformedListWatch(t *testing.T) {
+	fw := watch.NewFake()
+	lw := newTransformedListWatch(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &apiv1.EndpointsList{
+				Items: []apiv1.Endpoints{{ObjectMeta: newTestObjectMeta()}},
+			}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return fw, nil
+		},
+	}, transformEndpoints)
+
+	list, err := lw.List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ep := range list.(*apiv1.EndpointsList).Items {
+		if ep.Annotations != nil || ep.Labels != nil {
+			t.Errorf("expected the metadata of the listed endpoints to be stripped but returned %v", ep.ObjectMeta)
+		}
+	}
+
+	w, err := lw.Watch(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+
+	go fw.Add(&apiv1.Endpoints{ObjectMeta: newTestObjectMeta()})
+	evt := <-w.ResultChan()
+	ep := evt.Object.(*apiv1.Endpoints)
+	if ep.Annotations != nil || ep.Labels != nil {
+		t.Errorf("expected the metadata of the watched endpoints to be stripped but returned %v", ep.ObjectMeta)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/apis/nginx/v1alpha1"
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// getStreamRouteServices returns the TCP or UDP services defined using
+// StreamRoute resources. The routes that use a port reserved by the ingress
+// controller or already used by the services of the configmaps (or by an
+// older route) are rejected, unless all the routes of the port use hosts
+// to route the TLS connections by server name (SNI).
+func (n *NGINXController) getStreamRouteServices(svcs []ingress.L4Service, proto apiv1.Protocol) []ingress.L4Service {
+	usedPorts := n.reservedStreamPorts()
+	for _, svc := range svcs {
+		usedPorts.Insert(svc.Port)
+	}
+
+	var routes []*v1alpha1.StreamRoute
+	for _, route := range n.store.ListStreamRoutes() {
+		if route.GetProtocol() == proto {
+			routes = append(routes, route)
+		}
+	}
+
+	// the oldest route wins in case of conflicts
+	sort.SliceStable(routes, func(i, j int) bool {
+		ti, tj := routes[i].CreationTimestamp, routes[j].CreationTimestamp
+		if ti.Equal(&tj) {
+			return fmt.Sprintf("%v/%v", routes[i].Namespace, routes[i].Name) < fmt.Sprintf("%v/%v", routes[j].Namespace, routes[j].Name)
+		}
+		return ti.Before(&tj)
+	})
+
+	sniPorts := map[int]*sniPort{}
+
+	var routeSvcs []ingress.L4Service
+	for _, route := range routes {
+		if err := route.Validate(); err != nil {
+			n.rejectStreamRoute(route, err.Error())
+			continue
+		}
+
+		sni, isSNIPort := sniPorts[route.Spec.Port]
+		if len(route.Spec.Hosts) > 0 && isSNIPort {
+			if reason := sni.conflict(route); reason != "" {
+				n.rejectStreamRoute(route, reason)
+				continue
+			}
+		} else if usedPorts.Has(route.Spec.Port) {
+			n.rejectStreamRoute(route, fmt.Sprintf("port %v is already in use", route.Spec.Port))
+			continue
+		}
+
+		svcKey := fmt.Sprintf("%v/%v", route.Namespace, route.Spec.Backend.ServiceName)
+		svc, err := n.store.GetService(svcKey)
+		if err != nil {
+			glog.Warningf("error getting service %v of stream route %v/%v: %v", svcKey, route.Namespace, route.Name, err)
+			continue
+		}
+
+		svcPort := route.Spec.Backend.ServicePort.String()
+		endps := n.getStreamEndpoints(svc, svcPort, proto)
+		if len(endps) == 0 {
+			glog.Warningf("service %v does not have any active endpoints for port %v and protocol %v", svcKey, svcPort, proto)
+			continue
+		}
+
+		usedPorts.Insert(route.Spec.Port)
+		if len(route.Spec.Hosts) > 0 {
+			if !isSNIPort {
+				sni = newSNIPort(route)
+				sniPorts[route.Spec.Port] = sni
+			}
+			sni.add(route)
+		}
+
+		l4Svc := ingress.L4Service{
+			Port:        route.Spec.Port,
+			ServerNames: route.Spec.Hosts,
+			Backend: ingress.L4Backend{
+				Name:      svc.Name,
+				Namespace: svc.Namespace,
+				Port:      route.Spec.Backend.ServicePort,
+				Protocol:  proto,
+				ProxyProtocol: ingress.ProxyProtocol{
+					Decode:  route.Spec.ProxyProtocol.Decode,
+					Encode:  route.Spec.ProxyProtocol.Encode,
+					Version: route.Spec.ProxyProtocol.Version,
+				},
+				ConnectTimeout: route.Spec.ConnectTimeout,
+				ProxyTimeout:   route.Spec.ProxyTimeout,
+				ReusePort:      route.Spec.ReusePort,
+			},
+			Endpoints: endps,
+		}
+		for _, tlv := range route.Spec.ProxyProtocol.TLVs {
+			l4Svc.Backend.ProxyProtocol.TLVs = append(l4Svc.Backend.ProxyProtocol.TLVs, ingress.ProxyProtocolTLV{
+				Type:  tlv.Type,
+				Value: tlv.Value,
+			})
+		}
+		if route.Spec.ProxyResponses != nil {
+			l4Svc.Backend.ProxyResponses = fmt.Sprintf("%v", *route.Spec.ProxyResponses)
+		}
+
+		routeSvcs = append(routeSvcs, l4Svc)
+	}
+
+	return routeSvcs
+}
+
+// sniPort contains the TCP routes that share a port, routing the TLS
+// connections using the server name (SNI) sent by the clients
+type sniPort struct {
+	// route is the oldest route of the port, that defines
+	// the configuration of the port
+	route    *v1alpha1.StreamRoute
+	hosts    sets.String
+	backends sets.String
+}
+
+func newSNIPort(route *v1alpha1.StreamRoute) *sniPort {
+	return &sniPort{
+		route:    route,
+		hosts:    sets.NewString(),
+		backends: sets.NewString(),
+	}
+}
+
+// add registers the hosts and the backend of a route
+func (p *sniPort) add(route *v1alpha1.StreamRoute) {
+	p.hosts.Insert(route.Spec.Hosts...)
+	p.backends.Insert(sniBackendKey(route))
+}
+
+// conflict returns the reason why a route cannot share the port,
+// or an empty string if the route can be added
+func (p *sniPort) conflict(route *v1alpha1.StreamRoute) string {
+	for _, host := range route.Spec.Hosts {
+		if p.hosts.Has(host) {
+			return fmt.Sprintf("host %v is already in use in port %v", host, route.Spec.Port)
+		}
+	}
+
+	if p.backends.Has(sniBackendKey(route)) {
+		return fmt.Sprintf("service %v port %v is already exposed in port %v", route.Spec.Backend.ServiceName, route.Spec.Backend.ServicePort.String(), route.Spec.Port)
+	}
+
+	// the settings of the server cannot change by server name
+	s1, s2 := p.route.Spec, route.Spec
+	if s1.ProxyProtocol.Decode != s2.ProxyProtocol.Decode ||
+		s1.ProxyProtocol.Encode != s2.ProxyProtocol.Encode ||
+		s1.ConnectTimeout != s2.ConnectTimeout ||
+		s1.ProxyTimeout != s2.ProxyTimeout {
+		return fmt.Sprintf("the PROXY protocol and timeouts must be equal to the ones of the route %v/%v using port %v", p.route.Namespace, p.route.Name, route.Spec.Port)
+	}
+
+	return ""
+}
+
+func sniBackendKey(route *v1alpha1.StreamRoute) string {
+	return fmt.Sprintf("%v/%v:%v", route.Namespace, route.Spec.Backend.ServiceName, route.Spec.Backend.ServicePort.String())
+}
+
+// rejectStreamRoute emits a warning event in a StreamRoute that
+// cannot be used to configure NGINX
+func (n *NGINXController) rejectStreamRoute(route *v1alpha1.StreamRoute, reason string) {
+	glog.Warningf("ignoring stream route %v/%v: %v", route.Namespace, route.Name, reason)
+
+	// the objects returned by the API server do not contain the kind
+	// required to create the reference of the event
+	ref := route.DeepCopy()
+	ref.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("StreamRoute"))
+	n.recorder.Event(ref, apiv1.EventTypeWarning, "RejectedStreamRoute", reason)
+}
@@ -20,12 +20,44 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/glog"
 
 	"github.com/paultag/sniff/parser"
 )
 
+const (
+	// helloBufferSize is the size of the buffer used to read the TLS
+	// Client Hello message that contains the hostname (SNI)
+	helloBufferSize = 4096
+	// copyBufferSize is the size of the buffers used to copy the data
+	// between the connections that are not TCP connections
+	copyBufferSize = 32 * 1024
+	// helloTimeout is the maximum time to wait for the TLS Client Hello
+	helloTimeout = 10 * time.Second
+	// dialTimeout is the maximum time to establish the connection
+	// with the passthrough server
+	dialTimeout = 10 * time.Second
+)
+
+var (
+	helloBufferPool = sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, helloBufferSize)
+			return &b
+		},
+	}
+	copyBufferPool = sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, copyBufferSize)
+			return &b
+		},
+	}
+)
+
 // TCPServer describes a server that works in passthrough mode
 type TCPServer struct {
 	Hostname      string
@@ -36,20 +68,36 @@ type TCPServer struct {
 
 // TCPProxy describes the passthrough servers and a default as catch all
 type TCPProxy struct {
-	ServerList []*TCPServer
-	Default    *TCPServer
+	Default *TCPServer
+	// MaxConnections is the maximum number of concurrent connections.
+	// New connections are closed when the limit is reached (0 means unlimited)
+	MaxConnections int64
+
+	mu      sync.RWMutex
+	servers map[string]*TCPServer
+
+	active int64
+}
+
+// SetServers replaces the passthrough servers
+func (p *TCPProxy) SetServers(servers []*TCPServer) {
+	byHostname := make(map[string]*TCPServer, len(servers))
+	for _, s := range servers {
+		byHostname[s.Hostname] = s
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.servers = byHostname
 }
 
 // Get returns the TCPServer to use
 func (p *TCPProxy) Get(host string) *TCPServer {
-	if p.ServerList == nil {
-		return p.Default
-	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
-	for _, s := range p.ServerList {
-		if s.Hostname == host {
-			return s
-		}
+	if s, ok := p.servers[host]; ok {
+		return s
 	}
 
 	return p.Default
@@ -59,16 +107,37 @@ func (p *TCPProxy) Get(host string) *TCPServer {
 // and open a connection to the passthrough server.
 func (p *TCPProxy) Handle(conn net.Conn) {
 	defer conn.Close()
-	data := make([]byte, 4096)
 
+	active := atomic.AddInt64(&p.active, 1)
+	defer atomic.AddInt64(&p.active, -1)
+	if p.MaxConnections > 0 && active > p.MaxConnections {
+		glog.V(4).Infof("closing connection from %v: limit of %v connections reached", conn.RemoteAddr(), p.MaxConnections)
+		sslPassthroughConnections.WithLabelValues(passthroughRejected).Inc()
+		return
+	}
+
+	// the buffer is released once the Client Hello is sent to the server
+	buf := helloBufferPool.Get().(*[]byte)
+	release := func() {
+		if buf != nil {
+			helloBufferPool.Put(buf)
+			buf = nil
+		}
+	}
+	defer release()
+	data := *buf
+
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
 	length, err := conn.Read(data)
 	if err != nil {
 		glog.V(4).Infof("error reading the first 4k of the connection: %s", err)
+		sslPassthroughConnections.WithLabelValues(passthroughFailed).Inc()
 		return
 	}
+	conn.SetReadDeadline(time.Time{})
 
 	proxy := p.Default
-	hostname, err := parser.GetHostname(data[:])
+	hostname, err := parser.GetHostname(data[:length])
 	if err == nil {
 		glog.V(4).Infof("parsed hostname from TLS Client Hello: %s", hostname)
 		proxy = p.Get(hostname)
@@ -76,11 +145,14 @@ func (p *TCPProxy) Handle(conn net.Conn) {
 
 	if proxy == nil {
 		glog.V(4).Infof("there is no configured proxy for SSL connections")
+		sslPassthroughConnections.WithLabelValues(passthroughFailed).Inc()
 		return
 	}
 
-	clientConn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", proxy.IP, proxy.Port))
+	clientConn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", proxy.IP, proxy.Port), dialTimeout)
 	if err != nil {
+		glog.V(4).Infof("error connecting to %v:%v: %v", proxy.IP, proxy.Port, err)
+		sslPassthroughConnections.WithLabelValues(passthroughFailed).Inc()
 		return
 	}
 	defer clientConn.Close()
@@ -97,35 +169,76 @@ func (p *TCPProxy) Handle(conn net.Conn) {
 		}
 		proxyProtocolHeader := fmt.Sprintf("PROXY %s %s %s %d %d\r\n", protocol, remoteAddr.IP.String(), localAddr.IP.String(), remoteAddr.Port, localAddr.Port)
 		glog.V(4).Infof("Writing proxy protocol header - %s", proxyProtocolHeader)
-		_, err = fmt.Fprintf(clientConn, proxyProtocolHeader)
-	}
-	if err != nil {
-		glog.Errorf("unexpected error writing proxy-protocol header: %s", err)
-		clientConn.Close()
-	} else {
-		_, err = clientConn.Write(data[:length])
+		_, err = io.WriteString(clientConn, proxyProtocolHeader)
 		if err != nil {
-			glog.Errorf("unexpected error writing first 4k of proxy data: %s", err)
-			clientConn.Close()
+			glog.Errorf("unexpected error writing proxy-protocol header: %s", err)
+			sslPassthroughConnections.WithLabelValues(passthroughFailed).Inc()
+			return
 		}
 	}
 
+	_, err = clientConn.Write(data[:length])
+	if err != nil {
+		glog.Errorf("unexpected error writing first 4k of proxy data: %s", err)
+		sslPassthroughConnections.WithLabelValues(passthroughFailed).Inc()
+		return
+	}
+	release()
+
+	sslPassthroughConnections.WithLabelValues(passthroughAccepted).Inc()
+	sslPassthroughActiveConnections.Inc()
+	defer sslPassthroughActiveConnections.Dec()
+
 	pipe(clientConn, conn)
 }
 
+// pipe copies the data between the connections until one of them is closed
 func pipe(client, server net.Conn) {
-	doCopy := func(s, c net.Conn, cancel chan<- bool) {
-		io.Copy(s, c)
+	doCopy := func(dst, src net.Conn, direction string, cancel chan<- bool) {
+		n, _ := copyConn(dst, src)
+		sslPassthroughBytes.WithLabelValues(direction).Add(float64(n))
 		cancel <- true
 	}
 
 	cancel := make(chan bool, 2)
 
-	go doCopy(server, client, cancel)
-	go doCopy(client, server, cancel)
+	go doCopy(server, client, passthroughReceived, cancel)
+	go doCopy(client, server, passthroughSent, cancel)
 
-	select {
-	case <-cancel:
-		return
+	<-cancel
+}
+
+// copyConn copies from src to dst. The data between TCP connections is
+// copied by io.Copy, that uses splice on Linux to move the data without
+// copying it to userspace. The buffers of the pool are used to copy the
+// data of other connections, like the ones that decode the PROXY protocol
+func copyConn(dst, src net.Conn) (int64, error) {
+	if _, ok := dst.(*net.TCPConn); ok {
+		if _, ok := src.(*net.TCPConn); ok {
+			return io.Copy(dst, src)
+		}
 	}
+
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+
+	return copyBuffer(dst, src, *buf)
+}
+
+// copyBuffer copies from src to dst using buf. io.CopyBuffer ignores the
+// buffer when dst implements io.ReaderFrom (like *net.TCPConn, which
+// allocates a new buffer for every connection) or src implements
+// io.WriterTo, so these methods are hidden.
+func copyBuffer(dst io.Writer, src io.Reader, buf []byte) (int64, error) {
+	return io.CopyBuffer(writerOnly{dst}, readerOnly{src}, buf)
+}
+
+// writerOnly hides the ReadFrom method of the writer
+type writerOnly struct {
+	io.Writer
+}
+
+// readerOnly hides the WriteTo method of the reader
+type readerOnly struct {
+	io.Reader
 }
@@ -0,0 +1,200 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startEchoServer starts a TCP server that returns the received lines
+func startEchoServer(t *testing.T) *net.TCPAddr {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	return l.Addr().(*net.TCPAddr)
+}
+
+// startTCPProxy starts a listener that handles the connections using the proxy
+func startTCPProxy(t *testing.T, p *TCPProxy) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.Handle(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestTCPProxyGet(t *testing.T) {
+	def := &TCPServer{Hostname: "localhost"}
+	p := &TCPProxy{Default: def}
+
+	if s := p.Get("foo.bar"); s != def {
+		t.Errorf("expected the default server but returned %v", s)
+	}
+
+	foo := &TCPServer{Hostname: "foo.bar", IP: "10.0.0.1", Port: 443}
+	p.SetServers([]*TCPServer{foo})
+	if s := p.Get("foo.bar"); s != foo {
+		t.Errorf("expected %v but returned %v", foo, s)
+	}
+	if s := p.Get("bar.foo"); s != def {
+		t.Errorf("expected the default server but returned %v", s)
+	}
+}
+
+// fastWriter fails the test if the data is copied using ReadFrom
+type fastWriter struct {
+	bytes.Buffer
+	t *testing.T
+}
+
+func (w *fastWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.t.Errorf("unexpected copy using ReadFrom instead of the buffer")
+	return w.Buffer.ReadFrom(r)
+}
+
+// fastReader fails the test if the data is copied using WriteTo and
+// records the size of the buffers used to read
+type fastReader struct {
+	*strings.Reader
+	t     *testing.T
+	sizes []int
+}
+
+func (r *fastReader) Read(p []byte) (int, error) {
+	r.sizes = append(r.sizes, len(p))
+	return r.Reader.Read(p)
+}
+
+func (r *fastReader) WriteTo(w io.Writer) (int64, error) {
+	r.t.Errorf("unexpected copy using WriteTo instead of the buffer")
+	return r.Reader.WriteTo(w)
+}
+
+func TestCopyBuffer(t *testing.T) {
+	dst := &fastWriter{t: t}
+	src := &fastReader{Reader: strings.NewReader("hello world"), t: t}
+
+	buf := make([]byte, 4)
+	n, err := copyBuffer(dst, src, buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 11 || dst.String() != "hello world" {
+		t.Errorf("expected 11 bytes (hello world) but returned %v (%v)", n, dst.String())
+	}
+
+	for _, size := range src.sizes {
+		if size != len(buf) {
+			t.Errorf("expected reads using the buffer of %v bytes but returned %v", len(buf), src.sizes)
+			break
+		}
+	}
+}
+
+func TestCopyConn(t *testing.T) {
+	// the connections of net.Pipe are copied using the buffers of the pool
+	src, w := net.Pipe()
+	dst, r := net.Pipe()
+
+	go func() {
+		io.WriteString(w, "hello world")
+		w.Close()
+	}()
+
+	received := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		received <- string(b)
+	}()
+
+	n, err := copyConn(dst, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dst.Close()
+
+	if msg := <-received; n != 11 || msg != "hello world" {
+		t.Errorf("expected 11 bytes (hello world) but returned %v (%v)", n, msg)
+	}
+}
+
+func TestTCPProxyHandle(t *testing.T) {
+	addr := startEchoServer(t)
+	p := &TCPProxy{
+		Default:        &TCPServer{Hostname: "localhost", IP: addr.IP.String(), Port: addr.Port},
+		MaxConnections: 1,
+	}
+	proxyAddr := startTCPProxy(t, p)
+
+	conn, err := net.Dial("tcp", proxyAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for _, msg := range []string{"hello\n", "world\n"} {
+		if _, err := io.WriteString(conn, msg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if line != msg {
+			t.Errorf("expected %q but returned %q", msg, line)
+		}
+	}
+
+	// the limit of connections is reached
+	rejected, err := net.Dial("tcp", proxyAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rejected.Close()
+
+	rejected.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := rejected.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the connection to be closed but returned %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package template
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -40,10 +41,16 @@ const (
 	httpRedirectCode     = "http-redirect-code"
 	proxyStreamResponses = "proxy-stream-responses"
 	hideHeaders          = "hide-headers"
+	proxyCacheZones      = "proxy-cache-zones"
+	snippetBlocklist     = "snippet-directives-blocklist"
 )
 
 var (
 	validRedirectCodes = sets.NewInt([]int{301, 302, 307, 308}...)
+
+	cacheZoneNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	cacheSizeRegex     = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+	cacheTimeRegex     = regexp.MustCompile(`^[0-9]+(ms|[smhdwMy])?$`)
 )
 
 // ReadConfig obtains the configuration defined by the user merged with the defaults.
@@ -59,6 +66,8 @@ func ReadConfig(src map[string]string) config.Configuration {
 	whitelist := make([]string, 0)
 	proxylist := make([]string, 0)
 	hideHeaderslist := make([]string, 0)
+	cacheZones := make([]config.ProxyCacheZone, 0)
+	blockedDirectives := make([]string, 0)
 
 	bindAddressIpv4List := make([]string, 0)
 	bindAddressIpv6List := make([]string, 0)
@@ -79,6 +88,38 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, hideHeaders)
 		hideHeaderslist = strings.Split(val, ",")
 	}
+	if val, ok := conf[snippetBlocklist]; ok {
+		delete(conf, snippetBlocklist)
+		for _, directive := range strings.Split(val, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive != "" {
+				blockedDirectives = append(blockedDirectives, directive)
+			}
+		}
+	}
+	if val, ok := conf[proxyCacheZones]; ok {
+		delete(conf, proxyCacheZones)
+		names := sets.NewString()
+		for _, i := range strings.Split(val, ",") {
+			if strings.TrimSpace(i) == "" {
+				continue
+			}
+
+			zone, err := parseProxyCacheZone(i)
+			if err != nil {
+				glog.Warningf("%v is not a valid cache zone: %v", i, err)
+				continue
+			}
+
+			if names.Has(zone.Name) {
+				glog.Warningf("the cache zone %v is already defined", zone.Name)
+				continue
+			}
+
+			names.Insert(zone.Name)
+			cacheZones = append(cacheZones, zone)
+		}
+	}
 	if val, ok := conf[skipAccessLogUrls]; ok {
 		delete(conf, skipAccessLogUrls)
 		skipUrls = strings.Split(val, ",")
@@ -142,6 +183,8 @@ func ReadConfig(src map[string]string) config.Configuration {
 	to.BindAddressIpv4 = bindAddressIpv4List
 	to.BindAddressIpv6 = bindAddressIpv6List
 	to.HideHeaders = hideHeaderslist
+	to.ProxyCacheZones = cacheZones
+	to.SnippetDirectivesBlocklist = blockedDirectives
 	to.HTTPRedirectCode = redirectCode
 	to.ProxyStreamResponses = streamResponses
 
@@ -164,6 +207,45 @@ func ReadConfig(src map[string]string) config.Configuration {
 	return to
 }
 
+// parseProxyCacheZone parses the definition of a cache zone using the
+// format name:size[:max_size[:inactive]]
+func parseProxyCacheZone(val string) (config.ProxyCacheZone, error) {
+	zone := config.ProxyCacheZone{
+		Inactive: "10m",
+	}
+
+	parts := strings.Split(strings.TrimSpace(val), ":")
+	if len(parts) < 2 || len(parts) > 4 {
+		return zone, fmt.Errorf("the format must be name:size[:max_size[:inactive]]")
+	}
+
+	zone.Name = parts[0]
+	if !cacheZoneNameRegex.MatchString(zone.Name) {
+		return zone, fmt.Errorf("invalid name %v", zone.Name)
+	}
+
+	zone.Size = parts[1]
+	if !cacheSizeRegex.MatchString(zone.Size) {
+		return zone, fmt.Errorf("invalid size %v", zone.Size)
+	}
+
+	if len(parts) > 2 && parts[2] != "" {
+		zone.MaxSize = parts[2]
+		if !cacheSizeRegex.MatchString(zone.MaxSize) {
+			return zone, fmt.Errorf("invalid max size %v", zone.MaxSize)
+		}
+	}
+
+	if len(parts) > 3 {
+		zone.Inactive = parts[3]
+		if !cacheTimeRegex.MatchString(zone.Inactive) {
+			return zone, fmt.Errorf("invalid inactive time %v", zone.Inactive)
+		}
+	}
+
+	return zone, nil
+}
+
 func filterErrors(codes []int) []int {
 	var fa []int
 	for _, code := range codes {
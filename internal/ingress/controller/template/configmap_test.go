@@ -33,20 +33,21 @@ func TestFilterErrors(t *testing.T) {
 
 func TestMergeConfigMapToStruct(t *testing.T) {
 	conf := map[string]string{
-		"custom-http-errors":         "300,400,demo",
-		"proxy-read-timeout":         "1",
-		"proxy-send-timeout":         "2",
-		"skip-access-log-urls":       "/log,/demo,/test",
-		"use-proxy-protocol":         "true",
-		"disable-access-log":         "true",
-		"access-log-path":            "/var/log/test/access.log",
-		"error-log-path":             "/var/log/test/error.log",
-		"use-gzip":                   "true",
-		"enable-dynamic-tls-records": "false",
-		"gzip-types":                 "text/html",
-		"proxy-real-ip-cidr":         "1.1.1.1/8,2.2.2.2/24",
-		"bind-address":               "1.1.1.1,2.2.2.2,3.3.3,2001:db8:a0b:12f0::1,3731:54:65fe:2::a7,33:33:33::33::33",
-		"worker-shutdown-timeout":    "99s",
+		"custom-http-errors":           "300,400,demo",
+		"proxy-read-timeout":           "1",
+		"proxy-send-timeout":           "2",
+		"skip-access-log-urls":         "/log,/demo,/test",
+		"use-proxy-protocol":           "true",
+		"disable-access-log":           "true",
+		"access-log-path":              "/var/log/test/access.log",
+		"error-log-path":               "/var/log/test/error.log",
+		"use-gzip":                     "true",
+		"enable-dynamic-tls-records":   "false",
+		"gzip-types":                   "text/html",
+		"proxy-real-ip-cidr":           "1.1.1.1/8,2.2.2.2/24",
+		"bind-address":                 "1.1.1.1,2.2.2.2,3.3.3,2001:db8:a0b:12f0::1,3731:54:65fe:2::a7,33:33:33::33::33",
+		"worker-shutdown-timeout":      "99s",
+		"snippet-directives-blocklist": "lua_*, load_module,,root",
 	}
 	def := config.NewDefault()
 	def.CustomHTTPErrors = []int{300, 400}
@@ -63,6 +64,7 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 	def.BindAddressIpv4 = []string{"1.1.1.1", "2.2.2.2"}
 	def.BindAddressIpv6 = []string{"[2001:db8:a0b:12f0::1]", "[3731:54:65fe:2::a7]"}
 	def.WorkerShutdownTimeout = "99s"
+	def.SnippetDirectivesBlocklist = []string{"lua_*", "load_module", "root"}
 
 	to := ReadConfig(conf)
 	if diff := pretty.Compare(to, def); diff != "" {
@@ -93,3 +95,19 @@ func TestDefaultLoadBalance(t *testing.T) {
 		t.Errorf("default load balance algorithm wrong")
 	}
 }
+
+func TestProxyCacheZones(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"proxy-cache-zones": "static:10m:1g:60m, api:5m,invalid,api:1m,tmp:1m:10x,demo:1m::1h",
+	})
+
+	expected := []config.ProxyCacheZone{
+		{Name: "static", Size: "10m", MaxSize: "1g", Inactive: "60m"},
+		{Name: "api", Size: "5m", Inactive: "10m"},
+		{Name: "demo", Size: "1m", Inactive: "1h"},
+	}
+
+	if diff := pretty.Compare(to.ProxyCacheZones, expected); diff != "" {
+		t.Errorf("unexpected diff: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// contextLines is the number of lines before and after the line of an
+// error included in its context
+const contextLines = 3
+
+// templateErrorRegex matches the location of the errors of the templates, like:
+// template: nginx.tmpl:42:10: executing "nginx.tmpl" at <buildLocation $location>: ...
+// template: nginx.tmpl:42: unexpected "}" in operand
+var templateErrorRegex = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?:`)
+
+// Error is an error parsing or executing a template, including the lines
+// of the template around the line of the error
+type Error struct {
+	// Template is the name of the template, nginx.tmpl or a partial
+	Template string
+	// Line is the line of the template where the error occurred
+	Line int
+	// Context contains the lines of the template around Line
+	Context string
+	// Err is the error returned by the template
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v\n%v:\n%v", e.Err, e.Template, e.Context)
+}
+
+// newError returns an Error with the lines around the location of the
+// error in the source of the template, or err if the location is unknown.
+// The innermost location is used for the errors of the partials.
+func newError(err error, sources map[string]string) error {
+	matches := templateErrorRegex.FindAllStringSubmatch(err.Error(), -1)
+	if len(matches) == 0 {
+		return err
+	}
+
+	match := matches[len(matches)-1]
+	source, ok := sources[match[1]]
+	if !ok {
+		return err
+	}
+
+	line, _ := strconv.Atoi(match[2])
+	return &Error{
+		Template: match[1],
+		Line:     line,
+		Context:  Excerpt(source, line),
+		Err:      err,
+	}
+}
+
+// Excerpt returns the lines of the content around the given line (starting
+// at 1), numbered and with the line marked, or an empty string if the
+// content does not contain the line
+func Excerpt(content string, line int) string {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	first := line - contextLines
+	if first < 1 {
+		first = 1
+	}
+	last := line + contextLines
+	if last > len(lines) {
+		last = len(lines)
+	}
+
+	excerpt := []string{}
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		excerpt = append(excerpt, fmt.Sprintf("%v %5d | %v", marker, i, lines[i-1]))
+	}
+
+	return strings.Join(excerpt, "\n")
+}
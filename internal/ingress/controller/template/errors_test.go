@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func TestExcerpt(t *testing.T) {
+	content := "1\n2\n3\n4\n5\n6\n7\n8"
+
+	expected := `      2 | 2
+      3 | 3
+      4 | 4
+>     5 | 5
+      6 | 6
+      7 | 7
+      8 | 8`
+	if excerpt := Excerpt(content, 5); excerpt != expected {
+		t.Errorf("expected\n%v\nbut returned\n%v", expected, excerpt)
+	}
+
+	if excerpt := Excerpt(content, 1); !strings.HasPrefix(excerpt, ">     1 | 1") {
+		t.Errorf("expected the first line but returned\n%v", excerpt)
+	}
+
+	for _, line := range []int{0, 9} {
+		if excerpt := Excerpt(content, line); excerpt != "" {
+			t.Errorf("expected no excerpt of the line %v but returned\n%v", line, excerpt)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := NewTemplateFromData([]byte("events {}\nhttp {\n    {{ if .Cfg }}\n}\n"))
+	if err == nil {
+		t.Fatalf("expected an error parsing the template")
+	}
+
+	templateErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected a template error but returned %T: %v", err, err)
+	}
+	if templateErr.Template != "nginx.tmpl" {
+		t.Errorf("expected the error in nginx.tmpl but returned %v", templateErr.Template)
+	}
+	if !strings.Contains(templateErr.Error(), "|     {{ if .Cfg }}") {
+		t.Errorf("expected the lines of the template in the error but returned\n%v", templateErr.Error())
+	}
+}
+
+func TestPartialError(t *testing.T) {
+	fs := newPartialsFS(t, map[string]string{
+		"http.tmpl": "# first line\n{{ .All.Missing }}",
+	})
+
+	tmpl, err := NewTemplateFromData([]byte(`{{ $all := . }}
+{{ includePartials "http" $all nil nil }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tmpl.LoadPartials("/etc/nginx/template/partials", fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = tmpl.Check(config.TemplateConfig{})
+	templateErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected a template error but returned %T: %v", err, err)
+	}
+	if templateErr.Template != "http.tmpl" || templateErr.Line != 2 {
+		t.Errorf("expected the error in the line 2 of http.tmpl but returned %v:%v", templateErr.Template, templateErr.Line)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	text_template "text/template"
+)
+
+var (
+	// funcNameRegex matches the valid names of template functions
+	funcNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	registryLock sync.Mutex
+	// registeredFuncs contains the functions added with RegisterFunc
+	registeredFuncs = text_template.FuncMap{}
+	// registerErrors contains the invalid or duplicated registrations,
+	// reported when the template is created
+	registerErrors []string
+)
+
+// RegisterFunc adds a function to the ones available in the NGINX template.
+// It is intended to be called from the init function of a package compiled
+// into the controller, so the helpers of a custom template do not require
+// changes in this package:
+//
+//	func init() {
+//		template.RegisterFunc("buildCompanyAuth", buildCompanyAuth)
+//	}
+//
+// Functions with an invalid name or signature, or with the name of a
+// built-in or previously registered function are reported by NewTemplate.
+func RegisterFunc(name string, fn interface{}) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if err := validateFunc(name, fn); err != nil {
+		registerErrors = append(registerErrors, err.Error())
+		return
+	}
+
+	if _, ok := funcMap[name]; ok || name == includePartialsFunc || name == renderServerFunc {
+		registerErrors = append(registerErrors, fmt.Sprintf("function %v collides with a built-in template function", name))
+		return
+	}
+
+	if _, ok := registeredFuncs[name]; ok {
+		registerErrors = append(registerErrors, fmt.Sprintf("function %v is registered more than once", name))
+		return
+	}
+
+	registeredFuncs[name] = fn
+}
+
+// validateFunc checks the function can be used in a template, returning one
+// value or a value and an error
+func validateFunc(name string, fn interface{}) error {
+	if !funcNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid template function name %q", name)
+	}
+
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("template function %v is not a function", name)
+	}
+
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("template function %v must return one value, or a value and an error", name)
+	}
+
+	return nil
+}
+
+// templateFuncs returns the built-in and registered template functions, or
+// an error if any registration failed
+func templateFuncs() (text_template.FuncMap, error) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if len(registerErrors) > 0 {
+		errs := make([]string, len(registerErrors))
+		copy(errs, registerErrors)
+		sort.Strings(errs)
+		return nil, fmt.Errorf("invalid template functions: %v", errs)
+	}
+
+	funcs := text_template.FuncMap{}
+	for name, fn := range funcMap {
+		funcs[name] = fn
+	}
+	for name, fn := range registeredFuncs {
+		funcs[name] = fn
+	}
+
+	return funcs, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"strings"
+	"testing"
+	text_template "text/template"
+
+	"k8s.io/ingress-nginx/internal/file"
+)
+
+// resetRegistry removes the functions and errors of RegisterFunc
+func resetRegistry() {
+	registeredFuncs = text_template.FuncMap{}
+	registerErrors = nil
+}
+
+func TestRegisterFunc(t *testing.T) {
+	defer resetRegistry()
+
+	RegisterFunc("buildCompanyAuth", func(s string) string {
+		return "auth_request /company-auth/" + s + ";"
+	})
+
+	funcs, err := templateFuncs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := funcs["buildCompanyAuth"]; !ok {
+		t.Errorf("expected the registered function buildCompanyAuth")
+	}
+	if _, ok := funcs["buildLocation"]; !ok {
+		t.Errorf("expected the built-in function buildLocation")
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	testCases := map[string]struct {
+		name string
+		fn   interface{}
+	}{
+		"built-in collision":  {"buildLocation", func() string { return "" }},
+		"invalid name":        {"build-auth", func() string { return "" }},
+		"not a function":      {"buildAuth", "auth"},
+		"no return value":     {"buildAuth", func() {}},
+		"second is not error": {"buildAuth", func() (string, string) { return "", "" }},
+	}
+
+	for title, tc := range testCases {
+		resetRegistry()
+		RegisterFunc(tc.name, tc.fn)
+
+		if _, err := templateFuncs(); err == nil {
+			t.Errorf("%v: expected an error", title)
+		}
+	}
+
+	resetRegistry()
+	RegisterFunc("buildAuth", func() (string, error) { return "", nil })
+	RegisterFunc("buildAuth", func() (string, error) { return "", nil })
+	_, err := templateFuncs()
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("expected a duplicated registration error but returned %v", err)
+	}
+
+	fs, err := file.NewFakeFS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewTemplate("/etc/nginx/template/nginx.tmpl", fs); err == nil {
+		t.Errorf("expected an error creating the template with invalid registered functions")
+	}
+
+	resetRegistry()
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	text_template "text/template"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+// includePartialsFunc is the name of the template function that renders
+// the partials of an extension point
+const includePartialsFunc = "includePartials"
+
+// partialPoints are the extension points of the template where the
+// partials are included
+var partialPoints = []string{"http", "server", "location", "stream"}
+
+// PartialConfig is the data used to render the partials. The server and
+// location are nil in the extension points outside of them.
+type PartialConfig struct {
+	All      config.TemplateConfig
+	Server   *ingress.Server
+	Location *ingress.Location
+}
+
+// LoadPartials reads the partials of the directory, replacing the ones
+// loaded before. Every file named <point>-<name>.tmpl or <point>.tmpl is
+// rendered in the extension point (http, server, location or stream) in the
+// order of the names. The partials are not loaded if the directory is empty.
+func (t *Template) LoadPartials(dir string, fs file.Filesystem) error {
+	partials, err := ReadPartials(dir, fs)
+	if err != nil {
+		return err
+	}
+
+	return t.SetPartials(partials)
+}
+
+// ReadPartials returns the content of the partials of the directory by name
+func ReadPartials(dir string, fs file.Filesystem) (map[string]string, error) {
+	partials := map[string]string{}
+	if dir == "" {
+		return partials, nil
+	}
+
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error reading the template include directory %v: %v", dir, err)
+	}
+
+	for _, f := range files {
+		// the directories and hidden files of the ConfigMap volumes are ignored
+		if f.IsDir() || !IsPartial(f.Name()) {
+			continue
+		}
+
+		data, err := fs.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected error reading the partial %v: %v", f.Name(), err)
+		}
+		partials[f.Name()] = string(data)
+	}
+
+	return partials, nil
+}
+
+// IsPartial returns true if the name is the name of a partial
+func IsPartial(name string) bool {
+	return !strings.HasPrefix(name, ".") && filepath.Ext(name) == ".tmpl" && name != "nginx.tmpl"
+}
+
+// SetPartials parses the partials by name, replacing the ones loaded before
+func (t *Template) SetPartials(data map[string]string) error {
+	funcs, err := templateFuncs()
+	if err != nil {
+		return err
+	}
+	funcs[includePartialsFunc] = t.includePartials
+	funcs[renderServerFunc] = t.renderServer
+
+	partials := map[string][]*text_template.Template{}
+	sources := map[string]string{"nginx.tmpl": t.sources["nginx.tmpl"]}
+	names := []string{}
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		point := partialPoint(name)
+		if point == "" {
+			return fmt.Errorf("the partial %v does not start with an extension point (%v)", name, strings.Join(partialPoints, ", "))
+		}
+
+		sources[name] = data[name]
+		tmpl, err := text_template.New(name).Funcs(funcs).Parse(data[name])
+		if err != nil {
+			return newError(err, sources)
+		}
+		partials[point] = append(partials[point], tmpl)
+	}
+
+	t.partials = partials
+	t.sources = sources
+	// the server blocks include the partials
+	t.servers.set(nil)
+	return nil
+}
+
+// partialPoint returns the extension point of the name of a partial, or an
+// empty string if the name does not start with one
+func partialPoint(name string) string {
+	base := strings.TrimSuffix(name, ".tmpl")
+	for _, point := range partialPoints {
+		if base == point || strings.HasPrefix(base, point+"-") {
+			return point
+		}
+	}
+
+	return ""
+}
+
+// includePartials renders the partials of the extension point
+func (t *Template) includePartials(point string, all config.TemplateConfig, server *ingress.Server, location *ingress.Location) (string, error) {
+	partials := t.partials[point]
+	if len(partials) == 0 {
+		return "", nil
+	}
+
+	data := PartialConfig{
+		All:      all,
+		Server:   server,
+		Location: location,
+	}
+
+	buf := &bytes.Buffer{}
+	for _, partial := range partials {
+		fmt.Fprintf(buf, "# partial %v\n", partial.Name())
+		if err := partial.Execute(buf, data); err != nil {
+			return "", err
+		}
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), nil
+}
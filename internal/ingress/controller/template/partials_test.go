@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func newPartialsFS(t *testing.T, partials map[string]string) file.Filesystem {
+	fs, err := file.NewFakeFS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fs.MkdirAll("/etc/nginx/template/partials", 0655); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, content := range partials {
+		f, err := fs.Create("/etc/nginx/template/partials/" + name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f.Write([]byte(content))
+		f.Close()
+	}
+
+	return fs
+}
+
+func TestLoadPartials(t *testing.T) {
+	fs := newPartialsFS(t, map[string]string{
+		"http.tmpl":            "# http {{ len .All.Servers }}",
+		"server-b.tmpl":        "# server b {{ .Server.Hostname }}",
+		"server-a.tmpl":        "# server a {{ .Server.Hostname }}",
+		"location-access.tmpl": "# location {{ .Server.Hostname }}{{ .Location.Path }}",
+		"stream-tcp.tmpl":      "# stream {{ len .All.TCPBackends }}",
+		"README.md":            "ignored",
+	})
+
+	tmpl, err := NewTemplateFromData([]byte(`{{ $all := . }}{{ includePartials "http" $all nil nil }}
+{{ range $server := .Servers }}{{ includePartials "server" $all $server nil }}
+{{ range $location := $server.Locations }}{{ includePartials "location" $all $server $location }}
+{{ end }}{{ end }}{{ includePartials "stream" $all nil nil }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := tmpl.LoadPartials("/etc/nginx/template/partials", fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.tmpl.Execute(buf, config.TemplateConfig{
+		Servers: []*ingress.Server{
+			{Hostname: "foo.bar", Locations: []*ingress.Location{{Path: "/"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"# http 1", "# server a foo.bar", "# server b foo.bar", "# location foo.bar/", "# stream 0"}
+	content := buf.String()
+	last := -1
+	for _, e := range expected {
+		i := strings.Index(content, e)
+		if i < 0 {
+			t.Fatalf("expected %q in the rendered template:\n%v", e, content)
+		}
+		if i < last {
+			t.Errorf("expected %q after the previous partials:\n%v", e, content)
+		}
+		last = i
+	}
+}
+
+func TestLoadPartialsErrors(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unknown extension point": {"mail-smtp.tmpl": "# mail"},
+		"invalid partial":         {"server.tmpl": "{{ .Server.Hostname "},
+	}
+
+	for title, partials := range testCases {
+		tmpl, err := NewTemplateFromData([]byte(""))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := tmpl.LoadPartials("/etc/nginx/template/partials", newPartialsFS(t, partials)); err == nil {
+			t.Errorf("%v: expected an error", title)
+		}
+	}
+
+	tmpl, err := NewTemplateFromData([]byte(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tmpl.LoadPartials("/etc/nginx/missing", newPartialsFS(t, nil)); err == nil {
+		t.Errorf("expected an error reading a missing directory")
+	}
+	if err := tmpl.LoadPartials("", nil); err != nil {
+		t.Errorf("unexpected error without directory: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	text_template "text/template"
+
+	"github.com/golang/glog"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+// renderServerFunc is the name of the template function that renders a
+// server block with the template serverBlockTemplate, reusing the output
+// of the previous render if the server did not change
+const renderServerFunc = "renderServer"
+
+// serverBlockTemplate is the name of the template of a server block. It
+// receives the same data as the template function serverConfig
+const serverBlockTemplate = "SERVER_BLOCK"
+
+// renderedServer is the output of the template of a server block
+type renderedServer struct {
+	// key is the checksum of the data used to render the server
+	key string
+	// content is the rendered server block
+	content string
+}
+
+// serverCache contains the server blocks of the last render of a template,
+// by hostname
+type serverCache struct {
+	lock    sync.Mutex
+	servers map[string]renderedServer
+}
+
+// get returns the rendered server blocks
+func (c *serverCache) get() map[string]renderedServer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.servers
+}
+
+// set replaces the rendered server blocks
+func (c *serverCache) set(servers map[string]renderedServer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.servers = servers
+}
+
+// renderServer renders a server block without the cache, for the
+// executions of the template that do not use a serverRenderer
+func (t *Template) renderServer(all config.TemplateConfig, server *ingress.Server) (string, error) {
+	buf := &bytes.Buffer{}
+	err := t.tmpl.ExecuteTemplate(buf, serverBlockTemplate, serverBlockData(all, server))
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// serverRenderer renders the server blocks of an execution of a template.
+// The template functions must not depend on anything but their arguments,
+// because the server blocks are only rendered again if the data of the
+// server, of its backends, or the global configuration change.
+type serverRenderer struct {
+	tmpl *text_template.Template
+	// globalKey is the checksum of the configuration, without the servers,
+	// backends and endpoints
+	globalKey []byte
+	// cached are the server blocks of the previous render
+	cached map[string]renderedServer
+	// rendered are the server blocks of this render
+	rendered map[string]renderedServer
+	// hits is the number of server blocks reused
+	hits int
+}
+
+// newServerRenderer returns a serverRenderer of an execution of a template
+// using tmpl, which must be a clone of the template of t
+func (t *Template) newServerRenderer(tmpl *text_template.Template) *serverRenderer {
+	r := &serverRenderer{
+		tmpl:     tmpl,
+		cached:   t.servers.get(),
+		rendered: map[string]renderedServer{},
+	}
+	tmpl.Funcs(text_template.FuncMap{renderServerFunc: r.render})
+
+	return r
+}
+
+// render returns the server block of a server, from the previous render
+// if the key of the server did not change
+func (r *serverRenderer) render(all config.TemplateConfig, server *ingress.Server) (string, error) {
+	key, err := r.key(all, server)
+	if err != nil {
+		glog.Warningf("unexpected error computing the checksum of server %v: %v", server.Hostname, err)
+	}
+
+	if cached, ok := r.cached[server.Hostname]; ok && key != "" && cached.key == key {
+		r.rendered[server.Hostname] = cached
+		r.hits++
+		return cached.content, nil
+	}
+
+	buf := &bytes.Buffer{}
+	err = r.tmpl.ExecuteTemplate(buf, serverBlockTemplate, serverBlockData(all, server))
+	if err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		r.rendered[server.Hostname] = renderedServer{key, buf.String()}
+	}
+
+	return buf.String(), nil
+}
+
+// key returns the checksum of the data used to render a server
+func (r *serverRenderer) key(all config.TemplateConfig, server *ingress.Server) (string, error) {
+	if r.globalKey == nil {
+		// the endpoints are not part of the server blocks
+		global := all
+		global.Servers = nil
+		global.Backends = nil
+		global.PassthroughBackends = nil
+		global.TCPBackends = nil
+		global.UDPBackends = nil
+
+		h := sha256.New()
+		if err := json.NewEncoder(h).Encode(global); err != nil {
+			return "", err
+		}
+		r.globalKey = h.Sum(nil)
+	}
+
+	h := sha256.New()
+	h.Write(r.globalKey)
+
+	enc := json.NewEncoder(h)
+	if err := enc.Encode(server); err != nil {
+		return "", err
+	}
+
+	backends := map[string]bool{}
+	for _, location := range server.Locations {
+		backends[location.Backend] = true
+	}
+
+	for _, backend := range all.Backends {
+		if !backends[backend.Name] {
+			continue
+		}
+
+		b := *backend
+		b.Endpoints = nil
+		if err := enc.Encode(b); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// commit keeps the server blocks of the render for the next one
+func (r *serverRenderer) commit(t *Template) {
+	glog.V(3).Infof("rendered %v servers, %v of them reused from the previous configuration", len(r.rendered), r.hits)
+	t.servers.set(r.rendered)
+}
+
+// serverBlockData returns the data of the templates of a server, which is
+// also returned by the template function serverConfig
+func serverBlockData(all config.TemplateConfig, server *ingress.Server) interface{} {
+	return struct{ First, Second interface{} }{all, server}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func TestRenderServer(t *testing.T) {
+	rendered := map[string]int{}
+	RegisterFunc("countRender", func(hostname string) string {
+		rendered[hostname]++
+		return ""
+	})
+	defer resetRegistry()
+
+	tmpl, err := NewTemplateFromData([]byte(`http {
+{{ $all := . }}{{ range $server := .Servers }}{{ renderServer $all $server }}{{ end }}}
+{{ define "SERVER_BLOCK" }}{{ $server := .Second }}{{ countRender $server.Hostname }}
+    server {
+        server_name {{ $server.Hostname }};{{ range $location := $server.Locations }}
+        location {{ $location.Path }} {
+            proxy_pass http://{{ $location.Backend }}; # {{ (index $.First.Backends 0).Secure }}
+        }{{ end }}
+    }
+{{ end }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	backend := &ingress.Backend{Name: "default-app-80", Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "80"}}}
+	conf := config.TemplateConfig{
+		Backends: []*ingress.Backend{backend},
+		Servers: []*ingress.Server{
+			{Hostname: "bar.foo", Locations: []*ingress.Location{{Path: "/", Backend: "default-app-80"}}},
+			{Hostname: "foo.bar", Locations: []*ingress.Location{{Path: "/", Backend: "default-web-80"}}},
+		},
+	}
+
+	render := func() string {
+		content, err := tmpl.Write(conf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return string(content)
+	}
+
+	first := render()
+	if !strings.Contains(first, "server_name bar.foo;") || !strings.Contains(first, "server_name foo.bar;") {
+		t.Fatalf("expected the server blocks but returned\n%v", first)
+	}
+
+	testCases := []struct {
+		title    string
+		update   func()
+		expected map[string]int
+	}{
+		{"same configuration", func() {}, map[string]int{"bar.foo": 1, "foo.bar": 1}},
+		{"new endpoints", func() {
+			backend.Endpoints = append(backend.Endpoints, ingress.Endpoint{Address: "10.0.0.2", Port: "80"})
+		}, map[string]int{"bar.foo": 1, "foo.bar": 1}},
+		{"new location", func() {
+			conf.Servers[1].Locations = append(conf.Servers[1].Locations, &ingress.Location{Path: "/api", Backend: "default-app-80"})
+		}, map[string]int{"bar.foo": 1, "foo.bar": 2}},
+		{"backend of the server", func() {
+			backend.Secure = true
+		}, map[string]int{"bar.foo": 2, "foo.bar": 3}},
+		{"global configuration", func() {
+			conf.Cfg.ServerSnippet = "# global"
+		}, map[string]int{"bar.foo": 3, "foo.bar": 4}},
+	}
+
+	for _, tc := range testCases {
+		tc.update()
+		content := render()
+
+		if tc.title == "same configuration" && content != first {
+			t.Errorf("%v: expected the same configuration but returned\n%v", tc.title, content)
+		}
+		for hostname, count := range tc.expected {
+			if rendered[hostname] != count {
+				t.Errorf("%v: expected %v renders of %v but got %v", tc.title, count, hostname, rendered[hostname])
+			}
+		}
+	}
+
+	if content := render(); !strings.Contains(content, "location /api") {
+		t.Errorf("expected the new location in the configuration but returned\n%v", content)
+	}
+
+	// the servers are rendered again after loading the partials
+	if err := tmpl.LoadPartials("/etc/nginx/template/partials", newPartialsFS(t, nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	render()
+	if rendered["bar.foo"] != 4 {
+		t.Errorf("expected the servers rendered again after loading the partials but got %v renders", rendered["bar.foo"])
+	}
+}
@@ -17,35 +17,50 @@ limitations under the License.
 package template
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"math/rand"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/url"
 	"os"
-	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 	text_template "text/template"
-	"time"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 
+	apiv1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/pathtype"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/xforwardedprefix"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
+	"k8s.io/ingress-nginx/internal/net/proxyprotocol"
 )
 
 const (
 	slash         = "/"
 	nonIdempotent = "non_idempotent"
 	defBufferSize = 65535
+
+	// defResolverValid is the default time NGINX caches the resolved names
+	defResolverValid = "30s"
+
+	// luaBalancerUpstream is the name of the upstream that selects the
+	// endpoints of the dynamic backends using Lua
+	luaBalancerUpstream = "upstream_balancer"
 )
 
 // Template ...
@@ -53,25 +68,48 @@ type Template struct {
 	tmpl *text_template.Template
 	//fw   watch.FileWatcher
 	bp *BufferPool
+	// partials are the templates included in the extension points
+	partials map[string][]*text_template.Template
+	// sources contains the source of the template and the partials by name,
+	// used to report the lines of the errors
+	sources map[string]string
+	// servers contains the server blocks of the last render
+	servers *serverCache
 }
 
-//NewTemplate returns a new Template instance or an
-//error if the specified template file contains errors
+// NewTemplate returns a new Template instance or an
+// error if the specified template file contains errors
 func NewTemplate(file string, fs file.Filesystem) (*Template, error) {
 	data, err := fs.ReadFile(file)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unexpected error reading template %v", file)
 	}
 
-	tmpl, err := text_template.New("nginx.tmpl").Funcs(funcMap).Parse(string(data))
+	return NewTemplateFromData(data)
+}
+
+// NewTemplateFromData returns a new Template instance parsing the content
+// of a template, or an error if it contains errors
+func NewTemplateFromData(data []byte) (*Template, error) {
+	funcs, err := templateFuncs()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Template{
-		tmpl: tmpl,
-		bp:   NewBufferPool(defBufferSize),
-	}, nil
+	t := &Template{
+		bp:      NewBufferPool(defBufferSize),
+		sources: map[string]string{"nginx.tmpl": string(data)},
+		servers: &serverCache{},
+	}
+	funcs[includePartialsFunc] = t.includePartials
+	funcs[renderServerFunc] = t.renderServer
+
+	t.tmpl, err = text_template.New("nginx.tmpl").Funcs(funcs).Parse(string(data))
+	if err != nil {
+		return nil, newError(err, t.sources)
+	}
+
+	return t, nil
 }
 
 // Write populates a buffer using a template with NGINX configuration
@@ -80,9 +118,18 @@ func (t *Template) Write(conf config.TemplateConfig) ([]byte, error) {
 	tmplBuf := t.bp.Get()
 	defer t.bp.Put(tmplBuf)
 
-	outCmdBuf := t.bp.Get()
-	defer t.bp.Put(outCmdBuf)
+	err := t.WriteTo(tmplBuf, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyBytes(tmplBuf.Bytes()), nil
+}
 
+// WriteTo writes the NGINX configuration to w as it is rendered, without
+// building it in memory. The content written to w is incomplete if an
+// error is returned.
+func (t *Template) WriteTo(w io.Writer, conf config.TemplateConfig) error {
 	if glog.V(3) {
 		b, err := json.Marshal(conf)
 		if err != nil {
@@ -91,22 +138,64 @@ func (t *Template) Write(conf config.TemplateConfig) ([]byte, error) {
 		glog.Infof("NGINX configuration: %v", string(b))
 	}
 
-	err := t.tmpl.Execute(tmplBuf, conf)
+	return t.execute(w, conf)
+}
+
+// Render returns the NGINX configuration rendered with the template, like
+// Write but without logging the configuration, so it can be used outside
+// of the ingress controller (e.g. to compare the configuration generated
+// in tests).
+func (t *Template) Render(conf config.TemplateConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	err := t.execute(&buf, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	// squeezes multiple adjacent empty lines to be single
-	// spaced this is to avoid the use of regular expressions
-	cmd := exec.Command("/ingress-controller/clean-nginx-conf.sh")
-	cmd.Stdin = tmplBuf
-	cmd.Stdout = outCmdBuf
-	if err := cmd.Run(); err != nil {
-		glog.Warningf("unexpected error cleaning template: %v", err)
-		return tmplBuf.Bytes(), nil
+	return buf.Bytes(), nil
+}
+
+// execute renders the template to w, normalizing the whitespace. The
+// server blocks that did not change since the previous execution are not
+// rendered again
+func (t *Template) execute(w io.Writer, conf config.TemplateConfig) error {
+	ww := getWhitespaceWriter(w)
+	defer putWhitespaceWriter(ww)
+
+	// the clone binds the template function renderServer to this execution
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return err
 	}
+	renderer := t.newServerRenderer(tmpl)
 
-	return outCmdBuf.Bytes(), nil
+	err = tmpl.Execute(ww, conf)
+	if err != nil {
+		return newError(err, t.sources)
+	}
+	renderer.commit(t)
+
+	return ww.Flush()
+}
+
+// copyBytes returns a copy of the content of a buffer that returns to
+// the pool, because the template can be written by concurrent callers
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+// Check executes the template discarding the output, returning the error
+// of the execution. It is cheaper than Write to find the data that cannot
+// be rendered.
+func (t *Template) Check(conf config.TemplateConfig) error {
+	err := t.tmpl.Execute(ioutil.Discard, conf)
+	if err != nil {
+		return newError(err, t.sources)
+	}
+
+	return nil
 }
 
 var (
@@ -120,15 +209,27 @@ var (
 		},
 		"buildLocation":            buildLocation,
 		"buildAuthLocation":        buildAuthLocation,
+		"buildMirrorLocation":      buildMirrorLocation,
 		"buildAuthResponseHeaders": buildAuthResponseHeaders,
 		"buildProxyPass":           buildProxyPass,
+		"hasGRPCLocations":         hasGRPCLocations,
 		"filterRateLimits":         filterRateLimits,
 		"buildRateLimitZones":      buildRateLimitZones,
 		"buildRateLimit":           buildRateLimit,
+		"buildProxyCache":          buildProxyCache,
+		"buildCSPHeader":           buildCSPHeader,
+		"buildCorsOriginRegex":     buildCorsOriginRegex,
 		"buildResolvers":           buildResolvers,
 		"buildUpstreamName":        buildUpstreamName,
 		"isLocationAllowed":        isLocationAllowed,
+		"isCorsEnabled":            isCorsEnabled,
+		"buildCustomErrors":        buildCustomErrors,
+		"filterMaintenance":        filterMaintenance,
+		"buildMaintenancePages":    buildMaintenancePages,
+		"buildRequestHeaders":      buildRequestHeaders,
 		"buildLogFormatUpstream":   buildLogFormatUpstream,
+		"buildLogFormats":          buildLogFormats,
+		"logFormatName":            logFormatName,
 		"buildDenyVariable":        buildDenyVariable,
 		"getenv":                   os.Getenv,
 		"contains":                 strings.Contains,
@@ -138,13 +239,19 @@ var (
 		"toLower":                  strings.ToLower,
 		"formatIP":                 formatIP,
 		"buildNextUpstream":        buildNextUpstream,
+		"proxyProtocolV2Socket":    proxyprotocol.SocketPath,
+		"buildTCPServers":          buildTCPServers,
 		"getIngressInformation":    getIngressInformation,
 		"serverConfig": func(all config.TemplateConfig, server *ingress.Server) interface{} {
-			return struct{ First, Second interface{} }{all, server}
+			return serverBlockData(all, server)
 		},
 		"isValidClientBodyBufferSize": isValidClientBodyBufferSize,
 		"buildForwardedFor":           buildForwardedFor,
 		"buildAuthSignURL":            buildAuthSignURL,
+		"buildGeoBackendMaps":         buildGeoBackendMaps,
+		"buildABTestingSplits":        buildABTestingSplits,
+		"shouldLoadModSecurity":       shouldLoadModSecurity,
+		"buildOWASPSettings":          buildOWASPSettings,
 	}
 )
 
@@ -162,8 +269,13 @@ func formatIP(input string) string {
 	return fmt.Sprintf("[%s]", input)
 }
 
-// buildResolvers returns the resolvers reading the /etc/resolv.conf file
-func buildResolvers(input interface{}) string {
+// nginxTimeRegex matches the time values of NGINX, like 30s or 1h30m
+var nginxTimeRegex = regexp.MustCompile(`^(\d+(ms|s|m|h|d|w|M|y)?)+$`)
+
+// buildResolvers returns the resolvers reading the /etc/resolv.conf file.
+// The optional arguments are the time the names are cached (30s by default)
+// and if the IPv6 addresses are not resolved
+func buildResolvers(input interface{}, options ...interface{}) string {
 	// NGINX need IPV6 addresses to be surrounded by brackets
 	nss, ok := input.([]net.IP)
 	if !ok {
@@ -175,6 +287,26 @@ func buildResolvers(input interface{}) string {
 		return ""
 	}
 
+	valid := defResolverValid
+	if len(options) > 0 {
+		v, ok := options[0].(string)
+		if !ok {
+			glog.Errorf("expected a 'string' type but %T was returned", options[0])
+		} else if v != "" && !nginxTimeRegex.MatchString(v) {
+			glog.Warningf("invalid resolver valid time %v, using %v", v, defResolverValid)
+		} else if v != "" {
+			valid = v
+		}
+	}
+
+	disableIpv6 := false
+	if len(options) > 1 {
+		disableIpv6, ok = options[1].(bool)
+		if !ok {
+			glog.Errorf("expected a 'bool' type but %T was returned", options[1])
+		}
+	}
+
 	r := []string{"resolver"}
 	for _, ns := range nss {
 		if ing_net.IsIPV6(ns) {
@@ -183,9 +315,12 @@ func buildResolvers(input interface{}) string {
 			r = append(r, fmt.Sprintf("%v", ns))
 		}
 	}
-	r = append(r, "valid=30s;")
+	r = append(r, fmt.Sprintf("valid=%v", valid))
+	if disableIpv6 {
+		r = append(r, "ipv6=off")
+	}
 
-	return strings.Join(r, " ")
+	return strings.Join(r, " ") + ";"
 }
 
 // buildLocation produces the location string, if the ingress has redirects
@@ -198,6 +333,10 @@ func buildLocation(input interface{}) string {
 	}
 
 	path := location.Path
+	if location.Rewrite.UseRegex {
+		return fmt.Sprintf(`~* "^%s"`, escapeRegexPath(path))
+	}
+
 	if len(location.Rewrite.Target) > 0 && location.Rewrite.Target != path {
 		if path == slash {
 			return fmt.Sprintf("~* %s", path)
@@ -211,6 +350,13 @@ func buildLocation(input interface{}) string {
 		return fmt.Sprintf(`~* ^%s%s`, path, baseuri)
 	}
 
+	switch location.PathType {
+	case pathtype.Exact:
+		return fmt.Sprintf("= %s", path)
+	case pathtype.Prefix:
+		return fmt.Sprintf("^~ %s", path)
+	}
+
 	return path
 }
 
@@ -231,6 +377,25 @@ func buildAuthLocation(input interface{}) string {
 	return fmt.Sprintf("/_external-auth-%v", str)
 }
 
+// buildMirrorLocation returns the path of the internal location
+// used to mirror the requests of a location
+func buildMirrorLocation(input interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	if location.Mirror.Target == "" {
+		return ""
+	}
+
+	str := base64.URLEncoding.EncodeToString([]byte(location.Path))
+	// removes "=" after encoding
+	str = strings.Replace(str, "=", "", -1)
+	return fmt.Sprintf("/_mirror-%v", str)
+}
+
 func buildAuthResponseHeaders(input interface{}) []string {
 	location, ok := input.(*ingress.Location)
 	res := []string{}
@@ -262,11 +427,40 @@ func buildLogFormatUpstream(input interface{}) string {
 	return cfg.BuildLogFormatUpstream()
 }
 
+// buildLogFormats returns the distinct log formats of the locations
+// of the servers, in alphabetical order
+func buildLogFormats(input interface{}) []string {
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Server' type but %T was returned", input)
+		return []string{}
+	}
+
+	formats := sets.NewString()
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.LogFormat != "" {
+				formats.Insert(location.LogFormat)
+			}
+		}
+	}
+
+	return formats.List()
+}
+
+// logFormatName returns the name of the log_format of a format of the
+// locations, which is derived from the format to be stable across reloads
+func logFormatName(format string) string {
+	return fmt.Sprintf("upstreaminfo_%x", sha1.Sum([]byte(format)))[:21]
+}
+
 // buildProxyPass produces the proxy pass string, if the ingress has redirects
 // (specified through the nginx.ingress.kubernetes.io/rewrite-to annotation)
 // If the annotation nginx.ingress.kubernetes.io/add-base-url:"true" is specified it will
-// add a base tag in the head of the response from the service
-func buildProxyPass(host string, b interface{}, loc interface{}) string {
+// add a base tag in the head of the response from the service.
+// Locations using one of the dynamic backends (optional, to keep custom
+// templates working) are proxied to the Lua balancer.
+func buildProxyPass(host string, b interface{}, loc interface{}, d ...interface{}) string {
 	backends, ok := b.([]*ingress.Backend)
 	if !ok {
 		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
@@ -279,13 +473,25 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		return ""
 	}
 
+	dynamicBackends := sets.NewString()
+	if len(d) > 0 {
+		dynamicBackends, ok = d[0].(sets.String)
+		if !ok {
+			glog.Errorf("expected a 'sets.String' type but %T was returned", d[0])
+			return ""
+		}
+	}
+
 	path := location.Path
 	proto := "http"
+	// externalName contains the directive used to define the target of the
+	// proxy_pass when the backend is a service of type ExternalName
+	externalName := ""
 
 	upstreamName := location.Backend
 	for _, backend := range backends {
 		if backend.Name == location.Backend {
-			if backend.Secure || backend.SSLPassthrough {
+			if backend.Secure || backend.SSLPassthrough || location.BackendProtocol == "HTTPS" {
 				proto = "https"
 			}
 
@@ -293,12 +499,88 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 				upstreamName = fmt.Sprintf("sticky-%v", upstreamName)
 			}
 
+			if len(location.GeoBackend.Upstreams) > 0 {
+				upstreamName = fmt.Sprintf("$%v", buildLocationVariable("geo_upstream", location))
+			} else if location.ABTesting.Upstream != "" {
+				upstreamName = fmt.Sprintf("$%v", buildLocationVariable("ab_upstream", location))
+			}
+
+			if isExternalNameBackend(backend) {
+				// using a variable in proxy_pass forces NGINX to resolve the
+				// name at runtime using the configured resolver instead of
+				// using the IP address obtained when the configuration is loaded
+				ep := backend.Endpoints[0]
+				externalName = fmt.Sprintf(`set $external_name_target "%v:%v";
+	    `, ep.Address, ep.Port)
+				upstreamName = "$external_name_target"
+			}
+
+			if dynamicBackends.Has(backend.Name) {
+				upstreamName = luaBalancerUpstream
+			}
+
 			break
 		}
 	}
 
+	switch location.BackendProtocol {
+	case "GRPC", "GRPCS":
+		// gRPC requests cannot be rewritten
+		if len(location.Rewrite.Target) > 0 && location.Rewrite.Target != path {
+			glog.Warningf("rewrite-target is not supported in location %v with backend-protocol %v", path, location.BackendProtocol)
+		}
+		return fmt.Sprintf("%vgrpc_pass %s://%s;", externalName, strings.ToLower(location.BackendProtocol), upstreamName)
+	case "H2C", "H2":
+		// the grpc module is the only one able to use HTTP/2 to connect
+		// to the upstream servers and it does not rewrite the requests
+		if len(location.Rewrite.Target) > 0 && location.Rewrite.Target != path {
+			glog.Warningf("rewrite-target is not supported in location %v with backend-protocol %v", path, location.BackendProtocol)
+		}
+		scheme := "grpc"
+		if location.BackendProtocol == "H2" {
+			scheme = "grpcs"
+		}
+		return fmt.Sprintf("%vgrpc_pass %s://%s;", externalName, scheme, upstreamName)
+	case "FCGI", "UWSGI", "SCGI":
+		// the FastCGI, uwsgi and SCGI requests use the original URI
+		if len(location.Rewrite.Target) > 0 && location.Rewrite.Target != path {
+			glog.Warningf("rewrite-target is not supported in location %v with backend-protocol %v", path, location.BackendProtocol)
+		}
+		module := strings.ToLower(location.BackendProtocol)
+		if module == "fcgi" {
+			module = "fastcgi"
+		}
+		return fmt.Sprintf("%v%s_pass %s;", externalName, module, upstreamName)
+	}
+
 	// defProxyPass returns the default proxy_pass, just the name of the upstream
-	defProxyPass := fmt.Sprintf("proxy_pass %s://%s;", proto, upstreamName)
+	defProxyPass := fmt.Sprintf("%vproxy_pass %s://%s;", externalName, proto, upstreamName)
+
+	if location.Rewrite.UseRegex {
+		if len(location.Rewrite.Target) == 0 {
+			return defProxyPass
+		}
+
+		if location.Rewrite.AddBaseURL {
+			glog.Warningf("add-base-url is not supported in location %v with use-regex", path)
+		}
+
+		xForwardedPrefix := ""
+		if location.XForwardedPrefix == xforwardedprefix.UseLocationPath {
+			glog.Warningf("x-forwarded-prefix requires a custom prefix in location %v with use-regex", path)
+		} else if location.XForwardedPrefix != "" {
+			xForwardedPrefix = fmt.Sprintf(`proxy_set_header X-Forwarded-Prefix "%s";
+	    `, location.XForwardedPrefix)
+		}
+
+		// the rewrite evaluates the path again instead of using the captures
+		// of the location because they are overwritten by any regex checked
+		// in the location (if directives, maps, etc.)
+		return fmt.Sprintf(`
+	    %vrewrite "(?i)^%s" "%s" break;
+	    %vproxy_pass %s://%s;
+	    `, externalName, escapeRegexPath(path), escapeRegexPath(location.Rewrite.Target), xForwardedPrefix, proto, upstreamName)
+	}
 	// if the path in the ingress rule is equals to the target: no special rewrite
 	if path == location.Rewrite.Target {
 		return defProxyPass
@@ -324,30 +606,262 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		}
 
 		xForwardedPrefix := ""
-		if location.XForwardedPrefix {
+		if location.XForwardedPrefix == xforwardedprefix.UseLocationPath {
 			xForwardedPrefix = fmt.Sprintf(`proxy_set_header X-Forwarded-Prefix "%s";
 	    `, path)
+		} else if location.XForwardedPrefix != "" {
+			xForwardedPrefix = fmt.Sprintf(`proxy_set_header X-Forwarded-Prefix "%s";
+	    `, location.XForwardedPrefix)
 		}
 		if location.Rewrite.Target == slash {
 			// special case redirect to /
 			// ie /something to /
 			return fmt.Sprintf(`
-	    rewrite %s(.*) /$1 break;
+	    %vrewrite %s(.*) /$1 break;
 	    rewrite %s / break;
 	    %vproxy_pass %s://%s;
-	    %v`, path, location.Path, xForwardedPrefix, proto, upstreamName, abu)
+	    %v`, externalName, path, location.Path, xForwardedPrefix, proto, upstreamName, abu)
 		}
 
 		return fmt.Sprintf(`
-	    rewrite %s(.*) %s/$1 break;
+	    %vrewrite %s(.*) %s/$1 break;
 	    %vproxy_pass %s://%s;
-	    %v`, path, location.Rewrite.Target, xForwardedPrefix, proto, upstreamName, abu)
+	    %v`, externalName, path, location.Rewrite.Target, xForwardedPrefix, proto, upstreamName, abu)
 	}
 
 	// default proxy_pass
 	return defProxyPass
 }
 
+// hasGRPCLocations returns if any location of a server sends the
+// requests to gRPC upstream servers
+func hasGRPCLocations(input interface{}) bool {
+	server, ok := input.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Server' type but %T was returned", input)
+		return false
+	}
+
+	for _, location := range server.Locations {
+		if location.BackendProtocol == "GRPC" || location.BackendProtocol == "GRPCS" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// buildLocationVariable returns the name of a variable, unique for the
+// backend of the location, that contains the upstream to use (geo-backends
+// or A/B testing annotations)
+// escapeRegexPath escapes the backslashes and double quotes of a path used
+// in a quoted regular expression or replacement
+func escapeRegexPath(path string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path)
+}
+
+func buildLocationVariable(prefix string, location *ingress.Location) string {
+	name := location.Backend
+	if location.Ingress != nil {
+		name = fmt.Sprintf("%v_%v_%v", location.Ingress.Namespace, location.Ingress.Name, location.Backend)
+	}
+
+	return fmt.Sprintf("%v_%v", prefix, strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name))
+}
+
+// buildGeoBackendMaps returns the maps used to choose the upstream of the
+// locations with a geo-backends annotation using the country of the client.
+// Only upstreams with active endpoints are used.
+func buildGeoBackendMaps(s interface{}, b interface{}) []string {
+	maps := []string{}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected a '[]*ingress.Server' type but %T was returned", s)
+		return maps
+	}
+
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return maps
+	}
+
+	upstreams := sets.String{}
+	for _, backend := range backends {
+		upstreams.Insert(backend.Name)
+	}
+
+	variables := sets.String{}
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if len(location.GeoBackend.Upstreams) == 0 || location.Backend == "" {
+				continue
+			}
+
+			variable := buildLocationVariable("geo_upstream", location)
+			if variables.Has(variable) {
+				continue
+			}
+			variables.Insert(variable)
+
+			countries := sets.StringKeySet(location.GeoBackend.Upstreams).List()
+			entries := []string{fmt.Sprintf("default %v;", location.Backend)}
+			for _, country := range countries {
+				upstream := location.GeoBackend.Upstreams[country]
+				if !upstreams.Has(upstream) {
+					glog.Warningf("upstream %v for country %v does not have active endpoints", upstream, country)
+					continue
+				}
+				entries = append(entries, fmt.Sprintf("%v %v;", country, upstream))
+			}
+
+			maps = append(maps, fmt.Sprintf(`map $geoip_country_code $%v {
+        %v
+    }`, variable, strings.Join(entries, "\n        ")))
+		}
+	}
+
+	return maps
+}
+
+// buildABTestingSplits returns the split_clients blocks used to send a
+// percentage of the clients of a location to the alternate backend defined
+// in the A/B testing annotations. If the alternate backend does not have
+// active endpoints all the clients are sent to the default backend.
+func buildABTestingSplits(s interface{}, b interface{}) []string {
+	splits := []string{}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected a '[]*ingress.Server' type but %T was returned", s)
+		return splits
+	}
+
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return splits
+	}
+
+	upstreams := sets.String{}
+	for _, backend := range backends {
+		upstreams.Insert(backend.Name)
+	}
+
+	variables := sets.String{}
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			// the geo-backends annotation takes precedence
+			if location.ABTesting.Upstream == "" || location.Backend == "" ||
+				len(location.GeoBackend.Upstreams) > 0 {
+				continue
+			}
+
+			variable := buildLocationVariable("ab_upstream", location)
+			if variables.Has(variable) {
+				continue
+			}
+			variables.Insert(variable)
+
+			upstream := location.ABTesting.Upstream
+			if !upstreams.Has(upstream) {
+				glog.Warningf("upstream %v used for A/B testing does not have active endpoints", upstream)
+				upstream = location.Backend
+			}
+
+			splits = append(splits, fmt.Sprintf(`split_clients "%v" $%v {
+        %v%% %v;
+        * %v;
+    }`, location.ABTesting.Key, variable, location.ABTesting.Percentage, upstream, location.Backend))
+		}
+	}
+
+	return splits
+}
+
+// shouldLoadModSecurity checks if the ModSecurity module is enabled
+// globally or in at least one location using annotations
+func shouldLoadModSecurity(c interface{}, s interface{}) bool {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return false
+	}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected a '[]*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	if cfg.EnableModsecurity {
+		return true
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.ModSecurity.Enable {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// buildOWASPSettings returns the rules used to configure the OWASP Core Rule
+// Set of a location. The rules must be loaded before the Core Rule Set
+// because the initialization rules only set the variables with no value.
+func buildOWASPSettings(l interface{}) string {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return ""
+	}
+
+	rules := []string{}
+	if location.ModSecurity.ParanoiaLevel > 0 {
+		rules = append(rules, fmt.Sprintf(`SecAction "id:900000,phase:1,nolog,pass,t:none,setvar:tx.paranoia_level=%v"`,
+			location.ModSecurity.ParanoiaLevel))
+	}
+
+	thresholds := []string{}
+	if location.ModSecurity.InboundAnomalyThreshold > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("setvar:tx.inbound_anomaly_score_threshold=%v",
+			location.ModSecurity.InboundAnomalyThreshold))
+	}
+	if location.ModSecurity.OutboundAnomalyThreshold > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("setvar:tx.outbound_anomaly_score_threshold=%v",
+			location.ModSecurity.OutboundAnomalyThreshold))
+	}
+	if len(thresholds) > 0 {
+		rules = append(rules, fmt.Sprintf(`SecAction "id:900110,phase:1,nolog,pass,t:none,%v"`,
+			strings.Join(thresholds, ",")))
+	}
+
+	return strings.Join(rules, "\n")
+}
+
+// isExternalNameBackend checks if the backend points to a service of type
+// ExternalName with a hostname (not an IP address) as the external name
+func isExternalNameBackend(backend *ingress.Backend) bool {
+	if backend.Service == nil || backend.Service.Spec.Type != apiv1.ServiceTypeExternalName {
+		return false
+	}
+
+	if len(backend.Endpoints) != 1 {
+		return false
+	}
+
+	return net.ParseIP(backend.Endpoints[0].Address) == nil
+}
+
 // TODO: Needs Unit Tests
 func filterRateLimits(input interface{}) []ratelimit.Config {
 	ratelimits := []ratelimit.Config{}
@@ -422,6 +936,50 @@ func buildRateLimitZones(input interface{}) []string {
 	return zones.List()
 }
 
+// buildCorsOriginRegex returns a regular expression matching the
+// origins allowed in a CORS configuration
+func buildCorsOriginRegex(input interface{}) string {
+	config, ok := input.(cors.Config)
+	if !ok {
+		glog.Errorf("expected a 'cors.Config' type but %T was returned", input)
+		return ""
+	}
+
+	exprs := []string{}
+	for _, origin := range config.CorsAllowOrigins {
+		exprs = append(exprs, fmt.Sprintf("(^%v$)", regexp.QuoteMeta(origin)))
+	}
+	for _, expr := range config.CorsAllowOriginRegex {
+		exprs = append(exprs, fmt.Sprintf("(%v)", expr))
+	}
+
+	return strings.Join(exprs, "|")
+}
+
+// buildCSPHeader returns the directive used to send the
+// Content-Security-Policy header of a location, escaping the policy
+// to be used in a quoted string
+func buildCSPHeader(input interface{}) string {
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	policy := loc.SecurityHeaders.ContentSecurityPolicy
+	if policy == "" {
+		return ""
+	}
+
+	header := "Content-Security-Policy"
+	if loc.SecurityHeaders.CSPReportOnly {
+		header = "Content-Security-Policy-Report-Only"
+	}
+
+	policy = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(policy)
+	return fmt.Sprintf("more_set_headers \"%v: %v\";", header, policy)
+}
+
 // buildRateLimit produces an array of limit_req to be used inside the Path of
 // Ingress rules. The order: connections by IP first, then RPS, and RPM last.
 func buildRateLimit(input interface{}) []string {
@@ -451,21 +1009,93 @@ func buildRateLimit(input interface{}) []string {
 		limits = append(limits, limit)
 	}
 
-	if loc.RateLimit.LimitRateAfter > 0 {
+	if loc.RateLimit.StatusCode > 0 &&
+		(loc.RateLimit.Connections.Limit > 0 || loc.RateLimit.RPS.Limit > 0 || loc.RateLimit.RPM.Limit > 0) {
+		limits = append(limits,
+			fmt.Sprintf("limit_req_status %v;", loc.RateLimit.StatusCode),
+			fmt.Sprintf("limit_conn_status %v;", loc.RateLimit.StatusCode))
+
+		if loc.RateLimit.RetryAfter > 0 {
+			limit := fmt.Sprintf("more_set_headers -s %v \"Retry-After: %v\";",
+				loc.RateLimit.StatusCode, loc.RateLimit.RetryAfter)
+			limits = append(limits, limit)
+		}
+	}
+
+	if loc.LimitRate.After > 0 {
 		limit := fmt.Sprintf("limit_rate_after %vk;",
-			loc.RateLimit.LimitRateAfter)
+			loc.LimitRate.After)
 		limits = append(limits, limit)
 	}
 
-	if loc.RateLimit.LimitRate > 0 {
+	if loc.LimitRate.Rate > 0 {
 		limit := fmt.Sprintf("limit_rate %vk;",
-			loc.RateLimit.LimitRate)
+			loc.LimitRate.Rate)
 		limits = append(limits, limit)
 	}
 
 	return limits
 }
 
+// buildProxyCache produces the directives required to cache the responses
+// of a location in one of the cache zones defined in the configuration.
+// Locations using an undefined zone are not cached
+func buildProxyCache(c interface{}, input interface{}) []string {
+	directives := []string{}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return directives
+	}
+
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return directives
+	}
+
+	cache := loc.ProxyCache
+	if cache.Zone == "" {
+		return directives
+	}
+
+	found := false
+	for _, zone := range cfg.ProxyCacheZones {
+		if zone.Name == cache.Zone {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		glog.Warningf("the cache zone %v used in the location %v is not defined in the configuration", cache.Zone, loc.Path)
+		return directives
+	}
+
+	directives = append(directives, fmt.Sprintf("proxy_cache %v;", cache.Zone))
+
+	if cache.Key != "" {
+		directives = append(directives, fmt.Sprintf("proxy_cache_key \"%v\";", cache.Key))
+	}
+
+	for _, valid := range cache.Valid {
+		directives = append(directives, fmt.Sprintf("proxy_cache_valid %v;", valid))
+	}
+
+	if cache.Bypass != "" {
+		directives = append(directives, fmt.Sprintf("proxy_cache_bypass %v;", cache.Bypass))
+	}
+
+	if cache.NoCache != "" {
+		directives = append(directives, fmt.Sprintf("proxy_no_cache %v;", cache.NoCache))
+	}
+
+	directives = append(directives, "more_set_headers \"X-Cache-Status: $upstream_cache_status\";")
+
+	return directives
+}
+
 func isLocationAllowed(input interface{}) bool {
 	loc, ok := input.(*ingress.Location)
 	if !ok {
@@ -476,15 +1106,143 @@ func isLocationAllowed(input interface{}) bool {
 	return loc.Denied == nil
 }
 
-var (
-	denyPathSlugMap = map[string]string{}
-)
+// isCorsEnabled returns if the CORS headers must be added in a location
+func isCorsEnabled(input interface{}) bool {
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return false
+	}
+
+	return loc.CorsConfig.EnabledForPath(loc.Path)
+}
+
+// buildRequestHeaders returns the custom headers sent to the upstream
+// servers of a location. The headers defined in the Ingress replace
+// the global ones with the same name
+func buildRequestHeaders(global interface{}, input interface{}) map[string]string {
+	headers := map[string]string{}
+
+	globalHeaders, ok := global.(map[string]string)
+	if !ok {
+		glog.Errorf("expected a 'map[string]string' type but %T was returned", global)
+		return headers
+	}
+
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return globalHeaders
+	}
+
+	for name, value := range globalHeaders {
+		headers[name] = value
+	}
+
+	for name, value := range loc.RequestHeaders.Headers {
+		for k := range headers {
+			if strings.EqualFold(k, name) {
+				delete(headers, k)
+			}
+		}
+		headers[name] = value
+	}
+
+	return headers
+}
+
+type customError struct {
+	UpstreamName string
+	Code         int
+}
+
+// buildCustomErrors returns the list of named locations required to serve
+// the custom HTTP errors configured in the locations of a server
+func buildCustomErrors(input interface{}) []customError {
+	ces := []customError{}
+
+	server, ok := input.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Server' type but %T was returned", input)
+		return ces
+	}
+
+	found := map[customError]bool{}
+	for _, location := range server.Locations {
+		for _, code := range location.CustomHTTPErrors {
+			ce := customError{location.DefaultBackendUpstreamName, code}
+			if found[ce] {
+				continue
+			}
+
+			found[ce] = true
+			ces = append(ces, ce)
+		}
+	}
+
+	return ces
+}
+
+// filterMaintenance returns the maintenance mode configurations, one per
+// Ingress, that define source ranges allowed to bypass the maintenance
+func filterMaintenance(input interface{}) []maintenance.Config {
+	configs := []maintenance.Config{}
+	found := sets.String{}
+
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		glog.Errorf("expected a '[]*ingress.Server' type but %T was returned", input)
+		return configs
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if !loc.Maintenance.Enabled || len(loc.Maintenance.Whitelist) == 0 {
+				continue
+			}
+
+			if !found.Has(loc.Maintenance.ID) {
+				found.Insert(loc.Maintenance.ID)
+				configs = append(configs, loc.Maintenance)
+			}
+		}
+	}
+
+	return configs
+}
+
+// buildMaintenancePages returns the maintenance mode configurations of
+// the locations of a server with a custom page, one per Ingress
+func buildMaintenancePages(input interface{}) []maintenance.Config {
+	configs := []maintenance.Config{}
+	found := sets.String{}
+
+	server, ok := input.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Server' type but %T was returned", input)
+		return configs
+	}
+
+	for _, loc := range server.Locations {
+		if !loc.Maintenance.Enabled || loc.Maintenance.PageFile == "" {
+			continue
+		}
+
+		if !found.Has(loc.Maintenance.ID) {
+			found.Insert(loc.Maintenance.ID)
+			configs = append(configs, loc.Maintenance)
+		}
+	}
+
+	return configs
+}
 
 // buildDenyVariable returns a nginx variable for a location in a
 // server to be used in the whitelist check
-// This method uses a unique id generator library to reduce the
-// size of the string to be used as a variable in nginx to avoid
-// issue with the size of the variable bucket size directive
+// The name of the variable is a hash of the hostname and the path, to
+// reduce the size of the string to be used as a variable in nginx (avoiding
+// issues with the size of the variable bucket size directive) and to render
+// the same configuration in every replica and after restarts
 func buildDenyVariable(a interface{}) string {
 	l, ok := a.(string)
 	if !ok {
@@ -492,11 +1250,8 @@ func buildDenyVariable(a interface{}) string {
 		return ""
 	}
 
-	if _, ok := denyPathSlugMap[l]; !ok {
-		denyPathSlugMap[l] = randomString()
-	}
-
-	return fmt.Sprintf("$deny_%v", denyPathSlugMap[l])
+	// the prefix $deny_ and the first 32 characters of the hash
+	return fmt.Sprintf("$deny_%x", sha1.Sum([]byte(l)))[:38]
 }
 
 // TODO: Needs Unit Tests
@@ -688,17 +1443,36 @@ func buildAuthSignURL(input interface{}) string {
 	return fmt.Sprintf("%v&rd=$pass_access_scheme://$http_host$request_uri", s)
 }
 
-var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// tcpServer contains the TCP services exposed in a port
+type tcpServer struct {
+	Port     int
+	Services []ingress.L4Service
 }
 
-func randomString() string {
-	b := make([]rune, 32)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+// buildTCPServers groups the TCP services by port. The services with server
+// names (SNI) share the port, the rest of the services use a port each one.
+func buildTCPServers(input interface{}) []tcpServer {
+	servers := []tcpServer{}
+
+	services, ok := input.([]ingress.L4Service)
+	if !ok {
+		glog.Errorf("expected a '[]ingress.L4Service' type but %T was returned", input)
+		return servers
+	}
+
+	ports := map[int]int{}
+	for _, svc := range services {
+		if i, ok := ports[svc.Port]; ok {
+			servers[i].Services = append(servers[i].Services, svc)
+			continue
+		}
+
+		ports[svc.Port] = len(servers)
+		servers = append(servers, tcpServer{
+			Port:     svc.Port,
+			Services: []ingress.L4Service{svc},
+		})
 	}
 
-	return string(b)
+	return servers
 }
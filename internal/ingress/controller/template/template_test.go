@@ -17,20 +17,33 @@ limitations under the License.
 package template
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"net"
 	"os"
 	"path"
 	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"encoding/base64"
 	"fmt"
+	apiv1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/abtesting"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geobackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/pathtype"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 )
@@ -45,63 +58,68 @@ var (
 		AddBaseURL       bool
 		BaseURLScheme    string
 		Sticky           bool
-		XForwardedPrefix bool
+		XForwardedPrefix string
 	}{
-		"invalid redirect / to /": {"/", "/", "/", "proxy_pass http://upstream-name;", false, "", false, false},
+		"invalid redirect / to /": {"/", "/", "/", "proxy_pass http://upstream-name;", false, "", false, ""},
 		"redirect / to /jenkins": {"/", "/jenkins", "~* /",
 			`
 	    rewrite /(.*) /jenkins/$1 break;
 	    proxy_pass http://upstream-name;
-	    `, false, "", false, false},
+	    `, false, "", false, ""},
 		"redirect /something to /": {"/something", "/", `~* ^/something\/?(?<baseuri>.*)`, `
 	    rewrite /something/(.*) /$1 break;
 	    rewrite /something / break;
 	    proxy_pass http://upstream-name;
-	    `, false, "", false, false},
+	    `, false, "", false, ""},
 		"redirect /end-with-slash/ to /not-root": {"/end-with-slash/", "/not-root", "~* ^/end-with-slash/(?<baseuri>.*)", `
 	    rewrite /end-with-slash/(.*) /not-root/$1 break;
 	    proxy_pass http://upstream-name;
-	    `, false, "", false, false},
+	    `, false, "", false, ""},
 		"redirect /something-complex to /not-root": {"/something-complex", "/not-root", `~* ^/something-complex\/?(?<baseuri>.*)`, `
 	    rewrite /something-complex/(.*) /not-root/$1 break;
 	    proxy_pass http://upstream-name;
-	    `, false, "", false, false},
+	    `, false, "", false, ""},
 		"redirect / to /jenkins and rewrite": {"/", "/jenkins", "~* /", `
 	    rewrite /(.*) /jenkins/$1 break;
 	    proxy_pass http://upstream-name;
 	    subs_filter '(<(?:H|h)(?:E|e)(?:A|a)(?:D|d)(?:[^">]|"[^"]*")*>)' '$1<base href="$scheme://$http_host/$baseuri">' ro;
-	    `, true, "", false, false},
+	    `, true, "", false, ""},
 		"redirect /something to / and rewrite": {"/something", "/", `~* ^/something\/?(?<baseuri>.*)`, `
 	    rewrite /something/(.*) /$1 break;
 	    rewrite /something / break;
 	    proxy_pass http://upstream-name;
 	    subs_filter '(<(?:H|h)(?:E|e)(?:A|a)(?:D|d)(?:[^">]|"[^"]*")*>)' '$1<base href="$scheme://$http_host/something/$baseuri">' ro;
-	    `, true, "", false, false},
+	    `, true, "", false, ""},
 		"redirect /end-with-slash/ to /not-root and rewrite": {"/end-with-slash/", "/not-root", `~* ^/end-with-slash/(?<baseuri>.*)`, `
 	    rewrite /end-with-slash/(.*) /not-root/$1 break;
 	    proxy_pass http://upstream-name;
 	    subs_filter '(<(?:H|h)(?:E|e)(?:A|a)(?:D|d)(?:[^">]|"[^"]*")*>)' '$1<base href="$scheme://$http_host/end-with-slash/$baseuri">' ro;
-	    `, true, "", false, false},
+	    `, true, "", false, ""},
 		"redirect /something-complex to /not-root and rewrite": {"/something-complex", "/not-root", `~* ^/something-complex\/?(?<baseuri>.*)`, `
 	    rewrite /something-complex/(.*) /not-root/$1 break;
 	    proxy_pass http://upstream-name;
 	    subs_filter '(<(?:H|h)(?:E|e)(?:A|a)(?:D|d)(?:[^">]|"[^"]*")*>)' '$1<base href="$scheme://$http_host/something-complex/$baseuri">' ro;
-	    `, true, "", false, false},
+	    `, true, "", false, ""},
 		"redirect /something to / and rewrite with specific scheme": {"/something", "/", `~* ^/something\/?(?<baseuri>.*)`, `
 	    rewrite /something/(.*) /$1 break;
 	    rewrite /something / break;
 	    proxy_pass http://upstream-name;
 	    subs_filter '(<(?:H|h)(?:E|e)(?:A|a)(?:D|d)(?:[^">]|"[^"]*")*>)' '$1<base href="http://$http_host/something/$baseuri">' ro;
-	    `, true, "http", false, false},
+	    `, true, "http", false, ""},
 		"redirect / to /something with sticky enabled": {"/", "/something", `~* /`, `
 	    rewrite /(.*) /something/$1 break;
 	    proxy_pass http://sticky-upstream-name;
-	    `, false, "http", true, false},
+	    `, false, "http", true, ""},
 		"add the X-Forwarded-Prefix header": {"/there", "/something", `~* ^/there\/?(?<baseuri>.*)`, `
 	    rewrite /there/(.*) /something/$1 break;
 	    proxy_set_header X-Forwarded-Prefix "/there/";
 	    proxy_pass http://sticky-upstream-name;
-	    `, false, "http", true, true},
+	    `, false, "http", true, "true"},
+		"add a custom X-Forwarded-Prefix header": {"/there", "/something", `~* ^/there\/?(?<baseuri>.*)`, `
+	    rewrite /there/(.*) /something/$1 break;
+	    proxy_set_header X-Forwarded-Prefix "/external/there";
+	    proxy_pass http://upstream-name;
+	    `, false, "", false, "/external/there"},
 	}
 )
 
@@ -174,6 +192,364 @@ func TestBuildProxyPass(t *testing.T) {
 	}
 }
 
+func TestBuildProxyPassBackendProtocol(t *testing.T) {
+	testCases := map[string]struct {
+		Protocol  string
+		ProxyPass string
+	}{
+		"default":    {"", "proxy_pass http://upstream-name;"},
+		"http":       {"HTTP", "proxy_pass http://upstream-name;"},
+		"https":      {"HTTPS", "proxy_pass https://upstream-name;"},
+		"grpc":       {"GRPC", "grpc_pass grpc://upstream-name;"},
+		"grpc (ssl)": {"GRPCS", "grpc_pass grpcs://upstream-name;"},
+		"h2c":        {"H2C", "grpc_pass grpc://upstream-name;"},
+		"h2":         {"H2", "grpc_pass grpcs://upstream-name;"},
+		"fastcgi":    {"FCGI", "fastcgi_pass upstream-name;"},
+		"uwsgi":      {"UWSGI", "uwsgi_pass upstream-name;"},
+		"scgi":       {"SCGI", "scgi_pass upstream-name;"},
+	}
+
+	backends := []*ingress.Backend{{Name: "upstream-name"}}
+	for k, tc := range testCases {
+		loc := &ingress.Location{
+			Path:            "/",
+			Backend:         "upstream-name",
+			BackendProtocol: tc.Protocol,
+		}
+
+		pp := buildProxyPass("example.com", backends, loc)
+		if pp != tc.ProxyPass {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.ProxyPass, pp)
+		}
+	}
+
+	server := &ingress.Server{
+		Locations: []*ingress.Location{{Path: "/", BackendProtocol: "HTTP"}},
+	}
+	if hasGRPCLocations(server) {
+		t.Errorf("expected a server without gRPC locations")
+	}
+
+	server.Locations = append(server.Locations, &ingress.Location{Path: "/api", BackendProtocol: "GRPCS"})
+	if !hasGRPCLocations(server) {
+		t.Errorf("expected a server with gRPC locations")
+	}
+}
+
+func TestBuildLocationWithPathType(t *testing.T) {
+	testCases := map[string]struct {
+		Path     string
+		PathType string
+		UseRegex bool
+		Location string
+	}{
+		"without path type":       {"/api", "", false, "/api"},
+		"implementation specific": {"/api", pathtype.ImplementationSpecific, false, "/api"},
+		"exact":                   {"/api", pathtype.Exact, false, "= /api"},
+		"prefix":                  {"/api", pathtype.Prefix, false, "^~ /api"},
+		"regex has precedence":    {"/api/v[0-9]+", pathtype.Exact, true, `~* "^/api/v[0-9]+"`},
+	}
+
+	for k, tc := range testCases {
+		loc := &ingress.Location{
+			Path:     tc.Path,
+			PathType: tc.PathType,
+			Rewrite:  rewrite.Config{UseRegex: tc.UseRegex},
+		}
+
+		location := buildLocation(loc)
+		if location != tc.Location {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.Location, location)
+		}
+	}
+}
+
+func TestTemplateWithPathType(t *testing.T) {
+	ngxTpl, dat := rootfsTemplateWithData(t)
+
+	var location *ingress.Location
+	for _, server := range dat.Servers {
+		for _, loc := range server.Locations {
+			if loc.Path != "/" && !loc.Rewrite.UseRegex && loc.Rewrite.Target == "" {
+				location = loc
+				break
+			}
+		}
+	}
+	if location == nil {
+		t.Fatalf("expected a location without regex in the test data")
+	}
+
+	for pt, expected := range map[string]string{
+		pathtype.Exact:  fmt.Sprintf("location = %v {", location.Path),
+		pathtype.Prefix: fmt.Sprintf("location ^~ %v {", location.Path),
+	} {
+		location.PathType = pt
+
+		content, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in the configuration with the path type %v", expected, pt)
+		}
+	}
+}
+
+func TestBuildLocationAndProxyPassWithRegex(t *testing.T) {
+	testCases := map[string]struct {
+		Path      string
+		Target    string
+		Location  string
+		ProxyPass string
+	}{
+		"regex without rewrite": {"/api/v[0-9]+/", "", `~* "^/api/v[0-9]+/"`, "proxy_pass http://upstream-name;"},
+		"rewrite with capture groups": {"/api/(.*)", "/$1", `~* "^/api/(.*)"`, `
+	    rewrite "(?i)^/api/(.*)" "/$1" break;
+	    proxy_pass http://upstream-name;
+	    `},
+		"rewrite with multiple capture groups": {"/(app|web)/(v[0-9]{1,2})/(.*)", "/$2/$1/$3", `~* "^/(app|web)/(v[0-9]{1,2})/(.*)"`, `
+	    rewrite "(?i)^/(app|web)/(v[0-9]{1,2})/(.*)" "/$2/$1/$3" break;
+	    proxy_pass http://upstream-name;
+	    `},
+		"escape double quotes": {`/a"b/(.*)`, "/$1", `~* "^/a\"b/(.*)"`, `
+	    rewrite "(?i)^/a\"b/(.*)" "/$1" break;
+	    proxy_pass http://upstream-name;
+	    `},
+		"escape backslashes": {`/a\"; return 200; #/(.*)`, "/$1", `~* "^/a\\\"; return 200; #/(.*)"`, `
+	    rewrite "(?i)^/a\\\"; return 200; #/(.*)" "/$1" break;
+	    proxy_pass http://upstream-name;
+	    `},
+		"escaped regex": {`/v\d+/(.*)`, "/$1", `~* "^/v\\d+/(.*)"`, `
+	    rewrite "(?i)^/v\\d+/(.*)" "/$1" break;
+	    proxy_pass http://upstream-name;
+	    `},
+	}
+
+	for k, tc := range testCases {
+		loc := &ingress.Location{
+			Path:    tc.Path,
+			Rewrite: rewrite.Config{Target: tc.Target, UseRegex: true},
+			Backend: "upstream-name",
+		}
+
+		if newLoc := buildLocation(loc); tc.Location != newLoc {
+			t.Errorf("%s: expected '%v' but returned %v", k, tc.Location, newLoc)
+		}
+
+		if pp := buildProxyPass("example.com", []*ingress.Backend{}, loc); tc.ProxyPass != pp {
+			t.Errorf("%s: expected \n'%v'\nbut returned \n'%v'", k, tc.ProxyPass, pp)
+		}
+	}
+}
+
+func TestBuildProxyPassExternalName(t *testing.T) {
+	backends := []*ingress.Backend{
+		{
+			Name: "upstream-name",
+			Service: &apiv1.Service{
+				Spec: apiv1.ServiceSpec{
+					Type:         apiv1.ServiceTypeExternalName,
+					ExternalName: "api.example.com",
+				},
+			},
+			Endpoints: []ingress.Endpoint{
+				{Address: "api.example.com", Port: "443"},
+			},
+		},
+	}
+
+	loc := &ingress.Location{
+		Path:    "/",
+		Backend: "upstream-name",
+		Rewrite: rewrite.Config{Target: "/"},
+	}
+
+	expected := `set $external_name_target "api.example.com:443";
+	    proxy_pass http://$external_name_target;`
+	pp := buildProxyPass("example.com", backends, loc)
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+
+	loc.Path = "/api"
+	loc.Rewrite.Target = "/v1"
+	expected = `
+	    set $external_name_target "api.example.com:443";
+	    rewrite /api/(.*) /v1/$1 break;
+	    proxy_pass http://$external_name_target;
+	    `
+	pp = buildProxyPass("example.com", backends, loc)
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+
+	backends[0].Endpoints[0].Address = "10.0.0.1"
+	pp = buildProxyPass("example.com", backends, loc)
+	if strings.Contains(pp, "$external_name_target") {
+		t.Errorf("expected a proxy_pass without variables but returned \n'%v'", pp)
+	}
+}
+
+func TestBuildProxyPassDynamicBackend(t *testing.T) {
+	backends := []*ingress.Backend{
+		{Name: "upstream-name"},
+		{Name: "other-upstream"},
+	}
+
+	loc := &ingress.Location{
+		Path:    "/",
+		Backend: "upstream-name",
+	}
+
+	expected := "proxy_pass http://upstream_balancer;"
+	pp := buildProxyPass("example.com", backends, loc, sets.NewString("upstream-name"))
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+
+	expected = "proxy_pass http://upstream-name;"
+	pp = buildProxyPass("example.com", backends, loc, sets.NewString("other-upstream"))
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+}
+
+func TestBuildGeoBackendMaps(t *testing.T) {
+	ing := &extensions.Ingress{}
+	ing.Namespace = "default"
+	ing.Name = "web"
+
+	loc := &ingress.Location{
+		Path:    "/",
+		Backend: "default-web-80",
+		Ingress: ing,
+		GeoBackend: geobackend.Config{
+			Upstreams: map[string]string{
+				"US": "default-web-us-80",
+				"DE": "default-web-eu-80",
+				"FR": "default-web-eu-80",
+			},
+		},
+	}
+
+	servers := []*ingress.Server{
+		{Hostname: "example.com", Locations: []*ingress.Location{loc}},
+		{Hostname: "www.example.com", Locations: []*ingress.Location{loc}},
+	}
+	backends := []*ingress.Backend{
+		{Name: "default-web-80"},
+		{Name: "default-web-eu-80"},
+	}
+
+	maps := buildGeoBackendMaps(servers, backends)
+	if len(maps) != 1 {
+		t.Fatalf("expected one map but returned %v", len(maps))
+	}
+
+	expected := `map $geoip_country_code $geo_upstream_default_web_default_web_80 {
+        default default-web-80;
+        DE default-web-eu-80;
+        FR default-web-eu-80;
+    }`
+	if maps[0] != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, maps[0])
+	}
+
+	pp := buildProxyPass("example.com", backends, loc)
+	if pp != "proxy_pass http://$geo_upstream_default_web_default_web_80;" {
+		t.Errorf("unexpected proxy_pass: %v", pp)
+	}
+}
+
+func TestBuildABTestingSplits(t *testing.T) {
+	ing := &extensions.Ingress{}
+	ing.Namespace = "default"
+	ing.Name = "web"
+
+	loc := &ingress.Location{
+		Path:    "/",
+		Backend: "default-web-80",
+		Ingress: ing,
+		ABTesting: abtesting.Config{
+			Upstream:   "default-web-canary-80",
+			Percentage: 20,
+			Key:        "$cookie_user",
+		},
+	}
+
+	servers := []*ingress.Server{
+		{Hostname: "example.com", Locations: []*ingress.Location{loc}},
+	}
+	backends := []*ingress.Backend{
+		{Name: "default-web-80"},
+		{Name: "default-web-canary-80"},
+	}
+
+	splits := buildABTestingSplits(servers, backends)
+	if len(splits) != 1 {
+		t.Fatalf("expected one split_clients block but returned %v", len(splits))
+	}
+
+	expected := `split_clients "$cookie_user" $ab_upstream_default_web_default_web_80 {
+        20% default-web-canary-80;
+        * default-web-80;
+    }`
+	if splits[0] != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, splits[0])
+	}
+
+	pp := buildProxyPass("example.com", backends, loc)
+	if pp != "proxy_pass http://$ab_upstream_default_web_default_web_80;" {
+		t.Errorf("unexpected proxy_pass: %v", pp)
+	}
+
+	// without endpoints in the alternate backend all the clients use the default one
+	splits = buildABTestingSplits(servers, backends[:1])
+	if !strings.Contains(splits[0], "20% default-web-80;") {
+		t.Errorf("expected the default backend but returned \n'%v'", splits[0])
+	}
+}
+
+func TestShouldLoadModSecurity(t *testing.T) {
+	loc := &ingress.Location{Path: "/"}
+	servers := []*ingress.Server{
+		{Hostname: "example.com", Locations: []*ingress.Location{loc}},
+	}
+
+	if shouldLoadModSecurity(config.NewDefault(), servers) {
+		t.Errorf("expected the ModSecurity module to be disabled")
+	}
+
+	cfg := config.NewDefault()
+	cfg.EnableModsecurity = true
+	if !shouldLoadModSecurity(cfg, servers) {
+		t.Errorf("expected the ModSecurity module to be enabled globally")
+	}
+
+	loc.ModSecurity = modsecurity.Config{Enable: true}
+	if !shouldLoadModSecurity(config.NewDefault(), servers) {
+		t.Errorf("expected the ModSecurity module to be enabled by the location")
+	}
+}
+
+func TestBuildOWASPSettings(t *testing.T) {
+	loc := &ingress.Location{}
+	if s := buildOWASPSettings(loc); s != "" {
+		t.Errorf("expected no settings but returned %v", s)
+	}
+
+	loc.ModSecurity = modsecurity.Config{
+		ParanoiaLevel:           3,
+		InboundAnomalyThreshold: 10,
+	}
+	expected := `SecAction "id:900000,phase:1,nolog,pass,t:none,setvar:tx.paranoia_level=3"
+SecAction "id:900110,phase:1,nolog,pass,t:none,setvar:tx.inbound_anomaly_score_threshold=10"`
+	if s := buildOWASPSettings(loc); s != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, s)
+	}
+}
+
 func TestBuildAuthLocation(t *testing.T) {
 	authURL := "foo.com/auth"
 
@@ -240,10 +616,152 @@ func TestTemplateWithData(t *testing.T) {
 		t.Errorf("invalid NGINX template: %v", err)
 	}
 
-	_, err = ngxTpl.Write(dat)
+	content, err := ngxTpl.Write(dat)
 	if err != nil {
 		t.Errorf("invalid NGINX template: %v", err)
 	}
+
+	// the configuration must not change if the data does not change
+	for i := 0; i < 5; i++ {
+		again, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if !bytes.Equal(content, again) {
+			t.Fatalf("expected the same configuration rendering the same data")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ngxTpl.WriteTo(&buf, dat); err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if !bytes.Equal(content, buf.Bytes()) {
+		t.Errorf("expected the same configuration writing to a writer")
+	}
+}
+
+// rootfsTemplateWithData returns the template of the rootfs directory and
+// the configuration of test/data/config.json
+func rootfsTemplateWithData(t *testing.T) (*Template, config.TemplateConfig) {
+	pwd, _ := os.Getwd()
+	data, err := ioutil.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := json.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	dat.ListenPorts = &config.ListenPorts{Status: 18080, Configuration: 18081}
+
+	tmplData, err := ioutil.ReadFile(path.Join(pwd, "../../../../rootfs/etc/nginx/template/nginx.tmpl"))
+	if err != nil {
+		t.Fatalf("unexpected error reading the template: %v", err)
+	}
+
+	fs, err := file.NewFakeFS()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := fs.Create("/etc/nginx/template/nginx.tmpl")
+	if err != nil {
+		t.Fatalf("unexpected error creating the template: %v", err)
+	}
+	if _, err := f.Write(tmplData); err != nil {
+		t.Fatalf("unexpected error writing the template: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate("/etc/nginx/template/nginx.tmpl", fs)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	return ngxTpl, dat
+}
+
+func TestConcurrentWrite(t *testing.T) {
+	ngxTpl, dat := rootfsTemplateWithData(t)
+
+	// the locations with a whitelist use buildDenyVariable, with different
+	// hostnames in every render
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		conf := dat
+		conf.Servers = []*ingress.Server{}
+		for _, server := range dat.Servers {
+			s := *server
+			s.Hostname = fmt.Sprintf("%v.%v", i, server.Hostname)
+			conf.Servers = append(conf.Servers, &s)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ngxTpl.Write(conf); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("invalid NGINX template: %v", err)
+	}
+}
+
+func TestStaticUpstreamWithEwma(t *testing.T) {
+	ngxTpl, dat := rootfsTemplateWithData(t)
+	if len(dat.Backends) == 0 {
+		t.Fatalf("expected backends in the test data")
+	}
+	dat.Backends[0].LoadBalancing = "ewma"
+	dat.Cfg.LoadBalanceAlgorithm = "ewma"
+
+	content, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	// ewma is only supported by the Lua balancer
+	if strings.Contains(string(content), "ewma;") {
+		t.Errorf("unexpected ewma directive in the upstream blocks")
+	}
+}
+
+func TestConfigurationServerListen(t *testing.T) {
+	ngxTpl, dat := rootfsTemplateWithData(t)
+	dat.DynamicConfigurationEnabled = true
+
+	content, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	conf := string(content)
+
+	if strings.Count(conf, "location /configuration {") != 1 {
+		t.Fatalf("expected exactly one configuration location")
+	}
+
+	// the configuration location must be in the server listening on the
+	// loopback interface, not in the status server
+	location := strings.Index(conf, "location /configuration {")
+	listen := strings.LastIndex(conf[:location], "listen ")
+	line := conf[listen : listen+strings.Index(conf[listen:], "\n")]
+	if line != "listen 127.0.0.1:18081;" {
+		t.Errorf("expected the configuration server to listen on 127.0.0.1:18081 but got %q", line)
+	}
+
+	dat.DynamicConfigurationEnabled = false
+	content, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(content), "127.0.0.1:18081") {
+		t.Errorf("unexpected configuration server without dynamic configuration")
+	}
 }
 
 func BenchmarkTemplateWithData(b *testing.B) {
@@ -283,6 +801,31 @@ func TestBuildDenyVariable(t *testing.T) {
 	if !reflect.DeepEqual(a, b) {
 		t.Errorf("Expected '%v' but returned '%v'", a, b)
 	}
+
+	// the name does not depend on the process that renders the template
+	expected := "$deny_209457f09f72cced2e75b23c97913a61"
+	if a != expected {
+		t.Errorf("Expected '%v' but returned '%v'", expected, a)
+	}
+
+	c := buildDenyVariable("host1.example.com_/.well-known/acme-challenge_denylist")
+	if a == c {
+		t.Errorf("Expected different variables but both returned '%v'", a)
+	}
+}
+
+func TestBuildDenyVariableConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				buildDenyVariable(fmt.Sprintf("host%v.example.com_/%v", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
 }
 
 func TestBuildClientBodyBufferSize(t *testing.T) {
@@ -357,6 +900,28 @@ func TestBuildResolvers(t *testing.T) {
 	if resolver != validResolver {
 		t.Errorf("Expected '%v' but returned '%v'", validResolver, resolver)
 	}
+
+	testCases := map[string]struct {
+		options  []interface{}
+		expected string
+	}{
+		"valid time":          {[]interface{}{"1h30m"}, "resolver 192.0.0.1 [2001:db8:1234::] valid=1h30m;"},
+		"default valid time":  {[]interface{}{"", false}, "resolver 192.0.0.1 [2001:db8:1234::] valid=30s;"},
+		"invalid valid time":  {[]interface{}{"30 seconds", false}, "resolver 192.0.0.1 [2001:db8:1234::] valid=30s;"},
+		"ipv6 disabled":       {[]interface{}{"10s", true}, "resolver 192.0.0.1 [2001:db8:1234::] valid=10s ipv6=off;"},
+		"invalid option type": {[]interface{}{10, "true"}, "resolver 192.0.0.1 [2001:db8:1234::] valid=30s;"},
+	}
+
+	for title, tc := range testCases {
+		resolver := buildResolvers(ipList, tc.options...)
+		if resolver != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", title, tc.expected, resolver)
+		}
+	}
+
+	if resolver := buildResolvers([]net.IP{}, "10s", true); resolver != "" {
+		t.Errorf("expected no resolver without nameservers but returned '%v'", resolver)
+	}
 }
 
 func TestBuildNextUpstream(t *testing.T) {
@@ -411,18 +976,27 @@ func TestBuildRateLimit(t *testing.T) {
 	loc.RateLimit.RPM.Limit = 2
 	loc.RateLimit.RPM.Burst = 2
 
-	loc.RateLimit.LimitRateAfter = 1
-	loc.RateLimit.LimitRate = 1
+	loc.RateLimit.StatusCode = 429
+	loc.RateLimit.RetryAfter = 60
+
+	loc.LimitRate.After = 1
+	loc.LimitRate.Rate = 1
 
 	validLimits := []string{
 		"limit_conn con 1;",
 		"limit_req zone=rps burst=1 nodelay;",
 		"limit_req zone=rpm burst=2 nodelay;",
+		"limit_req_status 429;",
+		"limit_conn_status 429;",
+		`more_set_headers -s 429 "Retry-After: 60";`,
 		"limit_rate_after 1k;",
 		"limit_rate 1k;",
 	}
 
 	limits := buildRateLimit(loc)
+	if len(limits) != len(validLimits) {
+		t.Fatalf("Expected '%v' but returned '%v'", validLimits, limits)
+	}
 
 	for i, limit := range limits {
 		if limit != validLimits[i] {
@@ -431,6 +1005,89 @@ func TestBuildRateLimit(t *testing.T) {
 	}
 }
 
+func TestBuildProxyCache(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.ProxyCacheZones = []config.ProxyCacheZone{
+		{Name: "static", Size: "10m", Inactive: "10m"},
+	}
+
+	loc := &ingress.Location{Path: "/"}
+	if directives := buildProxyCache(cfg, loc); len(directives) != 0 {
+		t.Errorf("Expected no directives but returned '%v'", directives)
+	}
+
+	loc.ProxyCache = proxycache.Config{
+		Zone:    "static",
+		Key:     "$host$request_uri",
+		Valid:   []string{"200 302 10m", "any 1m"},
+		Bypass:  "$arg_nocache",
+		NoCache: "$http_pragma",
+	}
+
+	validDirectives := []string{
+		"proxy_cache static;",
+		`proxy_cache_key "$host$request_uri";`,
+		"proxy_cache_valid 200 302 10m;",
+		"proxy_cache_valid any 1m;",
+		"proxy_cache_bypass $arg_nocache;",
+		"proxy_no_cache $http_pragma;",
+		`more_set_headers "X-Cache-Status: $upstream_cache_status";`,
+	}
+
+	directives := buildProxyCache(cfg, loc)
+	if !reflect.DeepEqual(directives, validDirectives) {
+		t.Errorf("Expected '%v' but returned '%v'", validDirectives, directives)
+	}
+
+	loc.ProxyCache.Zone = "undefined"
+	if directives := buildProxyCache(cfg, loc); len(directives) != 0 {
+		t.Errorf("Expected no directives but returned '%v'", directives)
+	}
+}
+
+func TestBuildCorsOriginRegex(t *testing.T) {
+	config := cors.Config{
+		CorsAllowOrigins:     []string{"https://a.example.com", "http://b.example.com:8080"},
+		CorsAllowOriginRegex: []string{`^https://[a-z]+\.example\.org$`},
+	}
+
+	expected := `(^https://a\.example\.com$)|(^http://b\.example\.com:8080$)|(^https://[a-z]+\.example\.org$)`
+	if expr := buildCorsOriginRegex(config); expr != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, expr)
+	}
+
+	re := regexp.MustCompile(expected)
+	for _, origin := range []string{"https://a.example.com", "http://b.example.com:8080", "https://c.example.org"} {
+		if !re.MatchString(origin) {
+			t.Errorf("expected %v to be allowed", origin)
+		}
+	}
+	for _, origin := range []string{"https://a.example.com.evil.com", "https://aXexample.com", "https://c.example.org.evil.com"} {
+		if re.MatchString(origin) {
+			t.Errorf("expected %v to be denied", origin)
+		}
+	}
+}
+
+func TestBuildCSPHeader(t *testing.T) {
+	loc := &ingress.Location{}
+	if header := buildCSPHeader(loc); header != "" {
+		t.Errorf("expected an empty directive but returned '%v'", header)
+	}
+
+	loc.SecurityHeaders.ContentSecurityPolicy = `default-src 'self'; script-src "x\y"`
+	expected := `more_set_headers "Content-Security-Policy: default-src 'self'; script-src \"x\\y\"";`
+	if header := buildCSPHeader(loc); header != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, header)
+	}
+
+	loc.SecurityHeaders.CSPReportOnly = true
+	expected = `more_set_headers "Content-Security-Policy-Report-Only: default-src 'self'; script-src \"x\\y\"";`
+	if header := buildCSPHeader(loc); header != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, header)
+	}
+}
+
 func TestBuildAuthSignURL(t *testing.T) {
 	cases := map[string]struct {
 		Input, Output string
@@ -446,3 +1103,158 @@ func TestBuildAuthSignURL(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildCustomErrors(t *testing.T) {
+	server := &ingress.Server{
+		Locations: []*ingress.Location{
+			{Path: "/", CustomHTTPErrors: []int{404, 503}, DefaultBackendUpstreamName: "custom-default-backend-default-errors"},
+			{Path: "/api", CustomHTTPErrors: []int{404}, DefaultBackendUpstreamName: "custom-default-backend-default-errors"},
+			{Path: "/static", CustomHTTPErrors: []int{404}, DefaultBackendUpstreamName: "upstream-default-backend"},
+			{Path: "/other"},
+		},
+	}
+
+	expected := []customError{
+		{"custom-default-backend-default-errors", 404},
+		{"custom-default-backend-default-errors", 503},
+		{"upstream-default-backend", 404},
+	}
+
+	ces := buildCustomErrors(server)
+	if !reflect.DeepEqual(ces, expected) {
+		t.Errorf("expected '%v' but returned '%v'", expected, ces)
+	}
+
+	if ces := buildCustomErrors(&ingress.Location{}); len(ces) != 0 {
+		t.Errorf("expected an empty list but returned '%v'", ces)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	withPage := maintenance.Config{Enabled: true, ID: "a", PageFile: "/tmp/default_a.html"}
+	withWhitelist := maintenance.Config{Enabled: true, ID: "b", Whitelist: []string{"10.0.0.0/8"}}
+
+	server := &ingress.Server{
+		Locations: []*ingress.Location{
+			{Path: "/", Maintenance: withPage},
+			{Path: "/api", Maintenance: withPage},
+			{Path: "/admin", Maintenance: withWhitelist},
+			{Path: "/other"},
+		},
+	}
+
+	pages := buildMaintenancePages(server)
+	if !reflect.DeepEqual(pages, []maintenance.Config{withPage}) {
+		t.Errorf("expected '%v' but returned '%v'", []maintenance.Config{withPage}, pages)
+	}
+
+	configs := filterMaintenance([]*ingress.Server{server, server})
+	if !reflect.DeepEqual(configs, []maintenance.Config{withWhitelist}) {
+		t.Errorf("expected '%v' but returned '%v'", []maintenance.Config{withWhitelist}, configs)
+	}
+}
+
+func TestBuildRequestHeaders(t *testing.T) {
+	global := map[string]string{
+		"X-Env":     "production",
+		"X-Version": "1.0",
+	}
+
+	loc := &ingress.Location{}
+	if headers := buildRequestHeaders(global, loc); !reflect.DeepEqual(headers, global) {
+		t.Errorf("expected '%v' but returned '%v'", global, headers)
+	}
+
+	loc.RequestHeaders.Headers = map[string]string{
+		"x-env":  "staging",
+		"X-Team": "payments",
+	}
+	expected := map[string]string{
+		"x-env":     "staging",
+		"X-Team":    "payments",
+		"X-Version": "1.0",
+	}
+	if headers := buildRequestHeaders(global, loc); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected '%v' but returned '%v'", expected, headers)
+	}
+
+	if len(global) != 2 {
+		t.Errorf("expected the global headers to be unchanged but returned '%v'", global)
+	}
+}
+
+func TestBuildTCPServers(t *testing.T) {
+	services := []ingress.L4Service{
+		{Port: 6379, Backend: ingress.L4Backend{Name: "redis"}},
+		{Port: 443, Backend: ingress.L4Backend{Name: "postgres"}, ServerNames: []string{"postgres.example.com"}},
+		{Port: 443, Backend: ingress.L4Backend{Name: "mysql"}, ServerNames: []string{"mysql.example.com"}},
+	}
+
+	servers := buildTCPServers(services)
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers but returned %v", len(servers))
+	}
+	if servers[0].Port != 6379 || len(servers[0].Services) != 1 {
+		t.Errorf("expected the port 6379 with one service but returned %v", servers[0])
+	}
+	if servers[1].Port != 443 || len(servers[1].Services) != 2 {
+		t.Errorf("expected the port 443 with two services but returned %v", servers[1])
+	}
+
+	if servers := buildTCPServers(nil); len(servers) != 0 {
+		t.Errorf("expected no servers but returned %v", servers)
+	}
+}
+
+func TestBuildMirrorLocation(t *testing.T) {
+	loc := &ingress.Location{
+		Path: "/cat",
+	}
+
+	if str := buildMirrorLocation(loc); str != "" {
+		t.Errorf("expected an empty location but returned '%v'", str)
+	}
+
+	loc.Mirror.Target = "https://staging.example.com"
+	encodedPath := strings.Replace(base64.URLEncoding.EncodeToString([]byte(loc.Path)), "=", "", -1)
+	expected := fmt.Sprintf("/_mirror-%v", encodedPath)
+	if str := buildMirrorLocation(loc); str != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, str)
+	}
+}
+
+func TestBuildLogFormats(t *testing.T) {
+	format := `$remote_addr "$request" $status`
+	servers := []*ingress.Server{
+		{
+			Hostname: "foo.bar",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{Path: "/api", LogFormat: format},
+			},
+		},
+		{
+			Hostname: "bar.baz",
+			Locations: []*ingress.Location{
+				{Path: "/", LogFormat: format},
+			},
+		},
+	}
+
+	formats := buildLogFormats(servers)
+	if !reflect.DeepEqual(formats, []string{format}) {
+		t.Errorf("expected the log format '%v' but returned %v", format, formats)
+	}
+
+	name := logFormatName(format)
+	if !strings.HasPrefix(name, "upstreaminfo_") || len(name) != 21 {
+		t.Errorf("unexpected log format name '%v'", name)
+	}
+	if logFormatName(format+" $body_bytes_sent") == name {
+		t.Errorf("expected different names for different log formats")
+	}
+
+	if formats := buildLogFormats(nil); len(formats) != 0 {
+		t.Errorf("expected no log formats but returned %v", formats)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+)
+
+// whitespaceWriterSize is the size of the buffer of the writes
+// to the destination of a whitespaceWriter
+const whitespaceWriterSize = 32 * 1024
+
+// whitespaceWriters is a pool of the writers used to render the
+// templates, reusing their buffers between renders
+var whitespaceWriters = sync.Pool{
+	New: func() interface{} {
+		return &whitespaceWriter{
+			w: bufio.NewWriterSize(nil, whitespaceWriterSize),
+		}
+	},
+}
+
+// whitespaceWriter removes the carriage returns and the trailing spaces of
+// the lines written to it, and replaces the consecutive empty lines with a
+// single empty line, so the output does not depend on the spaces left by the
+// actions of the template. The lines are written to the destination as soon
+// as they are complete, and Flush must be called after the last write.
+type whitespaceWriter struct {
+	w *bufio.Writer
+	// line is the incomplete line written last
+	line []byte
+	// empty is true if the last line written to the destination is empty
+	empty bool
+}
+
+// getWhitespaceWriter returns a whitespaceWriter of the pool that writes
+// to w. It must be returned with putWhitespaceWriter
+func getWhitespaceWriter(w io.Writer) *whitespaceWriter {
+	ww := whitespaceWriters.Get().(*whitespaceWriter)
+	ww.w.Reset(w)
+	return ww
+}
+
+// putWhitespaceWriter returns a whitespaceWriter to the pool
+func putWhitespaceWriter(ww *whitespaceWriter) {
+	ww.w.Reset(nil)
+	ww.line = ww.line[:0]
+	ww.empty = false
+	whitespaceWriters.Put(ww)
+}
+
+// Write writes the complete lines of p to the destination
+func (ww *whitespaceWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			ww.line = append(ww.line, p...)
+			break
+		}
+
+		ww.line = append(ww.line, p[:i]...)
+		if err := ww.writeLine(true); err != nil {
+			return 0, err
+		}
+		p = p[i+1:]
+	}
+
+	return n, nil
+}
+
+// Flush writes the incomplete line, without adding a line feed, and the
+// buffered data to the destination
+func (ww *whitespaceWriter) Flush() error {
+	if err := ww.writeLine(false); err != nil {
+		return err
+	}
+
+	return ww.w.Flush()
+}
+
+// writeLine writes the line, followed by a line feed if newline is true.
+// A line feed is not added to an empty line without newline, which is the
+// end of the content.
+func (ww *whitespaceWriter) writeLine(newline bool) error {
+	line := ww.line[:0]
+	for _, c := range ww.line {
+		if c != '\r' {
+			line = append(line, c)
+		}
+	}
+	line = bytes.TrimRight(line, " \t")
+	ww.line = ww.line[:0]
+
+	if len(line) == 0 {
+		if ww.empty || !newline {
+			return nil
+		}
+		ww.empty = true
+	} else {
+		ww.empty = false
+	}
+
+	if _, err := ww.w.Write(line); err != nil {
+		return err
+	}
+
+	if newline {
+		return ww.w.WriteByte('\n')
+	}
+
+	return nil
+}
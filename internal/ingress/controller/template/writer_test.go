@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWhitespaceWriter(t *testing.T) {
+	testCases := map[string]string{
+		"":                                  "",
+		"events {}\n":                       "events {}\n",
+		"a\n\n\n\nb\n":                      "a\n\nb\n",
+		"a\r\n   \n\n  b\n\n":               "a\n\n  b\n\n",
+		"http {\n    \n    \n}\n\n\n":       "http {\n\n}\n\n",
+		"listen 80 ;  \n\t\n  root /a;\t\n": "listen 80 ;\n\n  root /a;\n",
+		"a\n  b  ":                          "a\n  b",
+		"a\n   ":                            "a\n",
+	}
+
+	for content, expected := range testCases {
+		var buf bytes.Buffer
+		ww := getWhitespaceWriter(&buf)
+		ww.Write([]byte(content))
+		if err := ww.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		putWhitespaceWriter(ww)
+
+		if buf.String() != expected {
+			t.Errorf("expected %q but returned %q", expected, buf.String())
+		}
+
+		// the result does not depend on the size of the writes
+		buf.Reset()
+		ww = getWhitespaceWriter(&buf)
+		for i := range content {
+			ww.Write([]byte{content[i]})
+		}
+		if err := ww.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		putWhitespaceWriter(ww)
+
+		if buf.String() != expected {
+			t.Errorf("expected %q writing a byte at a time but returned %q", expected, buf.String())
+		}
+	}
+}
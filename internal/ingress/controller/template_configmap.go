@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/golang/glog"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+// templateConfigMapKey is the key of the template in the TemplateConfigMap
+const templateConfigMapKey = "nginx.tmpl"
+
+// syncTemplateConfigMap replaces the template with the one of the
+// TemplateConfigMap when its content changes. The other keys of the
+// ConfigMap named like partials, like stream.tmpl, are loaded with the
+// partials of the include directory. The new template is used only if
+// the running configuration rendered with it is valid. It returns the
+// previous template, which must be restored with restoreTemplate if the
+// reload fails, or nil if the template did not change.
+func (n *NGINXController) syncTemplateConfigMap() *ngx_template.Template {
+	if n.cfg.TemplateConfigMap == "" {
+		return nil
+	}
+
+	cm, err := n.store.GetConfigMap(n.cfg.TemplateConfigMap)
+	if err != nil {
+		glog.Warningf("unexpected error reading the template ConfigMap %v: %v", n.cfg.TemplateConfigMap, err)
+		return nil
+	}
+
+	data, ok := cm.Data[templateConfigMapKey]
+	if !ok {
+		glog.Warningf("the template ConfigMap %v does not contain the key %v", n.cfg.TemplateConfigMap, templateConfigMapKey)
+		return nil
+	}
+
+	checksum := templateChecksum(cm.Data)
+	if checksum == n.templateChecksum {
+		return nil
+	}
+	// the content is not loaded again until it changes, even if it is not valid
+	n.templateChecksum = checksum
+
+	template, err := ngx_template.NewTemplateFromData([]byte(data))
+	if err == nil {
+		err = template.SetPartials(n.templatePartials(cm.Data))
+	}
+	if err == nil {
+		err = n.testNewTemplate(template)
+	}
+	if err != nil {
+		glog.Errorf("invalid NGINX template in ConfigMap %v, keeping the current template: %v", n.cfg.TemplateConfigMap, err)
+		return nil
+	}
+
+	previous := n.t
+	n.t = template
+	glog.Infof("new NGINX template loaded from ConfigMap %v", n.cfg.TemplateConfigMap)
+	// the template is synced before the configuration, so a new sync is not required
+	atomic.StoreInt32(&n.forceReload, 1)
+
+	return previous
+}
+
+// restoreTemplate restores the previous template when the reload with the
+// template of the ConfigMap fails. The template of the ConfigMap is tested
+// again in the next sync, because the reload could fail for another reason
+func (n *NGINXController) restoreTemplate(previous *ngx_template.Template) {
+	glog.Errorf("restoring the previous NGINX template, the reload with the template of ConfigMap %v failed", n.cfg.TemplateConfigMap)
+	n.t = previous
+	n.templateChecksum = ""
+}
+
+// templatePartials returns the partials of the include directory and the
+// partials of the ConfigMap, that replace the ones with the same name
+func (n *NGINXController) templatePartials(data map[string]string) map[string]string {
+	partials, err := ngx_template.ReadPartials(n.cfg.TemplateIncludeDir, n.fileSystem)
+	if err != nil {
+		glog.Warningf("unexpected error reading the partials of %v: %v", n.cfg.TemplateIncludeDir, err)
+		partials = map[string]string{}
+	}
+
+	for name, content := range data {
+		if ngx_template.IsPartial(name) {
+			partials[name] = content
+		}
+	}
+
+	return partials
+}
+
+// testNewTemplate tests the running configuration rendered with a template
+func (n *NGINXController) testNewTemplate(template *ngx_template.Template) error {
+	cfg := n.store.GetBackendConfiguration()
+	cfg.Resolver = n.resolver
+
+	tc := n.templateConfig(cfg, *n.runningConfig)
+	tc.Servers = filterSnippets(tc.Servers, func(snippet string, ing *extensions.Ingress) bool {
+		return blockedDirective(snippet, cfg.SnippetDirectivesBlocklist) == "" && !n.rejectedSnippets.has(ing, snippet)
+	})
+
+	content, err := template.Write(tc)
+	if err != nil {
+		return err
+	}
+
+	return n.testTemplate(content)
+}
+
+// templateChecksum returns the checksum of the template and
+// the partials of the data of the template ConfigMap
+func templateChecksum(data map[string]string) string {
+	names := []string{}
+	for name := range data {
+		if name == templateConfigMapKey || ngx_template.IsPartial(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha1.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%v\x00%v\x00", name, data[name])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
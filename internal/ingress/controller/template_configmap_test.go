@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+type fakeConfigMapStore struct {
+	store.Storer
+
+	configmaps map[string]*apiv1.ConfigMap
+}
+
+func (s *fakeConfigMapStore) GetConfigMap(key string) (*apiv1.ConfigMap, error) {
+	cm, ok := s.configmaps[key]
+	if !ok {
+		return nil, fmt.Errorf("configmap %v not found", key)
+	}
+	return cm, nil
+}
+
+func (s *fakeConfigMapStore) GetBackendConfiguration() ngx_config.Configuration {
+	return ngx_config.NewDefault()
+}
+
+func (s *fakeConfigMapStore) GetService(key string) (*apiv1.Service, error) {
+	return nil, fmt.Errorf("service %v not found", key)
+}
+
+func newTemplateConfigMapController(t *testing.T) (*NGINXController, *apiv1.ConfigMap) {
+	current, err := ngx_template.NewTemplateFromData([]byte("current"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm := &apiv1.ConfigMap{Data: map[string]string{}}
+	return &NGINXController{
+		cfg: &Configuration{TemplateConfigMap: "ingress/template", ListenPorts: &ngx_config.ListenPorts{}},
+		store: &fakeConfigMapStore{
+			configmaps: map[string]*apiv1.ConfigMap{"ingress/template": cm},
+		},
+		t:                current,
+		binary:           "true",
+		runningConfig:    &ingress.Configuration{},
+		rejectedSnippets: newRejectedSnippets(),
+	}, cm
+}
+
+func TestSyncTemplateConfigMap(t *testing.T) {
+	n, cm := newTemplateConfigMapController(t)
+	current := n.t
+
+	if previous := n.syncTemplateConfigMap(); previous != nil || n.t != current {
+		t.Errorf("expected the current template without the key %v", templateConfigMapKey)
+	}
+
+	cm.Data[templateConfigMapKey] = "{{ .Invalid "
+	if previous := n.syncTemplateConfigMap(); previous != nil || n.t != current {
+		t.Errorf("expected the current template with an invalid template")
+	}
+
+	cm.Data[templateConfigMapKey] = "events {}"
+	previous := n.syncTemplateConfigMap()
+	if previous != current || n.t == current {
+		t.Errorf("expected the template of the ConfigMap")
+	}
+	if !n.isForceReload() {
+		t.Errorf("expected a forced reload with the new template")
+	}
+
+	loaded := n.t
+	if previous := n.syncTemplateConfigMap(); previous != nil || n.t != loaded {
+		t.Errorf("expected the same template without changes in the ConfigMap")
+	}
+
+	// the template is tested again after the reload fails
+	n.restoreTemplate(current)
+	if previous := n.syncTemplateConfigMap(); previous != current || n.t == current {
+		t.Errorf("expected the template of the ConfigMap to be loaded again")
+	}
+}
+
+func TestSyncTemplateConfigMapInvalidConfiguration(t *testing.T) {
+	n, cm := newTemplateConfigMapController(t)
+	current := n.t
+
+	n.binary = "false"
+	cm.Data[templateConfigMapKey] = "events {}"
+	if previous := n.syncTemplateConfigMap(); previous != nil || n.t != current {
+		t.Errorf("expected the current template when the configuration is not valid")
+	}
+}
+
+func TestSyncTemplateConfigMapPartials(t *testing.T) {
+	n, cm := newTemplateConfigMapController(t)
+
+	cm.Data[templateConfigMapKey] = `stream { {{ includePartials "stream" . nil nil }} }`
+	cm.Data["stream.tmpl"] = "proxy_timeout 1h;"
+	if previous := n.syncTemplateConfigMap(); previous == nil {
+		t.Fatalf("expected the template of the ConfigMap")
+	}
+
+	content, err := n.t.Write(ngx_config.TemplateConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(content), "proxy_timeout 1h;") {
+		t.Errorf("expected the stream partial of the ConfigMap but got %s", content)
+	}
+
+	cm.Data["stream.tmpl"] = "proxy_timeout 2h;"
+	if previous := n.syncTemplateConfigMap(); previous == nil {
+		t.Errorf("expected the template to be loaded again when a partial changes")
+	}
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package controller
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/golang/glog"
@@ -66,3 +70,38 @@ func sysctlFSFileMax() int {
 	}
 	return int(rLimit.Max)
 }
+
+// streamTimeRegex matches a time in the NGINX format
+var streamTimeRegex = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+
+// parseUDPOptions parses the options of an UDP service defined in the
+// udp-services configmap (responses=<number>, timeout=<time> and reuseport)
+func parseUDPOptions(options []string, backend *ingress.L4Backend) error {
+	for _, option := range options {
+		kv := strings.SplitN(strings.TrimSpace(option), "=", 2)
+		switch strings.ToLower(kv[0]) {
+		case "responses":
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid UDP option %v", option)
+			}
+			if _, err := strconv.ParseUint(kv[1], 10, 32); err != nil {
+				return fmt.Errorf("invalid number of responses %v", kv[1])
+			}
+			backend.ProxyResponses = kv[1]
+		case "timeout":
+			if len(kv) != 2 || !streamTimeRegex.MatchString(kv[1]) {
+				return fmt.Errorf("invalid UDP option %v", option)
+			}
+			backend.ProxyTimeout = kv[1]
+		case "reuseport":
+			if len(kv) != 1 {
+				return fmt.Errorf("invalid UDP option %v", option)
+			}
+			backend.ReusePort = true
+		default:
+			return fmt.Errorf("unknown UDP option %v", option)
+		}
+	}
+
+	return nil
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package controller
 
 import (
+	"reflect"
 	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress"
 )
 
 type fakeError struct{}
@@ -39,3 +42,37 @@ func TestSysctlSomaxconn(t *testing.T) {
 		t.Errorf("returned %v but expected >= 511", i)
 	}
 }
+
+func TestParseUDPOptions(t *testing.T) {
+	tests := []struct {
+		options []string
+		backend ingress.L4Backend
+		expErr  bool
+	}{
+		{[]string{}, ingress.L4Backend{}, false},
+		{[]string{"responses=1", "timeout=5s"}, ingress.L4Backend{ProxyResponses: "1", ProxyTimeout: "5s"}, false},
+		{[]string{"responses=0", "reuseport"}, ingress.L4Backend{ProxyResponses: "0", ReusePort: true}, false},
+		{[]string{"responses=-1"}, ingress.L4Backend{}, true},
+		{[]string{"timeout=5 seconds"}, ingress.L4Backend{}, true},
+		{[]string{"reuseport=on"}, ingress.L4Backend{}, true},
+		{[]string{"PROXY"}, ingress.L4Backend{}, true},
+	}
+
+	for _, test := range tests {
+		backend := ingress.L4Backend{}
+		err := parseUDPOptions(test.options, &backend)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("expected error parsing %v but returned nil", test.options)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %v: %v", test.options, err)
+			continue
+		}
+		if !reflect.DeepEqual(backend, test.backend) {
+			t.Errorf("expected %v but returned %v", test.backend, backend)
+		}
+	}
+}
@@ -55,6 +55,14 @@ type Backend struct {
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_buffer_size)
 	ProxyBufferSize string `json:"proxy-buffer-size"`
 
+	// Sets the number of the buffers used for reading a response from the proxied server
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_buffers
+	ProxyBuffersNumber int `json:"proxy-buffers-number"`
+
+	// Enables or disables buffering of responses from the proxied server.
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_buffering
+	ProxyBuffering string `json:"proxy-buffering"`
+
 	// Sets a text that should be changed in the path attribute of the “Set-Cookie” header fields of
 	// a proxied server response.
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cookie_path
@@ -102,6 +110,23 @@ type Backend struct {
 	// This is useful if doing SSL offloading outside of cluster eg AWS ELB
 	ForceSSLRedirect bool `json:"force-ssl-redirect"`
 
+	// Enables or disables the header HSTS in servers running SSL
+	HSTS bool `json:"hsts"`
+
+	// Enables or disables the use of HSTS in all the subdomains of the servername
+	// Default: true
+	HSTSIncludeSubdomains bool `json:"hsts-include-subdomains"`
+
+	// HTTP Strict Transport Security (often abbreviated as HSTS) is a security feature (HTTP header)
+	// that tell browsers that it should only be communicated with using HTTPS, instead of using HTTP.
+	// https://developer.mozilla.org/en-US/docs/Web/Security/HTTP_strict_transport_security
+	// max-age is the time, in seconds, that the browser should remember that this site is only to be
+	// accessed using HTTPS.
+	HSTSMaxAge string `json:"hsts-max-age"`
+
+	// Enables or disables the preload attribute in HSTS feature
+	HSTSPreload bool `json:"hsts-preload"`
+
 	// Enables or disables the specification of port in redirects
 	// Default: false
 	UsePortInRedirects bool `json:"use-port-in-redirects"`
@@ -129,13 +154,18 @@ type Backend struct {
 	WhitelistSourceRange []string `json:"whitelist-source-range,-"`
 
 	// Limits the rate of response transmission to a client.
-	// The rate is specified in bytes per second. The zero value disables rate limiting.
+	// The rate is specified in kilobytes per second. The zero value disables rate limiting.
 	// The limit is set per a request, and so if a client simultaneously opens two connections,
 	// the overall rate will be twice as much as the specified limit.
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#limit_rate
 	LimitRate int `json:"limit-rate"`
 
-	// Sets the initial amount after which the further transmission of a response to a client will be rate limited.
+	// Sets the initial amount, in kilobytes, after which the further transmission of a response to a client will be rate limited.
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#limit_rate_after
 	LimitRateAfter int `json:"limit-rate-after"`
+
+	// LogFormat is the format of the access log of the locations defined by
+	// the NginxDefaults of the Ingresses. It is not read from the ConfigMap,
+	// where the format is defined with log-format-upstream.
+	LogFormat string `json:"-"`
 }
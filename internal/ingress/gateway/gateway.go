@@ -0,0 +1,418 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/apis/gateway/v1alpha2"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+const (
+	// HTTPRouteKind is the prefix of the names of the Ingresses of the HTTPRoutes
+	HTTPRouteKind = "httproute"
+	// TLSRouteKind is the prefix of the names of the Ingresses of the TLSRoutes
+	TLSRouteKind = "tlsroute"
+	// GatewayKind is the prefix of the names of the Ingresses that configure
+	// the certificates of the HTTPS listeners of the Gateways
+	GatewayKind = "gateway"
+)
+
+var (
+	// ControllerName is the controllerName of the GatewayClasses whose
+	// Gateways are managed by the ingress controller
+	ControllerName = class.ControllerName
+)
+
+// attachment is a listener of a Gateway to which a route is attached
+type attachment struct {
+	gateway  *v1alpha2.Gateway
+	listener *v1alpha2.Listener
+}
+
+// Ingresses translates the Gateway API resources into Ingresses, so they are
+// configured like any other Ingress. Only the Gateways of the GatewayClasses
+// of the ingress controller are used. The names of the Ingresses contain the
+// kind of the resource (e.g. httproute:name) to avoid conflicts with the
+// names of real Ingresses.
+func Ingresses(classes []*v1alpha2.GatewayClass, gateways []*v1alpha2.Gateway,
+	httpRoutes []*v1alpha2.HTTPRoute, tlsRoutes []*v1alpha2.TLSRoute) []*extensions.Ingress {
+
+	managed := sets.NewString()
+	for _, gc := range classes {
+		if gc.Spec.ControllerName == ControllerName {
+			managed.Insert(gc.Name)
+		}
+	}
+
+	gws := make(map[string]*v1alpha2.Gateway)
+	for _, gw := range gateways {
+		if !managed.Has(gw.Spec.GatewayClassName) {
+			glog.V(3).Infof("ignoring gateway %v/%v of class %v", gw.Namespace, gw.Name, gw.Spec.GatewayClassName)
+			continue
+		}
+		gws[fmt.Sprintf("%v/%v", gw.Namespace, gw.Name)] = gw
+	}
+
+	var ingresses []*extensions.Ingress
+
+	// hosts of the HTTPS listeners (namespace/gateway/listener)
+	tlsHosts := make(map[string]sets.String)
+
+	for _, route := range httpRoutes {
+		hosts := sets.NewString()
+		for _, a := range attachments(route.Namespace, route.Spec.ParentRefs, gws, isHTTPListener) {
+			h := hostnames(a.listener.Hostname, route.Spec.Hostnames)
+			hosts.Insert(h...)
+
+			if a.listener.Protocol == v1alpha2.HTTPSProtocolType {
+				key := fmt.Sprintf("%v/%v/%v", a.gateway.Namespace, a.gateway.Name, a.listener.Name)
+				if _, ok := tlsHosts[key]; !ok {
+					tlsHosts[key] = sets.NewString()
+				}
+				tlsHosts[key].Insert(h...)
+			}
+		}
+
+		if hosts.Len() == 0 {
+			glog.V(3).Infof("httproute %v/%v is not attached to any gateway", route.Namespace, route.Name)
+			continue
+		}
+
+		paths := httpPaths(route)
+		if len(paths) == 0 {
+			continue
+		}
+
+		ing := newIngress(HTTPRouteKind, route.ObjectMeta)
+		for _, host := range hosts.List() {
+			ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{
+				Host: host,
+				IngressRuleValue: extensions.IngressRuleValue{
+					HTTP: &extensions.HTTPIngressRuleValue{
+						Paths: paths,
+					},
+				},
+			})
+		}
+
+		ingresses = append(ingresses, ing)
+	}
+
+	for _, route := range tlsRoutes {
+		hosts := sets.NewString()
+		for _, a := range attachments(route.Namespace, route.Spec.ParentRefs, gws, isPassthroughListener) {
+			hosts.Insert(hostnames(a.listener.Hostname, route.Spec.Hostnames)...)
+		}
+		// the passthrough of the connections requires the server name
+		hosts.Delete("")
+
+		if hosts.Len() == 0 {
+			glog.V(3).Infof("tlsroute %v/%v is not attached to any gateway", route.Namespace, route.Name)
+			continue
+		}
+
+		var backend *extensions.IngressBackend
+		for _, rule := range route.Spec.Rules {
+			backend = ingressBackend(route.Namespace, route.Name, rule.BackendRefs)
+			if backend != nil {
+				break
+			}
+		}
+
+		if backend == nil {
+			glog.Warningf("tlsroute %v/%v does not contain a backend of its namespace", route.Namespace, route.Name)
+			continue
+		}
+
+		ing := newIngress(TLSRouteKind, route.ObjectMeta)
+		ing.Annotations[parser.GetAnnotationWithPrefix("ssl-passthrough")] = "true"
+		for _, host := range hosts.List() {
+			ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{
+				Host: host,
+				IngressRuleValue: extensions.IngressRuleValue{
+					HTTP: &extensions.HTTPIngressRuleValue{
+						Paths: []extensions.HTTPIngressPath{
+							{
+								Path:    "/",
+								Backend: *backend,
+							},
+						},
+					},
+				},
+			})
+		}
+
+		ingresses = append(ingresses, ing)
+	}
+
+	for _, gw := range gws {
+		for i := range gw.Spec.Listeners {
+			l := &gw.Spec.Listeners[i]
+			hosts := tlsHosts[fmt.Sprintf("%v/%v/%v", gw.Namespace, gw.Name, l.Name)]
+			if hosts == nil {
+				continue
+			}
+			// the certificate of the catch-all server is the default certificate
+			hosts.Delete("")
+
+			if hosts.Len() == 0 || l.TLS == nil || len(l.TLS.CertificateRefs) == 0 {
+				continue
+			}
+
+			ref := l.TLS.CertificateRefs[0]
+			secret := ref.Name
+			if ref.Namespace != "" && ref.Namespace != gw.Namespace {
+				secret = fmt.Sprintf("%v/%v", ref.Namespace, ref.Name)
+			}
+
+			ing := newIngress(GatewayKind, gw.ObjectMeta)
+			ing.Name = fmt.Sprintf("%v-%v", ing.Name, l.Name)
+			ing.Spec.TLS = []extensions.IngressTLS{
+				{
+					Hosts:      hosts.List(),
+					SecretName: secret,
+				},
+			}
+			for _, host := range hosts.List() {
+				ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{
+					Host: host,
+				})
+			}
+
+			ingresses = append(ingresses, ing)
+		}
+	}
+
+	sort.SliceStable(ingresses, func(i, j int) bool {
+		if ingresses[i].Namespace != ingresses[j].Namespace {
+			return ingresses[i].Namespace < ingresses[j].Namespace
+		}
+		return ingresses[i].Name < ingresses[j].Name
+	})
+
+	return ingresses
+}
+
+// newIngress returns an empty Ingress with the namespace, annotations and
+// creation timestamp of a Gateway API resource
+func newIngress(kind string, meta metav1.ObjectMeta) *extensions.Ingress {
+	annotations := make(map[string]string)
+	for k, v := range meta.Annotations {
+		annotations[k] = v
+	}
+
+	return &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         meta.Namespace,
+			Name:              fmt.Sprintf("%v:%v", kind, meta.Name),
+			Annotations:       annotations,
+			CreationTimestamp: meta.CreationTimestamp,
+			ResourceVersion:   meta.ResourceVersion,
+		},
+	}
+}
+
+// attachments returns the listeners of the Gateways to which a route of
+// a namespace is attached
+func attachments(namespace string, refs []v1alpha2.ParentReference,
+	gateways map[string]*v1alpha2.Gateway, accepts func(*v1alpha2.Listener) bool) []attachment {
+
+	var as []attachment
+	for _, ref := range refs {
+		ns := ref.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+
+		gw, ok := gateways[fmt.Sprintf("%v/%v", ns, ref.Name)]
+		if !ok {
+			continue
+		}
+
+		for i := range gw.Spec.Listeners {
+			l := &gw.Spec.Listeners[i]
+			if ref.SectionName != "" && ref.SectionName != l.Name {
+				continue
+			}
+
+			if !accepts(l) {
+				continue
+			}
+
+			if !allowsRoutes(gw, l, namespace) {
+				glog.V(3).Infof("listener %v of gateway %v/%v does not allow the routes of namespace %v", l.Name, gw.Namespace, gw.Name, namespace)
+				continue
+			}
+
+			as = append(as, attachment{gateway: gw, listener: l})
+		}
+	}
+
+	return as
+}
+
+// allowsRoutes returns true if the routes of a namespace can be attached
+// to a listener of a Gateway
+func allowsRoutes(gw *v1alpha2.Gateway, l *v1alpha2.Listener, namespace string) bool {
+	from := v1alpha2.NamespacesFromSame
+	if l.AllowedRoutes != nil && l.AllowedRoutes.Namespaces != nil && l.AllowedRoutes.Namespaces.From != "" {
+		from = l.AllowedRoutes.Namespaces.From
+	}
+
+	switch from {
+	case v1alpha2.NamespacesFromAll:
+		return true
+	case v1alpha2.NamespacesFromSame:
+		return gw.Namespace == namespace
+	default:
+		glog.Warningf("listener %v of gateway %v/%v uses an unsupported namespace selection %v", l.Name, gw.Namespace, gw.Name, from)
+		return false
+	}
+}
+
+// isHTTPListener returns true if the HTTPRoutes can be attached to a listener
+func isHTTPListener(l *v1alpha2.Listener) bool {
+	switch l.Protocol {
+	case v1alpha2.HTTPProtocolType:
+		return true
+	case v1alpha2.HTTPSProtocolType:
+		return l.TLS == nil || l.TLS.Mode != v1alpha2.TLSModePassthrough
+	default:
+		return false
+	}
+}
+
+// isPassthroughListener returns true if the TLSRoutes can be attached to a listener
+func isPassthroughListener(l *v1alpha2.Listener) bool {
+	return l.Protocol == v1alpha2.TLSProtocolType &&
+		l.TLS != nil && l.TLS.Mode == v1alpha2.TLSModePassthrough
+}
+
+// hostnames returns the hostnames of a route attached to a listener, which
+// are the hostnames of the route that match the hostname of the listener.
+// An empty hostname matches any host.
+func hostnames(listener string, route []string) []string {
+	if len(route) == 0 {
+		return []string{listener}
+	}
+
+	if listener == "" {
+		return route
+	}
+
+	hosts := sets.NewString()
+	for _, host := range route {
+		if matchesHost(listener, host) {
+			hosts.Insert(host)
+		} else if matchesHost(host, listener) {
+			hosts.Insert(listener)
+		}
+	}
+
+	return hosts.List()
+}
+
+// matchesHost returns true if a host matches a hostname, which can be
+// a wildcard hostname (e.g. *.example.com)
+func matchesHost(hostname, host string) bool {
+	if hostname == host {
+		return true
+	}
+
+	if !strings.HasPrefix(hostname, "*.") {
+		return false
+	}
+
+	suffix := hostname[1:]
+	return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+}
+
+// httpPaths returns the paths of the rules of an HTTPRoute
+func httpPaths(route *v1alpha2.HTTPRoute) []extensions.HTTPIngressPath {
+	var paths []extensions.HTTPIngressPath
+	for _, rule := range route.Spec.Rules {
+		refs := make([]v1alpha2.BackendRef, 0, len(rule.BackendRefs))
+		for _, ref := range rule.BackendRefs {
+			refs = append(refs, ref.BackendRef)
+		}
+
+		backend := ingressBackend(route.Namespace, route.Name, refs)
+		if backend == nil {
+			glog.Warningf("rule of httproute %v/%v does not contain a backend of its namespace", route.Namespace, route.Name)
+			continue
+		}
+
+		matches := rule.Matches
+		if len(matches) == 0 {
+			matches = []v1alpha2.HTTPRouteMatch{{}}
+		}
+
+		for _, match := range matches {
+			path := "/"
+			if match.Path != nil {
+				if match.Path.Type != "" && match.Path.Type != v1alpha2.PathMatchPathPrefix {
+					glog.Warningf("ignoring path %v of httproute %v/%v: unsupported match type %v", match.Path.Value, route.Namespace, route.Name, match.Path.Type)
+					continue
+				}
+				if match.Path.Value != "" {
+					path = match.Path.Value
+				}
+			}
+
+			paths = append(paths, extensions.HTTPIngressPath{
+				Path:    path,
+				Backend: *backend,
+			})
+		}
+	}
+
+	return paths
+}
+
+// ingressBackend returns the first backend of a route that references a
+// Service of the namespace of the route. The weights and the references to
+// other namespaces are not supported.
+func ingressBackend(namespace, name string, refs []v1alpha2.BackendRef) *extensions.IngressBackend {
+	for _, ref := range refs {
+		if ref.Namespace != "" && ref.Namespace != namespace {
+			glog.Warningf("ignoring backend %v/%v of route %v/%v of another namespace", ref.Namespace, ref.Name, namespace, name)
+			continue
+		}
+
+		if len(refs) > 1 {
+			glog.V(3).Infof("route %v/%v contains several backends, using %v", namespace, name, ref.Name)
+		}
+
+		return &extensions.IngressBackend{
+			ServiceName: ref.Name,
+			ServicePort: intstr.FromInt(int(ref.Port)),
+		}
+	}
+
+	return nil
+}
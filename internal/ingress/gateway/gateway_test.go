@@ -0,0 +1,241 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/apis/gateway/v1alpha2"
+)
+
+func buildGateways() ([]*v1alpha2.GatewayClass, []*v1alpha2.Gateway) {
+	classes := []*v1alpha2.GatewayClass{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+			Spec:       v1alpha2.GatewayClassSpec{ControllerName: ControllerName},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec:       v1alpha2.GatewayClassSpec{ControllerName: "example.com/other"},
+		},
+	}
+
+	gateways := []*v1alpha2.Gateway{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "gw"},
+			Spec: v1alpha2.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []v1alpha2.Listener{
+					{
+						Name:     "http",
+						Port:     80,
+						Protocol: v1alpha2.HTTPProtocolType,
+						AllowedRoutes: &v1alpha2.AllowedRoutes{
+							Namespaces: &v1alpha2.RouteNamespaces{From: v1alpha2.NamespacesFromAll},
+						},
+					},
+					{
+						Name:     "https",
+						Hostname: "*.example.com",
+						Port:     443,
+						Protocol: v1alpha2.HTTPSProtocolType,
+						TLS: &v1alpha2.GatewayTLSConfig{
+							CertificateRefs: []v1alpha2.SecretObjectReference{{Name: "wildcard"}},
+						},
+						AllowedRoutes: &v1alpha2.AllowedRoutes{
+							Namespaces: &v1alpha2.RouteNamespaces{From: v1alpha2.NamespacesFromAll},
+						},
+					},
+					{
+						Name:     "passthrough",
+						Port:     443,
+						Protocol: v1alpha2.TLSProtocolType,
+						TLS:      &v1alpha2.GatewayTLSConfig{Mode: v1alpha2.TLSModePassthrough},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "other"},
+			Spec: v1alpha2.GatewaySpec{
+				GatewayClassName: "other",
+				Listeners: []v1alpha2.Listener{
+					{Name: "http", Port: 80, Protocol: v1alpha2.HTTPProtocolType},
+				},
+			},
+		},
+	}
+
+	return classes, gateways
+}
+
+func TestIngressesOfHTTPRoutes(t *testing.T) {
+	classes, gateways := buildGateways()
+
+	routes := []*v1alpha2.HTTPRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "app",
+				Name:        "web",
+				Annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+			},
+			Spec: v1alpha2.HTTPRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Namespace: "infra", Name: "gw"}},
+				Hostnames:  []string{"web.example.com", "web.example.org"},
+				Rules: []v1alpha2.HTTPRouteRule{
+					{
+						Matches: []v1alpha2.HTTPRouteMatch{
+							{Path: &v1alpha2.HTTPPathMatch{Type: v1alpha2.PathMatchPathPrefix, Value: "/api"}},
+							{Path: &v1alpha2.HTTPPathMatch{Type: v1alpha2.PathMatchExact, Value: "/exact"}},
+						},
+						BackendRefs: []v1alpha2.HTTPBackendRef{
+							{BackendRef: v1alpha2.BackendRef{Namespace: "infra", Name: "other", Port: 80}},
+							{BackendRef: v1alpha2.BackendRef{Name: "api", Port: 8080}},
+						},
+					},
+					{
+						BackendRefs: []v1alpha2.HTTPBackendRef{
+							{BackendRef: v1alpha2.BackendRef{Name: "web", Port: 80}},
+						},
+					},
+				},
+			},
+		},
+		{
+			// gateway of a class of another controller
+			ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "ignored"},
+			Spec: v1alpha2.HTTPRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Name: "other"}},
+				Rules: []v1alpha2.HTTPRouteRule{
+					{BackendRefs: []v1alpha2.HTTPBackendRef{{BackendRef: v1alpha2.BackendRef{Name: "web", Port: 80}}}},
+				},
+			},
+		},
+	}
+
+	ings := Ingresses(classes, gateways, routes, nil)
+	if len(ings) != 2 {
+		t.Fatalf("expected 2 ingresses but %v returned", len(ings))
+	}
+
+	ing := ings[0]
+	if ing.Namespace != "app" || ing.Name != "httproute:web" {
+		t.Errorf("unexpected ingress %v/%v", ing.Namespace, ing.Name)
+	}
+	if ing.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] != "/" {
+		t.Errorf("expected the annotations of the route but %v returned", ing.Annotations)
+	}
+
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+
+		var paths []string
+		for _, path := range rule.HTTP.Paths {
+			paths = append(paths, path.Path+" "+path.Backend.ServiceName+":"+path.Backend.ServicePort.String())
+		}
+		expected := []string{"/api api:8080", "/ web:80"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("expected paths %v of host %v but %v returned", expected, rule.Host, paths)
+		}
+	}
+
+	// the http listener accepts both hosts, the https listener only the first one
+	expected := []string{"web.example.com", "web.example.org"}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected hosts %v but %v returned", expected, hosts)
+	}
+
+	tls := ings[1]
+	if tls.Namespace != "infra" || tls.Name != "gateway:gw-https" {
+		t.Fatalf("unexpected ingress %v/%v", tls.Namespace, tls.Name)
+	}
+	if len(tls.Spec.TLS) != 1 || tls.Spec.TLS[0].SecretName != "wildcard" ||
+		!reflect.DeepEqual(tls.Spec.TLS[0].Hosts, []string{"web.example.com"}) {
+		t.Errorf("unexpected tls section %v", tls.Spec.TLS)
+	}
+}
+
+func TestIngressesOfTLSRoutes(t *testing.T) {
+	classes, gateways := buildGateways()
+
+	routes := []*v1alpha2.TLSRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "db"},
+			Spec: v1alpha2.TLSRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Name: "gw", SectionName: "passthrough"}},
+				Hostnames:  []string{"db.example.com"},
+				Rules: []v1alpha2.TLSRouteRule{
+					{BackendRefs: []v1alpha2.BackendRef{{Name: "db", Port: 5432}}},
+				},
+			},
+		},
+		{
+			// the listener only allows the routes of the namespace of the gateway
+			ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "db"},
+			Spec: v1alpha2.TLSRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Namespace: "infra", Name: "gw"}},
+				Hostnames:  []string{"app.example.com"},
+				Rules: []v1alpha2.TLSRouteRule{
+					{BackendRefs: []v1alpha2.BackendRef{{Name: "db", Port: 5432}}},
+				},
+			},
+		},
+	}
+
+	ings := Ingresses(classes, gateways, nil, routes)
+	if len(ings) != 1 {
+		t.Fatalf("expected 1 ingress but %v returned", len(ings))
+	}
+
+	ing := ings[0]
+	if ing.Namespace != "infra" || ing.Name != "tlsroute:db" {
+		t.Errorf("unexpected ingress %v/%v", ing.Namespace, ing.Name)
+	}
+	if ing.Annotations["nginx.ingress.kubernetes.io/ssl-passthrough"] != "true" {
+		t.Errorf("expected the ssl-passthrough annotation but %v returned", ing.Annotations)
+	}
+	if len(ing.Spec.Rules) != 1 || ing.Spec.Rules[0].Host != "db.example.com" ||
+		ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName != "db" {
+		t.Errorf("unexpected rules %v", ing.Spec.Rules)
+	}
+}
+
+func TestHostnames(t *testing.T) {
+	testCases := []struct {
+		listener string
+		route    []string
+		expected []string
+	}{
+		{"", nil, []string{""}},
+		{"", []string{"foo.bar"}, []string{"foo.bar"}},
+		{"foo.bar", nil, []string{"foo.bar"}},
+		{"*.bar", []string{"foo.bar", "bar", "foo.baz"}, []string{"foo.bar"}},
+		{"foo.bar", []string{"*.bar"}, []string{"foo.bar"}},
+		{"foo.bar", []string{"foo.baz"}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		hosts := hostnames(tc.listener, tc.route)
+		if !reflect.DeepEqual(hosts, tc.expected) {
+			t.Errorf("expected hostnames %v of listener %v and route %v but %v returned", tc.expected, tc.listener, tc.route, hosts)
+		}
+	}
+}
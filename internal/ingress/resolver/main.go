@@ -37,6 +37,13 @@ type Resolver interface {
 
 	// GetService searches for services contenating the namespace and name using a the character /
 	GetService(string) (*apiv1.Service, error)
+
+	// GetConfigMap searches for configmaps contenating the namespace and name using a the character /
+	GetConfigMap(string) (*apiv1.ConfigMap, error)
+
+	// CanReferenceSecret returns true if the Ingresses of a namespace can
+	// reference a Secret (namespace/name) of another namespace
+	CanReferenceSecret(namespace, secret string) bool
 }
 
 // AuthSSLCert contains the necessary information to do certificate based
@@ -48,6 +55,13 @@ type AuthSSLCert struct {
 	CAFileName string `json:"caFilename"`
 	// PemSHA contains the SHA1 hash of the 'ca.crt' or combinations of (tls.crt, tls.key, tls.crt) depending on certs in secret
 	PemSHA string `json:"pemSha"`
+	// CRLFileName contains the path to the secrets 'ca.crl'
+	CRLFileName string `json:"crlFilename"`
+	// CRLSHA contains the SHA1 hash of the 'ca.crl'
+	CRLSHA string `json:"crlSha"`
+	// PemFileName contains the path to the file with the 'tls.crt' and
+	// 'tls.key' of the secret, empty if the secret only contains a CA
+	PemFileName string `json:"pemFilename"`
 }
 
 // Equal tests for equality between two AuthSSLCert types
@@ -61,6 +75,15 @@ func (asslc1 *AuthSSLCert) Equal(assl2 *AuthSSLCert) bool {
 	if asslc1.PemSHA != assl2.PemSHA {
 		return false
 	}
+	if asslc1.CRLFileName != assl2.CRLFileName {
+		return false
+	}
+	if asslc1.CRLSHA != assl2.CRLSHA {
+		return false
+	}
+	if asslc1.PemFileName != assl2.PemFileName {
+		return false
+	}
 
 	return true
 }
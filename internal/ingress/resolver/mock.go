@@ -47,3 +47,14 @@ func (m Mock) GetAuthCertificate(string) (*AuthSSLCert, error) {
 func (m Mock) GetService(string) (*apiv1.Service, error) {
 	return nil, nil
 }
+
+// GetConfigMap searches for configmaps contenating the namespace and name using a the character /
+func (m Mock) GetConfigMap(string) (*apiv1.ConfigMap, error) {
+	return nil, nil
+}
+
+// CanReferenceSecret returns true if the Ingresses of a namespace can
+// reference a Secret (namespace/name) of another namespace
+func (m Mock) CanReferenceSecret(namespace, secret string) bool {
+	return true
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"hash/fnv"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+const (
+	// NamespaceKey assigns the Ingresses to the shards using their namespace
+	NamespaceKey = "namespace"
+	// HostKey assigns the rules of the Ingresses to the shards using their host
+	HostKey = "host"
+)
+
+var (
+	// Count is the number of shards. Sharding is disabled with a single shard
+	Count = 1
+
+	// Index is the shard of the ingress controller, from 0 to Count-1
+	Index = 0
+
+	// Key is the value used to assign the Ingresses to the shards
+	Key = NamespaceKey
+)
+
+// Of returns the shard of a namespace or host
+func Of(value string) int {
+	h := fnv.New32a()
+	h.Write([]byte(value))
+	return int(h.Sum32() % uint32(Count))
+}
+
+// IsValid returns true if the given Ingress, or any of its rules if
+// the hosts are used as key, belongs to the shard of the ingress controller.
+func IsValid(ing *extensions.Ingress) bool {
+	return Filter(ing) != nil
+}
+
+// Filter returns the given Ingress restricted to the shard of the ingress
+// controller, or nil if the Ingress does not belong to the shard. If the hosts
+// are used as key the Ingress is copied and only contains the rules and TLS
+// hosts of the shard. The rules without host configure the catch-all server
+// and are kept in every shard.
+func Filter(ing *extensions.Ingress) *extensions.Ingress {
+	if Count <= 1 {
+		return ing
+	}
+
+	if Key != HostKey {
+		if Of(ing.Namespace) == Index {
+			return ing
+		}
+		return nil
+	}
+
+	var rules []extensions.IngressRule
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" || Of(rule.Host) == Index {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) == len(ing.Spec.Rules) {
+		return ing
+	}
+	if len(rules) == 0 && ing.Spec.Backend == nil {
+		return nil
+	}
+
+	filtered := ing.DeepCopy()
+	filtered.Spec.Rules = rules
+	filtered.Spec.TLS = nil
+	for _, tls := range ing.Spec.TLS {
+		var hosts []string
+		for _, host := range tls.Hosts {
+			if Of(host) == Index {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(tls.Hosts) > 0 && len(hosts) == 0 {
+			continue
+		}
+		filtered.Spec.TLS = append(filtered.Spec.TLS, extensions.IngressTLS{
+			Hosts:      hosts,
+			SecretName: tls.SecretName,
+		})
+	}
+
+	return filtered
+}
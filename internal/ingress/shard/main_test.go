@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	extensions "k8s.io/api/extensions/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hostOfShard returns a host assigned to the given shard
+func hostOfShard(index int) string {
+	for i := 0; ; i++ {
+		host := fmt.Sprintf("host%v.example.com", i)
+		if Of(host) == index {
+			return host
+		}
+	}
+}
+
+// namespaceOfShard returns a namespace assigned to the given shard
+func namespaceOfShard(index int) string {
+	for i := 0; ; i++ {
+		ns := fmt.Sprintf("namespace%v", i)
+		if Of(ns) == index {
+			return ns
+		}
+	}
+}
+
+func TestFilterByNamespace(t *testing.T) {
+	c, i, k := Count, Index, Key
+	// restore original values after the tests
+	defer func() {
+		Count, Index, Key = c, i, k
+	}()
+
+	ing := &extensions.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "foo"}}
+	if Filter(ing) != ing {
+		t.Errorf("expected the ingress to be valid without sharding")
+	}
+
+	Count, Index, Key = 3, 1, NamespaceKey
+
+	ing.Namespace = namespaceOfShard(1)
+	if Filter(ing) != ing {
+		t.Errorf("expected the ingress of namespace %v to be valid in shard 1", ing.Namespace)
+	}
+
+	ing.Namespace = namespaceOfShard(2)
+	if IsValid(ing) {
+		t.Errorf("expected the ingress of namespace %v to be invalid in shard 1", ing.Namespace)
+	}
+}
+
+func TestFilterByHost(t *testing.T) {
+	c, i, k := Count, Index, Key
+	// restore original values after the tests
+	defer func() {
+		Count, Index, Key = c, i, k
+	}()
+
+	Count, Index, Key = 2, 0, HostKey
+	local, remote := hostOfShard(0), hostOfShard(1)
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: extensions.IngressSpec{
+			Rules: []extensions.IngressRule{{Host: local}, {Host: remote}, {Host: ""}},
+			TLS: []extensions.IngressTLS{
+				{Hosts: []string{local, remote}, SecretName: "both"},
+				{Hosts: []string{remote}, SecretName: "remote"},
+			},
+		},
+	}
+
+	filtered := Filter(ing)
+	if filtered == nil || filtered == ing {
+		t.Fatalf("expected a copy of the ingress")
+	}
+	if len(filtered.Spec.Rules) != 2 || filtered.Spec.Rules[0].Host != local || filtered.Spec.Rules[1].Host != "" {
+		t.Errorf("unexpected rules %+v", filtered.Spec.Rules)
+	}
+	if len(filtered.Spec.TLS) != 1 || filtered.Spec.TLS[0].SecretName != "both" || len(filtered.Spec.TLS[0].Hosts) != 1 {
+		t.Errorf("unexpected TLS sections %+v", filtered.Spec.TLS)
+	}
+	if len(ing.Spec.Rules) != 3 {
+		t.Errorf("the original ingress must not be modified")
+	}
+
+	remoteIng := &extensions.Ingress{
+		Spec: extensions.IngressSpec{
+			Rules: []extensions.IngressRule{{Host: remote}},
+		},
+	}
+	if IsValid(remoteIng) {
+		t.Errorf("expected the ingress with host %v to be invalid in shard 0", remote)
+	}
+}
@@ -38,6 +38,11 @@ type SSLCert struct {
 	// PemSHA contains the sha1 of the pem file.
 	// This is used to detect changes in the secret that contains the certificates
 	PemSHA string `json:"pemSha"`
+	// CRLFileName contains the path to the file with the certificate revocation list
+	CRLFileName string `json:"crlFileName"`
+	// CRLSHA contains the sha1 of the certificate revocation list file.
+	// This is used to detect changes in the secret that contains the CRL
+	CRLSHA string `json:"crlSha"`
 	// CN contains all the common names defined in the SSL certificate
 	CN []string `json:"cn"`
 	// ExpiresTime contains the expiration of this SSL certificate in timestamp format
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prometheus.MustRegister(leaderElection)
+}
+
+var leaderElection = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "ingress_controller",
+		Name:      "leader_election_status",
+		Help:      "Whether this instance is the leader (1) of the status updates or not (0)",
+	},
+	[]string{"name"},
+)
+
+func setLeaderMetric(electionID string, leading bool) {
+	if leading {
+		leaderElection.WithLabelValues(electionID).Set(1)
+		return
+	}
+	leaderElection.WithLabelValues(electionID).Set(0)
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
@@ -40,18 +41,34 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/kubelet/util/sliceutils"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/task"
 )
 
 const (
 	updateInterval = 60 * time.Second
+
+	// skipStatusUpdateAnnotation is the annotation used to
+	// opt out of the update of the status of an Ingress
+	skipStatusUpdateAnnotation = "skip-status-update"
 )
 
 // Sync ...
 type Sync interface {
 	Run()
 	Shutdown()
+	// Election returns the state of the leader election of the status updates
+	Election() ElectionState
+}
+
+// ElectionState describes the leader election of the status updates. Only the
+// leader updates the status of the Ingresses, every instance of the ingress
+// controller configures NGINX independently of the election.
+type ElectionState struct {
+	ElectionID string `json:"electionID"`
+	Leader     string `json:"leader"`
+	IsLeader   bool   `json:"isLeader"`
 }
 
 type ingressLister interface {
@@ -63,7 +80,13 @@ type ingressLister interface {
 type Config struct {
 	Client clientset.Interface
 
+	// PublishService contains the comma-separated list of services
+	// with the addresses used in the status of the Ingresses
 	PublishService string
+	// PublishStatusAddress contains the comma-separated list of IP addresses
+	// or hostnames used in the status instead of the addresses of the services
+	// or nodes
+	PublishStatusAddress string
 
 	ElectionID string
 
@@ -88,14 +111,61 @@ type statusSync struct {
 	pod *k8s.PodInfo
 
 	elector *leaderelection.LeaderElector
+	// electionID is the name of the ConfigMap used as lock of the election
+	electionID string
+	// leading is 1 while this instance is the leader
+	leading *int32
+	// leader contains the identity of the current leader
+	leader *atomic.Value
 	// workqueue used to keep in sync the status IP/s
 	// in the Ingress rules
 	syncQueue *task.Queue
 }
 
-// Run starts the loop to keep the status in sync
+// Run starts the loop to keep the status in sync. The leader election
+// starts again when the leadership is lost, until the sync is shut down
 func (s statusSync) Run() {
-	s.elector.Run()
+	go s.syncQueue.Run(time.Second, wait.NeverStop)
+
+	for {
+		s.elector.Run()
+		if s.syncQueue.IsShuttingDown() {
+			return
+		}
+		glog.Infof("restarting leader election of the status updates")
+	}
+}
+
+// Election returns the state of the leader election of the status updates
+func (s statusSync) Election() ElectionState {
+	return ElectionState{
+		ElectionID: s.electionID,
+		Leader:     s.currentLeader(),
+		IsLeader:   s.isLeader(),
+	}
+}
+
+func (s statusSync) isLeader() bool {
+	return s.leading != nil && atomic.LoadInt32(s.leading) == 1
+}
+
+// currentLeader returns the identity of the last leader elected. The state
+// of the elector cannot be read while the election is running
+func (s statusSync) currentLeader() string {
+	if s.leader == nil {
+		return ""
+	}
+	leader, _ := s.leader.Load().(string)
+	return leader
+}
+
+func (s statusSync) setLeader(leading bool) {
+	var v int32
+	if leading {
+		v = 1
+	}
+	atomic.StoreInt32(s.leading, v)
+	setLeaderMetric(s.electionID, leading)
 }
 
 // Shutdown stop the sync. In case the instance is the leader it will remove the current IP
@@ -103,7 +173,7 @@ func (s statusSync) Run() {
 func (s statusSync) Shutdown() {
 	go s.syncQueue.Shutdown()
 	// remove IP from Ingress
-	if !s.elector.IsLeader() {
+	if !s.isLeader() {
 		return
 	}
 
@@ -164,7 +234,9 @@ func NewStatusSyncer(config Config) Sync {
 	st := statusSync{
 		pod: pod,
 
-		Config: config,
+		Config:  config,
+		leading: new(int32),
+		leader:  &atomic.Value{},
 	}
 	st.syncQueue = task.NewCustomTaskQueue(st.sync, st.keyfunc)
 
@@ -174,11 +246,13 @@ func NewStatusSyncer(config Config) Sync {
 	if config.IngressClass != "" {
 		electionID = fmt.Sprintf("%v-%v", config.ElectionID, config.IngressClass)
 	}
+	st.electionID = electionID
+	setLeaderMetric(electionID, false)
 
 	callbacks := leaderelection.LeaderCallbacks{
 		OnStartedLeading: func(stop <-chan struct{}) {
 			glog.V(2).Infof("I am the new status update leader")
-			go st.syncQueue.Run(time.Second, stop)
+			st.setLeader(true)
 			wait.PollUntil(updateInterval, func() (bool, error) {
 				// send a dummy object to the queue to force a sync
 				st.syncQueue.Enqueue("sync status")
@@ -187,9 +261,11 @@ func NewStatusSyncer(config Config) Sync {
 		},
 		OnStoppedLeading: func() {
 			glog.V(2).Infof("I am not status update leader anymore")
+			st.setLeader(false)
 		},
 		OnNewLeader: func(identity string) {
 			glog.Infof("new leader elected: %v", identity)
+			st.leader.Store(identity)
 		},
 	}
 
@@ -232,22 +308,29 @@ func NewStatusSyncer(config Config) Sync {
 func (s *statusSync) runningAddresses() ([]string, error) {
 	addrs := []string{}
 
-	if s.PublishService != "" {
-		ns, name, _ := k8s.ParseNameNS(s.PublishService)
-		svc, err := s.Client.CoreV1().Services(ns).Get(name, metav1.GetOptions{})
-		if err != nil {
-			return nil, err
+	if s.PublishStatusAddress != "" {
+		for _, addr := range splitList(s.PublishStatusAddress) {
+			if !sliceutils.StringInSlice(addr, addrs) {
+				addrs = append(addrs, addr)
+			}
 		}
+		return addrs, nil
+	}
 
-		for _, ip := range svc.Status.LoadBalancer.Ingress {
-			if ip.IP == "" {
-				addrs = append(addrs, ip.Hostname)
-			} else {
-				addrs = append(addrs, ip.IP)
+	if s.PublishService != "" {
+		for _, key := range splitList(s.PublishService) {
+			ns, name, _ := k8s.ParseNameNS(key)
+			svc, err := s.Client.CoreV1().Services(ns).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
 			}
-		}
 
-		addrs = append(addrs, svc.Spec.ExternalIPs...)
+			for _, addr := range serviceAddresses(svc) {
+				if !sliceutils.StringInSlice(addr, addrs) {
+					addrs = append(addrs, addr)
+				}
+			}
+		}
 		return addrs, nil
 	}
 
@@ -269,6 +352,39 @@ func (s *statusSync) runningAddresses() ([]string, error) {
 	return addrs, nil
 }
 
+// serviceAddresses returns the IP addresses and hostnames of the load
+// balancer and the external IPs of a service, or the external name
+// of a service of type ExternalName
+func serviceAddresses(svc *apiv1.Service) []string {
+	if svc.Spec.Type == apiv1.ServiceTypeExternalName {
+		return []string{svc.Spec.ExternalName}
+	}
+
+	addrs := []string{}
+	for _, ip := range svc.Status.LoadBalancer.Ingress {
+		if ip.IP == "" {
+			addrs = append(addrs, ip.Hostname)
+		} else {
+			addrs = append(addrs, ip.IP)
+		}
+	}
+
+	return append(addrs, svc.Spec.ExternalIPs...)
+}
+
+// splitList returns the non empty values of a comma-separated list
+func splitList(list string) []string {
+	values := []string{}
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
 func (s *statusSync) isRunningMultiplePods() bool {
 	pods, err := s.Client.CoreV1().Pods(s.pod.Namespace).List(metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(s.pod.Labels).String(),
@@ -308,13 +424,25 @@ func (s *statusSync) updateStatus(newIngressPoint []apiv1.LoadBalancerIngress) {
 	batch := p.Batch()
 
 	for _, ing := range ings {
-		batch.Queue(runUpdate(ing, newIngressPoint, s.Client))
+		status := newIngressPoint
+		if skipStatusUpdate(ing) {
+			// remove the addresses published before the opt-out
+			status = []apiv1.LoadBalancerIngress{}
+		}
+		batch.Queue(runUpdate(ing, status, s.Client))
 	}
 
 	batch.QueueComplete()
 	batch.WaitAll()
 }
 
+// skipStatusUpdate returns true if the addresses of the ingress
+// controller must not be published in the status of the Ingress
+func skipStatusUpdate(ing *extensions.Ingress) bool {
+	skip, _ := parser.GetBoolAnnotation(skipStatusUpdateAnnotation, ing)
+	return skip
+}
+
 func runUpdate(ing *extensions.Ingress, status []apiv1.LoadBalancerIngress,
 	client clientset.Interface) pool.WorkFunc {
 	return func(wu pool.WorkUnit) (interface{}, error) {
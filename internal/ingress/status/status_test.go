@@ -27,6 +27,7 @@ import (
 	testclient "k8s.io/client-go/kubernetes/fake"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/task"
 )
@@ -107,6 +108,16 @@ func buildSimpleClientSet() *testclient.Clientset {
 					Namespace: apiv1.NamespaceDefault,
 				},
 			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo_external",
+					Namespace: apiv1.NamespaceDefault,
+				},
+				Spec: apiv1.ServiceSpec{
+					Type:         apiv1.ServiceTypeExternalName,
+					ExternalName: "lb.example.com",
+				},
+			},
 		}},
 		&apiv1.NodeList{Items: []apiv1.Node{
 			{
@@ -282,6 +293,11 @@ func TestStatusActions(t *testing.T) {
 	go fk.Run()
 	//  wait for the election
 	time.Sleep(100 * time.Millisecond)
+
+	election := fk.Election()
+	if !election.IsLeader || election.Leader != "foo1" || election.ElectionID != "-nginx" {
+		t.Fatalf("expected foo1 to be the leader of the election -nginx but got %+v", election)
+	}
 	// execute sync
 	fk.sync("just-test")
 	// PublishService is empty, so the running address is: ["11.0.0.2"]
@@ -353,6 +369,35 @@ func TestRunningAddresessWithPublishService(t *testing.T) {
 	}
 }
 
+func TestRunningAddresessWithPublishServices(t *testing.T) {
+	fk := buildStatusSync()
+	fk.PublishService = "default/foo, default/foo_external"
+
+	r, err := fk.runningAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r) != 5 {
+		t.Fatalf("returned %v but expected %v", r, 5)
+	}
+	if r[4] != "lb.example.com" {
+		t.Errorf("returned %v but expected the external name of the service", r[4])
+	}
+}
+
+func TestRunningAddresessWithPublishStatusAddress(t *testing.T) {
+	fk := buildStatusSync()
+	fk.PublishStatusAddress = "lb.example.com,10.0.0.1,lb.example.com"
+
+	r, err := fk.runningAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r) != 2 || r[0] != "lb.example.com" || r[1] != "10.0.0.1" {
+		t.Errorf("returned %v but expected [lb.example.com 10.0.0.1]", r)
+	}
+}
+
 func TestRunningAddresessWithPods(t *testing.T) {
 	fk := buildStatusSync()
 	fk.PublishService = ""
@@ -397,6 +442,18 @@ func TestUpdateStatus(t *testing.T) {
 	}
 }
 */
+func TestSkipStatusUpdate(t *testing.T) {
+	ing := &extensions.Ingress{}
+	if skipStatusUpdate(ing) {
+		t.Errorf("expected the status of an Ingress without annotation to be updated")
+	}
+
+	ing.Annotations = map[string]string{parser.GetAnnotationWithPrefix(skipStatusUpdateAnnotation): "true"}
+	if !skipStatusUpdate(ing) {
+		t.Errorf("expected the status update of the Ingress to be skipped")
+	}
+}
+
 func TestSliceToStatus(t *testing.T) {
 	fkEndpoints := []string{
 		"10.0.0.1",
@@ -23,15 +23,33 @@ import (
 	extensions "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/abtesting"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/compression"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/countryfilter"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geobackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/limitrate"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenance"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/responseheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/useragent"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/websocket"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
@@ -86,6 +104,14 @@ type Backend struct {
 	SessionAffinity SessionAffinityConfig `json:"sessionAffinityConfig"`
 	// Consistent hashing by NGINX variable
 	UpstreamHashBy string `json:"upstream-hash-by,omitempty"`
+	// LB algorithm configuration per ingress
+	LoadBalancing string `json:"load-balance,omitempty"`
+	// Maximum number of idle keepalive connections to the servers of the backend.
+	// Zero means the value defined in the configuration configmap is used
+	UpstreamKeepaliveConnections int `json:"upstream-keepalive-connections,omitempty"`
+	// ServiceUpstream indicates the backend uses the ClusterIP of the service
+	// as the only endpoint instead of the list of pods
+	ServiceUpstream bool `json:"service-upstream,omitempty"`
 }
 
 // SessionAffinityConfig describes different affinity configurations for new sessions.
@@ -123,6 +149,9 @@ type Endpoint struct {
 	// of unsuccessful attempts to communicate with the server should happen
 	// to consider the endpoint unavailable
 	FailTimeout int `json:"failTimeout"`
+	// MaxConns limits the maximum number of simultaneous active
+	// connections to the endpoint. Zero means there is no limit
+	MaxConns int `json:"maxConns"`
 	// Target returns a reference to the object providing the endpoint
 	Target *apiv1.ObjectReference `json:"target,omipempty"`
 }
@@ -146,6 +175,10 @@ type Server struct {
 	// used to  determine if the secret changed without the use of file
 	// system notifications
 	SSLPemChecksum string `json:"sslPemChecksum"`
+	// SSLCertificateNames contains the hostnames (common name and subject
+	// alternative names) included in the SSL certificate
+	// +optional
+	SSLCertificateNames []string `json:"sslCertificateNames,omitempty"`
 	// Locations list of URIs configured in the server.
 	Locations []*Location `json:"locations,omitempty"`
 	// Alias return the alias of the server name
@@ -155,6 +188,13 @@ type Server struct {
 	// CertificateAuth indicates the this server requires mutual authentication
 	// +optional
 	CertificateAuth authtls.Config `json:"certificateAuth"`
+	// HSTS contains the HTTP Strict Transport Security configuration
+	// of the server
+	// +optional
+	HSTS hsts.Config `json:"hsts"`
+	// SSLCipher contains the SSL protocols and ciphers of the server
+	// +optional
+	SSLCipher sslcipher.Config `json:"sslCipher"`
 
 	// ServerSnippet returns the snippet of server
 	// +optional
@@ -226,6 +266,18 @@ type Location struct {
 	// addresses or networks are allowed.
 	// +optional
 	Whitelist ipwhitelist.SourceRange `json:"whitelist,omitempty"`
+	// Denylist indicates connections from certain client
+	// addresses or networks are blocked.
+	// +optional
+	Denylist ipwhitelist.SourceRange `json:"denylist,omitempty"`
+	// CountryFilter contains the countries allowed or blocked
+	// using the GeoIP database.
+	// +optional
+	CountryFilter countryfilter.Config `json:"countryFilter,omitempty"`
+	// UserAgent contains the User-Agents of the clients that
+	// cannot access the location.
+	// +optional
+	UserAgent useragent.Config `json:"userAgent,omitempty"`
 	// Proxy contains information about timeouts and buffer sizes
 	// to be used in connections against endpoints
 	// +optional
@@ -233,6 +285,10 @@ type Location struct {
 	// UsePortInRedirects indicates if redirects must specify the port
 	// +optional
 	UsePortInRedirects bool `json:"usePortInRedirects"`
+	// LogFormat is the format of the access log of the location, the
+	// format of the ConfigMap is used if it is empty
+	// +optional
+	LogFormat string `json:"logFormat,omitempty"`
 	// VtsFilterKey contains the vts filter key on the location level
 	// https://github.com/vozlt/nginx-module-vts#vhost_traffic_status_filter_by_set_key
 	// +optional
@@ -248,9 +304,91 @@ type Location struct {
 	// +optional
 	DefaultBackend *apiv1.Service `json:"defaultBackend,omitempty"`
 	// XForwardedPrefix allows to add a header X-Forwarded-Prefix to the request with the
-	// original location.
+	// original location ("true") or a custom prefix.
+	// +optional
+	XForwardedPrefix string `json:"xForwardedPrefix,omitempty"`
+	// PathType defines how the path is matched: Exact (location =),
+	// Prefix (location ^~) or ImplementationSpecific (NGINX prefix location)
+	// +optional
+	PathType string `json:"pathType,omitempty"`
+	// GeoBackend contains the backends used to route the requests
+	// using the country of the client
+	// +optional
+	GeoBackend geobackend.Config `json:"geoBackend,omitempty"`
+	// ABTesting contains the alternate backend used to send a percentage
+	// of the clients as part of an A/B test
+	// +optional
+	ABTesting abtesting.Config `json:"abTesting,omitempty"`
+	// ModSecurity contains the ModSecurity configuration of the location
+	// +optional
+	ModSecurity modsecurity.Config `json:"modsecurity,omitempty"`
+	// SecurityHeaders contains the security related headers
+	// added to the responses of the location.
+	// +optional
+	SecurityHeaders securityheaders.Config `json:"securityHeaders,omitempty"`
+	// Satisfy defines if all the access checks (whitelist, authentication)
+	// or only one of them must allow the request
+	// +optional
+	Satisfy string `json:"satisfy,omitempty"`
+	// CustomHTTPErrors contains the HTTP status codes returned by the
+	// backend that must be intercepted and served by the default backend
+	// of the Ingress (or the global one if it is not defined)
+	// +optional
+	CustomHTTPErrors []int `json:"customHTTPErrors,omitempty"`
+	// DefaultBackendUpstreamName is the name of the upstream used to serve
+	// the custom HTTP errors of the location
+	// +optional
+	DefaultBackendUpstreamName string `json:"defaultBackendUpstreamName,omitempty"`
+	// RequestHeaders contains the headers added to the requests
+	// sent to the upstream servers
+	// +optional
+	RequestHeaders requestheaders.Config `json:"requestHeaders,omitempty"`
+	// ResponseHeaders contains the headers added or removed from
+	// the responses returned to the clients
+	// +optional
+	ResponseHeaders responseheaders.Config `json:"responseHeaders,omitempty"`
+	// Gzip contains the gzip configuration of the location. If nil
+	// the global configuration is used
+	// +optional
+	Gzip *compression.Config `json:"gzip,omitempty"`
+	// Brotli contains the brotli configuration of the location. If nil
+	// the global configuration is used
 	// +optional
-	XForwardedPrefix bool `json:"xForwardedPrefix,omitempty"`
+	Brotli *compression.Config `json:"brotli,omitempty"`
+	// ProxyCache contains the configuration used to cache the responses
+	// of the upstream servers in one of the cache zones
+	// +optional
+	ProxyCache proxycache.Config `json:"proxyCache,omitempty"`
+	// LimitRate contains the bandwidth limits of the responses
+	// returned to the clients
+	// +optional
+	LimitRate limitrate.Config `json:"limitRate,omitempty"`
+	// Maintenance returns a 503 response to the requests that do
+	// not match the bypass conditions of the maintenance mode
+	// +optional
+	Maintenance maintenance.Config `json:"maintenance,omitempty"`
+	// BackendProtocol indicates the protocol used to connect to the
+	// upstream servers (HTTP, HTTPS, GRPC or GRPCS)
+	// +optional
+	BackendProtocol string `json:"backendProtocol"`
+	// GRPCWeb indicates if gRPC-Web requests should be translated to
+	// gRPC before being sent to the backend
+	// +optional
+	GRPCWeb bool `json:"grpcWeb"`
+	// FastCGI contains the parameters sent to the FastCGI servers
+	// when the backend protocol is FCGI
+	// +optional
+	FastCGI fastcgi.Config `json:"fastcgi,omitempty"`
+	// WebSocket contains the settings of the WebSocket connections
+	// +optional
+	WebSocket websocket.Config `json:"webSocket"`
+	// ProxySSL contains the client certificate presented to the HTTPS
+	// upstream servers and the verification of their certificates
+	// +optional
+	ProxySSL proxyssl.Config `json:"proxySSL"`
+	// Mirror contains the URL that receives a copy of the requests
+	// +optional
+	Mirror mirror.Config `json:"mirror"`
 }
 
 // SSLPassthroughBackend describes a SSL upstream server configured
@@ -272,6 +410,11 @@ type L4Service struct {
 	Port int `json:"port"`
 	// Backend of the service
 	Backend L4Backend `json:"backend"`
+	// ServerNames contains the server names (SNI) of the TLS connections
+	// routed to the backend. Several services with server names can
+	// share the same port
+	// +optional
+	ServerNames []string `json:"serverNames,omitempty"`
 	// Endpoints active endpoints of the service
 	Endpoints []Endpoint `json:"endpoins,omitEmpty"`
 }
@@ -284,10 +427,36 @@ type L4Backend struct {
 	Protocol  apiv1.Protocol     `json:"protocol"`
 	// +optional
 	ProxyProtocol ProxyProtocol `json:"proxyProtocol"`
+	// ConnectTimeout is the timeout to establish a connection with the endpoints
+	// +optional
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+	// ProxyTimeout is the timeout between two successive read or write operations
+	// +optional
+	ProxyTimeout string `json:"proxyTimeout,omitempty"`
+	// ProxyResponses is the number of datagrams expected from the endpoints
+	// in response to a client datagram (UDP only)
+	// +optional
+	ProxyResponses string `json:"proxyResponses,omitempty"`
+	// ReusePort creates an individual listening socket for each worker process (UDP only)
+	// +optional
+	ReusePort bool `json:"reusePort,omitempty"`
 }
 
 // ProxyProtocol describes the proxy protocol configuration
 type ProxyProtocol struct {
 	Decode bool `json:"decode"`
 	Encode bool `json:"encode"`
+	// Version is the version of the protocol sent to the endpoints (1 or 2)
+	// +optional
+	Version int `json:"version,omitempty"`
+	// TLVs contains the custom TLVs added to the version 2 header
+	// +optional
+	TLVs []ProxyProtocolTLV `json:"tlvs,omitempty"`
+}
+
+// ProxyProtocolTLV describes a Type-Length-Value vector of the version 2
+// of the proxy protocol
+type ProxyProtocolTLV struct {
+	Type  int    `json:"type"`
+	Value string `json:"value"`
 }
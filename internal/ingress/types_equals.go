@@ -152,6 +152,15 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 	if b1.UpstreamHashBy != b2.UpstreamHashBy {
 		return false
 	}
+	if b1.LoadBalancing != b2.LoadBalancing {
+		return false
+	}
+	if b1.UpstreamKeepaliveConnections != b2.UpstreamKeepaliveConnections {
+		return false
+	}
+	if b1.ServiceUpstream != b2.ServiceUpstream {
+		return false
+	}
 
 	if len(b1.Endpoints) != len(b2.Endpoints) {
 		return false
@@ -229,6 +238,9 @@ func (e1 *Endpoint) Equal(e2 *Endpoint) bool {
 	if e1.FailTimeout != e2.FailTimeout {
 		return false
 	}
+	if e1.MaxConns != e2.MaxConns {
+		return false
+	}
 
 	if e1.Target != e2.Target {
 		if e1.Target == nil || e2.Target == nil {
@@ -268,6 +280,14 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPemChecksum != s2.SSLPemChecksum {
 		return false
 	}
+	if len(s1.SSLCertificateNames) != len(s2.SSLCertificateNames) {
+		return false
+	}
+	for i, n1 := range s1.SSLCertificateNames {
+		if n1 != s2.SSLCertificateNames[i] {
+			return false
+		}
+	}
 	if !(&s1.CertificateAuth).Equal(&s2.CertificateAuth) {
 		return false
 	}
@@ -277,6 +297,12 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.RedirectFromToWWW != s2.RedirectFromToWWW {
 		return false
 	}
+	if !(&s1.HSTS).Equal(&s2.HSTS) {
+		return false
+	}
+	if !(&s1.SSLCipher).Equal(&s2.SSLCipher) {
+		return false
+	}
 
 	if len(s1.Locations) != len(s2.Locations) {
 		return false
@@ -352,12 +378,24 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if !(&l1.Whitelist).Equal(&l2.Whitelist) {
 		return false
 	}
+	if !(&l1.Denylist).Equal(&l2.Denylist) {
+		return false
+	}
+	if !(&l1.CountryFilter).Equal(&l2.CountryFilter) {
+		return false
+	}
+	if !(&l1.UserAgent).Equal(&l2.UserAgent) {
+		return false
+	}
 	if !(&l1.Proxy).Equal(&l2.Proxy) {
 		return false
 	}
 	if l1.UsePortInRedirects != l2.UsePortInRedirects {
 		return false
 	}
+	if l1.LogFormat != l2.LogFormat {
+		return false
+	}
 	if l1.ConfigurationSnippet != l2.ConfigurationSnippet {
 		return false
 	}
@@ -370,6 +408,74 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.XForwardedPrefix != l2.XForwardedPrefix {
 		return false
 	}
+	if l1.PathType != l2.PathType {
+		return false
+	}
+	if !(&l1.GeoBackend).Equal(&l2.GeoBackend) {
+		return false
+	}
+	if !(&l1.ABTesting).Equal(&l2.ABTesting) {
+		return false
+	}
+	if !(&l1.ModSecurity).Equal(&l2.ModSecurity) {
+		return false
+	}
+	if !(&l1.SecurityHeaders).Equal(&l2.SecurityHeaders) {
+		return false
+	}
+	if l1.Satisfy != l2.Satisfy {
+		return false
+	}
+	if len(l1.CustomHTTPErrors) != len(l2.CustomHTTPErrors) {
+		return false
+	}
+	for i, c1 := range l1.CustomHTTPErrors {
+		if c1 != l2.CustomHTTPErrors[i] {
+			return false
+		}
+	}
+	if l1.DefaultBackendUpstreamName != l2.DefaultBackendUpstreamName {
+		return false
+	}
+	if !(&l1.RequestHeaders).Equal(&l2.RequestHeaders) {
+		return false
+	}
+	if !(&l1.ResponseHeaders).Equal(&l2.ResponseHeaders) {
+		return false
+	}
+	if !l1.Gzip.Equal(l2.Gzip) {
+		return false
+	}
+	if !l1.Brotli.Equal(l2.Brotli) {
+		return false
+	}
+	if !(&l1.ProxyCache).Equal(&l2.ProxyCache) {
+		return false
+	}
+	if !(&l1.LimitRate).Equal(&l2.LimitRate) {
+		return false
+	}
+	if !(&l1.Maintenance).Equal(&l2.Maintenance) {
+		return false
+	}
+	if l1.BackendProtocol != l2.BackendProtocol {
+		return false
+	}
+	if l1.GRPCWeb != l2.GRPCWeb {
+		return false
+	}
+	if !(&l1.FastCGI).Equal(&l2.FastCGI) {
+		return false
+	}
+	if !(&l1.WebSocket).Equal(&l2.WebSocket) {
+		return false
+	}
+	if !(&l1.ProxySSL).Equal(&l2.ProxySSL) {
+		return false
+	}
+	if !(&l1.Mirror).Equal(&l2.Mirror) {
+		return false
+	}
 
 	return true
 }
@@ -424,6 +530,23 @@ func (e1 *L4Service) Equal(e2 *L4Service) bool {
 	if !(&e1.Backend).Equal(&e2.Backend) {
 		return false
 	}
+	if len(e1.ServerNames) != len(e2.ServerNames) {
+		return false
+	}
+
+	for _, sn1 := range e1.ServerNames {
+		found := false
+		for _, sn2 := range e2.ServerNames {
+			if sn1 == sn2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
 	if len(e1.Endpoints) != len(e2.Endpoints) {
 		return false
 	}
@@ -464,6 +587,50 @@ func (l4b1 *L4Backend) Equal(l4b2 *L4Backend) bool {
 	if l4b1.Protocol != l4b2.Protocol {
 		return false
 	}
+	if !(&l4b1.ProxyProtocol).Equal(&l4b2.ProxyProtocol) {
+		return false
+	}
+	if l4b1.ConnectTimeout != l4b2.ConnectTimeout {
+		return false
+	}
+	if l4b1.ProxyTimeout != l4b2.ProxyTimeout {
+		return false
+	}
+	if l4b1.ProxyResponses != l4b2.ProxyResponses {
+		return false
+	}
+	if l4b1.ReusePort != l4b2.ReusePort {
+		return false
+	}
+
+	return true
+}
+
+// Equal tests for equality between two ProxyProtocol types
+func (pp1 *ProxyProtocol) Equal(pp2 *ProxyProtocol) bool {
+	if pp1 == pp2 {
+		return true
+	}
+	if pp1 == nil || pp2 == nil {
+		return false
+	}
+	if pp1.Decode != pp2.Decode {
+		return false
+	}
+	if pp1.Encode != pp2.Encode {
+		return false
+	}
+	if pp1.Version != pp2.Version {
+		return false
+	}
+	if len(pp1.TLVs) != len(pp2.TLVs) {
+		return false
+	}
+	for i := range pp1.TLVs {
+		if pp1.TLVs[i] != pp2.TLVs[i] {
+			return false
+		}
+	}
 
 	return true
 }
@@ -482,6 +649,9 @@ func (s1 *SSLCert) Equal(s2 *SSLCert) bool {
 	if s1.PemSHA != s2.PemSHA {
 		return false
 	}
+	if s1.CRLSHA != s2.CRLSHA {
+		return false
+	}
 	if !s1.ExpireTime.Equal(s2.ExpireTime) {
 		return false
 	}
@@ -38,6 +38,17 @@ func ParseNameNS(input string) (string, string, error) {
 	return nsName[0], nsName[1], nil
 }
 
+// ReferenceKey returns the key (namespace/name) of an object referenced
+// from the given namespace. The reference can be the name of an object of
+// the same namespace or the key of an object of another namespace.
+func ReferenceKey(namespace, reference string) string {
+	if strings.Contains(reference, "/") {
+		return reference
+	}
+
+	return fmt.Sprintf("%v/%v", namespace, reference)
+}
+
 // GetNodeIPOrName returns the IP address or the name of a node in the cluster
 func GetNodeIPOrName(kubeClient clientset.Interface, name string, useInternalIP bool) string {
 	node, err := kubeClient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
@@ -56,6 +56,25 @@ func TestParseNameNS(t *testing.T) {
 	}
 }
 
+func TestReferenceKey(t *testing.T) {
+	tests := []struct {
+		namespace string
+		reference string
+		expected  string
+	}{
+		{"default", "tls", "default/tls"},
+		{"default", "certificates/tls", "certificates/tls"},
+		{"default", "default/tls", "default/tls"},
+	}
+
+	for _, test := range tests {
+		key := ReferenceKey(test.namespace, test.reference)
+		if key != test.expected {
+			t.Errorf("%v/%v: expected %v but returned %v", test.namespace, test.reference, test.expected, key)
+		}
+	}
+}
+
 func TestGetNodeIP(t *testing.T) {
 	fKNodes := []struct {
 		cs *testclient.Clientset
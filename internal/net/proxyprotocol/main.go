@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxyprotocol implements the parts of the PROXY protocol
+// (https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt) required
+// to translate the version 1 header sent by NGINX to the version 2.
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SocketDirectory contains the unix sockets used to receive the connections
+// that must be sent to the endpoints using the version 2 of the protocol
+const SocketDirectory = "/tmp/nginx-proxy-protocol"
+
+const (
+	// maxV1HeaderLength is the maximum length of a version 1 header
+	maxV1HeaderLength = 107
+
+	// TLVTypeMinCustom is the first TLV type reserved for custom values
+	TLVTypeMinCustom = 0xE0
+	// TLVTypeMaxCustom is the last TLV type reserved for custom values
+	TLVTypeMaxCustom = 0xEF
+)
+
+// v2Signature is the signature of the version 2 header
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// TLV is a Type-Length-Value vector added to the version 2 header
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// ReadV1Header reads a version 1 header returning the source and
+// destination addresses. The addresses are nil if the protocol of
+// the header is UNKNOWN.
+func ReadV1Header(r *bufio.Reader) (*net.TCPAddr, *net.TCPAddr, error) {
+	var line []byte
+	for len(line) < maxV1HeaderLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header")
+	}
+
+	parts := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(parts) < 2 || parts[0] != "PROXY" {
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header")
+	}
+
+	switch parts[1] {
+	case "UNKNOWN":
+		return nil, nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, nil, fmt.Errorf("invalid PROXY protocol %v", parts[1])
+	}
+
+	if len(parts) != 6 {
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header")
+	}
+
+	src, err := parseAddr(parts[1], parts[2], parts[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	dst, err := parseAddr(parts[1], parts[3], parts[5])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return src, dst, nil
+}
+
+func parseAddr(protocol, ip, port string) (*net.TCPAddr, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil || (protocol == "TCP4") != (addr.To4() != nil) {
+		return nil, fmt.Errorf("invalid %v address %v", protocol, ip)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %v", port)
+	}
+
+	return &net.TCPAddr{IP: addr, Port: int(p)}, nil
+}
+
+// V2Header returns a version 2 header with the command PROXY (or LOCAL
+// if the addresses are nil) and the TLVs
+func V2Header(src, dst *net.TCPAddr, tlvs []TLV) []byte {
+	var addrs []byte
+	// version 2 and command LOCAL, protocol UNSPEC
+	command, family := byte(0x20), byte(0x00)
+
+	if src != nil && dst != nil {
+		// command PROXY
+		command = 0x21
+		if src.IP.To4() != nil && dst.IP.To4() != nil {
+			// TCP over IPv4
+			family = 0x11
+			addrs = append(addrs, src.IP.To4()...)
+			addrs = append(addrs, dst.IP.To4()...)
+		} else {
+			// TCP over IPv6
+			family = 0x21
+			addrs = append(addrs, src.IP.To16()...)
+			addrs = append(addrs, dst.IP.To16()...)
+		}
+
+		ports := make([]byte, 4)
+		binary.BigEndian.PutUint16(ports[0:], uint16(src.Port))
+		binary.BigEndian.PutUint16(ports[2:], uint16(dst.Port))
+		addrs = append(addrs, ports...)
+	}
+
+	for _, tlv := range tlvs {
+		length := make([]byte, 2)
+		binary.BigEndian.PutUint16(length, uint16(len(tlv.Value)))
+		addrs = append(addrs, tlv.Type)
+		addrs = append(addrs, length...)
+		addrs = append(addrs, tlv.Value...)
+	}
+
+	header := make([]byte, 0, len(v2Signature)+4+len(addrs))
+	header = append(header, v2Signature...)
+	header = append(header, command, family)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(addrs)))
+	header = append(header, length...)
+
+	return append(header, addrs...)
+}
+
+// SocketPath returns the path of the unix socket that receives the
+// connections of a TCP service port that must be sent to an endpoint
+func SocketPath(port int, address, endpointPort string) string {
+	key := fmt.Sprintf("%v-%v:%v", port, address, endpointPort)
+	return filepath.Join(SocketDirectory, fmt.Sprintf("%x", sha1.Sum([]byte(key)))[:16]+".sock")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadV1Header(t *testing.T) {
+	tests := []struct {
+		header string
+		src    *net.TCPAddr
+		dst    *net.TCPAddr
+		expErr bool
+	}{
+		{"PROXY TCP4 192.168.0.1 10.0.0.1 56324 443\r\n",
+			&net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324},
+			&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 443}, false},
+		{"PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n",
+			&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+			&net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443}, false},
+		{"PROXY UNKNOWN\r\n", nil, nil, false},
+		{"PROXY TCP4 2001:db8::1 10.0.0.1 56324 443\r\n", nil, nil, true},
+		{"PROXY TCP4 192.168.0.1 10.0.0.1 56324\r\n", nil, nil, true},
+		{"PROXY TCP4 192.168.0.1 10.0.0.1 56324 70000\r\n", nil, nil, true},
+		{"PROXY UDP4 192.168.0.1 10.0.0.1 56324 443\r\n", nil, nil, true},
+		{"GET / HTTP/1.1\r\n", nil, nil, true},
+		{"PROXY TCP4 192.168.0.1 10.0.0.1 56324 443", nil, nil, true},
+		{"PROXY " + strings.Repeat("A", 200) + "\r\n", nil, nil, true},
+	}
+
+	for _, test := range tests {
+		r := bufio.NewReader(strings.NewReader(test.header + "data"))
+		src, dst, err := ReadV1Header(r)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("expected error reading %q but returned nil", test.header)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error reading %q: %v", test.header, err)
+			continue
+		}
+		if !reflect.DeepEqual(src, test.src) || !reflect.DeepEqual(dst, test.dst) {
+			t.Errorf("expected %v %v but returned %v %v", test.src, test.dst, src, dst)
+		}
+
+		rest, _ := ioutil.ReadAll(r)
+		if string(rest) != "data" {
+			t.Errorf("expected the data after the header but returned %q", rest)
+		}
+	}
+}
+
+func TestV2Header(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 443}
+
+	header := V2Header(src, dst, []TLV{{Type: 0xE0, Value: []byte("abc")}})
+	expected := append([]byte{}, v2Signature...)
+	expected = append(expected,
+		0x21, 0x11, 0x00, 0x12,
+		192, 168, 0, 1,
+		10, 0, 0, 1,
+		0xDC, 0x04, 0x01, 0xBB,
+		0xE0, 0x00, 0x03, 'a', 'b', 'c')
+	if !bytes.Equal(header, expected) {
+		t.Errorf("expected %v but returned %v", expected, header)
+	}
+
+	header = V2Header(
+		&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 1},
+		&net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 2}, nil)
+	if header[12] != 0x21 || header[13] != 0x21 || header[15] != 36 || len(header) != 16+36 {
+		t.Errorf("unexpected IPv6 header %v", header)
+	}
+
+	header = V2Header(nil, nil, nil)
+	if header[12] != 0x20 || header[13] != 0x00 || len(header) != 16 {
+		t.Errorf("unexpected LOCAL header %v", header)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	p1 := SocketPath(6379, "10.0.0.1", "6379")
+	p2 := SocketPath(6379, "10.0.0.2", "6379")
+	if p1 == p2 {
+		t.Errorf("expected different sockets for different endpoints")
+	}
+	if !strings.HasPrefix(p1, SocketDirectory+"/") || !strings.HasSuffix(p1, ".sock") {
+		t.Errorf("unexpected socket path %v", p1)
+	}
+}
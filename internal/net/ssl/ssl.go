@@ -289,6 +289,42 @@ func AddCertAuth(name string, ca []byte, fs file.Filesystem) (*ingress.SSLCert,
 	}, nil
 }
 
+// AddCertRevocationList creates a .pem file with the specified certificate
+// revocation list (CRL) to be used in Cert Authentication.
+// If it's already exists, it's clobbered.
+func AddCertRevocationList(name string, crl []byte, fs file.Filesystem) (string, error) {
+	crlName := fmt.Sprintf("crl-%v.pem", name)
+	crlFileName := fmt.Sprintf("%v/%v", file.DefaultSSLDirectory, crlName)
+
+	pemCRLBlock, _ := pem.Decode(crl)
+	if pemCRLBlock == nil {
+		return "", fmt.Errorf("no valid PEM formatted block found")
+	}
+	// If the first block does not start with 'BEGIN X509 CRL' it's invalid and must not be used.
+	if pemCRLBlock.Type != "X509 CRL" {
+		return "", fmt.Errorf("CRL file %v contains invalid data, and must be created only with PEM formated CRLs", name)
+	}
+
+	_, err := x509.ParseCRL(pemCRLBlock.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	crlFile, err := fs.Create(crlFileName)
+	if err != nil {
+		return "", fmt.Errorf("could not write CRL file %v: %v", crlFileName, err)
+	}
+	defer crlFile.Close()
+
+	_, err = crlFile.Write(crl)
+	if err != nil {
+		return "", fmt.Errorf("could not write CRL file %v: %v", crlFileName, err)
+	}
+
+	glog.V(3).Infof("Created CRL for Authentication: %v", crlFileName)
+	return crlFileName, nil
+}
+
 // AddOrUpdateDHParam creates a dh parameters file with the specified name
 func AddOrUpdateDHParam(name string, dh []byte, fs file.Filesystem) (string, error) {
 	pemName := fmt.Sprintf("%v.pem", name)
@@ -17,7 +17,9 @@ limitations under the License.
 package ssl
 
 import (
+	"crypto/rand"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"testing"
 	"time"
@@ -140,6 +142,34 @@ func TestAddCertAuth(t *testing.T) {
 	}
 }
 
+func TestAddCertRevocationList(t *testing.T) {
+	fs := newFS(t)
+
+	_, ca, err := generateRSACerts("demo-ca")
+	if err != nil {
+		t.Fatalf("unexpected error creating SSL certificate: %v", err)
+	}
+
+	der, err := ca.Cert.CreateCRL(rand.Reader, ca.Key, nil, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error creating CRL: %v", err)
+	}
+	crl := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+
+	name, err := AddCertRevocationList("demo-crl", crl, fs)
+	if err != nil {
+		t.Fatalf("unexpected error creating CRL file: %v", err)
+	}
+	if name == "" {
+		t.Fatalf("expected a valid CRL file name")
+	}
+
+	_, err = AddCertRevocationList("demo-crl", certutil.EncodeCertPEM(ca.Cert), fs)
+	if err == nil {
+		t.Fatalf("expected an error using a certificate as CRL")
+	}
+}
+
 func newFS(t *testing.T) file.Filesystem {
 	fs, err := file.NewFakeFS()
 	if err != nil {
@@ -18,12 +18,15 @@ package task
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -31,8 +34,8 @@ var (
 	keyFunc = cache.DeletionHandlingMetaNamespaceKeyFunc
 )
 
-// Queue manages a time work queue through an independent worker that invokes the
-// given sync function for every work item inserted.
+// Queue manages a time work queue through a pool of independent workers that
+// invoke the given sync function for every work item inserted.
 // The queue uses an internal timestamp that allows the removal of certain elements
 // which timestamp is older than the last successful get operation.
 type Queue struct {
@@ -40,12 +43,28 @@ type Queue struct {
 	queue workqueue.RateLimitingInterface
 	// sync is called for each item in the queue
 	sync func(interface{}) error
-	// workerDone is closed when the worker exits
+	// workerDone is closed when all the workers exit
 	workerDone chan bool
+	// quit is closed when the first worker sees the queue shutting down
+	quit     chan struct{}
+	quitOnce sync.Once
 
 	fn func(obj interface{}) (interface{}, error)
 
 	lastSync int64
+
+	// batchWindow is the time the worker waits before invoking sync, so the
+	// items enqueued during the window are processed in a single sync
+	batchWindow time.Duration
+
+	// workers is the number of workers polling the queue
+	workers int
+
+	// lowPriority holds the latest element enqueued with EnqueueLowPriority
+	// that is waiting for the lowPriorityLimiter
+	lowPriority chan Element
+	// lowPriorityLimiter limits the rate of the low priority elements
+	lowPriorityLimiter flowcontrol.RateLimiter
 }
 
 // Element represents one item of the queue
@@ -54,9 +73,30 @@ type Element struct {
 	Timestamp int64
 }
 
-// Run ...
+// Run starts the workers and blocks until they exit or stopCh is closed.
 func (t *Queue) Run(period time.Duration, stopCh <-chan struct{}) {
-	wait.Until(t.worker, period, stopCh)
+	if t.lowPriorityLimiter != nil {
+		go t.lowPriorityWorker(stopCh)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < t.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(t.worker, period, t.quit)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(t.workerDone)
+	}()
+
+	select {
+	case <-t.workerDone:
+	case <-stopCh:
+	}
 }
 
 // Enqueue enqueues ns/name of the given api object in the task queue.
@@ -79,6 +119,70 @@ func (t *Queue) Enqueue(obj interface{}) {
 	})
 }
 
+// EnqueueLowPriority enqueues ns/name of the given api object in the task
+// queue, limiting the rate of these elements so frequent updates (e.g. of
+// Endpoints) cannot delay the ones enqueued with Enqueue. The elements
+// waiting for the rate limiter are coalesced, as the sync of the latest
+// covers the changes of the previous ones.
+func (t *Queue) EnqueueLowPriority(obj interface{}) {
+	if t.lowPriorityLimiter == nil {
+		t.Enqueue(obj)
+		return
+	}
+
+	if t.IsShuttingDown() {
+		glog.Errorf("queue has been shutdown, failed to enqueue: %v", obj)
+		return
+	}
+
+	ts := time.Now().UnixNano()
+	glog.V(3).Infof("queuing low priority item %v", obj)
+	key, err := t.fn(obj)
+	if err != nil {
+		glog.Errorf("%v", err)
+		return
+	}
+	item := Element{
+		Key:       key,
+		Timestamp: ts,
+	}
+
+	for {
+		select {
+		case t.lowPriority <- item:
+			return
+		default:
+		}
+
+		// replace the pending element, older than this one
+		select {
+		case <-t.lowPriority:
+		default:
+		}
+	}
+}
+
+// lowPriorityWorker moves the low priority elements to the work queue at the
+// rate allowed by lowPriorityLimiter.
+func (t *Queue) lowPriorityWorker(stopCh <-chan struct{}) {
+	for {
+		select {
+		case item := <-t.lowPriority:
+			t.lowPriorityLimiter.Accept()
+			// a newer element may have been enqueued while waiting
+			select {
+			case item = <-t.lowPriority:
+			default:
+			}
+			t.queue.Add(item)
+		case <-t.quit:
+			return
+		case <-stopCh:
+			return
+		}
+	}
+}
+
 func (t *Queue) defaultKeyFunc(obj interface{}) (interface{}, error) {
 	key, err := keyFunc(obj)
 	if err != nil {
@@ -93,21 +197,31 @@ func (t *Queue) worker() {
 	for {
 		key, quit := t.queue.Get()
 		if quit {
-			if !isClosed(t.workerDone) {
-				close(t.workerDone)
-			}
+			t.quitOnce.Do(func() {
+				close(t.quit)
+			})
 			return
 		}
-		ts := time.Now().UnixNano()
-
 		item := key.(Element)
-		if t.lastSync > item.Timestamp {
-			glog.V(3).Infof("skipping %v sync (%v > %v)", item.Key, t.lastSync, item.Timestamp)
+		if t.skip(item) {
 			t.queue.Forget(key)
 			t.queue.Done(key)
 			continue
 		}
 
+		if t.batchWindow > 0 {
+			glog.V(3).Infof("waiting %v to batch the changes before syncing %v", t.batchWindow, item.Key)
+			time.Sleep(t.batchWindow)
+
+			// another worker may have synced the changes during the window
+			if t.skip(item) {
+				t.queue.Forget(key)
+				t.queue.Done(key)
+				continue
+			}
+		}
+		ts := time.Now().UnixNano()
+
 		glog.V(3).Infof("syncing %v", item.Key)
 		if err := t.sync(key); err != nil {
 			glog.Warningf("requeuing %v, err %v", item.Key, err)
@@ -117,24 +231,36 @@ func (t *Queue) worker() {
 			})
 		} else {
 			t.queue.Forget(key)
-			t.lastSync = ts
+			t.setLastSync(ts)
 		}
 
 		t.queue.Done(key)
 	}
 }
 
-func isClosed(ch <-chan bool) bool {
-	select {
-	case <-ch:
+// skip returns true if the element is older than the last successful sync
+func (t *Queue) skip(item Element) bool {
+	lastSync := atomic.LoadInt64(&t.lastSync)
+	if lastSync > item.Timestamp {
+		glog.V(3).Infof("skipping %v sync (%v > %v)", item.Key, lastSync, item.Timestamp)
 		return true
-	default:
 	}
 
 	return false
 }
 
-// Shutdown shuts down the work queue and waits for the worker to ACK
+// setLastSync updates the timestamp of the last successful sync unless a
+// sync started later already finished in another worker
+func (t *Queue) setLastSync(ts int64) {
+	for {
+		lastSync := atomic.LoadInt64(&t.lastSync)
+		if lastSync >= ts || atomic.CompareAndSwapInt64(&t.lastSync, lastSync, ts) {
+			return
+		}
+	}
+}
+
+// Shutdown shuts down the work queue and waits for the workers to ACK
 func (t *Queue) Shutdown() {
 	t.queue.ShutDown()
 	<-t.workerDone
@@ -151,13 +277,39 @@ func NewTaskQueue(syncFn func(interface{}) error) *Queue {
 	return NewCustomTaskQueue(syncFn, nil)
 }
 
+// NewBatchTaskQueue creates a new task queue with the given sync function.
+// The sync function is invoked once for all the elements inserted into the
+// queue during the batch window that starts with the first of them.
+func NewBatchTaskQueue(syncFn func(interface{}) error, batchWindow time.Duration) *Queue {
+	q := NewCustomTaskQueue(syncFn, nil)
+	q.batchWindow = batchWindow
+	return q
+}
+
+// NewPoolTaskQueue creates a new batch task queue polled by the given number
+// of workers. The elements enqueued with EnqueueLowPriority are limited to
+// lowPriorityRateLimit per second, unless it is zero.
+func NewPoolTaskQueue(syncFn func(interface{}) error, batchWindow time.Duration, workers int, lowPriorityRateLimit float32) *Queue {
+	q := NewBatchTaskQueue(syncFn, batchWindow)
+	if workers > 1 {
+		q.workers = workers
+	}
+	if lowPriorityRateLimit > 0 {
+		q.lowPriorityLimiter = flowcontrol.NewTokenBucketRateLimiter(lowPriorityRateLimit, 1)
+	}
+	return q
+}
+
 // NewCustomTaskQueue ...
 func NewCustomTaskQueue(syncFn func(interface{}) error, fn func(interface{}) (interface{}, error)) *Queue {
 	q := &Queue{
-		queue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		sync:       syncFn,
-		workerDone: make(chan bool),
-		fn:         fn,
+		queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		sync:        syncFn,
+		workerDone:  make(chan bool),
+		quit:        make(chan struct{}),
+		fn:          fn,
+		workers:     1,
+		lowPriority: make(chan Element, 1),
 	}
 
 	if fn == nil {
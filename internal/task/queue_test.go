@@ -157,3 +157,103 @@ func TestSkipEnqueue(t *testing.T) {
 	// shutdown queue before exit
 	q.Shutdown()
 }
+
+func TestBatchEnqueue(t *testing.T) {
+	// initialize result
+	atomic.StoreUint32(&sr, 0)
+	q := NewBatchTaskQueue(mockSynFn, 100*time.Millisecond)
+	q.fn = func(obj interface{}) (interface{}, error) {
+		return obj, nil
+	}
+	stopCh := make(chan struct{})
+	// run queue
+	go q.Run(time.Second, stopCh)
+	// elements with different keys enqueued during the batch window
+	q.Enqueue("first")
+	time.Sleep(time.Millisecond * 10)
+	q.Enqueue("second")
+	time.Sleep(time.Millisecond * 10)
+	q.Enqueue("third")
+	// wait for the batch window and 'mockSynFn'
+	time.Sleep(time.Millisecond * 200)
+	if atomic.LoadUint32(&sr) != 1 {
+		t.Errorf("sr should be 1, but is %d", sr)
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
+
+func TestPoolEnqueue(t *testing.T) {
+	// initialize result
+	atomic.StoreUint32(&sr, 0)
+	q := NewPoolTaskQueue(mockSynFn, 0, 4, 0)
+	q.fn = func(obj interface{}) (interface{}, error) {
+		return obj, nil
+	}
+	stopCh := make(chan struct{})
+	// run queue
+	go q.Run(time.Second, stopCh)
+	q.Enqueue("first")
+	q.Enqueue("second")
+	q.Enqueue("third")
+	// wait for 'mockSynFn'
+	time.Sleep(time.Millisecond * 50)
+	if s := atomic.LoadUint32(&sr); s < 1 || s > 3 {
+		t.Errorf("sr should be between 1 and 3, but is %d", s)
+	}
+
+	// shutdown queue before exit, waiting for all the workers
+	q.Shutdown()
+}
+
+func TestEnqueueLowPriority(t *testing.T) {
+	// initialize result
+	atomic.StoreUint32(&sr, 0)
+	q := NewPoolTaskQueue(mockSynFn, 0, 1, 1)
+	q.fn = func(obj interface{}) (interface{}, error) {
+		return obj, nil
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	// run queue
+	go q.Run(time.Second, stopCh)
+	// the first element consumes the token of the rate limiter
+	q.EnqueueLowPriority("endpoints")
+	time.Sleep(time.Millisecond * 50)
+	if atomic.LoadUint32(&sr) != 1 {
+		t.Errorf("sr should be 1, but is %d", sr)
+	}
+
+	// the next ones wait for the rate limiter and are coalesced
+	for i := 0; i < 10; i++ {
+		q.EnqueueLowPriority(fmt.Sprintf("endpoints-%v", i))
+	}
+	time.Sleep(time.Millisecond * 50)
+	if atomic.LoadUint32(&sr) != 1 {
+		t.Errorf("sr should be 1, but is %d", sr)
+	}
+
+	// the elements with normal priority are not delayed
+	q.Enqueue("secret")
+	time.Sleep(time.Millisecond * 50)
+	if atomic.LoadUint32(&sr) != 2 {
+		t.Errorf("sr should be 2, but is %d", sr)
+	}
+
+	// the coalesced element is older than the last sync
+	time.Sleep(time.Second)
+	if atomic.LoadUint32(&sr) != 2 {
+		t.Errorf("sr should be 2, but is %d", sr)
+	}
+
+	// the next element waits for the next token
+	q.EnqueueLowPriority("endpoints")
+	time.Sleep(time.Millisecond * 1100)
+	if atomic.LoadUint32(&sr) != 3 {
+		t.Errorf("sr should be 3, but is %d", sr)
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render renders the NGINX configuration that the ingress controller
+// generates for a TemplateConfig, the data of the NGINX template, in JSON
+// format. It allows the tests and pipelines outside of the ingress controller
+// to compare the configuration generated by a template.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/util/filesystem"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+// defaultTemplate is the asset of the template of the ingress controller
+const defaultTemplate = "etc/nginx/template/nginx.tmpl"
+
+// Options configures the rendering of the configuration
+type Options struct {
+	// Template is the path of the NGINX template. The template of the
+	// ingress controller is used if it is empty
+	Template string
+	// IncludeDir is the directory of the partials included in the template
+	IncludeDir string
+}
+
+// Render returns the NGINX configuration generated with the TemplateConfig
+// in JSON format
+func Render(templateConfig []byte, opts Options) ([]byte, error) {
+	var tc config.TemplateConfig
+	if err := json.Unmarshal(templateConfig, &tc); err != nil {
+		return nil, fmt.Errorf("invalid template configuration: %v", err)
+	}
+
+	if tc.ListenPorts == nil {
+		tc.ListenPorts = &config.ListenPorts{}
+	}
+
+	fs := filesystem.DefaultFs{}
+
+	var data []byte
+	var err error
+	if opts.Template == "" {
+		data, err = file.Asset(defaultTemplate)
+	} else {
+		data, err = fs.ReadFile(opts.Template)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error reading the template: %v", err)
+	}
+
+	tmpl, err := ngx_template.NewTemplateFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.LoadPartials(opts.IncludeDir, fs); err != nil {
+		return nil, err
+	}
+
+	return tmpl.Render(tc)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	data, err := ioutil.ReadFile("../../test/data/config.json")
+	if err != nil {
+		t.Fatalf("unexpected error reading the template configuration: %v", err)
+	}
+
+	content, err := Render(data, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(content), "http {") {
+		t.Errorf("expected the http block in the configuration")
+	}
+	if strings.Contains(string(content), "\n\n\n") {
+		t.Errorf("expected no consecutive empty lines in the configuration")
+	}
+
+	again, err := Render(data, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(again) != string(content) {
+		t.Errorf("expected the same configuration rendering the same data")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := filepath.Join(dir, "nginx.tmpl")
+	err = ioutil.WriteFile(tmpl, []byte(`{{ $all := . }}events {}
+
+{{ includePartials "http" $all nil nil }}
+backlog {{ .BacklogSize }};
+`), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	partials := filepath.Join(dir, "partials")
+	if err := os.Mkdir(partials, 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(partials, "http.tmpl"), []byte("# partial"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := Render([]byte(`{"BacklogSize": 511}`), Options{Template: tmpl, IncludeDir: partials})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "events {}\n\n# partial http.tmpl\n# partial\n\nbacklog 511;\n"
+	if string(content) != expected {
+		t.Errorf("expected %q but returned %q", expected, string(content))
+	}
+
+	if _, err := Render([]byte(`{`), Options{Template: tmpl}); err == nil {
+		t.Errorf("expected an error with an invalid template configuration")
+	}
+	if _, err := Render([]byte(`{}`), Options{Template: filepath.Join(dir, "missing.tmpl")}); err == nil {
+		t.Errorf("expected an error with a missing template")
+	}
+}
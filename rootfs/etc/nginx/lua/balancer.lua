@@ -0,0 +1,257 @@
+-- Selects the endpoint of the dynamic backends in balancer_by_lua. Every
+-- worker reads the backends stored by the configuration module periodically,
+-- so the endpoints are updated without reloading NGINX.
+-- The state of the algorithms is local to the worker, like the round robin
+-- and least_conn algorithms of the upstream blocks without a shared zone.
+-- The ewma algorithm, not available in the upstream blocks, selects the
+-- best of two random endpoints using the exponentially weighted moving
+-- average of the response time and the number of active requests.
+-- The max_fails and fail_timeout parameters of the endpoints are not used:
+-- failed requests are retried with the next endpoint (proxy_next_upstream).
+
+local cjson = require("cjson")
+local ngx_balancer = require("ngx.balancer")
+local configuration = require("configuration")
+
+local BACKENDS_SYNC_INTERVAL = 1
+-- time after which a response time has a weight of 1/e in the average of
+-- the ewma algorithm, in seconds
+local EWMA_DECAY_TIME = 10
+
+local _M = {}
+
+-- backends indexed by name
+local backends = {}
+-- raw data used to build backends, to skip decoding when nothing changed
+local backends_data = nil
+-- index of the last endpoint used by round robin, by backend name
+local round_robin_index = {}
+-- number of active requests, by backend name and endpoint
+local active_requests = {}
+-- average response time and time of the last update, by backend name and endpoint
+local ewma_scores = {}
+
+local function endpoint_key(endpoint)
+  return endpoint.address .. ":" .. endpoint.port
+end
+
+local function sync_backends()
+  local data = configuration.get_backends_data()
+  if not data or data == backends_data then
+    return
+  end
+
+  local ok, new_backends = pcall(cjson.decode, data)
+  if not ok then
+    ngx.log(ngx.ERR, "could not parse backends data: ", tostring(new_backends))
+    return
+  end
+
+  local by_name = {}
+  local new_active_requests = {}
+  local new_ewma_scores = {}
+
+  for _, backend in ipairs(new_backends) do
+    by_name[backend.name] = backend
+
+    -- keep the counters and averages of the endpoints that still exist
+    local current = active_requests[backend.name] or {}
+    local current_scores = ewma_scores[backend.name] or {}
+    local counters = {}
+    local scores = {}
+    for _, endpoint in ipairs(backend.endpoints or {}) do
+      local key = endpoint_key(endpoint)
+      counters[key] = current[key] or 0
+      scores[key] = current_scores[key] or { value = 0, updated = 0 }
+    end
+    new_active_requests[backend.name] = counters
+    new_ewma_scores[backend.name] = scores
+  end
+
+  backends = by_name
+  backends_data = data
+  active_requests = new_active_requests
+  ewma_scores = new_ewma_scores
+end
+
+local function round_robin(backend)
+  local endpoints = backend.endpoints
+
+  local index = round_robin_index[backend.name] or 0
+  index = index % #endpoints + 1
+  round_robin_index[backend.name] = index
+
+  return endpoints[index]
+end
+
+local function least_conn(backend)
+  local counters = active_requests[backend.name] or {}
+  local endpoints = backend.endpoints
+
+  -- start after the last endpoint used to distribute the requests
+  -- between the endpoints with the same number of active requests
+  local start = round_robin_index[backend.name] or 0
+  local selected, selected_index, min
+
+  for i = 1, #endpoints do
+    local index = (start + i - 1) % #endpoints + 1
+    local endpoint = endpoints[index]
+    local active = counters[endpoint_key(endpoint)] or 0
+    if not min or active < min then
+      selected, selected_index, min = endpoint, index, active
+    end
+  end
+
+  round_robin_index[backend.name] = selected_index
+  return selected
+end
+
+-- average response time of the endpoint, decayed since the last update
+-- so the endpoints without recent requests are tried again
+local function ewma_score(backend_name, endpoint, now)
+  local key = endpoint_key(endpoint)
+  local score = (ewma_scores[backend_name] or {})[key]
+  local active = (active_requests[backend_name] or {})[key] or 0
+  if not score then
+    return 0
+  end
+
+  local elapsed = math.max(now - score.updated, 0)
+  return score.value * math.exp(-elapsed / EWMA_DECAY_TIME) * (active + 1)
+end
+
+local function ewma(backend)
+  local endpoints = backend.endpoints
+  if #endpoints == 1 then
+    return endpoints[1]
+  end
+
+  -- power of two choices: two different random endpoints
+  local a = math.random(#endpoints)
+  local b = math.random(#endpoints - 1)
+  if b >= a then
+    b = b + 1
+  end
+
+  local now = ngx.now()
+  if ewma_score(backend.name, endpoints[b], now) < ewma_score(backend.name, endpoints[a], now) then
+    return endpoints[b]
+  end
+  return endpoints[a]
+end
+
+local function update_ewma(backend_name, key, response_time)
+  local score = (ewma_scores[backend_name] or {})[key]
+  if not score then
+    -- the endpoint was removed
+    return
+  end
+
+  local now = ngx.now()
+  local weight = math.exp(-math.max(now - score.updated, 0) / EWMA_DECAY_TIME)
+  score.value = score.value * weight + response_time * (1 - weight)
+  score.updated = now
+end
+
+-- response time of the last try of the request, the last value of the
+-- list of upstream_response_time
+local function upstream_response_time()
+  local value = ngx.var.upstream_response_time
+  if not value then
+    return nil
+  end
+
+  return tonumber(string.match(value, "([%d.]+)%s*$"))
+end
+
+local function update_active_requests(backend_name, key, delta)
+  local counters = active_requests[backend_name]
+  if not counters or not counters[key] then
+    -- the endpoint was removed
+    return
+  end
+
+  counters[key] = math.max(counters[key] + delta, 0)
+end
+
+-- release the endpoint used by the current (or previous) try of the request
+local function release_endpoint()
+  local peer = ngx.ctx.balancer_peer
+  if not peer then
+    return
+  end
+
+  update_active_requests(peer.backend, peer.key, -1)
+  ngx.ctx.balancer_peer = nil
+end
+
+function _M.init_worker()
+  -- different random endpoints in every worker for ewma
+  math.randomseed(ngx.time() + ngx.worker.pid())
+
+  sync_backends()
+
+  local ok, err = ngx.timer.every(BACKENDS_SYNC_INTERVAL, sync_backends)
+  if not ok then
+    ngx.log(ngx.ERR, "error when setting up timer.every for sync_backends: ", tostring(err))
+  end
+end
+
+function _M.balance()
+  local backend_name = ngx.var.proxy_upstream_name
+  local backend = backends[backend_name]
+  if not backend then
+    ngx.log(ngx.WARN, "no configuration found for backend ", backend_name)
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local endpoints = backend.endpoints
+  if not endpoints or #endpoints == 0 then
+    ngx.log(ngx.WARN, "no endpoints available for backend ", backend_name)
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  if ngx.ctx.balancer_peer then
+    -- this is a retry
+    release_endpoint()
+  elseif #endpoints > 1 then
+    -- proxy_next_upstream_tries limits the number of tries
+    local ok, err = ngx_balancer.set_more_tries(#endpoints - 1)
+    if not ok then
+      ngx.log(ngx.ERR, "error setting the number of tries: ", tostring(err))
+    end
+  end
+
+  local endpoint
+  if backend["load-balance"] == "least_conn" then
+    endpoint = least_conn(backend)
+  elseif backend["load-balance"] == "ewma" then
+    endpoint = ewma(backend)
+  else
+    endpoint = round_robin(backend)
+  end
+
+  local ok, err = ngx_balancer.set_current_peer(endpoint.address, tonumber(endpoint.port))
+  if not ok then
+    ngx.log(ngx.ERR, "error while setting current upstream peer to ", endpoint_key(endpoint), ": ", tostring(err))
+    return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+  end
+
+  local key = endpoint_key(endpoint)
+  update_active_requests(backend_name, key, 1)
+  ngx.ctx.balancer_peer = { backend = backend_name, key = key }
+end
+
+function _M.log()
+  local peer = ngx.ctx.balancer_peer
+  if peer then
+    local response_time = upstream_response_time()
+    if response_time then
+      update_ewma(peer.backend, peer.key, response_time)
+    end
+  end
+
+  release_endpoint()
+end
+
+return _M
@@ -0,0 +1,97 @@
+-- Sets the SSL certificate of the TLS handshake in ssl_certificate_by_lua,
+-- using the certificates configured by the ingress controller. The
+-- certificate defined in the server block is used when there is no
+-- certificate for the hostname (or the client does not send SNI).
+
+local ssl = require("ngx.ssl")
+local lrucache = require("resty.lrucache")
+local configuration = require("configuration")
+
+-- number of converted certificates cached by each worker
+local CACHE_SIZE = 1000
+
+local _M = {}
+
+local cache, err = lrucache.new(CACHE_SIZE)
+if not cache then
+  error("failed to create the certificate cache: " .. tostring(err))
+end
+
+local function get_pem_cert_key(hostname)
+  local pem = configuration.get_pem_cert_key(hostname)
+  if pem then
+    return hostname, pem
+  end
+
+  -- wildcard hostnames only match one label
+  local wildcard = string.gsub(hostname, "^[^%.]+%.", "*.", 1)
+  if wildcard == hostname then
+    return nil
+  end
+
+  return wildcard, configuration.get_pem_cert_key(wildcard)
+end
+
+local function get_der_cert_key(hostname, pem)
+  local cached = cache:get(hostname)
+  if cached and cached.pem == pem then
+    return cached.cert, cached.key
+  end
+
+  local der_cert, err = ssl.cert_pem_to_der(pem)
+  if not der_cert then
+    return nil, nil, "failed to convert the certificate from PEM to DER: " .. tostring(err)
+  end
+
+  local der_key
+  der_key, err = ssl.priv_key_pem_to_der(pem)
+  if not der_key then
+    return nil, nil, "failed to convert the private key from PEM to DER: " .. tostring(err)
+  end
+
+  cache:set(hostname, { pem = pem, cert = der_cert, key = der_key })
+  return der_cert, der_key
+end
+
+function _M.call()
+  local hostname, err = ssl.server_name()
+  if err then
+    ngx.log(ngx.ERR, "error while obtaining hostname: ", err)
+  end
+  if not hostname then
+    return
+  end
+
+  local name, pem = get_pem_cert_key(string.lower(hostname))
+  if not pem then
+    return
+  end
+
+  local der_cert, der_key
+  der_cert, der_key, err = get_der_cert_key(name, pem)
+  if err then
+    ngx.log(ngx.ERR, "certificate of ", name, ": ", err)
+    return
+  end
+
+  local ok
+  ok, err = ssl.clear_certs()
+  if not ok then
+    ngx.log(ngx.ERR, "failed to clear existing (fallback) certificates: ", tostring(err))
+    return ngx.exit(ngx.ERROR)
+  end
+
+  ok, err = ssl.set_der_cert(der_cert)
+  if not ok then
+    ngx.log(ngx.ERR, "failed to set DER cert: ", tostring(err))
+    return ngx.exit(ngx.ERROR)
+  end
+
+  ok, err = ssl.set_der_priv_key(der_key)
+  if not ok then
+    ngx.log(ngx.ERR, "failed to set DER private key: ", tostring(err))
+    return ngx.exit(ngx.ERROR)
+  end
+end
+
+return _M
@@ -0,0 +1,162 @@
+-- Receives the backends configured by the ingress controller and stores
+-- them in the configuration_data shared dictionary, where the Lua balancer
+-- of every worker reads them. The backends are a JSON array of objects with
+-- the name, the load balancing algorithm and the endpoints (address and port).
+-- The SSL certificates of the servers (/configuration/servers) are a JSON
+-- object with the PEM certificate and key indexed by hostname, stored in
+-- the certificate_data shared dictionary.
+-- The routes of the dynamic servers (/configuration/routes) are a JSON
+-- object with the locations indexed by hostname, read by the Lua router.
+-- The endpoint is served by a server listening on 127.0.0.1, because any
+-- client of the endpoint can replace the configuration.
+
+local cjson = require("cjson")
+
+local _M = {}
+
+local configuration_data = ngx.shared.configuration_data
+
+-- keys of the configuration_data dictionary by URI
+local DATA_KEYS = {
+  ["/configuration/backends"] = "backends",
+  ["/configuration/routes"] = "routes",
+}
+
+local function read_body()
+  ngx.req.read_body()
+
+  -- client_body_buffer_size is big enough to keep the body in memory
+  return ngx.req.get_body_data()
+end
+
+function _M.get_backends_data()
+  return configuration_data:get("backends")
+end
+
+function _M.get_routes_data()
+  return configuration_data:get("routes")
+end
+
+function _M.get_pem_cert_key(hostname)
+  local certificate_data = ngx.shared.certificate_data
+  if not certificate_data then
+    return nil
+  end
+
+  return certificate_data:get(hostname)
+end
+
+local function configure_servers(servers)
+  local certificate_data = ngx.shared.certificate_data
+  if not certificate_data then
+    return ngx.HTTP_NOT_FOUND, "dynamic certificates are not enabled"
+  end
+
+  for hostname, pem in pairs(servers) do
+    -- safe_set does not remove other certificates when the dictionary is full
+    local success, err = certificate_data:safe_set(hostname, pem)
+    if not success then
+      return ngx.HTTP_INTERNAL_SERVER_ERROR, "error storing the certificate of " .. hostname .. ": " .. tostring(err)
+    end
+  end
+
+  -- remove the certificates of the servers that do not exist anymore
+  for _, hostname in ipairs(certificate_data:get_keys(0)) do
+    if not servers[hostname] then
+      certificate_data:delete(hostname)
+    end
+  end
+
+  return ngx.HTTP_CREATED
+end
+
+-- is_loopback checks the address of the connection, before the realip
+-- module replaces it with the value of a header sent by the client
+local function is_loopback()
+  local addr = ngx.var.realip_remote_addr
+  return addr == "127.0.0.1" or addr == "::1"
+end
+
+function _M.call()
+  if not is_loopback() then
+    ngx.status = ngx.HTTP_FORBIDDEN
+    ngx.print("Only local requests are allowed!")
+    return
+  end
+
+  local method = ngx.req.get_method()
+  if method ~= "POST" and method ~= "GET" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only POST and GET requests are allowed!")
+    return
+  end
+
+  local uri = ngx.var.uri
+  local key = DATA_KEYS[uri]
+  if not key and uri ~= "/configuration/servers" then
+    ngx.status = ngx.HTTP_NOT_FOUND
+    ngx.print("Not found!")
+    return
+  end
+
+  if uri == "/configuration/servers" then
+    if method ~= "POST" then
+      ngx.status = ngx.HTTP_BAD_REQUEST
+      ngx.print("Only POST requests are allowed!")
+      return
+    end
+
+    local body = read_body()
+    local ok, servers = pcall(cjson.decode, body or "")
+    if not ok or type(servers) ~= "table" then
+      ngx.log(ngx.ERR, "dynamic-configuration: invalid servers: ", tostring(servers))
+      ngx.status = ngx.HTTP_BAD_REQUEST
+      return
+    end
+
+    local status, err = configure_servers(servers)
+    if err then
+      ngx.log(ngx.ERR, "dynamic-configuration: ", err)
+    end
+    ngx.status = status
+    return
+  end
+
+  if method == "GET" then
+    local data = configuration_data:get(key)
+    if not data then
+      ngx.status = ngx.HTTP_NOT_FOUND
+      ngx.print("Not configured!")
+      return
+    end
+
+    ngx.header.content_type = "application/json"
+    ngx.print(data)
+    return
+  end
+
+  local data = read_body()
+  if not data then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read the request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local ok, decoded = pcall(cjson.decode, data)
+  if not ok or type(decoded) ~= "table" then
+    ngx.log(ngx.ERR, "dynamic-configuration: invalid ", key, ": ", tostring(decoded))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local success, err = configuration_data:set(key, data)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating configuration: ", tostring(err))
+    ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+return _M
@@ -0,0 +1,133 @@
+-- Translates gRPC-Web requests to gRPC before they are sent to the
+-- upstream server and the gRPC responses back to gRPC-Web.
+-- The gRPC trailers are not available to HTTP/1.x clients, so they are
+-- appended to the response body as a trailer frame (flag 0x80).
+
+local _M = {}
+
+local GRPC_WEB = "application/grpc-web"
+local GRPC_WEB_TEXT = "application/grpc-web-text"
+
+local function read_body()
+  ngx.req.read_body()
+
+  local data = ngx.req.get_body_data()
+  if data then
+    return data
+  end
+
+  local file_name = ngx.req.get_body_file()
+  if not file_name then
+    return ""
+  end
+
+  local file, err = io.open(file_name, "rb")
+  if not file then
+    ngx.log(ngx.ERR, "unable to read request body: ", err)
+    return nil
+  end
+
+  data = file:read("*a")
+  file:close()
+  return data
+end
+
+-- encodes the length of a frame as a 4 byte big endian integer
+local function frame_length(n)
+  return string.char(math.floor(n / 16777216) % 256,
+                     math.floor(n / 65536) % 256,
+                     math.floor(n / 256) % 256,
+                     n % 256)
+end
+
+local function trailer_frame()
+  local status = ngx.var.upstream_trailer_grpc_status or ngx.header["grpc-status"]
+  local message = ngx.var.upstream_trailer_grpc_message or ngx.header["grpc-message"]
+
+  local trailers = "grpc-status:" .. (status or "0") .. "\r\n"
+  if message and message ~= "" then
+    trailers = trailers .. "grpc-message:" .. message .. "\r\n"
+  end
+
+  return string.char(128) .. frame_length(#trailers) .. trailers
+end
+
+function _M.rewrite()
+  local content_type = ngx.var.content_type
+  if not content_type or content_type:sub(1, #GRPC_WEB) ~= GRPC_WEB then
+    return
+  end
+
+  local text = content_type:sub(1, #GRPC_WEB_TEXT) == GRPC_WEB_TEXT
+  local suffix
+  if text then
+    suffix = content_type:sub(#GRPC_WEB_TEXT + 1)
+  else
+    suffix = content_type:sub(#GRPC_WEB + 1)
+  end
+
+  ngx.ctx.grpc_web = { text = text, suffix = suffix, pending = "" }
+
+  if text then
+    local body = read_body()
+    if not body then
+      return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+    end
+
+    local decoded = ngx.decode_base64(body)
+    if not decoded then
+      ngx.log(ngx.WARN, "invalid base64 encoded gRPC-Web request body")
+      return ngx.exit(ngx.HTTP_BAD_REQUEST)
+    end
+
+    ngx.req.set_body_data(decoded)
+  end
+
+  ngx.req.set_header("Content-Type", "application/grpc" .. suffix)
+end
+
+function _M.header_filter()
+  local ctx = ngx.ctx.grpc_web
+  if not ctx then
+    return
+  end
+
+  if ctx.text then
+    ngx.header["Content-Type"] = GRPC_WEB_TEXT .. ctx.suffix
+  else
+    ngx.header["Content-Type"] = GRPC_WEB .. ctx.suffix
+  end
+
+  -- the trailer frame is added to the body
+  ngx.header["Content-Length"] = nil
+end
+
+function _M.body_filter()
+  local ctx = ngx.ctx.grpc_web
+  if not ctx then
+    return
+  end
+
+  local chunk, eof = ngx.arg[1], ngx.arg[2]
+  if eof then
+    chunk = chunk .. trailer_frame()
+  end
+
+  if not ctx.text then
+    ngx.arg[1] = chunk
+    return
+  end
+
+  -- base64 works on groups of 3 bytes, the remainder is kept
+  -- until the next chunk to avoid padding in the middle of the body
+  chunk = ctx.pending .. chunk
+  local n = #chunk
+  if not eof then
+    n = n - (n % 3)
+  end
+
+  ctx.pending = chunk:sub(n + 1)
+  ngx.arg[1] = ngx.encode_base64(chunk:sub(1, n))
+end
+
+return _M
@@ -0,0 +1,101 @@
+-- Routes the requests of the dynamic servers (Ingress rules without
+-- annotations) in the default server. The routes are the locations of the
+-- servers indexed by hostname, sorted by the length of the path (longest
+-- first) like the prefix locations of NGINX. Every worker reads the routes
+-- stored by the configuration module periodically.
+
+local cjson = require("cjson")
+local configuration = require("configuration")
+
+local ROUTES_SYNC_INTERVAL = 1
+
+local _M = {}
+
+-- routes indexed by hostname
+local routes = {}
+-- raw data used to build routes, to skip decoding when nothing changed
+local routes_data = nil
+
+local function sync_routes()
+  local data = configuration.get_routes_data()
+  if not data or data == routes_data then
+    return
+  end
+
+  local ok, new_routes = pcall(cjson.decode, data)
+  if not ok then
+    ngx.log(ngx.ERR, "could not parse routes data: ", tostring(new_routes))
+    return
+  end
+
+  routes = new_routes
+  routes_data = data
+end
+
+local function get_route(hostname)
+  local route = routes[hostname]
+  if route then
+    return route
+  end
+
+  -- wildcard hostnames only match one label
+  local wildcard, count = string.gsub(hostname, "^[^%.]+%.", "*.", 1)
+  if count == 0 then
+    return nil
+  end
+
+  return routes[wildcard]
+end
+
+local function find_location(route, uri)
+  for _, location in ipairs(route.locations) do
+    if string.sub(uri, 1, #location.path) == location.path then
+      return location
+    end
+  end
+
+  return nil
+end
+
+function _M.init_worker()
+  sync_routes()
+
+  local ok, err = ngx.timer.every(ROUTES_SYNC_INTERVAL, sync_routes)
+  if not ok then
+    ngx.log(ngx.ERR, "error when setting up timer.every for sync_routes: ", tostring(err))
+  end
+end
+
+function _M.route(redirect_code)
+  -- the request was already routed (internal redirects to named locations)
+  if ngx.var.dynamic_route ~= "" then
+    return
+  end
+
+  local route = get_route(ngx.var.host)
+  if not route then
+    -- not a dynamic server, use the locations of the default server
+    return
+  end
+
+  ngx.var.dynamic_route = "1"
+
+  local location = find_location(route, ngx.var.uri)
+  if not location then
+    ngx.var.proxy_upstream_name = "upstream-default-backend"
+    return ngx.exec("@dynamic_default_backend")
+  end
+
+  if location.sslRedirect and ngx.var.redirect_to_https == "1" then
+    return ngx.redirect("https://" .. ngx.var.host .. ngx.var.request_uri, redirect_code)
+  end
+
+  ngx.var.proxy_upstream_name = location.backend
+  ngx.var.namespace = location.namespace
+  ngx.var.ingress_name = location.ingress
+  ngx.var.service_name = location.service
+
+  return ngx.exec("@dynamic_route")
+end
+
+return _M